@@ -560,6 +560,24 @@ func TestDefaultFunctionParsing(t *testing.T) {
 	}
 }
 
+func TestStateMutabilityParsing(t *testing.T) {
+	const definition = `[
+	{ "type" : "function", "name" : "get", "stateMutability" : "view" },
+	{ "type" : "function", "name" : "hash", "stateMutability" : "pure" },
+	{ "type" : "function", "name" : "set", "stateMutability" : "nonpayable" }
+	]`
+
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, constant := range map[string]bool{"get": true, "hash": true, "set": false} {
+		if abi.Methods[name].Const != constant {
+			t.Errorf("expected method '%s' const to be %v", name, constant)
+		}
+	}
+}
+
 func TestBareEvents(t *testing.T) {
 	const definition = `[
 	{ "type" : "event", "name" : "balance" },
@@ -0,0 +1,202 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of go-essentia.
+//
+// qwerty123 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// qwerty123 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-essentia. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/orangeAndSuns/essentia/accounts/abi/bind"
+	"github.com/orangeAndSuns/essentia/cmd/utils"
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/common/hexutil"
+	"github.com/orangeAndSuns/essentia/contracts/checkpointoracle"
+	"github.com/orangeAndSuns/essentia/core/types"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var commandDeploy = cli.Command{
+	Name:  "deploy",
+	Usage: "deploy a new checkpoint oracle",
+	Description: `
+Deploy a checkpoint oracle administered by the given signers, which publishes
+a checkpoint once the given number of them signed it. The deployment is paid
+by the keyfile account.
+`,
+	Flags: []cli.Flag{
+		rpcFlag,
+		keyfileFlag,
+		passphraseFlag,
+		signersFlag,
+		thresholdFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		var signers []common.Address
+		for _, s := range strings.Split(ctx.String(signersFlag.Name), ",") {
+			if s = strings.TrimSpace(s); !common.IsHexAddress(s) {
+				utils.Fatalf("Invalid signer address '%s'", s)
+			}
+			signers = append(signers, common.HexToAddress(s))
+		}
+		threshold := ctx.Int(thresholdFlag.Name)
+		if threshold < 1 || threshold > len(signers) {
+			utils.Fatalf("Threshold must be between 1 and the number of signers")
+		}
+		client, _ := dialRPC(ctx)
+		opts := bind.NewKeyedTransactor(loadKey(ctx))
+
+		address, tx, err := checkpointoracle.DeployCheckpointOracle(opts, client, signers, threshold)
+		if err != nil {
+			utils.Fatalf("Failed to deploy checkpoint oracle: %v", err)
+		}
+		fmt.Println("Transaction:", tx.Hash().Hex())
+		if _, err := bind.WaitDeployed(context.Background(), client, tx); err != nil {
+			utils.Fatalf("Failed to deploy checkpoint oracle: %v", err)
+		}
+		fmt.Println("Oracle:", address.Hex())
+		return nil
+	},
+}
+
+var commandStatus = cli.Command{
+	Name:  "status",
+	Usage: "show the latest published checkpoint",
+	Description: `
+Show the latest checkpoint published in the oracle, along with the admins who
+signed it.
+`,
+	Flags: []cli.Flag{
+		rpcFlag,
+		oracleFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		client, _ := dialRPC(ctx)
+		address := getOracle(ctx)
+		oracle, err := checkpointoracle.NewCheckpointOracle(address, client)
+		if err != nil {
+			utils.Fatalf("Failed to bind checkpoint oracle: %v", err)
+		}
+		threshold, err := oracle.Threshold(nil)
+		if err != nil {
+			utils.Fatalf("Failed to read checkpoint oracle: %v", err)
+		}
+		fmt.Println("Threshold:", threshold)
+
+		cp, sigs, err := oracle.LatestCheckpoint(nil)
+		if err != nil {
+			utils.Fatalf("Failed to read checkpoint oracle: %v", err)
+		}
+		if cp == nil {
+			fmt.Println("No checkpoint published yet")
+			return nil
+		}
+		printCheckpoint(cp)
+		for _, sig := range sigs {
+			signer, err := cp.Signer(address, sig)
+			if err != nil {
+				utils.Fatalf("Invalid signature %x: %v", sig, err)
+			}
+			fmt.Println("Signed by:", signer.Hex())
+		}
+		return nil
+	},
+}
+
+var commandSign = cli.Command{
+	Name:  "sign",
+	Usage: "sign the latest local checkpoint",
+	Description: `
+Sign the latest checkpoint generated by the LES server for the oracle. The
+signature can be submitted by any account through the publish command.
+`,
+	Flags: []cli.Flag{
+		rpcFlag,
+		oracleFlag,
+		keyfileFlag,
+		passphraseFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		_, rpcClient := dialRPC(ctx)
+		cp := localCheckpoint(rpcClient)
+		sig, err := cp.Sign(getOracle(ctx), loadKey(ctx))
+		if err != nil {
+			utils.Fatalf("Failed to sign checkpoint: %v", err)
+		}
+		printCheckpoint(cp)
+		fmt.Println("Signature:", hexutil.Encode(sig))
+		return nil
+	},
+}
+
+var commandPublish = cli.Command{
+	Name:  "publish",
+	Usage: "submit a signature of the latest local checkpoint",
+	Description: `
+Submit a signature of the latest checkpoint generated by the LES server to the
+oracle, which publishes the checkpoint once enough admins signed it. Unless a
+signature of another admin is given with --signature, the checkpoint is signed
+with the keyfile. The transaction is paid by the keyfile account.
+`,
+	Flags: []cli.Flag{
+		rpcFlag,
+		oracleFlag,
+		keyfileFlag,
+		passphraseFlag,
+		signatureFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		client, rpcClient := dialRPC(ctx)
+		cp := localCheckpoint(rpcClient)
+		address := getOracle(ctx)
+		key := loadKey(ctx)
+
+		var sig []byte
+		if s := ctx.String(signatureFlag.Name); s != "" {
+			var err error
+			if sig, err = hexutil.Decode(s); err != nil {
+				utils.Fatalf("Invalid signature: %v", err)
+			}
+		} else {
+			var err error
+			if sig, err = cp.Sign(address, key); err != nil {
+				utils.Fatalf("Failed to sign checkpoint: %v", err)
+			}
+		}
+		printCheckpoint(cp)
+		tx, err := checkpointoracle.Publish(bind.NewKeyedTransactor(key), client, address, cp, sig)
+		if err != nil {
+			utils.Fatalf("Failed to submit signature: %v", err)
+		}
+		fmt.Println("Transaction:", tx.Hash().Hex())
+		receipt, err := bind.WaitMined(context.Background(), client, tx)
+		if err != nil {
+			utils.Fatalf("Failed to submit signature: %v", err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			utils.Fatalf("Signature rejected by the oracle")
+		}
+		return nil
+	},
+}
+
+func printCheckpoint(cp *checkpointoracle.Checkpoint) {
+	fmt.Println("Section:", cp.SectionIndex)
+	fmt.Println("Section head:", cp.SectionHead.Hex())
+	fmt.Println("CHT root:", cp.ChtRoot.Hex())
+	fmt.Println("BloomTrie root:", cp.BloomTrieRoot.Hex())
+}
@@ -0,0 +1,82 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of go-essentia.
+//
+// qwerty123 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// qwerty123 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-essentia. If not, see <http://www.gnu.org/licenses/>.
+
+// checkpoint-admin is a utility for the admins of a checkpoint oracle to deploy
+// the oracle, and to sign and publish the checkpoints of their LES servers.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/orangeAndSuns/essentia/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+
+var app *cli.App
+
+func init() {
+	app = utils.NewApp(gitCommit, "a checkpoint oracle administration tool")
+	app.Commands = []cli.Command{
+		commandDeploy,
+		commandStatus,
+		commandSign,
+		commandPublish,
+	}
+}
+
+// Commonly used command line flags.
+var (
+	rpcFlag = cli.StringFlag{
+		Name:  "rpc",
+		Value: "http://localhost:8545",
+		Usage: "RPC endpoint of the node (an LES server for signing)",
+	}
+	oracleFlag = cli.StringFlag{
+		Name:  "oracle",
+		Usage: "address of the checkpoint oracle contract",
+	}
+	keyfileFlag = cli.StringFlag{
+		Name:  "keyfile",
+		Usage: "keyfile of the admin or transaction sender account",
+	}
+	passphraseFlag = cli.StringFlag{
+		Name:  "passwordfile",
+		Usage: "the file that contains the passphrase for the keyfile",
+	}
+	signersFlag = cli.StringFlag{
+		Name:  "signers",
+		Usage: "comma separated addresses of the oracle admins",
+	}
+	thresholdFlag = cli.IntFlag{
+		Name:  "threshold",
+		Usage: "number of admin signatures required to publish a checkpoint",
+	}
+	signatureFlag = cli.StringFlag{
+		Name:  "signature",
+		Usage: "hex encoded checkpoint signature of another admin to submit",
+	}
+)
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
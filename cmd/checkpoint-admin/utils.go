@@ -0,0 +1,93 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of go-essentia.
+//
+// qwerty123 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// qwerty123 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-essentia. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"strings"
+
+	"github.com/orangeAndSuns/essentia/accounts/keystore"
+	"github.com/orangeAndSuns/essentia/cmd/utils"
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/console"
+	"github.com/orangeAndSuns/essentia/contracts/checkpointoracle"
+	"github.com/orangeAndSuns/essentia/essclient"
+	"github.com/orangeAndSuns/essentia/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// dialRPC connects to the node given by the --rpc flag.
+func dialRPC(ctx *cli.Context) (*essclient.Client, *rpc.Client) {
+	client, err := rpc.Dial(ctx.String(rpcFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to connect to '%s': %v", ctx.String(rpcFlag.Name), err)
+	}
+	return essclient.NewClient(client), client
+}
+
+// getOracle returns the oracle address given by the --oracle flag.
+func getOracle(ctx *cli.Context) common.Address {
+	address := ctx.String(oracleFlag.Name)
+	if !common.IsHexAddress(address) {
+		utils.Fatalf("Invalid oracle address '%s'", address)
+	}
+	return common.HexToAddress(address)
+}
+
+// localCheckpoint retrieves the latest checkpoint generated by the LES server.
+func localCheckpoint(client *rpc.Client) *checkpointoracle.Checkpoint {
+	var cp checkpointoracle.Checkpoint
+	if err := client.Call(&cp, "les_latestCheckpoint"); err != nil {
+		utils.Fatalf("Failed to retrieve local checkpoint: %v", err)
+	}
+	return &cp
+}
+
+// loadKey decrypts the key given by the --keyfile flag.
+func loadKey(ctx *cli.Context) *ecdsa.PrivateKey {
+	keyfile := ctx.String(keyfileFlag.Name)
+	if keyfile == "" {
+		utils.Fatalf("Keyfile required (--%s)", keyfileFlag.Name)
+	}
+	keyjson, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		utils.Fatalf("Failed to read the keyfile at '%s': %v", keyfile, err)
+	}
+	key, err := keystore.DecryptKey(keyjson, getPassphrase(ctx))
+	if err != nil {
+		utils.Fatalf("Error decrypting key: %v", err)
+	}
+	return key.PrivateKey
+}
+
+// getPassphrase obtains the passphrase of the keyfile from the --passwordfile
+// flag, or prompts the user for it.
+func getPassphrase(ctx *cli.Context) string {
+	if passphraseFile := ctx.String(passphraseFlag.Name); passphraseFile != "" {
+		content, err := ioutil.ReadFile(passphraseFile)
+		if err != nil {
+			utils.Fatalf("Failed to read passphrase file '%s': %v", passphraseFile, err)
+		}
+		return strings.TrimRight(string(content), "\r\n")
+	}
+	passphrase, err := console.Stdin.PromptPassword("Passphrase: ")
+	if err != nil {
+		utils.Fatalf("Failed to read passphrase: %v", err)
+	}
+	return passphrase
+}
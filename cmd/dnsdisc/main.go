@@ -0,0 +1,231 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of go-essentia.
+//
+// qwerty123 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// qwerty123 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-essentia. If not, see <http://www.gnu.org/licenses/>.
+
+// dnsdisc signs node lists and converts them into the DNS TXT records served
+// to DNS discovery clients.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/cmd/utils"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+	"github.com/orangeAndSuns/essentia/p2p/dnsdisc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+
+var app *cli.App
+
+func init() {
+	app = utils.NewApp(gitCommit, "an Essentia DNS node list tool")
+	app.Commands = []cli.Command{
+		commandSign,
+		commandToTXT,
+		commandSync,
+	}
+}
+
+var (
+	keyFlag = cli.StringFlag{
+		Name:  "key",
+		Usage: "file containing the hex encoded signing key of the tree",
+	}
+	domainFlag = cli.StringFlag{
+		Name:  "domain",
+		Usage: "domain name the tree is published at",
+	}
+	seqFlag = cli.UintFlag{
+		Name:  "seq",
+		Usage: "sequence number of the tree (defaults to the current unix time)",
+	}
+	linksFlag = cli.StringSliceFlag{
+		Name:  "link",
+		Usage: "enrtree:// URL of another tree to link to (may be repeated)",
+	}
+	jsonFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "output JSON instead of zone file format",
+	}
+	timeoutFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "timeout of DNS lookups",
+		Value: 30 * time.Second,
+	}
+)
+
+var commandSign = cli.Command{
+	Name:      "sign",
+	Usage:     "sign a node list",
+	ArgsUsage: "<nodes.json>",
+	Description: `
+Sign creates a signed tree definition from a JSON list of essnode URLs. The
+definition is printed to stdout and can be converted into DNS records with
+the to-txt command.`,
+	Flags: []cli.Flag{keyFlag, domainFlag, seqFlag, linksFlag},
+	Action: func(ctx *cli.Context) error {
+		if ctx.NArg() != 1 {
+			utils.Fatalf("need node list file as argument")
+		}
+		if !ctx.IsSet(keyFlag.Name) || !ctx.IsSet(domainFlag.Name) {
+			utils.Fatalf("--%s and --%s are required", keyFlag.Name, domainFlag.Name)
+		}
+		key, err := crypto.LoadECDSA(ctx.String(keyFlag.Name))
+		if err != nil {
+			utils.Fatalf("can't load key: %v", err)
+		}
+		var urls []string
+		if err := loadJSON(ctx.Args().First(), &urls); err != nil {
+			utils.Fatalf("%v", err)
+		}
+		seq := ctx.Uint(seqFlag.Name)
+		if !ctx.IsSet(seqFlag.Name) {
+			seq = uint(time.Now().Unix())
+		}
+		def := &treeDef{Seq: seq, Links: ctx.StringSlice(linksFlag.Name), Nodes: urls}
+		t, err := def.makeTree()
+		if err != nil {
+			utils.Fatalf("can't create tree: %v", err)
+		}
+		if def.URL, err = t.Sign(key, ctx.String(domainFlag.Name)); err != nil {
+			utils.Fatalf("can't sign tree: %v", err)
+		}
+		def.Signature = t.Signature()
+		return writeJSON(def)
+	},
+}
+
+var commandToTXT = cli.Command{
+	Name:      "to-txt",
+	Usage:     "create DNS TXT records for a signed tree",
+	ArgsUsage: "<tree.json>",
+	Description: `
+To-txt verifies the signature of a tree definition created by the sign command
+and prints the TXT records to publish at the tree's domain.`,
+	Flags: []cli.Flag{jsonFlag},
+	Action: func(ctx *cli.Context) error {
+		if ctx.NArg() != 1 {
+			utils.Fatalf("need tree definition file as argument")
+		}
+		def := new(treeDef)
+		if err := loadJSON(ctx.Args().First(), def); err != nil {
+			utils.Fatalf("%v", err)
+		}
+		domain, pubkey, err := dnsdisc.ParseURL(def.URL)
+		if err != nil {
+			utils.Fatalf("invalid tree URL: %v", err)
+		}
+		t, err := def.makeTree()
+		if err != nil {
+			utils.Fatalf("can't create tree: %v", err)
+		}
+		if err := t.SetSignature(pubkey, def.Signature); err != nil {
+			utils.Fatalf("invalid tree signature: %v", err)
+		}
+		records := t.ToTXT(domain)
+		if ctx.Bool(jsonFlag.Name) {
+			return writeJSON(records)
+		}
+		names := make([]string, 0, len(records))
+		for name := range records {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s.\t60\tIN\tTXT\t%q\n", name, records[name])
+		}
+		return nil
+	},
+}
+
+var commandSync = cli.Command{
+	Name:      "sync",
+	Usage:     "download and verify a published tree",
+	ArgsUsage: "<enrtree://...>",
+	Flags:     []cli.Flag{timeoutFlag},
+	Action: func(ctx *cli.Context) error {
+		if ctx.NArg() != 1 {
+			utils.Fatalf("need tree URL as argument")
+		}
+		client := dnsdisc.NewClient(dnsdisc.Config{Timeout: ctx.Duration(timeoutFlag.Name)})
+		t, err := client.SyncTree(ctx.Args().First())
+		if err != nil {
+			utils.Fatalf("sync failed: %v", err)
+		}
+		def := &treeDef{URL: ctx.Args().First(), Seq: t.Seq(), Signature: t.Signature(), Links: t.Links()}
+		for _, n := range t.Nodes() {
+			def.Nodes = append(def.Nodes, n.String())
+		}
+		return writeJSON(def)
+	},
+}
+
+// treeDef is the JSON representation of a signed tree.
+type treeDef struct {
+	URL       string   `json:"url,omitempty"`
+	Seq       uint     `json:"seq"`
+	Signature string   `json:"signature,omitempty"`
+	Links     []string `json:"links,omitempty"`
+	Nodes     []string `json:"nodes"`
+}
+
+// makeTree recreates the unsigned tree from the definition.
+func (def *treeDef) makeTree() (*dnsdisc.Tree, error) {
+	nodes := make([]*discover.Node, 0, len(def.Nodes))
+	for _, url := range def.Nodes {
+		n, err := discover.ParseNode(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node %q: %v", url, err)
+		}
+		nodes = append(nodes, n)
+	}
+	return dnsdisc.MakeTree(def.Seq, nodes, def.Links)
+}
+
+func loadJSON(file string, v interface{}) error {
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(blob, v); err != nil {
+		return fmt.Errorf("invalid JSON in %s: %v", file, err)
+	}
+	return nil
+}
+
+func writeJSON(v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -68,6 +68,7 @@ It expects the genesis file as argument.`,
 			utils.CacheFlag,
 			utils.LightModeFlag,
 			utils.GCModeFlag,
+			utils.RecoverStateFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
 		},
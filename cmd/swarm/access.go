@@ -0,0 +1,288 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of go-essentia.
+//
+// qwerty123 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// qwerty123 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-essentia. If not, see <http://www.gnu.org/licenses/>.
+
+// Command access creates and manages access controlled root manifests
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/orangeAndSuns/essentia/cmd/utils"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/swarm/api"
+	swarm "github.com/orangeAndSuns/essentia/swarm/api/client"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// swarm access new pass --password <file> <ref>
+// swarm access new pk --grant-key <public key> <ref>
+// swarm access new act --grant-keys <file> [--password <file>] <ref>
+// swarm access act add --grant-keys <file> [--password <file>] <root manifest>
+// swarm access act revoke --grant-keys <file> [--password <file>] <root manifest>
+
+func accessNewPass(ctx *cli.Context) {
+	ref := accessRef(ctx)
+	passwords := readAccessPasswords(ctx)
+	if len(passwords) != 1 {
+		utils.Fatalf("The password file should contain a single password")
+	}
+	salt := newAccessSalt()
+	ae, err := api.NewAccessEntryPassword(salt, api.DefaultKdfParams)
+	if err != nil {
+		utils.Fatalf("Error creating access entry: %v", err)
+	}
+	accessKey, err := api.NewSessionKeyPassword(passwords[0], ae)
+	if err != nil {
+		utils.Fatalf("Error deriving access key: %v", err)
+	}
+	publishAccessManifest(ctx, ref, accessKey, ae)
+}
+
+func accessNewPK(ctx *cli.Context) {
+	ref := accessRef(ctx)
+	grantee := ctx.String(SwarmAccessGrantKeyFlag.Name)
+	if grantee == "" {
+		utils.Fatalf("Grantee public key required (--%s)", SwarmAccessGrantKeyFlag.Name)
+	}
+	granteeKey := parsePublicKey(grantee)
+
+	privateKey := getPrivKey(ctx)
+	ae, err := api.NewAccessEntryPK(compressedPublicKey(privateKey), newAccessSalt())
+	if err != nil {
+		utils.Fatalf("Error creating access entry: %v", err)
+	}
+	accessKey, err := api.NewSessionKeyPK(privateKey, granteeKey, ae.Salt)
+	if err != nil {
+		utils.Fatalf("Error deriving access key: %v", err)
+	}
+	publishAccessManifest(ctx, ref, accessKey, ae)
+}
+
+func accessNewACT(ctx *cli.Context) {
+	ref := accessRef(ctx)
+	privateKey := getPrivKey(ctx)
+	ae, err := api.NewAccessEntryACT(compressedPublicKey(privateKey), newAccessSalt(), "", api.DefaultKdfParams)
+	if err != nil {
+		utils.Fatalf("Error creating access entry: %v", err)
+	}
+	accessKey, err := api.NewAccessKey()
+	if err != nil {
+		utils.Fatalf("Error generating access key: %v", err)
+	}
+	// The publisher is always granted access, so that the grantees can be
+	// managed later on
+	grantees := append([]api.ACTGrantee{{PublicKey: &privateKey.PublicKey}}, readACTGrantees(ctx)...)
+	act, err := api.GenerateACT(privateKey, ae, accessKey, grantees)
+	if err != nil {
+		utils.Fatalf("Error generating access control trie: %v", err)
+	}
+	ae.Act = publishACT(ctx, act)
+	publishAccessManifest(ctx, ref, accessKey, ae)
+}
+
+func accessACTAdd(ctx *cli.Context) {
+	updateACT(ctx, func(privateKey *ecdsa.PrivateKey, ae *api.AccessEntry, act *api.Manifest, grantees []api.ACTGrantee) *api.Manifest {
+		sessionKey, err := api.NewSessionKeyPK(privateKey, &privateKey.PublicKey, ae.Salt)
+		if err != nil {
+			utils.Fatalf("Error deriving session key: %v", err)
+		}
+		accessKey, err := api.ACTAccessKey(act, sessionKey)
+		if err != nil {
+			utils.Fatalf("The access control trie doesn't grant access to the publisher: %v", err)
+		}
+		added, err := api.GenerateACT(privateKey, ae, accessKey, grantees)
+		if err != nil {
+			utils.Fatalf("Error generating access control trie: %v", err)
+		}
+		known := make(map[string]bool)
+		for _, entry := range act.Entries {
+			known[entry.Path] = true
+		}
+		for _, entry := range added.Entries {
+			if !known[entry.Path] {
+				act.Entries = append(act.Entries, entry)
+			}
+		}
+		return act
+	})
+}
+
+func accessACTRevoke(ctx *cli.Context) {
+	updateACT(ctx, func(privateKey *ecdsa.PrivateKey, ae *api.AccessEntry, act *api.Manifest, grantees []api.ACTGrantee) *api.Manifest {
+		updated, err := api.RevokeACT(act, privateKey, ae, grantees)
+		if err != nil {
+			utils.Fatalf("Error revoking access: %v", err)
+		}
+		return updated
+	})
+}
+
+// updateACT updates the access control trie of the given root manifest and
+// publishes the root manifest referencing the new trie.
+func updateACT(ctx *cli.Context, update func(*ecdsa.PrivateKey, *api.AccessEntry, *api.Manifest, []api.ACTGrantee) *api.Manifest) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		utils.Fatalf("Expected the root manifest of the access controlled content")
+	}
+	client := swarm.NewClient(strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/"))
+	root, _, err := client.DownloadManifest(args[0])
+	if err != nil {
+		utils.Fatalf("Error downloading root manifest: %v", err)
+	}
+	if len(root.Entries) != 1 || root.Entries[0].Access == nil || root.Entries[0].Access.Type != api.AccessTypeACT {
+		utils.Fatalf("%s is not a root manifest controlled by an access control trie", args[0])
+	}
+	ae := root.Entries[0].Access
+	privateKey := getPrivKey(ctx)
+	if ae.Publisher != compressedPublicKey(privateKey) {
+		utils.Fatalf("The access control trie is managed by another publisher")
+	}
+	act, _, err := client.DownloadManifest(ae.Act)
+	if err != nil {
+		utils.Fatalf("Error downloading access control trie: %v", err)
+	}
+	ae.Act = publishACT(ctx, update(privateKey, ae, act, readACTGrantees(ctx)))
+	publishManifest(ctx, root)
+}
+
+// publishAccessManifest creates and publishes the root manifest protecting the
+// reference with the access key.
+func publishAccessManifest(ctx *cli.Context, ref string, accessKey []byte, ae *api.AccessEntry) {
+	m, err := api.GenerateAccessControlManifest(ref, accessKey, ae)
+	if err != nil {
+		utils.Fatalf("Error generating root manifest: %v", err)
+	}
+	publishManifest(ctx, m)
+}
+
+// publishACT uploads the access control trie and returns its address. In dry
+// run mode, the trie is printed instead.
+func publishACT(ctx *cli.Context, act *api.Manifest) string {
+	if ctx.Bool(SwarmDryRunFlag.Name) {
+		data, _ := json.MarshalIndent(act, "", "  ")
+		fmt.Println(string(data))
+		return ""
+	}
+	client := swarm.NewClient(strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/"))
+	hash, err := client.UploadManifest(act, false)
+	if err != nil {
+		utils.Fatalf("Error uploading access control trie: %v", err)
+	}
+	return hash
+}
+
+// publishManifest uploads the manifest and prints its address. In dry run
+// mode, the manifest is printed instead.
+func publishManifest(ctx *cli.Context, m *api.Manifest) {
+	if ctx.Bool(SwarmDryRunFlag.Name) {
+		data, _ := json.MarshalIndent(m, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	client := swarm.NewClient(strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/"))
+	hash, err := client.UploadManifest(m, false)
+	if err != nil {
+		utils.Fatalf("Error uploading root manifest: %v", err)
+	}
+	fmt.Println(hash)
+}
+
+// accessRef returns the reference of the content to protect.
+func accessRef(ctx *cli.Context) string {
+	args := ctx.Args()
+	if len(args) != 1 {
+		utils.Fatalf("Expected the reference of the content to protect")
+	}
+	ref := strings.TrimPrefix(args[0], "0x")
+	if b, err := hex.DecodeString(ref); err != nil || (len(b) != 32 && len(b) != 64) {
+		utils.Fatalf("Invalid reference %q", args[0])
+	}
+	return ref
+}
+
+// readAccessPasswords reads the passwords of the password file, one per line.
+func readAccessPasswords(ctx *cli.Context) []string {
+	file := ctx.String(SwarmAccessPasswordFlag.Name)
+	if file == "" {
+		return nil
+	}
+	return readLines(file)
+}
+
+// readACTGrantees reads the grantees identified by the public keys of the grant
+// keys file and by the passwords of the password file.
+func readACTGrantees(ctx *cli.Context) (grantees []api.ACTGrantee) {
+	if file := ctx.String(SwarmAccessGrantKeysFlag.Name); file != "" {
+		for _, key := range readLines(file) {
+			grantees = append(grantees, api.ACTGrantee{PublicKey: parsePublicKey(key)})
+		}
+	}
+	for _, password := range readAccessPasswords(ctx) {
+		grantees = append(grantees, api.ACTGrantee{Password: password})
+	}
+	if len(grantees) == 0 {
+		utils.Fatalf("No grantees given (--%s or --%s)", SwarmAccessGrantKeysFlag.Name, SwarmAccessPasswordFlag.Name)
+	}
+	return grantees
+}
+
+func readLines(file string) (lines []string) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		utils.Fatalf("Failed to read %s: %v", file, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parsePublicKey parses a hex encoded compressed or uncompressed public key.
+func parsePublicKey(s string) *ecdsa.PublicKey {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil {
+		utils.Fatalf("Invalid public key %q: %v", s, err)
+	}
+	var key *ecdsa.PublicKey
+	if len(b) == 33 {
+		key, err = crypto.DecompressPubkey(b)
+	} else {
+		key, err = crypto.UnmarshalPubkey(b)
+	}
+	if err != nil {
+		utils.Fatalf("Invalid public key %q: %v", s, err)
+	}
+	return key
+}
+
+func compressedPublicKey(key *ecdsa.PrivateKey) string {
+	return hex.EncodeToString(crypto.CompressPubkey(&key.PublicKey))
+}
+
+func newAccessSalt() []byte {
+	salt, err := api.NewSalt()
+	if err != nil {
+		utils.Fatalf("Error generating salt: %v", err)
+	}
+	return salt
+}
@@ -0,0 +1,295 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of go-essentia.
+//
+// qwerty123 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// qwerty123 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-essentia. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/swarm"
+	"github.com/orangeAndSuns/essentia/swarm/api"
+	swarmapi "github.com/orangeAndSuns/essentia/swarm/api/client"
+)
+
+const accessHashRegexp = `[a-f\d]{64}`
+
+// TestCLIAccessPass tests that content protected with 'swarm access new pass'
+// is only served with the password
+func TestCLIAccessPass(t *testing.T) {
+	cluster := newTestCluster(t, 1)
+	defer cluster.Shutdown()
+	node := cluster.Nodes[0]
+
+	data := "notsorandomdata"
+	ref := accessUpload(t, node, data)
+	passFile := accessTempFile(t, "pass\n")
+	defer os.Remove(passFile)
+
+	root := runAccess(t,
+		"--bzzapi", node.URL,
+		"access", "new", "pass",
+		"--password", passFile,
+		ref)
+
+	checkAccessHTTP(t, node, root, "", http.StatusUnauthorized, "")
+	checkAccessHTTP(t, node, root, "wrong", http.StatusUnauthorized, "")
+	checkAccessHTTP(t, node, root, "pass", http.StatusOK, data)
+}
+
+// TestCLIAccessPK tests that content protected with 'swarm access new pk' can
+// only be decrypted with the key of the grantee
+func TestCLIAccessPK(t *testing.T) {
+	cluster := newTestCluster(t, 1)
+	defer cluster.Shutdown()
+	node := cluster.Nodes[0]
+
+	data := "notsorandomdata"
+	ref := accessUpload(t, node, data)
+	grantee, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	args := append(accessPublisherFlags(t, node),
+		"access", "new", "pk",
+		"--grant-key", hex.EncodeToString(crypto.CompressPubkey(&grantee.PublicKey)),
+		ref)
+	root := runAccess(t, args...)
+
+	client := swarmapi.NewClient(node.URL)
+	m, _, err := client.DownloadManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 1 || m.Entries[0].Access == nil || m.Entries[0].Access.Type != api.AccessTypePK {
+		t.Fatalf("expected a root manifest with a pk access entry, got %v", m)
+	}
+	entry := m.Entries[0]
+	publisher := decompressAccessPublisher(t, entry.Access)
+
+	sessionKey, err := api.NewSessionKeyPK(grantee, publisher, entry.Access.Salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAccessRef(t, entry.Hash, sessionKey, ref, true)
+	sessionKey, err = api.NewSessionKeyPK(other, publisher, entry.Access.Salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAccessRef(t, entry.Hash, sessionKey, ref, false)
+
+	// the node doesn't decrypt content with its key unless configured to
+	checkAccessHTTP(t, node, root, "", http.StatusUnauthorized, "")
+}
+
+// TestCLIAccessACT tests that the grantees of content protected with 'swarm
+// access new act' can be managed with 'swarm access act add|revoke'
+func TestCLIAccessACT(t *testing.T) {
+	cluster := newTestCluster(t, 1)
+	defer cluster.Shutdown()
+	node := cluster.Nodes[0]
+
+	data := "notsorandomdata"
+	ref := accessUpload(t, node, data)
+	grantee, _ := crypto.GenerateKey()
+	added, _ := crypto.GenerateKey()
+
+	granteeFile := accessTempFile(t, hex.EncodeToString(crypto.CompressPubkey(&grantee.PublicKey))+"\n")
+	defer os.Remove(granteeFile)
+	addedFile := accessTempFile(t, hex.EncodeToString(crypto.CompressPubkey(&added.PublicKey))+"\n")
+	defer os.Remove(addedFile)
+	passFile := accessTempFile(t, "pass\n")
+	defer os.Remove(passFile)
+
+	// grant access to a key and a password
+	args := append(accessPublisherFlags(t, node),
+		"access", "new", "act",
+		"--grant-keys", granteeFile,
+		"--password", passFile,
+		ref)
+	root := runAccess(t, args...)
+	checkAccessACT(t, node, root, grantee, ref, true)
+	checkAccessACT(t, node, root, added, ref, false)
+	checkAccessHTTP(t, node, root, "pass", http.StatusOK, data)
+
+	// grant access to another key
+	args = append(accessPublisherFlags(t, node),
+		"access", "act", "add",
+		"--grant-keys", addedFile,
+		root)
+	root = runAccess(t, args...)
+	checkAccessACT(t, node, root, grantee, ref, true)
+	checkAccessACT(t, node, root, added, ref, true)
+	checkAccessHTTP(t, node, root, "pass", http.StatusOK, data)
+
+	// revoke the access of the first key and the password
+	args = append(accessPublisherFlags(t, node),
+		"access", "act", "revoke",
+		"--grant-keys", granteeFile,
+		"--password", passFile,
+		root)
+	root = runAccess(t, args...)
+	checkAccessACT(t, node, root, grantee, ref, false)
+	checkAccessACT(t, node, root, added, ref, true)
+	checkAccessHTTP(t, node, root, "pass", http.StatusUnauthorized, "")
+}
+
+// accessUpload uploads data with 'swarm up' and returns its manifest hash
+func accessUpload(t *testing.T, node *testNode, data string) string {
+	tmp := accessTempFile(t, data)
+	defer os.Remove(tmp)
+
+	up := runSwarm(t, "--bzzapi", node.URL, "up", tmp)
+	_, matches := up.ExpectRegexp(accessHashRegexp)
+	up.ExpectExit()
+	return matches[0]
+}
+
+// runAccess runs a 'swarm access' command and returns the printed root
+// manifest hash
+func runAccess(t *testing.T, args ...string) string {
+	access := runSwarm(t, args...)
+	_, matches := access.ExpectRegexp(accessHashRegexp)
+	access.ExpectExit()
+	return matches[0]
+}
+
+// accessPublisherFlags returns the flags unlocking the swarm account of the
+// node, which publishes the access controlled content
+func accessPublisherFlags(t *testing.T, node *testNode) []string {
+	var info swarm.Info
+	if err := node.Client.Call(&info, "bzz_info"); err != nil {
+		t.Fatal(err)
+	}
+	passwordFile := filepath.Join(node.Dir, "password")
+	if err := ioutil.WriteFile(passwordFile, []byte(testPassphrase), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return []string{
+		"--bzzapi", node.URL,
+		"--datadir", node.Dir,
+		"--bzzaccount", info.BzzAccount,
+		"--password", passwordFile,
+	}
+}
+
+func accessTempFile(t *testing.T, content string) string {
+	tmp, err := ioutil.TempFile("", "swarm-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.Close()
+	if _, err := io.WriteString(tmp, content); err != nil {
+		t.Fatal(err)
+	}
+	return tmp.Name()
+}
+
+func decompressAccessPublisher(t *testing.T, ae *api.AccessEntry) *ecdsa.PublicKey {
+	pub, err := hex.DecodeString(ae.Publisher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher, err := crypto.DecompressPubkey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return publisher
+}
+
+// checkAccessRef checks whether the encrypted reference of a root manifest
+// decrypts to ref with the given key
+func checkAccessRef(t *testing.T, encrypted string, key []byte, ref string, granted bool) {
+	enc, err := hex.DecodeString(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := api.NewRefEncryption(len(enc)-8).Decrypt(enc, key)
+	if err != nil && granted {
+		t.Fatal(err)
+	}
+	if got := err == nil && hex.EncodeToString(decrypted) == ref; got != granted {
+		t.Fatalf("expected access granted %v, got %v", granted, got)
+	}
+}
+
+// checkAccessACT checks whether the access control trie of a root manifest
+// grants access to ref to the given key
+func checkAccessACT(t *testing.T, node *testNode, root string, key *ecdsa.PrivateKey, ref string, granted bool) {
+	client := swarmapi.NewClient(node.URL)
+	m, _, err := client.DownloadManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries) != 1 || m.Entries[0].Access == nil || m.Entries[0].Access.Type != api.AccessTypeACT {
+		t.Fatalf("expected a root manifest with an act access entry, got %v", m)
+	}
+	entry := m.Entries[0]
+	act, _, err := client.DownloadManifest(entry.Access.Act)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionKey, err := api.NewSessionKeyPK(key, decompressAccessPublisher(t, entry.Access), entry.Access.Salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessKey, err := api.ACTAccessKey(act, sessionKey)
+	if !granted {
+		if err != api.ErrDecrypt {
+			t.Fatalf("expected access to be denied, got %v", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAccessRef(t, entry.Hash, accessKey, ref, true)
+}
+
+// checkAccessHTTP checks the response of the node to a request of the root
+// manifest with the given password
+func checkAccessHTTP(t *testing.T, node *testNode, root, password string, status int, data string) {
+	req, err := http.NewRequest("GET", node.URL+"/bzz:/"+root+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "" {
+		req.SetBasicAuth("", password)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != status {
+		t.Fatalf("password %q: expected status %d, got %d: %s", password, status, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if status == http.StatusOK && !bytes.Equal(body, []byte(data)) {
+		t.Fatalf("expected body %q, got %q", data, body)
+	}
+}
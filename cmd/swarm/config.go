@@ -71,6 +71,7 @@ const (
 	SWARM_ENV_SYNC_DISABLE         = "SWARM_SYNC_DISABLE"
 	SWARM_ENV_SYNC_UPDATE_DELAY    = "SWARM_ENV_SYNC_UPDATE_DELAY"
 	SWARM_ENV_DELIVERY_SKIP_CHECK  = "SWARM_DELIVERY_SKIP_CHECK"
+	SWARM_ENV_ACCESS_NODE_KEY      = "SWARM_ACCESS_NODE_KEY"
 	SWARM_ENV_ENS_API              = "SWARM_ENS_API"
 	SWARM_ENV_ENS_ADDR             = "SWARM_ENS_ADDR"
 	SWARM_ENV_CORS                 = "SWARM_CORS"
@@ -210,6 +211,10 @@ func cmdLineOverride(currentConfig *bzzapi.Config, ctx *cli.Context) *bzzapi.Con
 		currentConfig.DeliverySkipCheck = true
 	}
 
+	if ctx.GlobalIsSet(SwarmAccessNodeKeyFlag.Name) {
+		currentConfig.AccessNodeKey = true
+	}
+
 	currentConfig.SwapAPI = ctx.GlobalString(SwarmSwapAPIFlag.Name)
 	if currentConfig.SwapEnabled && currentConfig.SwapAPI == "" {
 		utils.Fatalf(SWARM_ERR_SWAP_SET_NO_API)
@@ -297,6 +302,12 @@ func envVarsOverride(currentConfig *bzzapi.Config) (config *bzzapi.Config) {
 		}
 	}
 
+	if v := os.Getenv(SWARM_ENV_ACCESS_NODE_KEY); v != "" {
+		if accessNodeKey, err := strconv.ParseBool(v); err == nil {
+			currentConfig.AccessNodeKey = accessNodeKey
+		}
+	}
+
 	if v := os.Getenv(SWARM_ENV_SYNC_UPDATE_DELAY); v != "" {
 		if d, err := time.ParseDuration(v); err != nil {
 			currentConfig.SyncUpdateDelay = d
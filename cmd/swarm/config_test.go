@@ -89,6 +89,7 @@ func TestConfigCmdLineOverrides(t *testing.T) {
 		fmt.Sprintf("--%s", CorsStringFlag.Name), "*",
 		fmt.Sprintf("--%s", SwarmAccountFlag.Name), account.Address.String(),
 		fmt.Sprintf("--%s", SwarmDeliverySkipCheckFlag.Name),
+		fmt.Sprintf("--%s", SwarmAccessNodeKeyFlag.Name),
 		fmt.Sprintf("--%s", EnsAPIFlag.Name), "",
 		"--datadir", dir,
 		"--ipcpath", conf.IPCPath,
@@ -133,6 +134,10 @@ func TestConfigCmdLineOverrides(t *testing.T) {
 		t.Fatal("Expected DeliverySkipCheck to be enabled, but it is not")
 	}
 
+	if !info.AccessNodeKey {
+		t.Fatal("Expected AccessNodeKey to be enabled, but it is not")
+	}
+
 	if info.Cors != "*" {
 		t.Fatalf("Expected Cors flag to be set to %s, got %s", "*", info.Cors)
 	}
@@ -128,6 +128,11 @@ var (
 		Usage:  "Skip chunk delivery check (default false)",
 		EnvVar: SWARM_ENV_DELIVERY_SKIP_CHECK,
 	}
+	SwarmAccessNodeKeyFlag = cli.BoolFlag{
+		Name:   "access-node-key",
+		Usage:  "Decrypt access controlled content granted to the node key for any HTTP API request, only enable if the API is not exposed (default false)",
+		EnvVar: SWARM_ENV_ACCESS_NODE_KEY,
+	}
 	EnsAPIFlag = cli.StringSliceFlag{
 		Name:   "ens-api",
 		Usage:  "ENS API endpoint for a TLD and with contract address, can be repeated, format [tld:][contract-addr@]url",
@@ -162,6 +167,10 @@ var (
 		Name:  "encrypt",
 		Usage: "use encrypted upload",
 	}
+	SwarmUploadWaitFlag = cli.BoolFlag{
+		Name:  "wait",
+		Usage: "wait until the uploaded content is synced to the network, showing the progress",
+	}
 	CorsStringFlag = cli.StringFlag{
 		Name:   "corsdomain",
 		Usage:  "Domain on which to send Access-Control-Allow-Origin header (multiple domains can be supplied separated by a ',')",
@@ -182,17 +191,49 @@ var (
 		Usage:  "Number of recent chunks cached in memory (default 5000)",
 		EnvVar: SWARM_ENV_STORE_CACHE_CAPACITY,
 	}
-	SwarmResourceMultihashFlag = cli.BoolFlag{
+	SwarmFeedMultihashFlag = cli.BoolFlag{
 		Name:  "multihash",
-		Usage: "Determines how to interpret data for a resource update. If not present, data will be interpreted as raw, literal data that will be included in the resource",
+		Usage: "Determines how to interpret data for a feed update. If not present, data will be interpreted as raw, literal data that will be included in the update",
 	}
-	SwarmResourceNameFlag = cli.StringFlag{
+	SwarmFeedNameFlag = cli.StringFlag{
 		Name:  "name",
-		Usage: "User-defined name for the new resource",
+		Usage: "User-defined name for the feed, limited to 32 characters. If combined with topic, it will refer to a subtopic with this name",
+	}
+	SwarmFeedTopicFlag = cli.StringFlag{
+		Name:  "topic",
+		Usage: "User-defined topic this feed is tracking, hex encoded. Limited to 64 hexadecimal characters",
 	}
-	SwarmResourceDataOnCreateFlag = cli.StringFlag{
+	SwarmFeedDataOnCreateFlag = cli.StringFlag{
 		Name:  "data",
-		Usage: "Initializes the resource with the given hex-encoded data. Data must be prefixed by 0x",
+		Usage: "Initializes the feed with the given hex-encoded data. Data must be prefixed by 0x",
+	}
+	SwarmFeedManifestFlag = cli.StringFlag{
+		Name:  "manifest",
+		Usage: "Refers to the feed through a manifest",
+	}
+	SwarmFeedUserFlag = cli.StringFlag{
+		Name:  "user",
+		Usage: "Indicates the user who updates the feed",
+	}
+	SwarmAccessPasswordFlag = cli.StringFlag{
+		Name:  "password",
+		Usage: "Password file granting access to the content, one password per line",
+	}
+	SwarmAccessGrantKeyFlag = cli.StringFlag{
+		Name:  "grant-key",
+		Usage: "Hex encoded public key of the grantee",
+	}
+	SwarmAccessGrantKeysFlag = cli.StringFlag{
+		Name:  "grant-keys",
+		Usage: "File with the hex encoded public keys of the grantees, one key per line",
+	}
+	SwarmPinRawFlag = cli.BoolFlag{
+		Name:  "raw",
+		Usage: "Pin the content as raw data rather than as a manifest",
+	}
+	SwarmDryRunFlag = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Print the manifests instead of uploading them",
 	}
 )
 
@@ -244,41 +285,172 @@ func init() {
 			Name:               "up",
 			Usage:              "uploads a file or directory to swarm using the HTTP API",
 			ArgsUsage:          "<file>",
-			Flags:              []cli.Flag{SwarmEncryptedFlag},
-			Description:        "uploads a file or directory to swarm using the HTTP API and prints the root hash",
+			Flags:              []cli.Flag{SwarmEncryptedFlag, SwarmUploadWaitFlag},
+			Description:        "uploads a file or directory to swarm using the HTTP API and prints the root hash. With --wait, it then waits until all the chunks of the upload are synced to the network",
 		},
 		{
 			CustomHelpTemplate: helpTemplate,
-			Name:               "resource",
-			Usage:              "(Advanced) Create and update Mutable Resources",
+			Name:               "feed",
+			Usage:              "(Advanced) Create and update Swarm feeds",
 			ArgsUsage:          "<create|update|info>",
-			Description:        "Works with Mutable Resource Updates",
+			Description:        "Works with Swarm feeds",
 			Subcommands: []cli.Command{
 				{
-					Action:             resourceCreate,
+					Action:             feedCreateManifest,
 					CustomHelpTemplate: helpTemplate,
 					Name:               "create",
-					Usage:              "creates a new Mutable Resource",
-					ArgsUsage:          "<frequency>",
-					Description:        "creates a new Mutable Resource",
-					Flags:              []cli.Flag{SwarmResourceNameFlag, SwarmResourceDataOnCreateFlag, SwarmResourceMultihashFlag},
+					Usage:              "creates and publishes a new feed manifest",
+					Description: `creates and publishes a new feed manifest pointing to a specified user's updates about a particular topic.
+					The feed topic can be built in the following ways:
+					* use --topic to set the topic to an arbitrary binary hex string.
+					* use --name to set the topic to a human-readable name.
+					    For example --name could be set to "profile-picture", meaning this feed allows to get this user's current profile picture.
+					* use both --topic and --name to create named subtopics.
+						For example, --topic could be set to an Swarm address and --name to "comments", meaning
+						this feed tracks a discussion about that Swarm address.
+					The --user flag allows to have this manifest refer to a user other than yourself. If not specified,
+					it will then default to your local account (--bzzaccount)`,
+					Flags: []cli.Flag{SwarmFeedNameFlag, SwarmFeedTopicFlag, SwarmFeedUserFlag, SwarmFeedDataOnCreateFlag, SwarmFeedMultihashFlag},
 				},
 				{
-					Action:             resourceUpdate,
+					Action:             feedUpdate,
 					CustomHelpTemplate: helpTemplate,
 					Name:               "update",
-					Usage:              "updates the content of an existing Mutable Resource",
-					ArgsUsage:          "<Manifest Address or ENS domain> <0x Hex data>",
-					Description:        "updates the content of an existing Mutable Resource",
-					Flags:              []cli.Flag{SwarmResourceMultihashFlag},
+					Usage:              "updates the content of an existing Swarm feed",
+					ArgsUsage:          "<0x Hex data>",
+					Description: `publishes a new update on the specified topic
+					The feed topic can be built in the following ways:
+					* use --topic to set the topic to an arbitrary binary hex string.
+					* use --name to set the topic to a human-readable name.
+					    For example --name could be set to "profile-picture", meaning this feed allows to get this user's current profile picture.
+					* use both --topic and --name to create named subtopics.
+						For example, --topic could be set to an Swarm address and --name to "comments", meaning
+						this feed tracks a discussion about that Swarm address.
+
+					If you have a manifest, you can specify it with --manifest to refer to the feed,
+					instead of using --topic / --name
+					`,
+					Flags: []cli.Flag{SwarmFeedManifestFlag, SwarmFeedTopicFlag, SwarmFeedNameFlag, SwarmFeedMultihashFlag},
 				},
 				{
-					Action:             resourceInfo,
+					Action:             feedInfo,
 					CustomHelpTemplate: helpTemplate,
 					Name:               "info",
-					Usage:              "obtains information about an existing Mutable Resource",
-					ArgsUsage:          "<Manifest Address or ENS domain>",
-					Description:        "obtains information about an existing Mutable Resource",
+					Usage:              "obtains information about an existing Swarm feed",
+					Description: `obtains information about an existing Swarm feed
+					The topic can be specified directly with the --topic flag as an hex string
+					If no topic is specified, the default topic (zero) will be used
+					The --name flag can be used to specify subtopics with a specific name.
+					The --user flag allows to refer to a user other than yourself. If not specified,
+					it will then default to your local account (--bzzaccount)
+					If you have a manifest, you can specify it with --manifest instead of --topic / --name / ---user
+					to refer to the feed`,
+					Flags: []cli.Flag{SwarmFeedManifestFlag, SwarmFeedNameFlag, SwarmFeedTopicFlag, SwarmFeedUserFlag},
+				},
+			},
+		},
+		{
+			CustomHelpTemplate: helpTemplate,
+			Name:               "access",
+			Usage:              "encrypts a reference and embeds it into a root manifest",
+			ArgsUsage:          "<new|act>",
+			Description:        "Creates and manages access controlled root manifests",
+			Subcommands: []cli.Command{
+				{
+					CustomHelpTemplate: helpTemplate,
+					Name:               "new",
+					Usage:              "encrypts a reference and embeds it into a root manifest",
+					ArgsUsage:          "<pass|pk|act>",
+					Description:        "Encrypts a reference and embeds it into a root manifest",
+					Subcommands: []cli.Command{
+						{
+							Action:             accessNewPass,
+							CustomHelpTemplate: helpTemplate,
+							Flags:              []cli.Flag{SwarmAccessPasswordFlag, SwarmDryRunFlag},
+							Name:               "pass",
+							Usage:              "encrypts a reference with a password and embeds it into a root manifest",
+							ArgsUsage:          "<ref>",
+							Description:        "Encrypts a reference with a key derived from a password and embeds it into a root manifest",
+						},
+						{
+							Action:             accessNewPK,
+							CustomHelpTemplate: helpTemplate,
+							Flags:              []cli.Flag{SwarmAccessGrantKeyFlag, SwarmDryRunFlag},
+							Name:               "pk",
+							Usage:              "encrypts a reference for a grantee public key and embeds it into a root manifest",
+							ArgsUsage:          "<ref>",
+							Description:        "Encrypts a reference with a key shared between the publisher and the grantee and embeds it into a root manifest",
+						},
+						{
+							Action:             accessNewACT,
+							CustomHelpTemplate: helpTemplate,
+							Flags:              []cli.Flag{SwarmAccessGrantKeysFlag, SwarmAccessPasswordFlag, SwarmDryRunFlag},
+							Name:               "act",
+							Usage:              "encrypts a reference for a list of grantees and embeds it into a root manifest",
+							ArgsUsage:          "<ref>",
+							Description:        "Encrypts a reference with a random key, publishes an access control trie granting the key to each grantee and embeds both into a root manifest",
+						},
+					},
+				},
+				{
+					CustomHelpTemplate: helpTemplate,
+					Name:               "act",
+					Usage:              "manages the grantees of an access control trie",
+					ArgsUsage:          "<add|revoke>",
+					Description:        "Manages the grantees of the access control trie of a root manifest",
+					Subcommands: []cli.Command{
+						{
+							Action:             accessACTAdd,
+							CustomHelpTemplate: helpTemplate,
+							Flags:              []cli.Flag{SwarmAccessGrantKeysFlag, SwarmAccessPasswordFlag, SwarmDryRunFlag},
+							Name:               "add",
+							Usage:              "grants access to new grantees",
+							ArgsUsage:          "<root manifest>",
+							Description:        "Grants access to new grantees and prints the updated root manifest",
+						},
+						{
+							Action:             accessACTRevoke,
+							CustomHelpTemplate: helpTemplate,
+							Flags:              []cli.Flag{SwarmAccessGrantKeysFlag, SwarmAccessPasswordFlag, SwarmDryRunFlag},
+							Name:               "revoke",
+							Usage:              "revokes access from grantees",
+							ArgsUsage:          "<root manifest>",
+							Description:        "Revokes access from grantees and prints the updated root manifest",
+						},
+					},
+				},
+			},
+		},
+		{
+			CustomHelpTemplate: helpTemplate,
+			Name:               "pin",
+			Usage:              "manage the content pinned in the local store",
+			ArgsUsage:          "<add|rm|ls>",
+			Description:        "Pinned content is never garbage collected from the local store",
+			Subcommands: []cli.Command{
+				{
+					Action:             pinAdd,
+					CustomHelpTemplate: helpTemplate,
+					Flags:              []cli.Flag{SwarmPinRawFlag},
+					Name:               "add",
+					Usage:              "pins content in the local store",
+					ArgsUsage:          "<hash>",
+					Description:        "Retrieves and pins the content of a manifest, including all its entries, or of a raw hash with --raw",
+				},
+				{
+					Action:             pinRemove,
+					CustomHelpTemplate: helpTemplate,
+					Name:               "rm",
+					Usage:              "unpins content from the local store",
+					ArgsUsage:          "<hash>",
+					Description:        "Releases pinned content to garbage collection",
+				},
+				{
+					Action:             pinList,
+					CustomHelpTemplate: helpTemplate,
+					Name:               "ls",
+					Usage:              "lists the content pinned in the local store",
+					Description:        "Lists the content pinned in the local store",
 				},
 			},
 		},
@@ -465,6 +637,7 @@ pv(1) tool to get a progress bar:
 		SwarmSyncDisabledFlag,
 		SwarmSyncUpdateDelay,
 		SwarmDeliverySkipCheckFlag,
+		SwarmAccessNodeKeyFlag,
 		SwarmListenAddrFlag,
 		SwarmPortFlag,
 		SwarmAccountFlag,
@@ -611,7 +784,7 @@ func getAccount(bzzaccount string, ctx *cli.Context, stack *node.Node) *ecdsa.Pr
 }
 
 // getPrivKey returns the private key of the specified bzzaccount
-// Used only by client commands, such as `resource`
+// Used only by client commands, such as `feed`
 func getPrivKey(ctx *cli.Context) *ecdsa.PrivateKey {
 	// booting up the swarm node just as we do in bzzd action
 	bzzconfig, err := buildConfig(ctx)
@@ -0,0 +1,67 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of go-essentia.
+//
+// qwerty123 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// qwerty123 is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-essentia. If not, see <http://www.gnu.org/licenses/>.
+
+// Command pin manages the content pinned in the local store of a swarm node
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/orangeAndSuns/essentia/cmd/utils"
+	swarm "github.com/orangeAndSuns/essentia/swarm/api/client"
+	"gopkg.in/urfave/cli.v1"
+)
+
+func pinAdd(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		utils.Fatalf("Usage: swarm pin add [--raw] <hash>")
+	}
+	client := swarm.NewClient(strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/"))
+	if err := client.Pin(args[0], ctx.Bool(SwarmPinRawFlag.Name)); err != nil {
+		utils.Fatalf("Failed to pin %s: %v", args[0], err)
+	}
+	fmt.Println(args[0])
+}
+
+func pinRemove(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		utils.Fatalf("Usage: swarm pin rm <hash>")
+	}
+	client := swarm.NewClient(strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/"))
+	if err := client.Unpin(args[0]); err != nil {
+		utils.Fatalf("Failed to unpin %s: %v", args[0], err)
+	}
+	fmt.Println(args[0])
+}
+
+func pinList(ctx *cli.Context) {
+	client := swarm.NewClient(strings.TrimRight(ctx.GlobalString(SwarmApiFlag.Name), "/"))
+	roots, err := client.PinnedRoots()
+	if err != nil {
+		utils.Fatalf("Failed to list pinned content: %v", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintln(w, "HASH\tRAW\tCHUNKS")
+	for _, root := range roots {
+		fmt.Fprintf(w, "%s\t%v\t%d\n", root.Addr, root.Raw, root.Chunks)
+	}
+}
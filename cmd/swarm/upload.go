@@ -29,9 +29,11 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/orangeAndSuns/essentia/cmd/utils"
 	swarm "github.com/orangeAndSuns/essentia/swarm/api/client"
+	"github.com/orangeAndSuns/essentia/swarm/storage"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -47,6 +49,7 @@ func upload(ctx *cli.Context) {
 		mimeType     = ctx.GlobalString(SwarmUploadMimeType.Name)
 		client       = swarm.NewClient(bzzapi)
 		toEncrypt    = ctx.Bool(SwarmEncryptedFlag.Name)
+		wait         = ctx.Bool(SwarmUploadWaitFlag.Name)
 		file         string
 	)
 
@@ -71,6 +74,15 @@ func upload(ctx *cli.Context) {
 		file = expandPath(args[0])
 	}
 
+	if wait {
+		// count the chunks of the upload with a tag, to follow their syncing
+		tag, err := client.CreateTag(filepath.Base(file))
+		if err != nil {
+			utils.Fatalf("Error creating upload tag: %s", err)
+		}
+		client.Tag = tag.Uid
+	}
+
 	if !wantManifest {
 		f, err := swarm.Open(file)
 		if err != nil {
@@ -82,6 +94,9 @@ func upload(ctx *cli.Context) {
 			utils.Fatalf("Upload failed: %s", err)
 		}
 		fmt.Println(hash)
+		if wait {
+			waitToSync(client)
+		}
 		return
 	}
 
@@ -119,6 +134,60 @@ func upload(ctx *cli.Context) {
 		utils.Fatalf("Upload failed: %s", err)
 	}
 	fmt.Println(hash)
+	if wait {
+		waitToSync(client)
+	}
+}
+
+// syncPollInterval is the interval between two queries of the status of an
+// upload while waiting for it to be synced
+const syncPollInterval = 500 * time.Millisecond
+
+// syncStallTimeout is how long waiting for an upload to be synced goes on
+// without any of its chunks being sent or synced
+const syncStallTimeout = 5 * time.Minute
+
+// waitToSync polls the status of the tag of the client, showing the progress
+// of the upload on stderr until all its chunks are synced to the network. It
+// fails if the syncing makes no progress for syncStallTimeout, as happens
+// when the node has no peers or runs with syncing disabled.
+func waitToSync(client *swarm.Client) {
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+	var sent, synced int64
+	progressed := time.Now()
+	for range ticker.C {
+		status, err := client.TagStatus(client.Tag)
+		if err != nil {
+			utils.Fatalf("Error getting upload status: %s", err)
+		}
+		printSyncProgress(os.Stderr, status)
+		if status.Done(storage.StateSynced) {
+			fmt.Fprintln(os.Stderr)
+			return
+		}
+		if status.Sent != sent || status.Synced != synced {
+			sent, synced = status.Sent, status.Synced
+			progressed = time.Now()
+		} else if time.Since(progressed) > syncStallTimeout {
+			fmt.Fprintln(os.Stderr)
+			utils.Fatalf("Upload not synced: no progress for %v, the node may have no peers or run with --nosync", syncStallTimeout)
+		}
+	}
+}
+
+// printSyncProgress draws a progress bar of the chunks synced, overwriting
+// the current line
+func printSyncProgress(w io.Writer, status *storage.TagStatus) {
+	const width = 40
+	var done int64
+	if status.Total > 0 {
+		done = status.Synced * width / status.Total
+	}
+	if done > width {
+		done = width
+	}
+	fmt.Fprintf(w, "\r[%s%s] %d/%d chunks synced", strings.Repeat("=", int(done)), strings.Repeat(" ", int(width-done)), status.Synced, status.Total)
 }
 
 // Expands a file path
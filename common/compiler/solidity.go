@@ -61,9 +61,12 @@ type Solidity struct {
 }
 
 // --combined-output format
+// solcOutput is the --combined-json output of solc. Before solc 0.8.0, the
+// ABI and docs are JSON encoded strings, later versions embed them directly.
 type solcOutput struct {
 	Contracts map[string]struct {
-		Bin, Abi, Devdoc, Userdoc, Metadata string
+		Bin, Metadata        string
+		Abi, Devdoc, Userdoc json.RawMessage
 	}
 	Version string
 }
@@ -172,15 +175,15 @@ func ParseCombinedJSON(combinedJSON []byte, source string, languageVersion strin
 	for name, info := range output.Contracts {
 		// Parse the individual compilation results.
 		var abi interface{}
-		if err := json.Unmarshal([]byte(info.Abi), &abi); err != nil {
+		if err := unmarshalEmbedded(info.Abi, &abi); err != nil {
 			return nil, fmt.Errorf("solc: error reading abi definition (%v)", err)
 		}
 		var userdoc interface{}
-		if err := json.Unmarshal([]byte(info.Userdoc), &userdoc); err != nil {
+		if err := unmarshalEmbedded(info.Userdoc, &userdoc); err != nil {
 			return nil, fmt.Errorf("solc: error reading user doc: %v", err)
 		}
 		var devdoc interface{}
-		if err := json.Unmarshal([]byte(info.Devdoc), &devdoc); err != nil {
+		if err := unmarshalEmbedded(info.Devdoc, &devdoc); err != nil {
 			return nil, fmt.Errorf("solc: error reading dev doc: %v", err)
 		}
 		contracts[name] = &Contract{
@@ -212,3 +215,13 @@ func slurpFiles(files []string) (string, error) {
 	}
 	return concat.String(), nil
 }
+
+// unmarshalEmbedded decodes a JSON value of the solc output, which may also be
+// embedded as a JSON encoded string.
+func unmarshalEmbedded(data json.RawMessage, v interface{}) error {
+	var embedded string
+	if err := json.Unmarshal(data, &embedded); err == nil {
+		data = json.RawMessage(embedded)
+	}
+	return json.Unmarshal(data, v)
+}
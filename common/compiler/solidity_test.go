@@ -17,6 +17,7 @@
 package compiler
 
 import (
+	"fmt"
 	"os/exec"
 	"testing"
 )
@@ -75,3 +76,34 @@ func TestCompileError(t *testing.T) {
 	}
 	t.Logf("error: %v", err)
 }
+
+func TestParseCombinedJSON(t *testing.T) {
+	const (
+		abi     = `[{"inputs":[],"name":"f","outputs":[],"stateMutability":"view","type":"function"}]`
+		userdoc = `{"methods":{}}`
+		devdoc  = `{"methods":{}}`
+	)
+	// solc before 0.8.0 embeds the ABI and docs as JSON encoded strings
+	embedded := fmt.Sprintf(`{"contracts":{"test":{"abi":%q,"bin":"00","userdoc":%q,"devdoc":%q}},"version":"0.4.24"}`, abi, userdoc, devdoc)
+	direct := fmt.Sprintf(`{"contracts":{"test":{"abi":%s,"bin":"00","userdoc":%s,"devdoc":%s}},"version":"0.8.0"}`, abi, userdoc, devdoc)
+
+	for _, output := range []string{embedded, direct} {
+		contracts, err := ParseCombinedJSON([]byte(output), "", "", "", "")
+		if err != nil {
+			t.Fatalf("error parsing %s: %v", output, err)
+		}
+		c, ok := contracts["test"]
+		if !ok {
+			t.Fatal("info for contract 'test' not present in result")
+		}
+		if c.Code != "0x00" {
+			t.Errorf("wrong code %s", c.Code)
+		}
+		if def, ok := c.Info.AbiDefinition.([]interface{}); !ok || len(def) != 1 {
+			t.Errorf("wrong abi definition %v", c.Info.AbiDefinition)
+		}
+		if c.Info.UserDoc == nil || c.Info.DeveloperDoc == nil {
+			t.Error("missing docs")
+		}
+	}
+}
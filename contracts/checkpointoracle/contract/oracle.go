@@ -0,0 +1,493 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package contract
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/orangeAndSuns/essentia"
+	"github.com/orangeAndSuns/essentia/accounts/abi"
+	"github.com/orangeAndSuns/essentia/accounts/abi/bind"
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/core/types"
+	"github.com/orangeAndSuns/essentia/event"
+)
+
+// CheckpointOracleABI is the input ABI used to generate the binding from.
+const CheckpointOracleABI = "[{\"inputs\":[{\"internalType\":\"address[]\",\"name\":\"_admins\",\"type\":\"address[]\"},{\"internalType\":\"uint256\",\"name\":\"_threshold\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"uint64\",\"name\":\"sectionIndex\",\"type\":\"uint64\"},{\"indexed\":false,\"internalType\":\"bytes32\",\"name\":\"checkpointHash\",\"type\":\"bytes32\"}],\"name\":\"NewCheckpoint\",\"type\":\"event\"},{\"inputs\":[],\"name\":\"GetLatestCheckpoint\",\"outputs\":[{\"internalType\":\"uint64\",\"name\":\"sectionIndex\",\"type\":\"uint64\"},{\"internalType\":\"bytes32\",\"name\":\"sectionHead\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"chtRoot\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"bloomTrieRoot\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"_index\",\"type\":\"uint256\"}],\"name\":\"GetSignature\",\"outputs\":[{\"internalType\":\"uint8\",\"name\":\"v\",\"type\":\"uint8\"},{\"internalType\":\"bytes32\",\"name\":\"r\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"s\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"GetSignatureCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_addr\",\"type\":\"address\"}],\"name\":\"IsAdmin\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint64\",\"name\":\"_sectionIndex\",\"type\":\"uint64\"},{\"internalType\":\"bytes32\",\"name\":\"_sectionHead\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"_chtRoot\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"_bloomTrieRoot\",\"type\":\"bytes32\"},{\"internalType\":\"uint8\",\"name\":\"_v\",\"type\":\"uint8\"},{\"internalType\":\"bytes32\",\"name\":\"_r\",\"type\":\"bytes32\"},{\"internalType\":\"bytes32\",\"name\":\"_s\",\"type\":\"bytes32\"}],\"name\":\"SetCheckpoint\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"Threshold\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]"
+
+// CheckpointOracleBin is the compiled bytecode used for deploying new contracts.
+const CheckpointOracleBin = `0x608060405234801561001057600080fd5b506040516107ff3803806107ff83398101604081905261002f91610105565b600081118015610040575081518111155b61004957600080fd5b60005b82518110156100b057600160008085848151811061006c5761006c6101d1565b602090810291909101810151600160a060020a03168252810191909152604001600020805460ff1916911515919091179055806100a881610200565b91505061004c565b5060015550610240565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b8051600160a060020a038116811461010057600080fd5b919050565b6000806040838503121561011857600080fd5b825167ffffffffffffffff8082111561013057600080fd5b818501915085601f83011261014457600080fd5b8151602082821115610158576101586100ba565b808202604051601f19603f8301168101818110868211171561017c5761017c6100ba565b60405292835281830193508481018201928984111561019a57600080fd5b948201945b838610156101bf576101b0866100e9565b8552948201949382019361019f565b97909101519698969750505050505050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603260045260246000fd5b600060018201610239577f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b5060010190565b6105b08061024f6000396000f3fe608060405234801561001057600080fd5b506004361061007e577c010000000000000000000000000000000000000000000000000000000060003504634d6a304c811461008357806373c15829146100bf578063b11e3c1a146100f1578063dcb56ed81461013a578063f8921ccc14610150578063fd7babb814610165575b600080fd5b6002546003546004546005546040805167ffffffffffffffff909516855260208501939093529183015260608201526080015b60405180910390f35b6100d26100cd36600461047a565b61016d565b6040805160ff90941684526020840192909252908201526060016100b6565b61012a6100ff366004610493565b73ffffffffffffffffffffffffffffffffffffffff1660009081526020819052604090205460ff1690565b60405190151581526020016100b6565b6101426101d6565b6040519081526020016100b6565b61016361015e3660046104d0565b6101ef565b005b600154610142565b600080600061017a6101d6565b841061018557600080fd5b60065460009081526007602052604081208054869081106101a8576101a861054b565b600091825260209091206003909102018054600182015460029092015460ff90911697919650945092505050565b60065460009081036101e85750600090565b5060015490565b600654158061020d575060025467ffffffffffffffff908116908816115b61021657600080fd5b6040805130602082015267ffffffffffffffff891691810191909152606081018790526080810186905260a0810185905260009060c00160408051601f198184030181528282528051602091820120600080855291840180845281905260ff88169284019290925260608301869052608083018590529092509060019060a0016020604051602081039080840390855afa1580156102b8573d6000803e3d6000fd5b5050604051601f19015191505073ffffffffffffffffffffffffffffffffffffffff81161580159061030f575073ffffffffffffffffffffffffffffffffffffffff811660009081526020819052604090205460ff165b61031857600080fd5b600082815260086020908152604080832073ffffffffffffffffffffffffffffffffffffffff8516845290915290205460ff161561035557600080fd5b600082815260086020908152604080832073ffffffffffffffffffffffffffffffffffffffff8516845282528083208054600160ff1991821681179092558685526007808552838620845160608101865260ff8d811682528188018d81529682018c815283548088018555848b52898b20935160039091029093018054909616929091169190911784559451838501559351600290920191909155905493869052909152541015610407575050610471565b6002805467ffffffffffffffff191667ffffffffffffffff8b1690811790915560038990556004889055600587905560068390556040518381527f6ed2a44ae7c4d8df24691e0a03c6dc555ff35892b002305ae60ab84d3ea99af19060200160405180910390a250505b50505050505050565b60006020828403121561048c57600080fd5b5035919050565b6000602082840312156104a557600080fd5b813573ffffffffffffffffffffffffffffffffffffffff811681146104c957600080fd5b9392505050565b600080600080600080600060e0888a0312156104eb57600080fd5b873567ffffffffffffffff8116811461050357600080fd5b9650602088013595506040880135945060608801359350608088013560ff8116811461052e57600080fd5b9699959850939692959460a0840135945060c09093013592915050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603260045260246000fdfea2646970667358221220dd9a4e516b3dca89c346e8693a290a939a55a022533965768abd48a9ad6ad68864736f6c63430008150033`
+
+// DeployCheckpointOracle deploys a new Essentia contract, binding an instance of CheckpointOracle to it.
+func DeployCheckpointOracle(auth *bind.TransactOpts, backend bind.ContractBackend, _admins []common.Address, _threshold *big.Int) (common.Address, *types.Transaction, *CheckpointOracle, error) {
+	parsed, err := abi.JSON(strings.NewReader(CheckpointOracleABI))
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(CheckpointOracleBin), backend, _admins, _threshold)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return address, tx, &CheckpointOracle{CheckpointOracleCaller: CheckpointOracleCaller{contract: contract}, CheckpointOracleTransactor: CheckpointOracleTransactor{contract: contract}, CheckpointOracleFilterer: CheckpointOracleFilterer{contract: contract}}, nil
+}
+
+// CheckpointOracle is an auto generated Go binding around an Essentia contract.
+type CheckpointOracle struct {
+	CheckpointOracleCaller     // Read-only binding to the contract
+	CheckpointOracleTransactor // Write-only binding to the contract
+	CheckpointOracleFilterer   // Log filterer for contract events
+}
+
+// CheckpointOracleCaller is an auto generated read-only Go binding around an Essentia contract.
+type CheckpointOracleCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// CheckpointOracleTransactor is an auto generated write-only Go binding around an Essentia contract.
+type CheckpointOracleTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// CheckpointOracleFilterer is an auto generated log filtering Go binding around an Essentia contract events.
+type CheckpointOracleFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// CheckpointOracleSession is an auto generated Go binding around an Essentia contract,
+// with pre-set call and transact options.
+type CheckpointOracleSession struct {
+	Contract     *CheckpointOracle // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// CheckpointOracleCallerSession is an auto generated read-only Go binding around an Essentia contract,
+// with pre-set call options.
+type CheckpointOracleCallerSession struct {
+	Contract *CheckpointOracleCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts           // Call options to use throughout this session
+}
+
+// CheckpointOracleTransactorSession is an auto generated write-only Go binding around an Essentia contract,
+// with pre-set transact options.
+type CheckpointOracleTransactorSession struct {
+	Contract     *CheckpointOracleTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts           // Transaction auth options to use throughout this session
+}
+
+// CheckpointOracleRaw is an auto generated low-level Go binding around an Essentia contract.
+type CheckpointOracleRaw struct {
+	Contract *CheckpointOracle // Generic contract binding to access the raw methods on
+}
+
+// CheckpointOracleCallerRaw is an auto generated low-level read-only Go binding around an Essentia contract.
+type CheckpointOracleCallerRaw struct {
+	Contract *CheckpointOracleCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// CheckpointOracleTransactorRaw is an auto generated low-level write-only Go binding around an Essentia contract.
+type CheckpointOracleTransactorRaw struct {
+	Contract *CheckpointOracleTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewCheckpointOracle creates a new instance of CheckpointOracle, bound to a specific deployed contract.
+func NewCheckpointOracle(address common.Address, backend bind.ContractBackend) (*CheckpointOracle, error) {
+	contract, err := bindCheckpointOracle(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracle{CheckpointOracleCaller: CheckpointOracleCaller{contract: contract}, CheckpointOracleTransactor: CheckpointOracleTransactor{contract: contract}, CheckpointOracleFilterer: CheckpointOracleFilterer{contract: contract}}, nil
+}
+
+// NewCheckpointOracleCaller creates a new read-only instance of CheckpointOracle, bound to a specific deployed contract.
+func NewCheckpointOracleCaller(address common.Address, caller bind.ContractCaller) (*CheckpointOracleCaller, error) {
+	contract, err := bindCheckpointOracle(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracleCaller{contract: contract}, nil
+}
+
+// NewCheckpointOracleTransactor creates a new write-only instance of CheckpointOracle, bound to a specific deployed contract.
+func NewCheckpointOracleTransactor(address common.Address, transactor bind.ContractTransactor) (*CheckpointOracleTransactor, error) {
+	contract, err := bindCheckpointOracle(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracleTransactor{contract: contract}, nil
+}
+
+// NewCheckpointOracleFilterer creates a new log filterer instance of CheckpointOracle, bound to a specific deployed contract.
+func NewCheckpointOracleFilterer(address common.Address, filterer bind.ContractFilterer) (*CheckpointOracleFilterer, error) {
+	contract, err := bindCheckpointOracle(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracleFilterer{contract: contract}, nil
+}
+
+// bindCheckpointOracle binds a generic wrapper to an already deployed contract.
+func bindCheckpointOracle(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(CheckpointOracleABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_CheckpointOracle *CheckpointOracleRaw) Call(opts *bind.CallOpts, result interface{}, method string, params ...interface{}) error {
+	return _CheckpointOracle.Contract.CheckpointOracleCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_CheckpointOracle *CheckpointOracleRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.CheckpointOracleTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_CheckpointOracle *CheckpointOracleRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.CheckpointOracleTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_CheckpointOracle *CheckpointOracleCallerRaw) Call(opts *bind.CallOpts, result interface{}, method string, params ...interface{}) error {
+	return _CheckpointOracle.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_CheckpointOracle *CheckpointOracleTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_CheckpointOracle *CheckpointOracleTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.contract.Transact(opts, method, params...)
+}
+
+// GetLatestCheckpoint is a free data retrieval call binding the contract method 0x4d6a304c.
+//
+// Solidity: function GetLatestCheckpoint() constant returns(sectionIndex uint64, sectionHead bytes32, chtRoot bytes32, bloomTrieRoot bytes32)
+func (_CheckpointOracle *CheckpointOracleCaller) GetLatestCheckpoint(opts *bind.CallOpts) (struct {
+	SectionIndex  uint64
+	SectionHead   [32]byte
+	ChtRoot       [32]byte
+	BloomTrieRoot [32]byte
+}, error) {
+	ret := new(struct {
+		SectionIndex  uint64
+		SectionHead   [32]byte
+		ChtRoot       [32]byte
+		BloomTrieRoot [32]byte
+	})
+	out := ret
+	err := _CheckpointOracle.contract.Call(opts, out, "GetLatestCheckpoint")
+	return *ret, err
+}
+
+// GetLatestCheckpoint is a free data retrieval call binding the contract method 0x4d6a304c.
+//
+// Solidity: function GetLatestCheckpoint() constant returns(sectionIndex uint64, sectionHead bytes32, chtRoot bytes32, bloomTrieRoot bytes32)
+func (_CheckpointOracle *CheckpointOracleSession) GetLatestCheckpoint() (struct {
+	SectionIndex  uint64
+	SectionHead   [32]byte
+	ChtRoot       [32]byte
+	BloomTrieRoot [32]byte
+}, error) {
+	return _CheckpointOracle.Contract.GetLatestCheckpoint(&_CheckpointOracle.CallOpts)
+}
+
+// GetLatestCheckpoint is a free data retrieval call binding the contract method 0x4d6a304c.
+//
+// Solidity: function GetLatestCheckpoint() constant returns(sectionIndex uint64, sectionHead bytes32, chtRoot bytes32, bloomTrieRoot bytes32)
+func (_CheckpointOracle *CheckpointOracleCallerSession) GetLatestCheckpoint() (struct {
+	SectionIndex  uint64
+	SectionHead   [32]byte
+	ChtRoot       [32]byte
+	BloomTrieRoot [32]byte
+}, error) {
+	return _CheckpointOracle.Contract.GetLatestCheckpoint(&_CheckpointOracle.CallOpts)
+}
+
+// GetSignature is a free data retrieval call binding the contract method 0x73c15829.
+//
+// Solidity: function GetSignature(_index uint256) constant returns(v uint8, r bytes32, s bytes32)
+func (_CheckpointOracle *CheckpointOracleCaller) GetSignature(opts *bind.CallOpts, _index *big.Int) (struct {
+	V uint8
+	R [32]byte
+	S [32]byte
+}, error) {
+	ret := new(struct {
+		V uint8
+		R [32]byte
+		S [32]byte
+	})
+	out := ret
+	err := _CheckpointOracle.contract.Call(opts, out, "GetSignature", _index)
+	return *ret, err
+}
+
+// GetSignature is a free data retrieval call binding the contract method 0x73c15829.
+//
+// Solidity: function GetSignature(_index uint256) constant returns(v uint8, r bytes32, s bytes32)
+func (_CheckpointOracle *CheckpointOracleSession) GetSignature(_index *big.Int) (struct {
+	V uint8
+	R [32]byte
+	S [32]byte
+}, error) {
+	return _CheckpointOracle.Contract.GetSignature(&_CheckpointOracle.CallOpts, _index)
+}
+
+// GetSignature is a free data retrieval call binding the contract method 0x73c15829.
+//
+// Solidity: function GetSignature(_index uint256) constant returns(v uint8, r bytes32, s bytes32)
+func (_CheckpointOracle *CheckpointOracleCallerSession) GetSignature(_index *big.Int) (struct {
+	V uint8
+	R [32]byte
+	S [32]byte
+}, error) {
+	return _CheckpointOracle.Contract.GetSignature(&_CheckpointOracle.CallOpts, _index)
+}
+
+// GetSignatureCount is a free data retrieval call binding the contract method 0xdcb56ed8.
+//
+// Solidity: function GetSignatureCount() constant returns(uint256)
+func (_CheckpointOracle *CheckpointOracleCaller) GetSignatureCount(opts *bind.CallOpts) (*big.Int, error) {
+	var (
+		ret0 = new(*big.Int)
+	)
+	out := ret0
+	err := _CheckpointOracle.contract.Call(opts, out, "GetSignatureCount")
+	return *ret0, err
+}
+
+// GetSignatureCount is a free data retrieval call binding the contract method 0xdcb56ed8.
+//
+// Solidity: function GetSignatureCount() constant returns(uint256)
+func (_CheckpointOracle *CheckpointOracleSession) GetSignatureCount() (*big.Int, error) {
+	return _CheckpointOracle.Contract.GetSignatureCount(&_CheckpointOracle.CallOpts)
+}
+
+// GetSignatureCount is a free data retrieval call binding the contract method 0xdcb56ed8.
+//
+// Solidity: function GetSignatureCount() constant returns(uint256)
+func (_CheckpointOracle *CheckpointOracleCallerSession) GetSignatureCount() (*big.Int, error) {
+	return _CheckpointOracle.Contract.GetSignatureCount(&_CheckpointOracle.CallOpts)
+}
+
+// IsAdmin is a free data retrieval call binding the contract method 0xb11e3c1a.
+//
+// Solidity: function IsAdmin(_addr address) constant returns(bool)
+func (_CheckpointOracle *CheckpointOracleCaller) IsAdmin(opts *bind.CallOpts, _addr common.Address) (bool, error) {
+	var (
+		ret0 = new(bool)
+	)
+	out := ret0
+	err := _CheckpointOracle.contract.Call(opts, out, "IsAdmin", _addr)
+	return *ret0, err
+}
+
+// IsAdmin is a free data retrieval call binding the contract method 0xb11e3c1a.
+//
+// Solidity: function IsAdmin(_addr address) constant returns(bool)
+func (_CheckpointOracle *CheckpointOracleSession) IsAdmin(_addr common.Address) (bool, error) {
+	return _CheckpointOracle.Contract.IsAdmin(&_CheckpointOracle.CallOpts, _addr)
+}
+
+// IsAdmin is a free data retrieval call binding the contract method 0xb11e3c1a.
+//
+// Solidity: function IsAdmin(_addr address) constant returns(bool)
+func (_CheckpointOracle *CheckpointOracleCallerSession) IsAdmin(_addr common.Address) (bool, error) {
+	return _CheckpointOracle.Contract.IsAdmin(&_CheckpointOracle.CallOpts, _addr)
+}
+
+// Threshold is a free data retrieval call binding the contract method 0xfd7babb8.
+//
+// Solidity: function Threshold() constant returns(uint256)
+func (_CheckpointOracle *CheckpointOracleCaller) Threshold(opts *bind.CallOpts) (*big.Int, error) {
+	var (
+		ret0 = new(*big.Int)
+	)
+	out := ret0
+	err := _CheckpointOracle.contract.Call(opts, out, "Threshold")
+	return *ret0, err
+}
+
+// Threshold is a free data retrieval call binding the contract method 0xfd7babb8.
+//
+// Solidity: function Threshold() constant returns(uint256)
+func (_CheckpointOracle *CheckpointOracleSession) Threshold() (*big.Int, error) {
+	return _CheckpointOracle.Contract.Threshold(&_CheckpointOracle.CallOpts)
+}
+
+// Threshold is a free data retrieval call binding the contract method 0xfd7babb8.
+//
+// Solidity: function Threshold() constant returns(uint256)
+func (_CheckpointOracle *CheckpointOracleCallerSession) Threshold() (*big.Int, error) {
+	return _CheckpointOracle.Contract.Threshold(&_CheckpointOracle.CallOpts)
+}
+
+// SetCheckpoint is a paid mutator transaction binding the contract method 0xf8921ccc.
+//
+// Solidity: function SetCheckpoint(_sectionIndex uint64, _sectionHead bytes32, _chtRoot bytes32, _bloomTrieRoot bytes32, _v uint8, _r bytes32, _s bytes32) returns()
+func (_CheckpointOracle *CheckpointOracleTransactor) SetCheckpoint(opts *bind.TransactOpts, _sectionIndex uint64, _sectionHead [32]byte, _chtRoot [32]byte, _bloomTrieRoot [32]byte, _v uint8, _r [32]byte, _s [32]byte) (*types.Transaction, error) {
+	return _CheckpointOracle.contract.Transact(opts, "SetCheckpoint", _sectionIndex, _sectionHead, _chtRoot, _bloomTrieRoot, _v, _r, _s)
+}
+
+// SetCheckpoint is a paid mutator transaction binding the contract method 0xf8921ccc.
+//
+// Solidity: function SetCheckpoint(_sectionIndex uint64, _sectionHead bytes32, _chtRoot bytes32, _bloomTrieRoot bytes32, _v uint8, _r bytes32, _s bytes32) returns()
+func (_CheckpointOracle *CheckpointOracleSession) SetCheckpoint(_sectionIndex uint64, _sectionHead [32]byte, _chtRoot [32]byte, _bloomTrieRoot [32]byte, _v uint8, _r [32]byte, _s [32]byte) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.SetCheckpoint(&_CheckpointOracle.TransactOpts, _sectionIndex, _sectionHead, _chtRoot, _bloomTrieRoot, _v, _r, _s)
+}
+
+// SetCheckpoint is a paid mutator transaction binding the contract method 0xf8921ccc.
+//
+// Solidity: function SetCheckpoint(_sectionIndex uint64, _sectionHead bytes32, _chtRoot bytes32, _bloomTrieRoot bytes32, _v uint8, _r bytes32, _s bytes32) returns()
+func (_CheckpointOracle *CheckpointOracleTransactorSession) SetCheckpoint(_sectionIndex uint64, _sectionHead [32]byte, _chtRoot [32]byte, _bloomTrieRoot [32]byte, _v uint8, _r [32]byte, _s [32]byte) (*types.Transaction, error) {
+	return _CheckpointOracle.Contract.SetCheckpoint(&_CheckpointOracle.TransactOpts, _sectionIndex, _sectionHead, _chtRoot, _bloomTrieRoot, _v, _r, _s)
+}
+
+// CheckpointOracleNewCheckpointIterator is returned from FilterNewCheckpoint and is used to iterate over the raw logs and unpacked data for NewCheckpoint events raised by the CheckpointOracle contract.
+type CheckpointOracleNewCheckpointIterator struct {
+	Event *CheckpointOracleNewCheckpoint // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  essentia.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *CheckpointOracleNewCheckpointIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(CheckpointOracleNewCheckpoint)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(CheckpointOracleNewCheckpoint)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *CheckpointOracleNewCheckpointIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *CheckpointOracleNewCheckpointIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// CheckpointOracleNewCheckpoint represents a NewCheckpoint event raised by the CheckpointOracle contract.
+type CheckpointOracleNewCheckpoint struct {
+	SectionIndex   uint64
+	CheckpointHash [32]byte
+	Raw            types.Log // Blockchain specific contextual infos
+}
+
+// FilterNewCheckpoint is a free log retrieval operation binding the contract event 0x6ed2a44ae7c4d8df24691e0a03c6dc555ff35892b002305ae60ab84d3ea99af1.
+//
+// Solidity: e NewCheckpoint(sectionIndex indexed uint64, checkpointHash bytes32)
+func (_CheckpointOracle *CheckpointOracleFilterer) FilterNewCheckpoint(opts *bind.FilterOpts, sectionIndex []uint64) (*CheckpointOracleNewCheckpointIterator, error) {
+
+	var sectionIndexRule []interface{}
+	for _, sectionIndexItem := range sectionIndex {
+		sectionIndexRule = append(sectionIndexRule, sectionIndexItem)
+	}
+
+	logs, sub, err := _CheckpointOracle.contract.FilterLogs(opts, "NewCheckpoint", sectionIndexRule)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracleNewCheckpointIterator{contract: _CheckpointOracle.contract, event: "NewCheckpoint", logs: logs, sub: sub}, nil
+}
+
+// WatchNewCheckpoint is a free log subscription operation binding the contract event 0x6ed2a44ae7c4d8df24691e0a03c6dc555ff35892b002305ae60ab84d3ea99af1.
+//
+// Solidity: e NewCheckpoint(sectionIndex indexed uint64, checkpointHash bytes32)
+func (_CheckpointOracle *CheckpointOracleFilterer) WatchNewCheckpoint(opts *bind.WatchOpts, sink chan<- *CheckpointOracleNewCheckpoint, sectionIndex []uint64) (event.Subscription, error) {
+
+	var sectionIndexRule []interface{}
+	for _, sectionIndexItem := range sectionIndex {
+		sectionIndexRule = append(sectionIndexRule, sectionIndexItem)
+	}
+
+	logs, sub, err := _CheckpointOracle.contract.WatchLogs(opts, "NewCheckpoint", sectionIndexRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(CheckpointOracleNewCheckpoint)
+				if err := _CheckpointOracle.contract.UnpackLog(event, "NewCheckpoint", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
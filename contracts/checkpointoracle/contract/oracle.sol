@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: LGPL-3.0-or-later
+
+pragma solidity ^0.8.0;
+
+/// @title Checkpoint oracle for light clients
+/// @notice A threshold of admins signs checkpoints of post-processed trie
+/// roots (CHT and BloomTrie), which light clients can start syncing from.
+/// A checkpoint is published once enough admins have signed it.
+contract CheckpointOracle {
+    // Signature of a checkpoint by an admin.
+    struct Signature {
+        uint8 v;
+        bytes32 r;
+        bytes32 s;
+    }
+
+    // Whether an address may sign checkpoints.
+    mapping(address => bool) admins;
+
+    // Number of admin signatures needed to publish a checkpoint.
+    uint256 threshold;
+
+    // The latest published checkpoint.
+    uint64 latestSectionIndex;
+    bytes32 latestSectionHead;
+    bytes32 latestChtRoot;
+    bytes32 latestBloomTrieRoot;
+    bytes32 latestHash;
+
+    // Signatures collected for a checkpoint, and which admins signed it.
+    mapping(bytes32 => Signature[]) signatures;
+    mapping(bytes32 => mapping(address => bool)) signed;
+
+    /// @notice Emitted when a checkpoint is published
+    event NewCheckpoint(uint64 indexed sectionIndex, bytes32 checkpointHash);
+
+    /// @param _admins addresses allowed to sign checkpoints
+    /// @param _threshold number of admin signatures needed to publish a checkpoint
+    constructor(address[] memory _admins, uint256 _threshold) {
+        require(_threshold > 0 && _threshold <= _admins.length);
+        for (uint256 i = 0; i < _admins.length; i++) {
+            admins[_admins[i]] = true;
+        }
+        threshold = _threshold;
+    }
+
+    /// @notice Submit an admin signature of a checkpoint
+    ///
+    /// @param _sectionIndex index of the section, newer than the latest published one
+    /// @param _sectionHead hash of the last block of the section
+    /// @param _chtRoot root of the canonical hash trie
+    /// @param _bloomTrieRoot root of the bloom trie
+    /// @param _v signature parameter v
+    /// @param _r signature parameter r
+    /// @param _s signature parameter s
+    /// The signature is calculated on the hash of the oracle address and the
+    /// checkpoint, each left padded to 32 bytes.
+    function SetCheckpoint(
+        uint64 _sectionIndex,
+        bytes32 _sectionHead,
+        bytes32 _chtRoot,
+        bytes32 _bloomTrieRoot,
+        uint8 _v,
+        bytes32 _r,
+        bytes32 _s
+    ) public {
+        // Only checkpoints newer than the latest published one are considered.
+        require(latestHash == 0 || _sectionIndex > latestSectionIndex);
+
+        // The signer must be an admin which did not sign the checkpoint yet.
+        bytes32 hash = keccak256(abi.encode(address(this), _sectionIndex, _sectionHead, _chtRoot, _bloomTrieRoot));
+        address signer = ecrecover(hash, _v, _r, _s);
+        require(signer != address(0) && admins[signer]);
+        require(!signed[hash][signer]);
+        signed[hash][signer] = true;
+        signatures[hash].push(Signature(_v, _r, _s));
+
+        // Publish the checkpoint once the threshold is reached.
+        if (signatures[hash].length < threshold) {
+            return;
+        }
+        latestSectionIndex = _sectionIndex;
+        latestSectionHead = _sectionHead;
+        latestChtRoot = _chtRoot;
+        latestBloomTrieRoot = _bloomTrieRoot;
+        latestHash = hash;
+        emit NewCheckpoint(_sectionIndex, hash);
+    }
+
+    /// @notice Get the latest published checkpoint
+    function GetLatestCheckpoint() public view returns (
+        uint64 sectionIndex,
+        bytes32 sectionHead,
+        bytes32 chtRoot,
+        bytes32 bloomTrieRoot
+    ) {
+        return (latestSectionIndex, latestSectionHead, latestChtRoot, latestBloomTrieRoot);
+    }
+
+    /// @notice Get the number of signatures of the latest published checkpoint
+    function GetSignatureCount() public view returns (uint256) {
+        if (latestHash == 0) {
+            return 0;
+        }
+        return threshold;
+    }
+
+    /// @notice Get a signature of the latest published checkpoint
+    ///
+    /// @param _index index of the signature, below the signature count
+    function GetSignature(uint256 _index) public view returns (uint8 v, bytes32 r, bytes32 s) {
+        require(_index < GetSignatureCount());
+        Signature storage sig = signatures[latestHash][_index];
+        return (sig.v, sig.r, sig.s);
+    }
+
+    /// @notice Check whether an address may sign checkpoints
+    function IsAdmin(address _addr) public view returns (bool) {
+        return admins[_addr];
+    }
+
+    /// @notice Get the number of admin signatures needed to publish a checkpoint
+    function Threshold() public view returns (uint256) {
+        return threshold;
+    }
+}
@@ -0,0 +1,191 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package checkpointoracle is a wrapper around the on-chain registry in which
+// a threshold of admins publishes trusted light client checkpoints.
+package checkpointoracle
+
+// The oracle is built for byzantium, newer compilers default to later forks.
+//go:generate sh -c "solc --optimize --evm-version byzantium --combined-json bin,abi,userdoc,devdoc contract/oracle.sol | abigen --abi - --pkg contract --out contract/oracle.go"
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/orangeAndSuns/essentia/accounts/abi/bind"
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/contracts/checkpointoracle/contract"
+	"github.com/orangeAndSuns/essentia/core/types"
+	"github.com/orangeAndSuns/essentia/crypto"
+)
+
+var errInvalidSignature = errors.New("invalid checkpoint signature")
+
+// Checkpoint is a set of post-processed trie roots (CHT and BloomTrie) of a
+// section, which light clients can start syncing from.
+type Checkpoint struct {
+	SectionIndex  uint64
+	SectionHead   common.Hash
+	ChtRoot       common.Hash
+	BloomTrieRoot common.Hash
+}
+
+// Hash returns the hash the admins of the given oracle sign to approve the
+// checkpoint. It covers the oracle address, so signatures can't be replayed
+// against another oracle.
+func (c *Checkpoint) Hash(oracle common.Address) common.Hash {
+	var index [32]byte
+	binary.BigEndian.PutUint64(index[24:], c.SectionIndex)
+	return crypto.Keccak256Hash(common.LeftPadBytes(oracle.Bytes(), 32), index[:], c.SectionHead[:], c.ChtRoot[:], c.BloomTrieRoot[:])
+}
+
+// Sign signs the checkpoint for the given oracle, returning a signature in the
+// [R || S || V] format where V is 0 or 1.
+func (c *Checkpoint) Sign(oracle common.Address, key *ecdsa.PrivateKey) ([]byte, error) {
+	hash := c.Hash(oracle)
+	return crypto.Sign(hash[:], key)
+}
+
+// Signer recovers the address which produced the signature of the checkpoint.
+func (c *Checkpoint) Signer(oracle common.Address, sig []byte) (common.Address, error) {
+	hash := c.Hash(oracle)
+	pub, err := crypto.SigToPub(hash[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// VerifySigners reports whether at least threshold distinct addresses of the
+// signer list signed the checkpoint for the given oracle.
+func VerifySigners(c *Checkpoint, oracle common.Address, sigs [][]byte, signers []common.Address, threshold int) bool {
+	if threshold <= 0 {
+		return false
+	}
+	allowed := make(map[common.Address]bool)
+	for _, signer := range signers {
+		allowed[signer] = true
+	}
+	signed := make(map[common.Address]bool)
+	for _, sig := range sigs {
+		signer, err := c.Signer(oracle, sig)
+		if err != nil || !allowed[signer] {
+			continue
+		}
+		signed[signer] = true
+	}
+	return len(signed) >= threshold
+}
+
+// CheckpointOracle is a read-only view of a deployed checkpoint oracle.
+type CheckpointOracle struct {
+	address  common.Address
+	contract *contract.CheckpointOracleCaller
+}
+
+// NewCheckpointOracle binds a checkpoint oracle deployed at the given address.
+func NewCheckpointOracle(address common.Address, caller bind.ContractCaller) (*CheckpointOracle, error) {
+	c, err := contract.NewCheckpointOracleCaller(address, caller)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracle{address: address, contract: c}, nil
+}
+
+// DeployCheckpointOracle deploys a new checkpoint oracle administered by the
+// given admins, which requires threshold signatures to publish a checkpoint.
+func DeployCheckpointOracle(opts *bind.TransactOpts, backend bind.ContractBackend, admins []common.Address, threshold int) (common.Address, *types.Transaction, error) {
+	address, tx, _, err := contract.DeployCheckpointOracle(opts, backend, admins, big.NewInt(int64(threshold)))
+	return address, tx, err
+}
+
+// Address returns the address of the oracle contract.
+func (o *CheckpointOracle) Address() common.Address {
+	return o.address
+}
+
+// Threshold returns the number of admin signatures needed to publish a checkpoint.
+func (o *CheckpointOracle) Threshold(opts *bind.CallOpts) (int, error) {
+	threshold, err := o.contract.Threshold(opts)
+	if err != nil {
+		return 0, err
+	}
+	return int(threshold.Int64()), nil
+}
+
+// IsAdmin reports whether the given address may sign checkpoints.
+func (o *CheckpointOracle) IsAdmin(opts *bind.CallOpts, addr common.Address) (bool, error) {
+	return o.contract.IsAdmin(opts, addr)
+}
+
+// LatestCheckpoint returns the latest published checkpoint along with the
+// admin signatures approving it, or nil if nothing was published yet.
+func (o *CheckpointOracle) LatestCheckpoint(opts *bind.CallOpts) (*Checkpoint, [][]byte, error) {
+	latest, err := o.contract.GetLatestCheckpoint(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	count, err := o.contract.GetSignatureCount(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if count.Sign() == 0 {
+		return nil, nil, nil
+	}
+	sigs := make([][]byte, 0, count.Int64())
+	for i := int64(0); i < count.Int64(); i++ {
+		sig, err := o.contract.GetSignature(opts, big.NewInt(i))
+		if err != nil {
+			return nil, nil, err
+		}
+		sigs = append(sigs, joinSignature(sig.V, sig.R, sig.S))
+	}
+	cp := &Checkpoint{
+		SectionIndex:  latest.SectionIndex,
+		SectionHead:   latest.SectionHead,
+		ChtRoot:       latest.ChtRoot,
+		BloomTrieRoot: latest.BloomTrieRoot,
+	}
+	return cp, sigs, nil
+}
+
+// Publish submits an admin signature of the checkpoint to the oracle at the
+// given address. The checkpoint is published once enough admins signed it.
+func Publish(opts *bind.TransactOpts, transactor bind.ContractTransactor, oracle common.Address, c *Checkpoint, sig []byte) (*types.Transaction, error) {
+	if len(sig) != 65 {
+		return nil, errInvalidSignature
+	}
+	t, err := contract.NewCheckpointOracleTransactor(oracle, transactor)
+	if err != nil {
+		return nil, err
+	}
+	var r, s [32]byte
+	copy(r[:], sig[:32])
+	copy(s[:], sig[32:64])
+	return t.SetCheckpoint(opts, c.SectionIndex, c.SectionHead, c.ChtRoot, c.BloomTrieRoot, sig[64]+27, r, s)
+}
+
+// joinSignature converts the signature stored by the contract back into the
+// [R || S || V] format.
+func joinSignature(v uint8, r, s [32]byte) []byte {
+	sig := make([]byte, 65)
+	copy(sig, r[:])
+	copy(sig[32:], s[:])
+	sig[64] = v - 27
+	return sig
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package checkpointoracle
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/orangeAndSuns/essentia/accounts/abi/bind"
+	"github.com/orangeAndSuns/essentia/accounts/abi/bind/backends"
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/core"
+	"github.com/orangeAndSuns/essentia/crypto"
+)
+
+var (
+	deployKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	deployAddr   = crypto.PubkeyToAddress(deployKey.PublicKey)
+)
+
+func TestCheckpointOracle(t *testing.T) {
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{deployAddr: {Balance: big.NewInt(1000000000)}})
+	opts := bind.NewKeyedTransactor(deployKey)
+
+	var (
+		keys   []*ecdsa.PrivateKey
+		admins []common.Address
+	)
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		admins = append(admins, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	// A threshold above the number of admins is rejected
+	if _, _, err := DeployCheckpointOracle(opts, backend, admins, 4); err == nil {
+		t.Fatalf("deployed oracle with unreachable threshold")
+	}
+	address, _, err := DeployCheckpointOracle(opts, backend, admins, 2)
+	if err != nil {
+		t.Fatalf("failed to deploy oracle: %v", err)
+	}
+	backend.Commit()
+
+	oracle, err := NewCheckpointOracle(address, backend)
+	if err != nil {
+		t.Fatalf("failed to bind oracle: %v", err)
+	}
+	if threshold, err := oracle.Threshold(nil); err != nil || threshold != 2 {
+		t.Fatalf("threshold mismatch: have %d (%v), want 2", threshold, err)
+	}
+	for _, admin := range admins {
+		if ok, err := oracle.IsAdmin(nil, admin); err != nil || !ok {
+			t.Fatalf("admin %x not registered: %v", admin, err)
+		}
+	}
+	if ok, _ := oracle.IsAdmin(nil, deployAddr); ok {
+		t.Fatalf("deployer registered as admin")
+	}
+	if cp, _, err := oracle.LatestCheckpoint(nil); err != nil || cp != nil {
+		t.Fatalf("unexpected checkpoint before publishing: %v (%v)", cp, err)
+	}
+
+	cp := &Checkpoint{
+		SectionIndex:  3,
+		SectionHead:   common.HexToHash("0x01"),
+		ChtRoot:       common.HexToHash("0x02"),
+		BloomTrieRoot: common.HexToHash("0x03"),
+	}
+	sign := func(key *ecdsa.PrivateKey, cp *Checkpoint) []byte {
+		sig, err := cp.Sign(address, key)
+		if err != nil {
+			t.Fatalf("failed to sign checkpoint: %v", err)
+		}
+		return sig
+	}
+	// Signatures of non-admins are rejected
+	if _, err := Publish(opts, backend, address, cp, sign(deployKey, cp)); err == nil {
+		t.Fatalf("accepted signature of non-admin")
+	}
+	// A single signature is not enough, and can't be submitted twice
+	if _, err := Publish(opts, backend, address, cp, sign(keys[0], cp)); err != nil {
+		t.Fatalf("failed to submit signature: %v", err)
+	}
+	backend.Commit()
+	if _, err := Publish(opts, backend, address, cp, sign(keys[0], cp)); err == nil {
+		t.Fatalf("accepted duplicate signature")
+	}
+	if latest, _, _ := oracle.LatestCheckpoint(nil); latest != nil {
+		t.Fatalf("checkpoint published below threshold")
+	}
+	// The second signature publishes the checkpoint
+	if _, err := Publish(opts, backend, address, cp, sign(keys[2], cp)); err != nil {
+		t.Fatalf("failed to submit signature: %v", err)
+	}
+	backend.Commit()
+
+	latest, sigs, err := oracle.LatestCheckpoint(nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve checkpoint: %v", err)
+	}
+	if !reflect.DeepEqual(latest, cp) {
+		t.Fatalf("checkpoint mismatch: have %+v, want %+v", latest, cp)
+	}
+	if !VerifySigners(latest, address, sigs, admins, 2) {
+		t.Fatalf("failed to verify published checkpoint")
+	}
+	if VerifySigners(latest, address, sigs, admins, 3) {
+		t.Fatalf("verified checkpoint above its signature count")
+	}
+	if VerifySigners(latest, address, sigs, admins[:2], 2) {
+		t.Fatalf("verified checkpoint with unknown signer")
+	}
+	if VerifySigners(latest, common.Address{1}, sigs, admins, 2) {
+		t.Fatalf("verified checkpoint for another oracle")
+	}
+	// Checkpoints must be newer than the published one
+	stale := &Checkpoint{SectionIndex: 3, SectionHead: common.HexToHash("0x04")}
+	if _, err := Publish(opts, backend, address, stale, sign(keys[1], stale)); err == nil {
+		t.Fatalf("accepted signature of stale checkpoint")
+	}
+	next := &Checkpoint{SectionIndex: 4, SectionHead: common.HexToHash("0x05")}
+	if _, err := Publish(opts, backend, address, next, sign(keys[1], next)); err != nil {
+		t.Fatalf("failed to submit signature: %v", err)
+	}
+	backend.Commit()
+}
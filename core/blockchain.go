@@ -67,9 +67,12 @@ const (
 // CacheConfig contains the configuration values for the trie caching/pruning
 // that's resident in a blockchain.
 type CacheConfig struct {
-	Disabled      bool          // Whether to disable trie write caching (archive node)
-	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
-	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+	Disabled       bool          // Whether to disable trie write caching (archive node)
+	TrieCleanLimit int           // Memory allowance (MB) to use for caching clean trie nodes in memory
+	TrieNodeLimit  int           // Memory limit (MB) at which to flush the current in-memory trie to disk
+	TrieTimeLimit  time.Duration // Time limit after which to flush the current in-memory trie to disk
+	NoPrefetch     bool          // Whether to disable speculative state prefetching of followup blocks
+	RecoverState   bool          // Whether to regenerate missing state by re-executing blocks instead of rewinding
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -123,12 +126,17 @@ type BlockChain struct {
 	procInterrupt int32          // interrupt signaler for block processing
 	wg            sync.WaitGroup // chain processing wait group for shutting down
 
-	engine    consensus.Engine
-	processor Processor // block processor interface
-	validator Validator // block and state validator interface
-	vmConfig  vm.Config
+	engine     consensus.Engine
+	processor  Processor  // block processor interface
+	validator  Validator  // block and state validator interface
+	prefetcher Prefetcher // block state prefetcher interface
+	vmConfig   vm.Config
 
 	badBlocks *lru.Cache // Bad block cache
+
+	checkpoints      map[uint64]common.Hash // Finalized checkpoints the chain must adhere to
+	signedCheckpoint uint64                 // Number of the latest checkpoint accepted from trusted signers
+	checkpointLock   sync.RWMutex
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -137,8 +145,9 @@ type BlockChain struct {
 func NewBlockChain(db essdb.Database, cacheConfig *CacheConfig, chainConfig *params.ChainConfig, engine consensus.Engine, vmConfig vm.Config) (*BlockChain, error) {
 	if cacheConfig == nil {
 		cacheConfig = &CacheConfig{
-			TrieNodeLimit: 256 * 1024 * 1024,
-			TrieTimeLimit: 5 * time.Minute,
+			TrieCleanLimit: 256,
+			TrieNodeLimit:  256 * 1024 * 1024,
+			TrieTimeLimit:  5 * time.Minute,
 		}
 	}
 	bodyCache, _ := lru.New(bodyCacheLimit)
@@ -152,7 +161,7 @@ func NewBlockChain(db essdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		cacheConfig:  cacheConfig,
 		db:           db,
 		triegc:       prque.New(),
-		stateCache:   state.NewDatabase(db),
+		stateCache:   state.NewDatabaseWithCache(db, cacheConfig.TrieCleanLimit),
 		quit:         make(chan struct{}),
 		bodyCache:    bodyCache,
 		bodyRLPCache: bodyRLPCache,
@@ -164,6 +173,8 @@ func NewBlockChain(db essdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	}
 	bc.SetValidator(NewBlockValidator(chainConfig, bc, engine))
 	bc.SetProcessor(NewStateProcessor(chainConfig, bc, engine))
+	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
+	bc.loadCheckpoints()
 
 	var err error
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.getProcInterrupt)
@@ -218,10 +229,22 @@ func (bc *BlockChain) loadLastState() error {
 	}
 	// Make sure the state associated with the block is available
 	if _, err := state.New(currentBlock.Root(), bc.stateCache); err != nil {
-		// Dangling block without a state associated, init from scratch
-		log.Warn("Head state missing, repairing chain", "number", currentBlock.Number(), "hash", currentBlock.Hash())
-		if err := bc.repair(&currentBlock); err != nil {
-			return err
+		// Dangling block without a state associated, try to regenerate it if allowed
+		recovered := false
+		if bc.cacheConfig.RecoverState {
+			log.Warn("Head state missing, regenerating", "number", currentBlock.Number(), "hash", currentBlock.Hash())
+			if err := bc.regenerateState(currentBlock); err != nil {
+				log.Error("Failed to regenerate head state", "err", err)
+			} else {
+				recovered = true
+			}
+		}
+		// Regeneration disabled or failed, rewind to the newest block with state
+		if !recovered {
+			log.Warn("Head state missing, repairing chain", "number", currentBlock.Number(), "hash", currentBlock.Hash())
+			if err := bc.repair(&currentBlock); err != nil {
+				return err
+			}
 		}
 	}
 	// Everything seems to be fine, set as the head block
@@ -311,6 +334,61 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	return bc.loadLastState()
 }
 
+// SetHeadByHash rewinds the local chain to the block with the given hash. Contrary
+// to SetHead, the target block does not need to be canonical: if it resides on a
+// side chain, the canonical chain is rewound to the common ancestor and the side
+// chain is reimported on top, making the requested block the new head.
+func (bc *BlockChain) SetHeadByHash(hash common.Hash) error {
+	block := bc.GetBlockByHash(hash)
+	if block == nil {
+		return fmt.Errorf("unknown block [%x…]", hash[:4])
+	}
+	// Gather all the side chain blocks until a canonical ancestor is found
+	var (
+		sidechain []*types.Block
+		ancestor  = block
+	)
+	for rawdb.ReadCanonicalHash(bc.db, ancestor.NumberU64()) != ancestor.Hash() {
+		sidechain = append(sidechain, ancestor)
+		if ancestor = bc.GetBlock(ancestor.ParentHash(), ancestor.NumberU64()-1); ancestor == nil {
+			return fmt.Errorf("missing side chain ancestor of [%x…]", hash[:4])
+		}
+	}
+	// Ensure the ancestor's state is available, otherwise the rewind would drop the
+	// chain all the way back to genesis
+	if !bc.HasState(ancestor.Root()) {
+		if !bc.cacheConfig.RecoverState {
+			return fmt.Errorf("missing state of block #%d [%x…]", ancestor.NumberU64(), ancestor.Hash().Bytes()[:4])
+		}
+		if err := bc.regenerateState(ancestor); err != nil {
+			return err
+		}
+	}
+	if len(sidechain) > 0 {
+		log.Warn("Rewinding blockchain to side chain", "number", block.Number(), "hash", hash, "ancestor", ancestor.Number(), "depth", len(sidechain))
+	}
+	if err := bc.SetHead(ancestor.NumberU64()); err != nil {
+		return err
+	}
+	if current := bc.CurrentBlock(); current.Hash() != ancestor.Hash() {
+		return fmt.Errorf("rewound to #%d [%x…] instead of #%d [%x…]", current.NumberU64(), current.Hash().Bytes()[:4], ancestor.NumberU64(), ancestor.Hash().Bytes()[:4])
+	}
+	if len(sidechain) == 0 {
+		return nil
+	}
+	// Reimport the side chain, which now outweighs the rewound canonical one
+	for i := 0; i < len(sidechain)/2; i++ {
+		sidechain[i], sidechain[len(sidechain)-1-i] = sidechain[len(sidechain)-1-i], sidechain[i]
+	}
+	if _, err := bc.InsertChain(sidechain); err != nil {
+		return err
+	}
+	if current := bc.CurrentBlock(); current.Hash() != hash {
+		return fmt.Errorf("side chain reimport ended at #%d [%x…]", current.NumberU64(), current.Hash().Bytes()[:4])
+	}
+	return nil
+}
+
 // FastSyncCommitHead sets the current head block to the one defined by the hash
 // irrelevant what the chain contents were prior.
 func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
@@ -435,6 +513,84 @@ func (bc *BlockChain) repair(head **types.Block) error {
 	}
 }
 
+// regenerateState recreates the missing state of the given block by finding the
+// newest ancestor with an available state root and re-executing all subsequent
+// blocks on top of it. The regenerated state is flushed to disk once done.
+func (bc *BlockChain) regenerateState(head *types.Block) error {
+	// Gather all the blocks that need to be re-executed
+	var (
+		blocks []*types.Block
+		origin = head
+	)
+	for !bc.HasState(origin.Root()) {
+		blocks = append(blocks, origin)
+		if origin.NumberU64() == 0 {
+			return errors.New("genesis state missing")
+		}
+		parent := bc.GetBlock(origin.ParentHash(), origin.NumberU64()-1)
+		if parent == nil {
+			return fmt.Errorf("missing block #%d [%x…]", origin.NumberU64()-1, origin.ParentHash().Bytes()[:4])
+		}
+		origin = parent
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+	log.Info("Regenerating historical state", "from", origin.Number(), "to", head.Number(), "blocks", len(blocks))
+
+	var (
+		triedb  = bc.stateCache.TrieDB()
+		start   = time.Now()
+		logged  = time.Now()
+		parent  = origin
+		regened common.Hash // Last regenerated root still referenced in memory
+	)
+	statedb, err := state.New(origin.Root(), bc.stateCache)
+	if err != nil {
+		return err
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+
+		receipts, _, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
+		if err != nil {
+			return fmt.Errorf("block #%d [%x…] re-execution failed: %v", block.NumberU64(), block.Hash().Bytes()[:4], err)
+		}
+		if err := bc.Validator().ValidateState(block, parent, statedb, receipts, usedGas); err != nil {
+			return fmt.Errorf("block #%d [%x…] re-execution mismatch: %v", block.NumberU64(), block.Hash().Bytes()[:4], err)
+		}
+		root, err := statedb.Commit(bc.chainConfig.IsEIP158(block.Number()))
+		if err != nil {
+			return err
+		}
+		// Keep only the most recent state in memory, flushing if it grows too large
+		triedb.Reference(root, common.Hash{})
+		if regened != (common.Hash{}) {
+			triedb.Dereference(regened)
+		}
+		regened = root
+
+		if nodes, _ := triedb.Size(); nodes > common.StorageSize(bc.cacheConfig.TrieNodeLimit)*1024*1024 {
+			if err := triedb.Commit(root, false); err != nil {
+				return err
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Regenerating historical state", "block", block.Number(), "target", head.Number(), "remaining", i, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		if statedb, err = state.New(root, bc.stateCache); err != nil {
+			return err
+		}
+		parent = block
+	}
+	if err := triedb.Commit(regened, false); err != nil {
+		return err
+	}
+	log.Info("Regenerated historical state", "number", head.Number(), "hash", head.Hash(), "blocks", len(blocks), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
 // Export writes the active chain to the given writer.
 func (bc *BlockChain) Export(w io.Writer) error {
 	return bc.ExportN(w, uint64(0), bc.CurrentBlock().NumberU64())
@@ -1062,6 +1218,11 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 			bc.reportBlock(block, nil, ErrBlacklistedHash)
 			return i, events, coalescedLogs, ErrBlacklistedHash
 		}
+		// If the header conflicts with a finalized checkpoint, abort
+		if err := bc.checkCheckpoint(block); err != nil {
+			bc.reportBlock(block, nil, err)
+			return i, events, coalescedLogs, err
+		}
 		// Wait for the block's verification to complete
 		bstart := time.Now()
 
@@ -1139,12 +1300,25 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		} else {
 			parent = chain[i-1]
 		}
+		// If we have a followup block, run that against the current state to pre-cache
+		// transactions and probabilistically some of the account/storage trie nodes.
+		var followupInterrupt uint32
+
+		if !bc.cacheConfig.NoPrefetch && i+1 < len(chain) {
+			if throwaway, err := state.New(parent.Root(), bc.stateCache); err == nil {
+				go func(followup *types.Block, throwaway *state.StateDB) {
+					bc.prefetcher.Prefetch(followup, throwaway, bc.vmConfig, &followupInterrupt)
+				}(chain[i+1], throwaway)
+			}
+		}
 		state, err := state.New(parent.Root(), bc.stateCache)
 		if err != nil {
+			atomic.StoreUint32(&followupInterrupt, 1)
 			return i, events, coalescedLogs, err
 		}
 		// Process block using the parent state as reference point.
 		receipts, logs, usedGas, err := bc.processor.Process(block, state, bc.vmConfig)
+		atomic.StoreUint32(&followupInterrupt, 1)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			return i, events, coalescedLogs, err
@@ -1316,6 +1490,12 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			return fmt.Errorf("Invalid new chain")
 		}
 	}
+	// Ensure the reorg doesn't violate any finality rules
+	if len(oldChain) > 0 {
+		if err := bc.checkReorg(oldChain[0], commonBlock); err != nil {
+			return err
+		}
+	}
 	// Ensure the user sees large reorgs
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Debug
@@ -1374,6 +1374,115 @@ func TestLargeReorgTrieGC(t *testing.T) {
 	}
 }
 
+// Tests that the chain can be rewound to a block residing on a side chain, in
+// which case the side chain is reimported and becomes the canonical one.
+func TestSetHeadByHashSideChain(t *testing.T) {
+	engine := esshash.NewFaker()
+
+	db := essdb.NewMemDatabase()
+	genesis := new(Genesis).MustCommit(db)
+
+	shared, _ := GenerateChain(params.TestChainConfig, genesis, engine, db, 4, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{1}) })
+	original, _ := GenerateChain(params.TestChainConfig, shared[len(shared)-1], engine, db, 4, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{2}) })
+	competitor, _ := GenerateChain(params.TestChainConfig, shared[len(shared)-1], engine, db, 3, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{3}) })
+
+	diskdb := essdb.NewMemDatabase()
+	new(Genesis).MustCommit(diskdb)
+
+	chain, err := NewBlockChain(diskdb, nil, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(append(shared, original...)); err != nil {
+		t.Fatalf("failed to insert original chain: %v", err)
+	}
+	if _, err := chain.InsertChain(competitor); err != nil {
+		t.Fatalf("failed to insert competitor chain: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != original[len(original)-1].Hash() {
+		t.Fatalf("head mismatch before rewind: have #%d [%x…]", head.NumberU64(), head.Hash().Bytes()[:4])
+	}
+	// Rewind to the side chain and ensure it became canonical
+	target := competitor[len(competitor)-1]
+	if err := chain.SetHeadByHash(target.Hash()); err != nil {
+		t.Fatalf("failed to rewind to side chain: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != target.Hash() {
+		t.Fatalf("head mismatch after rewind: have #%d [%x…], want #%d [%x…]", head.NumberU64(), head.Hash().Bytes()[:4], target.NumberU64(), target.Hash().Bytes()[:4])
+	}
+	for _, block := range competitor {
+		if have := chain.GetBlockByNumber(block.NumberU64()); have == nil || have.Hash() != block.Hash() {
+			t.Errorf("block #%d: side chain block not canonical", block.NumberU64())
+		}
+	}
+	// Rewind back to a canonical block too
+	target = shared[1]
+	if err := chain.SetHeadByHash(target.Hash()); err != nil {
+		t.Fatalf("failed to rewind to canonical block: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != target.Hash() {
+		t.Fatalf("head mismatch after canonical rewind: have #%d [%x…], want #%d [%x…]", head.NumberU64(), head.Hash().Bytes()[:4], target.NumberU64(), target.Hash().Bytes()[:4])
+	}
+	if err := chain.SetHeadByHash(common.Hash{0xff}); err == nil {
+		t.Fatalf("rewind to unknown block succeeded")
+	}
+}
+
+// Tests that missing head state is regenerated on startup by re-executing blocks
+// if state recovery is enabled, and the chain is rewound otherwise.
+func TestStateRecovery(t *testing.T) {
+	testStateRecovery(t, true)
+	testStateRecovery(t, false)
+}
+
+func testStateRecovery(t *testing.T, recover bool) {
+	engine := esshash.NewFaker()
+
+	db := essdb.NewMemDatabase()
+	genesis := new(Genesis).MustCommit(db)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, engine, db, 8, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{1}) })
+
+	// Import the chain as an archive node, making all states available on disk
+	diskdb := essdb.NewMemDatabase()
+	new(Genesis).MustCommit(diskdb)
+
+	chain, err := NewBlockChain(diskdb, &CacheConfig{Disabled: true}, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.Stop()
+
+	// Drop the state roots of the last few blocks and reopen the chain
+	for _, block := range blocks[len(blocks)-3:] {
+		diskdb.Delete(block.Root().Bytes())
+	}
+	head := blocks[len(blocks)-1]
+
+	chain, err = NewBlockChain(diskdb, &CacheConfig{Disabled: true, RecoverState: recover}, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if recover {
+		if current := chain.CurrentBlock(); current.Hash() != head.Hash() {
+			t.Fatalf("head mismatch: have #%d [%x…], want #%d [%x…]", current.NumberU64(), current.Hash().Bytes()[:4], head.NumberU64(), head.Hash().Bytes()[:4])
+		}
+		if !chain.HasState(head.Root()) {
+			t.Fatalf("head state not regenerated")
+		}
+	} else {
+		if current := chain.CurrentBlock(); current.NumberU64() != head.NumberU64()-3 {
+			t.Fatalf("head number mismatch: have %d, want %d", current.NumberU64(), head.NumberU64()-3)
+		}
+	}
+}
+
 // Benchmarks large blocks with value transfers to non-existing accounts
 func benchmarkLargeNumberOfValueToNonexisting(b *testing.B, numTxs, numBlocks int, recipientFn func(uint64) common.Address, dataFn func(uint64) []byte) {
 	var (
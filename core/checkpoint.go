@@ -0,0 +1,170 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/core/rawdb"
+	"github.com/orangeAndSuns/essentia/core/types"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/log"
+)
+
+var (
+	// errNoCheckpointSigners is returned if a signed checkpoint is submitted but
+	// the chain is not configured with any trusted signers.
+	errNoCheckpointSigners = errors.New("no trusted checkpoint signers")
+
+	// errStaleCheckpoint is returned if a signed checkpoint is submitted that is
+	// older than an already accepted one.
+	errStaleCheckpoint = errors.New("stale checkpoint")
+)
+
+// CheckpointSigHash returns the hash which trusted signers need to sign in order
+// to finalize the given block as a checkpoint.
+func CheckpointSigHash(number uint64, hash common.Hash) []byte {
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], number)
+	return crypto.Keccak256([]byte("essentia checkpoint"), enc[:], hash[:])
+}
+
+// loadCheckpoints assembles the set of finalized checkpoints from the chain
+// config and the latest signed checkpoint stored in the database.
+func (bc *BlockChain) loadCheckpoints() {
+	bc.checkpoints = make(map[uint64]common.Hash)
+	if cfg := bc.chainConfig.Finality; cfg != nil {
+		for number, hash := range cfg.Checkpoints {
+			bc.checkpoints[number] = hash
+		}
+	}
+	if number, hash := rawdb.ReadSignedCheckpoint(bc.db); hash != (common.Hash{}) {
+		bc.checkpoints[number] = hash
+		bc.signedCheckpoint = number
+	}
+}
+
+// AddCheckpoint verifies the signatures of a checkpoint and if enough trusted
+// signers approved it, marks the block as finalized. The chain will refuse to
+// import any block conflicting with it and to reorganise beyond it.
+func (bc *BlockChain) AddCheckpoint(number uint64, hash common.Hash, sigs [][]byte) error {
+	cfg := bc.chainConfig.Finality
+	if cfg == nil || len(cfg.Signers) == 0 {
+		return errNoCheckpointSigners
+	}
+	// Count the distinct trusted signers approving the checkpoint
+	trusted := make(map[common.Address]bool)
+	for _, signer := range cfg.Signers {
+		trusted[signer] = true
+	}
+	var (
+		digest   = CheckpointSigHash(number, hash)
+		approved = make(map[common.Address]bool)
+	)
+	for _, sig := range sigs {
+		pubkey, err := crypto.SigToPub(digest, sig)
+		if err != nil {
+			return err
+		}
+		if signer := crypto.PubkeyToAddress(*pubkey); trusted[signer] {
+			approved[signer] = true
+		}
+	}
+	if len(approved) < cfg.SignerThreshold() {
+		return fmt.Errorf("insufficient checkpoint signatures: have %d, want %d", len(approved), cfg.SignerThreshold())
+	}
+	// Checkpoint approved, make sure it supersedes the current one
+	bc.checkpointLock.Lock()
+	defer bc.checkpointLock.Unlock()
+
+	if number < bc.signedCheckpoint {
+		return errStaleCheckpoint
+	}
+	if known, ok := bc.checkpoints[number]; ok && known != hash {
+		return ErrCheckpointMismatch
+	}
+	if canon := rawdb.ReadCanonicalHash(bc.db, number); canon != (common.Hash{}) && canon != hash {
+		log.Warn("Signed checkpoint conflicts with local chain", "number", number, "hash", hash, "local", canon)
+	}
+	bc.checkpoints[number] = hash
+	bc.signedCheckpoint = number
+	rawdb.WriteSignedCheckpoint(bc.db, number, hash)
+
+	log.Info("Accepted signed checkpoint", "number", number, "hash", hash, "signers", len(approved))
+	return nil
+}
+
+// checkCheckpoint returns an error if the given block conflicts with any of the
+// finalized checkpoints.
+func (bc *BlockChain) checkCheckpoint(block *types.Block) error {
+	bc.checkpointLock.RLock()
+	defer bc.checkpointLock.RUnlock()
+
+	if hash, ok := bc.checkpoints[block.NumberU64()]; ok && hash != block.Hash() {
+		return ErrCheckpointMismatch
+	}
+	return nil
+}
+
+// CurrentFinalizedBlock retrieves the newest canonical block that the chain is
+// not allowed to reorganise away anymore, either due to being a checkpoint, or
+// due to being deeper than the maximum permitted reorg depth.
+func (bc *BlockChain) CurrentFinalizedBlock() *types.Block {
+	return bc.GetBlockByNumber(bc.finalizedNumber(bc.CurrentBlock()))
+}
+
+// finalizedNumber returns the number of the newest finalized block, assuming
+// the given block is the current head of the chain.
+func (bc *BlockChain) finalizedNumber(head *types.Block) uint64 {
+	var finalized uint64
+
+	cfg := bc.chainConfig.Finality
+	if cfg != nil && cfg.MaxReorgDepth > 0 && head.NumberU64() > cfg.MaxReorgDepth {
+		finalized = head.NumberU64() - cfg.MaxReorgDepth
+	}
+	bc.checkpointLock.RLock()
+	defer bc.checkpointLock.RUnlock()
+
+	for number, hash := range bc.checkpoints {
+		if number <= finalized || number > head.NumberU64() {
+			continue
+		}
+		if rawdb.ReadCanonicalHash(bc.db, number) == hash {
+			finalized = number
+		}
+	}
+	return finalized
+}
+
+// checkReorg returns an error if reorganising the chain from the given old head
+// back to the common ancestor would violate the finality rules.
+func (bc *BlockChain) checkReorg(oldHead, ancestor *types.Block) error {
+	if cfg := bc.chainConfig.Finality; cfg != nil && cfg.MaxReorgDepth > 0 {
+		if depth := oldHead.NumberU64() - ancestor.NumberU64(); depth > cfg.MaxReorgDepth {
+			log.Warn("Refusing too deep chain reorg", "ancestor", ancestor.Number(), "head", oldHead.Number(), "depth", depth, "limit", cfg.MaxReorgDepth)
+			return ErrReorgTooDeep
+		}
+	}
+	if finalized := bc.finalizedNumber(oldHead); ancestor.NumberU64() < finalized {
+		log.Warn("Refusing chain reorg beyond finalized block", "ancestor", ancestor.Number(), "finalized", finalized)
+		return ErrFinalizedReorg
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/consensus/esshash"
+	"github.com/orangeAndSuns/essentia/core/types"
+	"github.com/orangeAndSuns/essentia/core/vm"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/essdb"
+	"github.com/orangeAndSuns/essentia/params"
+)
+
+// newFinalityTester creates a chain with the given finality rules and imports
+// the shared and original chain segments into it, returning the chain and a
+// longer competitor segment forking off the shared one.
+func newFinalityTester(t *testing.T, finality *params.FinalityConfig) (*BlockChain, []*types.Block, []*types.Block, []*types.Block) {
+	config := *params.TestChainConfig
+	config.Finality = finality
+
+	engine := esshash.NewFaker()
+
+	db := essdb.NewMemDatabase()
+	genesis := (&Genesis{Config: &config}).MustCommit(db)
+
+	shared, _ := GenerateChain(&config, genesis, engine, db, 4, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{1}) })
+	original, _ := GenerateChain(&config, shared[len(shared)-1], engine, db, 4, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{2}) })
+	competitor, _ := GenerateChain(&config, shared[len(shared)-1], engine, db, 5, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{3}) })
+
+	diskdb := essdb.NewMemDatabase()
+	(&Genesis{Config: &config}).MustCommit(diskdb)
+
+	chain, err := NewBlockChain(diskdb, nil, &config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if _, err := chain.InsertChain(append(shared, original...)); err != nil {
+		t.Fatalf("failed to insert original chain: %v", err)
+	}
+	return chain, shared, original, competitor
+}
+
+// Tests that reorgs within the configured depth are accepted.
+func TestShallowReorgAccepted(t *testing.T) {
+	chain, _, _, competitor := newFinalityTester(t, &params.FinalityConfig{MaxReorgDepth: 4})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(competitor); err != nil {
+		t.Fatalf("failed to reorg to competitor: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != competitor[len(competitor)-1].Hash() {
+		t.Fatalf("head mismatch: have #%d [%x…]", head.NumberU64(), head.Hash().Bytes()[:4])
+	}
+}
+
+// Tests that reorgs deeper than the configured depth are refused.
+func TestDeepReorgRefused(t *testing.T) {
+	chain, _, original, competitor := newFinalityTester(t, &params.FinalityConfig{MaxReorgDepth: 3})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(competitor); err != ErrReorgTooDeep {
+		t.Fatalf("reorg error mismatch: have %v, want %v", err, ErrReorgTooDeep)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != original[len(original)-1].Hash() {
+		t.Fatalf("head mismatch: have #%d [%x…]", head.NumberU64(), head.Hash().Bytes()[:4])
+	}
+	if finalized := chain.CurrentFinalizedBlock(); finalized.NumberU64() != 5 {
+		t.Fatalf("finalized number mismatch: have %d, want %d", finalized.NumberU64(), 5)
+	}
+}
+
+// Tests that reorgs beyond a hard coded checkpoint are refused, as are blocks
+// conflicting with it.
+func TestCheckpointReorgRefused(t *testing.T) {
+	// Generate the chain once to find out which block to checkpoint
+	chain, _, original, _ := newFinalityTester(t, nil)
+	chain.Stop()
+
+	checkpoints := map[uint64]common.Hash{original[0].NumberU64(): original[0].Hash()}
+	chain, _, original, competitor := newFinalityTester(t, &params.FinalityConfig{Checkpoints: checkpoints})
+	defer chain.Stop()
+
+	if finalized := chain.CurrentFinalizedBlock(); finalized.Hash() != original[0].Hash() {
+		t.Fatalf("finalized block mismatch: have #%d [%x…]", finalized.NumberU64(), finalized.Hash().Bytes()[:4])
+	}
+	if _, err := chain.InsertChain(competitor); err != ErrCheckpointMismatch {
+		t.Fatalf("import error mismatch: have %v, want %v", err, ErrCheckpointMismatch)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != original[len(original)-1].Hash() {
+		t.Fatalf("head mismatch: have #%d [%x…]", head.NumberU64(), head.Hash().Bytes()[:4])
+	}
+}
+
+// Tests that checkpoints signed by enough trusted signers are accepted and
+// persisted, while insufficiently signed or stale ones are rejected.
+func TestSignedCheckpoint(t *testing.T) {
+	var (
+		keys    = make([]*ecdsa.PrivateKey, 3)
+		signers = make([]common.Address, len(keys))
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		signers[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	chain, _, original, competitor := newFinalityTester(t, &params.FinalityConfig{Signers: signers})
+	defer chain.Stop()
+
+	sign := func(number uint64, hash common.Hash, keys ...*ecdsa.PrivateKey) [][]byte {
+		var sigs [][]byte
+		for _, key := range keys {
+			sig, err := crypto.Sign(CheckpointSigHash(number, hash), key)
+			if err != nil {
+				t.Fatalf("failed to sign checkpoint: %v", err)
+			}
+			sigs = append(sigs, sig)
+		}
+		return sigs
+	}
+	checkpoint := original[1]
+
+	// Insufficient and untrusted signatures must be rejected
+	outsider, _ := crypto.GenerateKey()
+	if err := chain.AddCheckpoint(checkpoint.NumberU64(), checkpoint.Hash(), sign(checkpoint.NumberU64(), checkpoint.Hash(), keys[0], keys[0], outsider)); err == nil {
+		t.Fatalf("insufficiently signed checkpoint accepted")
+	}
+	if finalized := chain.CurrentFinalizedBlock(); finalized.NumberU64() != 0 {
+		t.Fatalf("finalized number mismatch: have %d, want %d", finalized.NumberU64(), 0)
+	}
+	// A majority of trusted signers should finalize the block
+	if err := chain.AddCheckpoint(checkpoint.NumberU64(), checkpoint.Hash(), sign(checkpoint.NumberU64(), checkpoint.Hash(), keys[0], keys[2])); err != nil {
+		t.Fatalf("failed to add signed checkpoint: %v", err)
+	}
+	if finalized := chain.CurrentFinalizedBlock(); finalized.Hash() != checkpoint.Hash() {
+		t.Fatalf("finalized block mismatch: have #%d [%x…]", finalized.NumberU64(), finalized.Hash().Bytes()[:4])
+	}
+	if _, err := chain.InsertChain(competitor); err != ErrCheckpointMismatch {
+		t.Fatalf("import error mismatch: have %v, want %v", err, ErrCheckpointMismatch)
+	}
+	// Older checkpoints must be rejected
+	stale := original[0]
+	if err := chain.AddCheckpoint(stale.NumberU64(), stale.Hash(), sign(stale.NumberU64(), stale.Hash(), keys...)); err != errStaleCheckpoint {
+		t.Fatalf("stale checkpoint error mismatch: have %v, want %v", err, errStaleCheckpoint)
+	}
+	// The signed checkpoint should survive a restart
+	chain.Stop()
+	restarted, err := NewBlockChain(chain.db, nil, chain.chainConfig, chain.engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer restarted.Stop()
+
+	if finalized := restarted.CurrentFinalizedBlock(); finalized.Hash() != checkpoint.Hash() {
+		t.Fatalf("finalized block mismatch after restart: have #%d [%x…]", finalized.NumberU64(), finalized.Hash().Bytes()[:4])
+	}
+}
@@ -29,6 +29,18 @@ var (
 	// ErrBlacklistedHash is returned if a block to import is on the blacklist.
 	ErrBlacklistedHash = errors.New("blacklisted hash")
 
+	// ErrCheckpointMismatch is returned if a block to import conflicts with a
+	// finalized checkpoint.
+	ErrCheckpointMismatch = errors.New("checkpoint mismatch")
+
+	// ErrReorgTooDeep is returned if a block would cause a chain reorganisation
+	// dropping more canonical blocks than the configured maximum.
+	ErrReorgTooDeep = errors.New("reorg too deep")
+
+	// ErrFinalizedReorg is returned if a block would cause a chain reorganisation
+	// dropping an already finalized block.
+	ErrFinalizedReorg = errors.New("reorg beyond finalized block")
+
 	// ErrNonceTooHigh is returned if the nonce of a transaction is higher than the
 	// next one expected based on the local chain.
 	ErrNonceTooHigh = errors.New("nonce too high")
@@ -0,0 +1,169 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package forkid implements a compact identifier of the chain and fork rules a
+// node runs on, allowing peers to detect incompatibilities before syncing.
+package forkid
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math/big"
+	"sort"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/params"
+)
+
+var (
+	// ErrRemoteStale is returned by the filter if a remote fork checksum is a
+	// subset of our already applied forks, but the announced next fork block is
+	// not on our already passed chain.
+	ErrRemoteStale = errors.New("remote needs update")
+
+	// ErrLocalIncompatibleOrStale is returned by the filter if a remote fork
+	// checksum does not match any local checksum variation, signalling that the
+	// two chains have diverged in the past at some point (possibly at genesis).
+	ErrLocalIncompatibleOrStale = errors.New("local incompatible or needs update")
+)
+
+// ID is a fork identifier, consisting of a CRC32 checksum of the genesis hash
+// and all the fork blocks already passed, along with the next scheduled fork.
+type ID struct {
+	Hash [4]byte // CRC32 checksum of the genesis block and passed fork block numbers
+	Next uint64  // Block number of the next upcoming fork, or 0 if no forks are known
+}
+
+// Filter is a fork identifier validator, returning an error if a remote fork
+// identifier is incompatible with the local chain.
+type Filter func(id ID) error
+
+// NewID calculates the fork identifier for the given chain configuration,
+// genesis hash and current head block number.
+func NewID(config *params.ChainConfig, genesis common.Hash, head uint64) ID {
+	hash := crc32.ChecksumIEEE(genesis[:])
+
+	var next uint64
+	for _, fork := range gatherForks(config) {
+		if fork <= head {
+			hash = checksumUpdate(hash, fork)
+			continue
+		}
+		next = fork
+		break
+	}
+	return ID{Hash: checksumToBytes(hash), Next: next}
+}
+
+// NewFilter creates a filter validating remote fork identifiers against the
+// local chain, whose current head number is retrieved through the callback.
+func NewFilter(config *params.ChainConfig, genesis common.Hash, headfn func() uint64) Filter {
+	// Calculate all the valid fork checksums for the local chain
+	var (
+		forks = gatherForks(config)
+		sums  = make([][4]byte, len(forks)+1) // 0th is the genesis
+	)
+	hash := crc32.ChecksumIEEE(genesis[:])
+	sums[0] = checksumToBytes(hash)
+	for i, fork := range forks {
+		hash = checksumUpdate(hash, fork)
+		sums[i+1] = checksumToBytes(hash)
+	}
+	// Add a sentinel fork to avoid special casing the last checksum
+	forks = append(forks, ^uint64(0))
+
+	return func(id ID) error {
+		head := headfn()
+		for i, fork := range forks {
+			// Skip forks already passed, until the current fork is found
+			if head >= fork {
+				continue
+			}
+			// Local chain is on fork i, check remote against the current checksum
+			if sums[i] == id.Hash {
+				// Remote fork set is the same as ours; reject if the remote
+				// announces a fork we've already passed without applying it
+				if id.Next > 0 && head >= id.Next {
+					return ErrLocalIncompatibleOrStale
+				}
+				return nil
+			}
+			// Check whether the remote is a subset of our passed forks
+			for j := 0; j < i; j++ {
+				if sums[j] == id.Hash {
+					// Remote is stale, accept only if its next fork is our next one
+					if forks[j] != id.Next {
+						return ErrRemoteStale
+					}
+					return nil
+				}
+			}
+			// Check whether the remote is a superset of our forks, in which case
+			// we're the stale one and need to sync up
+			for j := i + 1; j < len(sums); j++ {
+				if sums[j] == id.Hash {
+					return nil
+				}
+			}
+			return ErrLocalIncompatibleOrStale
+		}
+		return ErrLocalIncompatibleOrStale
+	}
+}
+
+// checksumUpdate calculates the next fork checksum from the previous one and
+// a newly applied fork block number.
+func checksumUpdate(hash uint32, fork uint64) uint32 {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], fork)
+	return crc32.Update(hash, crc32.IEEETable, blob[:])
+}
+
+// checksumToBytes converts a uint32 checksum into a [4]byte array.
+func checksumToBytes(hash uint32) [4]byte {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], hash)
+	return blob
+}
+
+// gatherForks gathers all the known fork block numbers from the chain config,
+// sorted and deduplicated, omitting forks active from genesis.
+func gatherForks(config *params.ChainConfig) []uint64 {
+	var forks []uint64
+	for _, block := range []*big.Int{
+		config.HomesteadBlock,
+		config.DAOForkBlock,
+		config.EIP150Block,
+		config.EIP155Block,
+		config.EIP158Block,
+		config.ByzantiumBlock,
+		config.ConstantinopleBlock,
+	} {
+		if block != nil && block.Sign() > 0 {
+			forks = append(forks, block.Uint64())
+		}
+	}
+	sort.Slice(forks, func(i, j int) bool { return forks[i] < forks[j] })
+
+	for i := 1; i < len(forks); i++ {
+		if forks[i] == forks[i-1] {
+			forks = append(forks[:i], forks[i+1:]...)
+			i--
+		}
+	}
+	return forks
+}
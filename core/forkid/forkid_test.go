@@ -0,0 +1,126 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"hash/crc32"
+	"testing"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/params"
+)
+
+// Tests that fork identifiers are correctly calculated for the main network.
+func TestCreation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		want ID
+	}{
+		{0, ID{Hash: [4]byte{0xfc, 0x64, 0xec, 0x04}, Next: 1150000}},
+		{1149999, ID{Hash: [4]byte{0xfc, 0x64, 0xec, 0x04}, Next: 1150000}},
+		{1150000, ID{Hash: [4]byte{0x97, 0xc2, 0xc3, 0x4c}, Next: 1920000}},
+		{1920000, ID{Hash: [4]byte{0x91, 0xd1, 0xf9, 0x48}, Next: 2463000}},
+		{2463000, ID{Hash: [4]byte{0x7a, 0x64, 0xda, 0x13}, Next: 2675000}},
+		{2675000, ID{Hash: [4]byte{0x3e, 0xdd, 0x5b, 0x10}, Next: 4370000}},
+		{4370000, ID{Hash: [4]byte{0xa0, 0x0b, 0xc3, 0x24}, Next: 0}},
+		{9999999, ID{Hash: [4]byte{0xa0, 0x0b, 0xc3, 0x24}, Next: 0}},
+	}
+	for i, tt := range tests {
+		if have := NewID(params.MainnetChainConfig, params.MainnetGenesisHash, tt.head); have != tt.want {
+			t.Errorf("test %d: fork ID mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that fork identifiers are correctly calculated for the test network.
+func TestCreationTestnet(t *testing.T) {
+	tests := []struct {
+		head uint64
+		want ID
+	}{
+		{0, ID{Hash: [4]byte{0x30, 0xc7, 0xdd, 0xbc}, Next: 10}},
+		{9, ID{Hash: [4]byte{0x30, 0xc7, 0xdd, 0xbc}, Next: 10}},
+		{10, ID{Hash: [4]byte{0x63, 0x76, 0x01, 0x90}, Next: 1700000}},
+		{1699999, ID{Hash: [4]byte{0x63, 0x76, 0x01, 0x90}, Next: 1700000}},
+		{1700000, ID{Hash: [4]byte{0x3e, 0xa1, 0x59, 0xc7}, Next: 0}},
+	}
+	for i, tt := range tests {
+		if have := NewID(params.TestnetChainConfig, params.TestnetGenesisHash, tt.head); have != tt.want {
+			t.Errorf("test %d: fork ID mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that chains with all forks active from genesis, such as the ones used
+// in tests, have a constant fork identifier depending only on the genesis.
+func TestCreationGenesisForks(t *testing.T) {
+	genesis := common.HexToHash("0x1234")
+	for _, config := range []*params.ChainConfig{params.TestChainConfig, params.AllEsshashProtocolChanges} {
+		want := ID{Hash: checksumToBytes(crc32.ChecksumIEEE(genesis[:])), Next: 0}
+		for _, head := range []uint64{0, 1, 1000000} {
+			if have := NewID(config, genesis, head); have != want {
+				t.Errorf("config %v, head %d: fork ID mismatch: have %x, want %x", config, head, have, want)
+			}
+		}
+		filter := NewFilter(config, genesis, func() uint64 { return 0 })
+		if err := filter(want); err != nil {
+			t.Errorf("config %v: own fork ID rejected: %v", config, err)
+		}
+		if err := filter(NewID(config, params.MainnetGenesisHash, 0)); err != ErrLocalIncompatibleOrStale {
+			t.Errorf("config %v: foreign fork ID error mismatch: have %v, want %v", config, err, ErrLocalIncompatibleOrStale)
+		}
+	}
+}
+
+// Tests that remote fork identifiers are correctly validated against the local
+// chain at various head positions.
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		id   ID
+		err  error
+	}{
+		// Local and remote on the same fork, remote not announcing anything
+		{4370000, ID{Hash: [4]byte{0xa0, 0x0b, 0xc3, 0x24}, Next: 0}, nil},
+
+		// Local and remote on the same fork, remote announcing a future fork
+		{2675000, ID{Hash: [4]byte{0x3e, 0xdd, 0x5b, 0x10}, Next: 4370000}, nil},
+
+		// Local and remote on the same fork, remote announcing a fork already
+		// passed locally (local needs update or remote is broken)
+		{4370000, ID{Hash: [4]byte{0xa0, 0x0b, 0xc3, 0x24}, Next: 4000000}, ErrLocalIncompatibleOrStale},
+
+		// Remote on a previous fork, correctly announcing our current one
+		{4370000, ID{Hash: [4]byte{0x3e, 0xdd, 0x5b, 0x10}, Next: 4370000}, nil},
+
+		// Remote on a previous fork, not announcing our current one
+		{4370000, ID{Hash: [4]byte{0x3e, 0xdd, 0x5b, 0x10}, Next: 0}, ErrRemoteStale},
+
+		// Remote ahead of us on our own future forks, we need to sync
+		{2675000, ID{Hash: [4]byte{0xa0, 0x0b, 0xc3, 0x24}, Next: 0}, nil},
+
+		// Remote on an entirely different chain
+		{4370000, ID{Hash: [4]byte{0xaf, 0xec, 0x6b, 0x27}, Next: 0}, ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		head := tt.head
+		filter := NewFilter(params.MainnetChainConfig, params.MainnetGenesisHash, func() uint64 { return head })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
@@ -126,6 +126,24 @@ func WriteFastTrieProgress(db DatabaseWriter, count uint64) {
 	}
 }
 
+// ReadSignedCheckpoint retrieves the number and hash of the latest checkpoint
+// finalized by the trusted signers, or a zero hash if none was stored yet.
+func ReadSignedCheckpoint(db DatabaseReader) (uint64, common.Hash) {
+	data, _ := db.Get(signedCheckpointKey)
+	if len(data) != 8+common.HashLength {
+		return 0, common.Hash{}
+	}
+	return binary.BigEndian.Uint64(data[:8]), common.BytesToHash(data[8:])
+}
+
+// WriteSignedCheckpoint stores the number and hash of the latest checkpoint
+// finalized by the trusted signers.
+func WriteSignedCheckpoint(db DatabaseWriter, number uint64, hash common.Hash) {
+	if err := db.Put(signedCheckpointKey, append(encodeBlockNumber(number), hash.Bytes()...)); err != nil {
+		log.Crit("Failed to store signed checkpoint", "err", err)
+	}
+}
+
 // ReadHeaderRLP retrieves a block header in its raw RLP database encoding.
 func ReadHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := db.Get(headerKey(number, hash))
@@ -0,0 +1,94 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/consensus/esshash"
+	"github.com/orangeAndSuns/essentia/core/types"
+	"github.com/orangeAndSuns/essentia/core/vm"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/essdb"
+	"github.com/orangeAndSuns/essentia/params"
+)
+
+// Tests that the state prefetcher executes the transactions of a block on the
+// state it is given without touching the chain state, and that it stops when
+// interrupted or when the state is stale.
+func TestStatePrefetch(t *testing.T) {
+	var (
+		db      = essdb.NewMemDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		to      = common.Address{0x01}
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainID)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, esshash.NewFaker(), db, 2, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), to, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.AddTx(tx)
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, esshash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	prefetch := func(block *types.Block, interrupt *uint32) *big.Int {
+		statedb, err := chain.State()
+		if err != nil {
+			t.Fatalf("failed to get chain state: %v", err)
+		}
+		chain.prefetcher.Prefetch(block, statedb, chain.vmConfig, interrupt)
+		return statedb.GetBalance(to)
+	}
+	// An interrupted prefetch executes nothing
+	interrupt := uint32(1)
+	if balance := prefetch(blocks[0], &interrupt); balance.Sign() != 0 {
+		t.Errorf("interrupted prefetch executed transactions: balance %v", balance)
+	}
+	// A prefetch on the parent state executes the transactions
+	if balance := prefetch(blocks[0], nil); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("prefetch balance mismatch: have %v, want 1000", balance)
+	}
+	// A prefetch on a stale state stops at the first failing transaction
+	if balance := prefetch(blocks[1], nil); balance.Sign() != 0 {
+		t.Errorf("prefetch on stale state executed transactions: balance %v", balance)
+	}
+	// The chain state is untouched, and imports with prefetching succeed
+	statedb, _ := chain.State()
+	if balance := statedb.GetBalance(to); balance.Sign() != 0 {
+		t.Errorf("prefetch modified the chain state: balance %v", balance)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	statedb, _ = chain.State()
+	if balance := statedb.GetBalance(to); balance.Cmp(big.NewInt(2000)) != 0 {
+		t.Errorf("imported balance mismatch: have %v, want 2000", balance)
+	}
+}
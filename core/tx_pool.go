@@ -127,6 +127,9 @@ type TxPoolConfig struct {
 	Journal   string        // Journal of local transactions to survive node restarts
 	Rejournal time.Duration // Time interval to regenerate the local transaction journal
 
+	RemoteJournal    string // Journal of remote pending transactions to survive node restarts (empty = disabled)
+	RemoteJournalCap uint64 // Maximum number of remote transactions to keep in the remote journal
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
@@ -144,6 +147,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	RemoteJournalCap: 4096,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -163,6 +168,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool journal time", "provided", conf.Rejournal, "updated", time.Second)
 		conf.Rejournal = time.Second
 	}
+	if conf.RemoteJournal != "" && conf.RemoteJournalCap < 1 {
+		log.Warn("Sanitizing invalid txpool remote journal cap", "provided", conf.RemoteJournalCap, "updated", DefaultTxPoolConfig.RemoteJournalCap)
+		conf.RemoteJournalCap = DefaultTxPoolConfig.RemoteJournalCap
+	}
 	if conf.PriceLimit < 1 {
 		log.Warn("Sanitizing invalid txpool price limit", "provided", conf.PriceLimit, "updated", DefaultTxPoolConfig.PriceLimit)
 		conf.PriceLimit = DefaultTxPoolConfig.PriceLimit
@@ -197,8 +206,9 @@ type TxPool struct {
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 	currentMaxGas uint64              // Current gas limit for transaction caps
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *txJournal  // Journal of local transaction to back up to disk
+	locals        *accountSet // Set of local transaction to exempt from eviction rules
+	journal       *txJournal  // Journal of local transaction to back up to disk
+	remoteJournal *txJournal  // Journal of remote pending transactions to back up to disk
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
@@ -245,6 +255,18 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote transaction journaling is enabled, load from disk too. The remote
+	// transactions are revalidated against the current head state on insertion.
+	if config.RemoteJournal != "" {
+		pool.remoteJournal = newTxJournal(config.RemoteJournal)
+
+		if err := pool.remoteJournal.load(pool.AddRemotes); err != nil {
+			log.Warn("Failed to load remote transaction journal", "err", err)
+		}
+		if err := pool.remoteJournal.rotate(pool.remotes()); err != nil {
+			log.Warn("Failed to rotate remote transaction journal", "err", err)
+		}
+	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
@@ -324,7 +346,7 @@ func (pool *TxPool) loop() {
 			}
 			pool.mu.Unlock()
 
-		// Handle local transaction journal rotation
+		// Handle local and remote transaction journal rotation
 		case <-journal.C:
 			if pool.journal != nil {
 				pool.mu.Lock()
@@ -333,6 +355,13 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			if pool.remoteJournal != nil {
+				pool.mu.Lock()
+				if err := pool.remoteJournal.rotate(pool.remotes()); err != nil {
+					log.Warn("Failed to rotate remote tx journal", "err", err)
+				}
+				pool.mu.Unlock()
+			}
 		}
 	}
 }
@@ -442,6 +471,15 @@ func (pool *TxPool) Stop() {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	// Remote transactions are not journaled on arrival, dump the final pool
+	if pool.remoteJournal != nil {
+		pool.mu.Lock()
+		if err := pool.remoteJournal.rotate(pool.remotes()); err != nil {
+			log.Warn("Failed to rotate remote tx journal", "err", err)
+		}
+		pool.mu.Unlock()
+		pool.remoteJournal.close()
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -550,6 +588,32 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// remotes retrieves the most valuable remote pending transactions, groupped by
+// origin account and sorted by nonce, capped to the configured remote journal
+// size. Transactions are picked in price order, but never with nonce gaps.
+func (pool *TxPool) remotes() map[common.Address]types.Transactions {
+	pending := make(map[common.Address]types.Transactions)
+	for addr, list := range pool.pending {
+		if !pool.locals.contains(addr) {
+			pending[addr] = list.Flatten()
+		}
+	}
+	txs := make(map[common.Address]types.Transactions)
+
+	set := types.NewTransactionsByPriceAndNonce(pool.signer, pending)
+	for count := uint64(0); count < pool.config.RemoteJournalCap; count++ {
+		tx := set.Peek()
+		if tx == nil {
+			break
+		}
+		from, _ := types.Sender(pool.signer, tx) // already validated
+		txs[from] = append(txs[from], tx)
+
+		set.Shift()
+	}
+	return txs
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -1680,6 +1680,81 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that remote pending transactions are journaled when enabled, that the
+// journal is capped to the most valuable transactions without nonce gaps, and
+// that reloaded transactions are revalidated against the current state.
+func TestTransactionRemoteJournaling(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the journal
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	// Clean up the temporary file, we only need the path for now
+	file.Close()
+	os.Remove(journal)
+
+	// Create the original pool to inject transaction into the journal
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(essdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.NoLocals = true
+	config.RemoteJournal = journal
+	config.RemoteJournalCap = 3
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	// Create a cheap and an expensive remote account
+	cheap, _ := crypto.GenerateKey()
+	pricey, _ := crypto.GenerateKey()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(cheap.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(pricey.PublicKey), big.NewInt(1000000000))
+
+	// Add more remote transactions than the journal can hold
+	txs := types.Transactions{
+		pricedTransaction(0, 100000, big.NewInt(1), cheap),
+		pricedTransaction(1, 100000, big.NewInt(1), cheap),
+		pricedTransaction(0, 100000, big.NewInt(2), pricey),
+		pricedTransaction(1, 100000, big.NewInt(2), pricey),
+	}
+	for i, err := range pool.AddRemotes(txs) {
+		if err != nil {
+			t.Fatalf("failed to add remote transaction %d: %v", i, err)
+		}
+	}
+	if pending, _ := pool.Stats(); pending != 4 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 4)
+	}
+	// Terminate the old pool, bump the expensive account's nonce and ensure the
+	// most valuable remote transactions survive, except for the now stale one
+	pool.Stop()
+	statedb.SetNonce(crypto.PubkeyToAddress(pricey.PublicKey), 1)
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	pending, queued := pool.Stats()
+	if pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	if queued != 0 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
+	}
+	if pool.Get(txs[0].Hash()) == nil || pool.Get(txs[3].Hash()) == nil {
+		t.Fatalf("expected journaled transactions missing")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // TestTransactionStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestTransactionStatusCheck(t *testing.T) {
@@ -245,6 +245,16 @@ func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	return true
 }
 
+// AddCheckpoint submits a checkpoint signed by the trusted signers, finalizing
+// the given block. The chain will not reorganise beyond it afterwards.
+func (api *PrivateAdminAPI) AddCheckpoint(number hexutil.Uint64, hash common.Hash, sigs []hexutil.Bytes) error {
+	blobs := make([][]byte, len(sigs))
+	for i, sig := range sigs {
+		blobs[i] = sig
+	}
+	return api.ess.BlockChain().AddCheckpoint(uint64(number), hash, blobs)
+}
+
 // ImportChain imports a blockchain from a local file.
 func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	// Make sure the can access the file to import
@@ -318,6 +328,8 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 	var block *types.Block
 	if blockNr == rpc.LatestBlockNumber {
 		block = api.ess.blockchain.CurrentBlock()
+	} else if blockNr == rpc.FinalizedBlockNumber {
+		block = api.ess.blockchain.CurrentFinalizedBlock()
 	} else {
 		block = api.ess.blockchain.GetBlockByNumber(uint64(blockNr))
 	}
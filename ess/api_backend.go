@@ -57,6 +57,11 @@ func (b *EssAPIBackend) SetHead(number uint64) {
 	b.ess.blockchain.SetHead(number)
 }
 
+func (b *EssAPIBackend) SetHeadByHash(hash common.Hash) error {
+	b.ess.protocolManager.downloader.Cancel()
+	return b.ess.blockchain.SetHeadByHash(hash)
+}
+
 func (b *EssAPIBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
 	// Pending block is only known by the miner
 	if blockNr == rpc.PendingBlockNumber {
@@ -67,6 +72,9 @@ func (b *EssAPIBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 	if blockNr == rpc.LatestBlockNumber {
 		return b.ess.blockchain.CurrentBlock().Header(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		return b.ess.blockchain.CurrentFinalizedBlock().Header(), nil
+	}
 	return b.ess.blockchain.GetHeaderByNumber(uint64(blockNr)), nil
 }
 
@@ -84,6 +92,9 @@ func (b *EssAPIBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumb
 	if blockNr == rpc.LatestBlockNumber {
 		return b.ess.blockchain.CurrentBlock(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		return b.ess.blockchain.CurrentFinalizedBlock(), nil
+	}
 	return b.ess.blockchain.GetBlockByNumber(uint64(blockNr)), nil
 }
 
@@ -101,6 +101,8 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		from = api.ess.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		from = api.ess.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		from = api.ess.blockchain.CurrentFinalizedBlock()
 	default:
 		from = api.ess.blockchain.GetBlockByNumber(uint64(start))
 	}
@@ -109,6 +111,8 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		to = api.ess.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		to = api.ess.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		to = api.ess.blockchain.CurrentFinalizedBlock()
 	default:
 		to = api.ess.blockchain.GetBlockByNumber(uint64(end))
 	}
@@ -346,6 +350,8 @@ func (api *PrivateDebugAPI) TraceBlockByNumber(ctx context.Context, number rpc.B
 		block = api.ess.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		block = api.ess.blockchain.CurrentBlock()
+	case rpc.FinalizedBlockNumber:
+		block = api.ess.blockchain.CurrentFinalizedBlock()
 	default:
 		block = api.ess.blockchain.GetBlockByNumber(uint64(number))
 	}
@@ -55,6 +55,7 @@ type LesServer interface {
 	Start(srvr *p2p.Server)
 	Stop()
 	Protocols() []p2p.Protocol
+	APIs() []rpc.API
 	SetBloomBitsIndexer(bbIndexer *core.ChainIndexer)
 }
 
@@ -145,7 +146,14 @@ func New(ctx *node.ServiceContext, config *Config) (*Essentia, error) {
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{
+			Disabled:       config.NoPruning,
+			TrieCleanLimit: config.TrieCleanCache,
+			TrieNodeLimit:  config.TrieCache,
+			TrieTimeLimit:  config.TrieTimeout,
+			NoPrefetch:     config.NoPrefetch,
+			RecoverState:   config.RecoverState,
+		}
 	)
 	ess.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, ess.chainConfig, ess.engine, vmConfig)
 	if err != nil {
@@ -162,6 +170,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Essentia, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.RemoteJournal != "" {
+		config.TxPool.RemoteJournal = ctx.ResolvePath(config.TxPool.RemoteJournal)
+	}
 	ess.txPool = core.NewTxPool(config.TxPool, ess.chainConfig, ess.blockchain)
 
 	if ess.protocolManager, err = NewProtocolManager(ess.chainConfig, config.SyncMode, config.NetworkId, ess.eventMux, ess.txPool, ess.engine, ess.blockchain, chainDb); err != nil {
@@ -248,8 +259,8 @@ func (s *Essentia) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
-	// Append all the local APIs and return
-	return append(apis, []rpc.API{
+	// Append all the local APIs and the ones of the LES server
+	apis = append(apis, []rpc.API{
 		{
 			Namespace: "ess",
 			Version:   "1.0",
@@ -295,6 +306,10 @@ func (s *Essentia) APIs() []rpc.API {
 			Public:    true,
 		},
 	}...)
+	if s.lesServer != nil {
+		apis = append(apis, s.lesServer.APIs()...)
+	}
+	return apis
 }
 
 func (s *Essentia) ResetWithGenesisBlock(gb *types.Block) {
@@ -399,6 +414,9 @@ func (s *Essentia) Start(srvr *p2p.Server) error {
 		}
 		maxPeers -= s.config.LightPeers
 	}
+	// Keep the chain advertised in the node record up to date
+	startEssEntryUpdate(s.blockchain, srvr)
+
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(maxPeers)
 	if s.lesServer != nil {
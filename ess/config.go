@@ -44,12 +44,13 @@ var DefaultConfig = Config{
 		DatasetsOnDisk: 2,
 		Difficulty:     big.NewInt(1),
 	},
-	NetworkId:     1,
-	LightPeers:    100,
-	DatabaseCache: 768,
-	TrieCache:     256,
-	TrieTimeout:   60 * time.Minute,
-	GasPrice:      big.NewInt(18 * params.Shannon),
+	NetworkId:      1,
+	LightPeers:     100,
+	DatabaseCache:  768,
+	TrieCleanCache: 256,
+	TrieCache:      256,
+	TrieTimeout:    60 * time.Minute,
+	GasPrice:       big.NewInt(18 * params.Shannon),
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
@@ -84,16 +85,28 @@ type Config struct {
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// RecoverState regenerates missing state on startup by re-executing blocks
+	// instead of rewinding the chain to the newest block with state available.
+	RecoverState bool `toml:",omitempty"`
+
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
 
+	// Ultra light client options
+	ULC *ULCConfig `toml:",omitempty"`
+
+	// Checkpoint oracle from which light clients learn trusted checkpoints
+	CheckpointOracle *CheckpointOracleConfig `toml:",omitempty"`
+
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
+	TrieCleanCache     int
 	TrieCache          int
 	TrieTimeout        time.Duration
+	NoPrefetch         bool // Whether to disable prefetching and only load state on demand
 
 	// Mining-related options
 	ESSBase      common.Address `toml:",omitempty"`
@@ -117,6 +130,26 @@ type Config struct {
 	DocRoot string `toml:"-"`
 }
 
+// DefaultULCMinTrustedFraction is the default percentage of trusted servers
+// which must announce a head before an ultra light client accepts it.
+const DefaultULCMinTrustedFraction = 75
+
+// ULCConfig contains the options of the ultra light client mode, in which a
+// light client follows the heads signed by a set of trusted LES servers instead
+// of downloading and verifying the header chain.
+type ULCConfig struct {
+	TrustedServers     []string `toml:",omitempty"` // Enode URLs of the trusted servers
+	MinTrustedFraction int      `toml:",omitempty"` // Percentage of trusted servers which must announce a head (1-100)
+}
+
+// CheckpointOracleConfig describes the on-chain registry in which a threshold
+// of admins publishes trusted checkpoints for light clients.
+type CheckpointOracleConfig struct {
+	Address   common.Address   // Address of the oracle contract
+	Signers   []common.Address // Admins whose signatures a light client accepts
+	Threshold int              // Number of signatures a checkpoint needs to be accepted
+}
+
 type configMarshaling struct {
 	ExtraData hexutil.Bytes
 }
@@ -0,0 +1,56 @@
+// Copyright 2017 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package ess
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/naoina/toml"
+)
+
+// Tests that the settings of the config survive a TOML round trip, i.e. that
+// the generated TOML codec knows about them.
+func TestConfigTOML(t *testing.T) {
+	settings := toml.Config{
+		NormFieldName: func(rt reflect.Type, key string) string { return key },
+		FieldToKey:    func(rt reflect.Type, field string) string { return field },
+	}
+	config := DefaultConfig
+	config.RecoverState = true
+	config.TrieCleanCache = 123
+	config.NoPrefetch = true
+
+	out, err := settings.Marshal(&config)
+	if err != nil {
+		t.Fatalf("failed to encode config: %v", err)
+	}
+	var decoded Config
+	if err := settings.NewDecoder(bytes.NewReader(out)).Decode(&decoded); err != nil {
+		t.Fatalf("failed to decode config: %v", err)
+	}
+	if decoded.RecoverState != config.RecoverState {
+		t.Errorf("RecoverState mismatch: have %v, want %v", decoded.RecoverState, config.RecoverState)
+	}
+	if decoded.TrieCleanCache != config.TrieCleanCache {
+		t.Errorf("TrieCleanCache mismatch: have %d, want %d", decoded.TrieCleanCache, config.TrieCleanCache)
+	}
+	if decoded.NoPrefetch != config.NoPrefetch {
+		t.Errorf("NoPrefetch mismatch: have %v, want %v", decoded.NoPrefetch, config.NoPrefetch)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package ess
+
+import (
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/core"
+	"github.com/orangeAndSuns/essentia/core/forkid"
+	"github.com/orangeAndSuns/essentia/log"
+	"github.com/orangeAndSuns/essentia/p2p"
+	"github.com/orangeAndSuns/essentia/p2p/enr"
+	"github.com/orangeAndSuns/essentia/rlp"
+)
+
+// essEntry is the "ess" ENR entry which advertises the chain a node is on,
+// allowing dialers to skip nodes of other networks before connecting.
+type essEntry struct {
+	Genesis common.Hash
+	ForkID  forkid.ID
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e essEntry) ENRKey() string {
+	return "ess"
+}
+
+// currentEssEntry constructs the ess entry from the current chain head.
+func currentEssEntry(chain *core.BlockChain) *essEntry {
+	genesis := chain.Genesis().Hash()
+	return &essEntry{
+		Genesis: genesis,
+		ForkID:  forkid.NewID(chain.Config(), genesis, chain.CurrentHeader().Number.Uint64()),
+	}
+}
+
+// newEssDialFilter creates a dial filter which only accepts nodes advertising
+// the same genesis and a fork identifier compatible with the local chain.
+func newEssDialFilter(chain *core.BlockChain) func(r *enr.Record) bool {
+	genesis := chain.Genesis().Hash()
+	filter := forkid.NewFilter(chain.Config(), genesis, func() uint64 {
+		return chain.CurrentHeader().Number.Uint64()
+	})
+	return func(r *enr.Record) bool {
+		var entry essEntry
+		if err := r.Load(&entry); err != nil {
+			return false
+		}
+		if entry.Genesis != genesis {
+			return false
+		}
+		return filter(entry.ForkID) == nil
+	}
+}
+
+// startEssEntryUpdate keeps the ess entry of the local node record in sync
+// with the fork identifier of the chain head. The update loop terminates when
+// the blockchain is stopped.
+func startEssEntryUpdate(chain *core.BlockChain, srvr *p2p.Server) {
+	var (
+		headCh  = make(chan core.ChainHeadEvent, 10)
+		headSub = chain.SubscribeChainHeadEvent(headCh)
+		current = currentEssEntry(chain)
+	)
+	go func() {
+		defer headSub.Unsubscribe()
+
+		for {
+			select {
+			case <-headCh:
+				next := currentEssEntry(chain)
+				if next.ForkID == current.ForkID {
+					continue
+				}
+				current = next
+				if err := srvr.SetRecordEntry(current); err != nil {
+					log.Warn("Failed to update ess node record entry", "err", err)
+				}
+			case <-headSub.Err():
+				return
+			}
+		}
+	}()
+}
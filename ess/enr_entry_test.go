@@ -0,0 +1,71 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package ess
+
+import (
+	"testing"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/consensus/esshash"
+	"github.com/orangeAndSuns/essentia/core"
+	"github.com/orangeAndSuns/essentia/core/forkid"
+	"github.com/orangeAndSuns/essentia/core/vm"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/essdb"
+	"github.com/orangeAndSuns/essentia/p2p/enr"
+	"github.com/orangeAndSuns/essentia/params"
+)
+
+// Tests that the dial filter built from the ess entry only accepts nodes on
+// the same chain.
+func TestEssDialFilter(t *testing.T) {
+	db := essdb.NewMemDatabase()
+	gspec := &core.Genesis{Config: params.TestChainConfig}
+	gspec.MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, esshash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	local := currentEssEntry(chain)
+	tests := []struct {
+		entry enr.Entry
+		want  bool
+	}{
+		{entry: nil, want: false},
+		{entry: local, want: true},
+		{entry: &essEntry{Genesis: common.Hash{1}, ForkID: local.ForkID}, want: false},
+		{entry: &essEntry{Genesis: local.Genesis, ForkID: forkid.ID{Hash: [4]byte{1, 2, 3, 4}}}, want: false},
+		{entry: enr.WithEntry("ess", []byte{0x01}), want: false},
+	}
+	filter := newEssDialFilter(chain)
+	key, _ := crypto.GenerateKey()
+	for i, tt := range tests {
+		var r enr.Record
+		if tt.entry != nil {
+			r.Set(tt.entry)
+		}
+		if err := enr.SignV4(&r, key); err != nil {
+			t.Fatalf("test %d: failed to sign record: %v", i, err)
+		}
+		if have := filter(&r); have != tt.want {
+			t.Errorf("test %d: filter mismatch: have %t, want %t", i, have, tt.want)
+		}
+	}
+}
@@ -40,4 +40,14 @@ var (
 	headerFilterOutMeter = metrics.NewRegisteredMeter("ess/fetcher/filter/headers/out", nil)
 	bodyFilterInMeter    = metrics.NewRegisteredMeter("ess/fetcher/filter/bodies/in", nil)
 	bodyFilterOutMeter   = metrics.NewRegisteredMeter("ess/fetcher/filter/bodies/out", nil)
+
+	txAnnounceInMeter    = metrics.NewRegisteredMeter("ess/fetcher/tx/announces/in", nil)
+	txAnnounceKnownMeter = metrics.NewRegisteredMeter("ess/fetcher/tx/announces/known", nil)
+	txAnnounceDOSMeter   = metrics.NewRegisteredMeter("ess/fetcher/tx/announces/dos", nil)
+
+	txBroadcastInMeter  = metrics.NewRegisteredMeter("ess/fetcher/tx/broadcasts/in", nil)
+	txRequestInMeter    = metrics.NewRegisteredMeter("ess/fetcher/tx/requests/in", nil)
+	txDropMeter         = metrics.NewRegisteredMeter("ess/fetcher/tx/drop", nil)
+	txFetchMeter        = metrics.NewRegisteredMeter("ess/fetcher/fetch/txs", nil)
+	txFetchTimeoutMeter = metrics.NewRegisteredMeter("ess/fetcher/fetch/txs/timeout", nil)
 )
@@ -0,0 +1,450 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/core/types"
+	"github.com/orangeAndSuns/essentia/log"
+)
+
+const (
+	txArriveTimeout = 500 * time.Millisecond // Time allowance before an announced transaction is explicitly requested
+	txFetchTimeout  = 5 * time.Second        // Maximum allotted time to return an explicitly requested transaction
+	txHashLimit     = 4096                   // Maximum number of unique transactions a peer may have announced
+
+	// MaxTransactionFetch is the maximum number of transactions that are requested
+	// from, or served to a single peer in one go.
+	MaxTransactionFetch = 256
+)
+
+// txCheckerFn is a callback type for checking whether a transaction is already
+// known locally (e.g. contained in the transaction pool).
+type txCheckerFn func(common.Hash) bool
+
+// txRequesterFn is a callback type for sending a transaction retrieval request.
+type txRequesterFn func([]common.Hash) error
+
+// txAdderFn is a callback type for injecting a batch of transactions into the
+// local transaction pool.
+type txAdderFn func([]*types.Transaction) []error
+
+// txAnnounce is the hash notification of the availability of a single new
+// transaction in the network.
+type txAnnounce struct {
+	hash   common.Hash // Hash of the transaction being announced
+	time   time.Time   // Timestamp of the announcement (or the request when fetching)
+	origin string      // Identifier of the peer originating the notification
+
+	fetchTxs txRequesterFn // Fetcher function to retrieve the announced transaction
+}
+
+// txNotify is a batch of transaction announcements from a single peer.
+type txNotify struct {
+	origin   string
+	hashes   []common.Hash
+	time     time.Time
+	fetchTxs txRequesterFn
+}
+
+// TxFetcher is responsible for accumulating transaction announcements from
+// various peers and scheduling them for retrieval, so that the full transaction
+// bodies only need to be transferred once.
+type TxFetcher struct {
+	// Various event channels
+	notify  chan *txNotify
+	cleanup chan []common.Hash
+	drop    chan string
+	quit    chan struct{}
+
+	// Announce states
+	announces  map[string]int                // Per peer announce counts to prevent memory exhaustion
+	announced  map[common.Hash][]*txAnnounce // Announced transactions, scheduled for fetching
+	fetching   map[common.Hash]*txAnnounce   // Announced transactions, currently fetching
+	alternates map[common.Hash][]*txAnnounce // Further announcers of in-flight transactions, used on timeout
+
+	// Callbacks
+	hasTx  txCheckerFn // Checks whether a transaction is already known locally
+	addTxs txAdderFn   // Injects a batch of transactions into the pool
+
+	// Testing hooks
+	announceChangeHook func(common.Hash, bool)     // Method to call upon adding or deleting a hash from the announce list
+	fetchingHook       func(string, []common.Hash) // Method to call upon starting a transaction fetch
+}
+
+// NewTxFetcher creates a transaction fetcher to retrieve transactions based on
+// hash announcements.
+func NewTxFetcher(hasTx txCheckerFn, addTxs txAdderFn) *TxFetcher {
+	return &TxFetcher{
+		notify:     make(chan *txNotify),
+		cleanup:    make(chan []common.Hash),
+		drop:       make(chan string),
+		quit:       make(chan struct{}),
+		announces:  make(map[string]int),
+		announced:  make(map[common.Hash][]*txAnnounce),
+		fetching:   make(map[common.Hash]*txAnnounce),
+		alternates: make(map[common.Hash][]*txAnnounce),
+		hasTx:      hasTx,
+		addTxs:     addTxs,
+	}
+}
+
+// Start boots up the announcement based transaction retriever, accepting and
+// processing hash notifications and transaction deliveries until termination
+// is requested.
+func (f *TxFetcher) Start() {
+	go f.loop()
+}
+
+// Stop terminates the announcement based transaction retriever, canceling all
+// pending operations.
+func (f *TxFetcher) Stop() {
+	close(f.quit)
+}
+
+// Notify announces the fetcher of the potential availability of a batch of new
+// transactions in the network.
+func (f *TxFetcher) Notify(peer string, hashes []common.Hash, time time.Time, fetchTxs txRequesterFn) error {
+	notification := &txNotify{
+		origin:   peer,
+		hashes:   hashes,
+		time:     time,
+		fetchTxs: fetchTxs,
+	}
+	select {
+	case f.notify <- notification:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Enqueue imports a batch of transactions into the transaction pool and removes
+// all traces of them from the fetcher's announce and retrieval queues. The direct
+// flag specifies whether the transactions were explicitly requested or arrived
+// via a full broadcast.
+func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool) error {
+	if direct {
+		txRequestInMeter.Mark(int64(len(txs)))
+	} else {
+		txBroadcastInMeter.Mark(int64(len(txs)))
+	}
+	// Push the transactions into the pool, tracking the rejected ones
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	var dropped int
+	for _, err := range f.addTxs(txs) {
+		if err != nil {
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		log.Trace("Discarded delivered transactions", "peer", peer, "direct", direct, "count", dropped)
+		txDropMeter.Mark(int64(dropped))
+	}
+	// Remove the now known transactions from the scheduler
+	select {
+	case f.cleanup <- hashes:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Drop removes all the announcements originating from a disconnected peer.
+func (f *TxFetcher) Drop(peer string) error {
+	select {
+	case f.drop <- peer:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Loop is the main fetcher loop, checking and processing various notification
+// events.
+func (f *TxFetcher) loop() {
+	fetchTimer := time.NewTimer(0)
+
+	for {
+		select {
+		case <-f.quit:
+			// Fetcher terminating, abort all operations
+			return
+
+		case notification := <-f.notify:
+			// A batch of transactions was announced, make sure the peer isn't DOSing us
+			txAnnounceInMeter.Mark(int64(len(notification.hashes)))
+
+			count := f.announces[notification.origin]
+			for _, hash := range notification.hashes {
+				if count >= txHashLimit {
+					log.Debug("Peer exceeded outstanding tx announces", "peer", notification.origin, "limit", txHashLimit)
+					txAnnounceDOSMeter.Mark(1)
+					break
+				}
+				// Skip anything we already know about or are already pulling
+				if f.hasTx(hash) || f.announcedBy(hash, notification.origin) {
+					txAnnounceKnownMeter.Mark(1)
+					continue
+				}
+				announce := &txAnnounce{
+					hash:     hash,
+					time:     notification.time,
+					origin:   notification.origin,
+					fetchTxs: notification.fetchTxs,
+				}
+				if _, ok := f.fetching[hash]; ok {
+					f.alternates[hash] = append(f.alternates[hash], announce)
+					count++
+					continue
+				}
+				if f.announceChangeHook != nil && len(f.announced[hash]) == 0 {
+					f.announceChangeHook(hash, true)
+				}
+				f.announced[hash] = append(f.announced[hash], announce)
+				count++
+			}
+			f.announces[notification.origin] = count
+			f.rescheduleFetch(fetchTimer)
+
+		case hashes := <-f.cleanup:
+			// A batch of transactions arrived, remove all traces of them
+			for _, hash := range hashes {
+				f.forgetHash(hash)
+			}
+			f.rescheduleFetch(fetchTimer)
+
+		case peer := <-f.drop:
+			// A peer disconnected, forget its announces and retry its retrievals elsewhere
+			f.dropAnnounces(peer)
+			f.rescheduleFetch(fetchTimer)
+
+		case <-fetchTimer.C:
+			// Time out any retrievals that didn't complete, falling back to alternate
+			// announcers if there are any available.
+			for hash, announce := range f.fetching {
+				if time.Since(announce.time) > txFetchTimeout {
+					log.Trace("Transaction retrieval timed out", "peer", announce.origin, "hash", hash)
+					txFetchTimeoutMeter.Mark(1)
+					f.retry(hash)
+				}
+			}
+			// At least one transaction's timer ran out, check for needing retrieval
+			request := make(map[string][]common.Hash)
+			fetchers := make(map[string]txRequesterFn)
+
+			for hash, announces := range f.announced {
+				if time.Since(announces[0].time) <= txArriveTimeout-gatherSlack {
+					continue
+				}
+				// Pick a random peer to retrieve from, keep the others as fallbacks
+				idx := rand.Intn(len(announces))
+				announce := announces[idx]
+
+				delete(f.announced, hash)
+				if f.announceChangeHook != nil {
+					f.announceChangeHook(hash, false)
+				}
+				alternates := append(announces[:idx:idx], announces[idx+1:]...)
+
+				// If the transaction still didn't arrive, queue for fetching
+				if f.hasTx(hash) {
+					for _, ann := range announces {
+						f.decAnnounce(ann.origin)
+					}
+					continue
+				}
+				announce.time = time.Now()
+				f.fetching[hash] = announce
+				if len(alternates) > 0 {
+					f.alternates[hash] = alternates
+				}
+				request[announce.origin] = append(request[announce.origin], hash)
+				fetchers[announce.origin] = announce.fetchTxs
+			}
+			// Send out all transaction requests, split up into allowed chunks
+			for peer, hashes := range request {
+				log.Trace("Fetching scheduled transactions", "peer", peer, "count", len(hashes))
+
+				fetchTxs, peer, hashes := fetchers[peer], peer, hashes
+				go func() {
+					if f.fetchingHook != nil {
+						f.fetchingHook(peer, hashes)
+					}
+					for len(hashes) > 0 {
+						batch := hashes
+						if len(batch) > MaxTransactionFetch {
+							batch = batch[:MaxTransactionFetch]
+						}
+						hashes = hashes[len(batch):]
+
+						txFetchMeter.Mark(int64(len(batch)))
+						if err := fetchTxs(batch); err != nil {
+							log.Debug("Failed to request transactions", "peer", peer, "err", err)
+							return
+						}
+					}
+				}()
+			}
+			// Schedule the next fetch if transactions are still pending
+			f.rescheduleFetch(fetchTimer)
+		}
+	}
+}
+
+// rescheduleFetch resets the specified fetch timer to the next announce or
+// retrieval timeout.
+func (f *TxFetcher) rescheduleFetch(fetch *time.Timer) {
+	// Short circuit if no transactions are announced or in flight
+	if len(f.announced) == 0 && len(f.fetching) == 0 {
+		return
+	}
+	// Otherwise find the earliest expiring event
+	earliest := time.Now().Add(txFetchTimeout)
+	for _, announces := range f.announced {
+		if deadline := announces[0].time.Add(txArriveTimeout); deadline.Before(earliest) {
+			earliest = deadline
+		}
+	}
+	for _, announce := range f.fetching {
+		if deadline := announce.time.Add(txFetchTimeout); deadline.Before(earliest) {
+			earliest = deadline
+		}
+	}
+	fetch.Reset(time.Until(earliest))
+}
+
+// retry abandons an in-flight retrieval of a transaction and reschedules it
+// from any alternate announcers, or forgets it completely if there are none.
+func (f *TxFetcher) retry(hash common.Hash) {
+	announce := f.fetching[hash]
+	delete(f.fetching, hash)
+	f.decAnnounce(announce.origin)
+
+	alternates := f.alternates[hash]
+	delete(f.alternates, hash)
+	if len(alternates) == 0 {
+		return
+	}
+	// Reinsert the alternates with an expired arrival time to fetch on next tick
+	for _, alt := range alternates {
+		alt.time = time.Now().Add(-txArriveTimeout)
+	}
+	if f.announceChangeHook != nil {
+		f.announceChangeHook(hash, true)
+	}
+	f.announced[hash] = alternates
+}
+
+// dropAnnounces removes all traces of a peer from the fetcher's internal state.
+// Retrievals in flight from the peer are rescheduled via alternate announcers.
+func (f *TxFetcher) dropAnnounces(peer string) {
+	for hash, announces := range f.announced {
+		kept := announces[:0]
+		for _, announce := range announces {
+			if announce.origin != peer {
+				kept = append(kept, announce)
+			}
+		}
+		if len(kept) == 0 {
+			delete(f.announced, hash)
+			if f.announceChangeHook != nil {
+				f.announceChangeHook(hash, false)
+			}
+		} else {
+			f.announced[hash] = kept
+		}
+	}
+	for hash, alternates := range f.alternates {
+		kept := alternates[:0]
+		for _, announce := range alternates {
+			if announce.origin != peer {
+				kept = append(kept, announce)
+			}
+		}
+		if len(kept) == 0 {
+			delete(f.alternates, hash)
+		} else {
+			f.alternates[hash] = kept
+		}
+	}
+	for hash, announce := range f.fetching {
+		if announce.origin == peer {
+			f.retry(hash)
+		}
+	}
+	delete(f.announces, peer)
+}
+
+// forgetHash removes all traces of a transaction announcement from the fetcher's
+// internal state.
+func (f *TxFetcher) forgetHash(hash common.Hash) {
+	if announces, ok := f.announced[hash]; ok {
+		for _, announce := range announces {
+			f.decAnnounce(announce.origin)
+		}
+		delete(f.announced, hash)
+		if f.announceChangeHook != nil {
+			f.announceChangeHook(hash, false)
+		}
+	}
+	if announce := f.fetching[hash]; announce != nil {
+		f.decAnnounce(announce.origin)
+		delete(f.fetching, hash)
+	}
+	for _, announce := range f.alternates[hash] {
+		f.decAnnounce(announce.origin)
+	}
+	delete(f.alternates, hash)
+}
+
+// announcedBy checks whether the given peer already has a pending announcement
+// or retrieval tracked for a transaction.
+func (f *TxFetcher) announcedBy(hash common.Hash, peer string) bool {
+	if announce := f.fetching[hash]; announce != nil && announce.origin == peer {
+		return true
+	}
+	for _, announce := range f.announced[hash] {
+		if announce.origin == peer {
+			return true
+		}
+	}
+	for _, announce := range f.alternates[hash] {
+		if announce.origin == peer {
+			return true
+		}
+	}
+	return false
+}
+
+// decAnnounce decrements the outstanding announce counter of a peer, removing
+// it altogether if no more announcements are tracked.
+func (f *TxFetcher) decAnnounce(peer string) {
+	if _, ok := f.announces[peer]; !ok {
+		return
+	}
+	f.announces[peer]--
+	if f.announces[peer] <= 0 {
+		delete(f.announces, peer)
+	}
+}
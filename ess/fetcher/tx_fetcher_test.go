@@ -0,0 +1,271 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/core/types"
+)
+
+// makeTransactions creates a batch of unique dummy transactions.
+func makeTransactions(n int) ([]common.Hash, map[common.Hash]*types.Transaction) {
+	hashes := make([]common.Hash, 0, n)
+	txs := make(map[common.Hash]*types.Transaction)
+
+	for i := 0; i < n; i++ {
+		tx := types.NewTransaction(uint64(i), common.Address{}, big.NewInt(0), 0, big.NewInt(0), nil)
+		hashes = append(hashes, tx.Hash())
+		txs[tx.Hash()] = tx
+	}
+	return hashes, txs
+}
+
+// txFetcherTester is a test simulator for mocking out a local transaction pool.
+type txFetcherTester struct {
+	fetcher *TxFetcher
+
+	pool map[common.Hash]*types.Transaction // Transactions belonging to the tester
+	lock sync.RWMutex
+}
+
+// newTxTester creates a new transaction fetcher test mocker.
+func newTxTester() *txFetcherTester {
+	tester := &txFetcherTester{
+		pool: make(map[common.Hash]*types.Transaction),
+	}
+	tester.fetcher = NewTxFetcher(tester.hasTx, tester.addTxs)
+	tester.fetcher.Start()
+
+	return tester
+}
+
+// hasTx checks whether the tester's pool contains a transaction.
+func (f *txFetcherTester) hasTx(hash common.Hash) bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	_, ok := f.pool[hash]
+	return ok
+}
+
+// addTxs injects a batch of transactions into the tester's pool.
+func (f *txFetcherTester) addTxs(txs []*types.Transaction) []error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, tx := range txs {
+		f.pool[tx.Hash()] = tx
+	}
+	return make([]error, len(txs))
+}
+
+// makeTxFetcher retrieves a transaction fetcher associated with a simulated peer.
+// If deliver is false, the requests are swallowed to simulate an unresponsive peer.
+func (f *txFetcherTester) makeTxFetcher(peer string, txs map[common.Hash]*types.Transaction, deliver bool) txRequesterFn {
+	closure := make(map[common.Hash]*types.Transaction)
+	for hash, tx := range txs {
+		closure[hash] = tx
+	}
+	return func(hashes []common.Hash) error {
+		if !deliver {
+			return nil
+		}
+		var txs []*types.Transaction
+		for _, hash := range hashes {
+			if tx, ok := closure[hash]; ok {
+				txs = append(txs, tx)
+			}
+		}
+		go f.fetcher.Enqueue(peer, txs, true)
+		return nil
+	}
+}
+
+// verifyTxFetchingEvent verifies that one single event arrive on a fetching
+// channel, containing the given number of hashes.
+func verifyTxFetchingEvent(t *testing.T, fetching chan []common.Hash, count int) {
+	select {
+	case hashes := <-fetching:
+		if len(hashes) != count {
+			t.Fatalf("fetch count mismatch: have %d, want %d", len(hashes), count)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("fetching timeout")
+	}
+}
+
+// verifyTxPoolCount waits until the tester's pool contains the given number of
+// transactions.
+func verifyTxPoolCount(t *testing.T, tester *txFetcherTester, count int) {
+	for i := 0; i < 100; i++ {
+		tester.lock.RLock()
+		have := len(tester.pool)
+		tester.lock.RUnlock()
+
+		if have == count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("pool count mismatch: want %d", count)
+}
+
+// Tests that announced transactions are retrieved after the arrival timeout and
+// imported into the local pool.
+func TestTxSequentialAnnouncements(t *testing.T) {
+	hashes, txs := makeTransactions(5)
+
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	fetching := make(chan []common.Hash, 5)
+	tester.fetcher.fetchingHook = func(peer string, hashes []common.Hash) { fetching <- hashes }
+
+	fetchTxs := tester.makeTxFetcher("valid", txs, true)
+	for _, hash := range hashes {
+		tester.fetcher.Notify("valid", []common.Hash{hash}, time.Now().Add(-txArriveTimeout), fetchTxs)
+		verifyTxFetchingEvent(t, fetching, 1)
+	}
+	verifyTxPoolCount(t, tester, len(hashes))
+}
+
+// Tests that transactions announced by multiple peers are only retrieved once,
+// and that already known transactions are not retrieved at all.
+func TestTxAnnounceDeduplication(t *testing.T) {
+	hashes, txs := makeTransactions(4)
+
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	// Inject one of the transactions into the pool beforehand
+	tester.addTxs([]*types.Transaction{txs[hashes[0]]})
+
+	var requests int32
+	fetching := make(chan []common.Hash, 4)
+	tester.fetcher.fetchingHook = func(peer string, hashes []common.Hash) {
+		atomic.AddInt32(&requests, int32(len(hashes)))
+		fetching <- hashes
+	}
+	// Announce the same set of transactions from two peers concurrently
+	now := time.Now()
+	tester.fetcher.Notify("first", hashes, now, tester.makeTxFetcher("first", txs, true))
+	tester.fetcher.Notify("second", hashes, now, tester.makeTxFetcher("second", txs, true))
+	tester.fetcher.Notify("first", hashes, now, tester.makeTxFetcher("first", txs, true))
+
+	verifyTxPoolCount(t, tester, len(hashes))
+	if n := atomic.LoadInt32(&requests); n != int32(len(hashes)-1) {
+		t.Fatalf("retrieval count mismatch: have %v, want %v", n, len(hashes)-1)
+	}
+}
+
+// Tests that broadcast transactions arriving before the announcements are
+// retrieved cancel any pending retrievals.
+func TestTxBroadcastCancelsAnnounce(t *testing.T) {
+	hashes, txs := makeTransactions(3)
+
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	announced := make(chan bool, 2*len(hashes))
+	tester.fetcher.announceChangeHook = func(hash common.Hash, added bool) { announced <- added }
+	tester.fetcher.fetchingHook = func(peer string, hashes []common.Hash) {
+		t.Errorf("unexpected retrieval: %v", hashes)
+	}
+	tester.fetcher.Notify("announcer", hashes, time.Now(), tester.makeTxFetcher("announcer", txs, true))
+	for i := 0; i < len(hashes); i++ {
+		if added := <-announced; !added {
+			t.Fatalf("announce %d: unexpected removal", i)
+		}
+	}
+	var batch []*types.Transaction
+	for _, hash := range hashes {
+		batch = append(batch, txs[hash])
+	}
+	tester.fetcher.Enqueue("broadcaster", batch, false)
+	for i := 0; i < len(hashes); i++ {
+		if added := <-announced; added {
+			t.Fatalf("announce %d: unexpected addition", i)
+		}
+	}
+	time.Sleep(txArriveTimeout)
+	verifyTxPoolCount(t, tester, len(hashes))
+}
+
+// Tests that if a peer that is being fetched from disconnects, the retrieval is
+// rescheduled from an alternate announcer.
+func TestTxDropFallback(t *testing.T) {
+	hashes, txs := makeTransactions(1)
+
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	fetching := make(chan string, 2)
+	tester.fetcher.fetchingHook = func(peer string, hashes []common.Hash) { fetching <- peer }
+
+	// Announce from two unresponsive peers, wait for the first retrieval
+	now := time.Now().Add(-txArriveTimeout)
+	tester.fetcher.Notify("first", hashes, now, tester.makeTxFetcher("first", txs, false))
+	tester.fetcher.Notify("second", hashes, now, tester.makeTxFetcher("second", txs, false))
+
+	var origin string
+	select {
+	case origin = <-fetching:
+	case <-time.After(time.Second):
+		t.Fatalf("fetching timeout")
+	}
+	// Drop the peer being fetched from and ensure the other one takes over
+	tester.fetcher.Drop(origin)
+	select {
+	case peer := <-fetching:
+		if peer == origin {
+			t.Fatalf("retrieval rescheduled from dropped peer %s", peer)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("fallback fetching timeout")
+	}
+}
+
+// Tests that a peer is unable to use unbounded memory with sending infinite
+// transaction announcements to a node.
+func TestTxAnnounceMemoryExhaustionAttack(t *testing.T) {
+	hashes, txs := makeTransactions(txHashLimit + 16)
+
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	var announces int32
+	tester.fetcher.announceChangeHook = func(hash common.Hash, added bool) {
+		if added {
+			atomic.AddInt32(&announces, 1)
+		} else {
+			atomic.AddInt32(&announces, -1)
+		}
+	}
+	tester.fetcher.Notify("attacker", hashes, time.Now(), tester.makeTxFetcher("attacker", txs, false))
+
+	// Sync with the fetcher loop to ensure the announcement was processed
+	tester.fetcher.Notify("valid", nil, time.Now(), nil)
+	if n := atomic.LoadInt32(&announces); n != txHashLimit {
+		t.Fatalf("queued announce count mismatch: have %d, want %d", n, txHashLimit)
+	}
+}
@@ -47,6 +47,14 @@ type Backend interface {
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 }
 
+// logsFilterer is implemented by backends which can retrieve the logs matching
+// the filter criteria in a range of blocks without downloading all receipts of
+// the blocks, such as light clients connected to servers filtering the logs.
+type logsFilterer interface {
+	CanFilterLogs() bool
+	FilterLogs(ctx context.Context, begin, end uint64, addresses []common.Address, topics [][]common.Hash) ([]*types.Log, error)
+}
+
 // Filter can be used to retrieve and filter logs.
 type Filter struct {
 	backend Backend
@@ -142,6 +150,18 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.end == -1 {
 		end = head
 	}
+	if f.begin == rpc.FinalizedBlockNumber.Int64() || f.end == rpc.FinalizedBlockNumber.Int64() {
+		finalized, err := f.backend.HeaderByNumber(ctx, rpc.FinalizedBlockNumber)
+		if finalized == nil || err != nil {
+			return nil, err
+		}
+		if f.begin == rpc.FinalizedBlockNumber.Int64() {
+			f.begin = finalized.Number.Int64()
+		}
+		if f.end == rpc.FinalizedBlockNumber.Int64() {
+			end = finalized.Number.Uint64()
+		}
+	}
 	// Gather all indexed logs, and finish with non indexed ones
 	var (
 		logs []*types.Log
@@ -198,7 +218,12 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 			if header == nil || err != nil {
 				return logs, err
 			}
-			found, err := f.checkMatches(ctx, header)
+			var found []*types.Log
+			if lf, ok := f.backend.(logsFilterer); ok && lf.CanFilterLogs() {
+				found, err = lf.FilterLogs(ctx, number, number, f.addresses, f.topics)
+			} else {
+				found, err = f.checkMatches(ctx, header)
+			}
 			if err != nil {
 				return logs, err
 			}
@@ -213,6 +238,13 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 // indexedLogs returns the logs matching the filter criteria based on raw block
 // iteration and bloom matching.
 func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
+	if lf, ok := f.backend.(logsFilterer); ok && lf.CanFilterLogs() && f.begin <= int64(end) {
+		logs, err := lf.FilterLogs(ctx, uint64(f.begin), end, f.addresses, f.topics)
+		if err == nil {
+			f.begin = int64(end) + 1
+		}
+		return logs, err
+	}
 	var logs []*types.Log
 
 	for ; f.begin <= int64(end); f.begin++ {
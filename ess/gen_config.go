@@ -4,6 +4,7 @@ package ess
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/orangeAndSuns/essentia/common"
 	"github.com/orangeAndSuns/essentia/common/hexutil"
@@ -15,21 +16,30 @@ import (
 
 var _ = (*configMarshaling)(nil)
 
+// MarshalTOML marshals as TOML.
 func (c Config) MarshalTOML() (interface{}, error) {
 	type Config struct {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               uint64
 		SyncMode                downloader.SyncMode
-		LightServ               int  `toml:",omitempty"`
-		LightPeers              int  `toml:",omitempty"`
-		SkipBcVersionCheck      bool `toml:"-"`
-		DatabaseHandles         int  `toml:"-"`
+		NoPruning               bool
+		RecoverState            bool                    `toml:",omitempty"`
+		LightServ               int                     `toml:",omitempty"`
+		LightPeers              int                     `toml:",omitempty"`
+		ULC                     *ULCConfig              `toml:",omitempty"`
+		CheckpointOracle        *CheckpointOracleConfig `toml:",omitempty"`
+		SkipBcVersionCheck      bool                    `toml:"-"`
+		DatabaseHandles         int                     `toml:"-"`
 		DatabaseCache           int
-		ESSBase               common.Address `toml:",omitempty"`
+		TrieCleanCache          int
+		TrieCache               int
+		TrieTimeout             time.Duration
+		NoPrefetch              bool
+		ESSBase                 common.Address `toml:",omitempty"`
 		MinerThreads            int            `toml:",omitempty"`
 		ExtraData               hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
-		ESShash                  esshash.Config
+		ESShash                 esshash.Config
 		TxPool                  core.TxPoolConfig
 		GPO                     gasprice.Config
 		EnablePreimageRecording bool
@@ -39,11 +49,19 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Genesis = c.Genesis
 	enc.NetworkId = c.NetworkId
 	enc.SyncMode = c.SyncMode
+	enc.NoPruning = c.NoPruning
+	enc.RecoverState = c.RecoverState
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
+	enc.ULC = c.ULC
+	enc.CheckpointOracle = c.CheckpointOracle
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
+	enc.TrieCleanCache = c.TrieCleanCache
+	enc.TrieCache = c.TrieCache
+	enc.TrieTimeout = c.TrieTimeout
+	enc.NoPrefetch = c.NoPrefetch
 	enc.ESSBase = c.ESSBase
 	enc.MinerThreads = c.MinerThreads
 	enc.ExtraData = c.ExtraData
@@ -56,21 +74,30 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	return &enc, nil
 }
 
+// UnmarshalTOML unmarshals from TOML.
 func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	type Config struct {
 		Genesis                 *core.Genesis `toml:",omitempty"`
 		NetworkId               *uint64
 		SyncMode                *downloader.SyncMode
-		LightServ               *int  `toml:",omitempty"`
-		LightPeers              *int  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool `toml:"-"`
-		DatabaseHandles         *int  `toml:"-"`
+		NoPruning               *bool
+		RecoverState            *bool                   `toml:",omitempty"`
+		LightServ               *int                    `toml:",omitempty"`
+		LightPeers              *int                    `toml:",omitempty"`
+		ULC                     *ULCConfig              `toml:",omitempty"`
+		CheckpointOracle        *CheckpointOracleConfig `toml:",omitempty"`
+		SkipBcVersionCheck      *bool                   `toml:"-"`
+		DatabaseHandles         *int                    `toml:"-"`
 		DatabaseCache           *int
-		ESSBase               *common.Address `toml:",omitempty"`
+		TrieCleanCache          *int
+		TrieCache               *int
+		TrieTimeout             *time.Duration
+		NoPrefetch              *bool
+		ESSBase                 *common.Address `toml:",omitempty"`
 		MinerThreads            *int            `toml:",omitempty"`
 		ExtraData               *hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
-		ESShash                  *esshash.Config
+		ESShash                 *esshash.Config
 		TxPool                  *core.TxPoolConfig
 		GPO                     *gasprice.Config
 		EnablePreimageRecording *bool
@@ -89,12 +116,24 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.SyncMode != nil {
 		c.SyncMode = *dec.SyncMode
 	}
+	if dec.NoPruning != nil {
+		c.NoPruning = *dec.NoPruning
+	}
+	if dec.RecoverState != nil {
+		c.RecoverState = *dec.RecoverState
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
 	if dec.LightPeers != nil {
 		c.LightPeers = *dec.LightPeers
 	}
+	if dec.ULC != nil {
+		c.ULC = dec.ULC
+	}
+	if dec.CheckpointOracle != nil {
+		c.CheckpointOracle = dec.CheckpointOracle
+	}
 	if dec.SkipBcVersionCheck != nil {
 		c.SkipBcVersionCheck = *dec.SkipBcVersionCheck
 	}
@@ -104,6 +143,18 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DatabaseCache != nil {
 		c.DatabaseCache = *dec.DatabaseCache
 	}
+	if dec.TrieCleanCache != nil {
+		c.TrieCleanCache = *dec.TrieCleanCache
+	}
+	if dec.TrieCache != nil {
+		c.TrieCache = *dec.TrieCache
+	}
+	if dec.TrieTimeout != nil {
+		c.TrieTimeout = *dec.TrieTimeout
+	}
+	if dec.NoPrefetch != nil {
+		c.NoPrefetch = *dec.NoPrefetch
+	}
 	if dec.ESSBase != nil {
 		c.ESSBase = *dec.ESSBase
 	}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/orangeAndSuns/essentia/common"
 	"github.com/orangeAndSuns/essentia/core"
+	"github.com/orangeAndSuns/essentia/core/forkid"
 	"github.com/orangeAndSuns/essentia/core/types"
 	"github.com/orangeAndSuns/essentia/event"
 	"github.com/orangeAndSuns/essentia/rlp"
@@ -32,16 +33,18 @@ import (
 const (
 	ess62 = 62
 	ess63 = 63
+	ess64 = 64
+	ess65 = 65
 )
 
 // ProtocolName is the official short name of the protocol used during capability negotiation.
 var ProtocolName = "ess"
 
 // ProtocolVersions are the upported versions of the ess protocol (first is primary).
-var ProtocolVersions = []uint{ess63, ess62}
+var ProtocolVersions = []uint{ess65, ess64, ess63, ess62}
 
 // ProtocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{17, 8}
+var ProtocolLengths = []uint64{17, 17, 17, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -62,6 +65,11 @@ const (
 	NodeDataMsg    = 0x0e
 	GetReceiptsMsg = 0x0f
 	ReceiptsMsg    = 0x10
+
+	// Protocol messages belonging to ess/64
+	NewPooledTransactionHashesMsg = 0x08
+	GetPooledTransactionsMsg      = 0x09
+	PooledTransactionsMsg         = 0x0a
 )
 
 type errCode int
@@ -76,6 +84,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrForkIDRejected
 )
 
 func (e errCode) String() string {
@@ -93,6 +102,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrForkIDRejected:          "Fork ID rejected",
 }
 
 type txPool interface {
@@ -106,6 +116,10 @@ type txPool interface {
 	// SubscribeNewTxsEvent should return an event subscription of
 	// NewTxsEvent and send events to the given channel.
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
+
+	// Get should return a transaction from the pool if it is known, or nil
+	// otherwise.
+	Get(hash common.Hash) *types.Transaction
 }
 
 // statusData is the network packet for the status message.
@@ -117,6 +131,17 @@ type statusData struct {
 	GenesisBlock    common.Hash
 }
 
+// statusData65 is the network packet for the status message since ess/65,
+// extending the original one with the fork identifier of the sender.
+type statusData65 struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	TD              *big.Int
+	CurrentBlock    common.Hash
+	GenesisBlock    common.Hash
+	ForkID          forkid.ID
+}
+
 // newBlockHashesData is the network packet for the block announcements.
 type newBlockHashesData []struct {
 	Hash   common.Hash // Hash of one particular block being announced
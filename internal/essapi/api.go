@@ -1464,6 +1464,12 @@ func (api *PrivateDebugAPI) SetHead(number hexutil.Uint64) {
 	api.b.SetHead(uint64(number))
 }
 
+// SetHeadByHash rewinds the head of the blockchain to the block with the given
+// hash, which may also reside on a side chain.
+func (api *PrivateDebugAPI) SetHeadByHash(hash common.Hash) error {
+	return api.b.SetHeadByHash(hash)
+}
+
 // PublicNetAPI offers network related RPC methods
 type PublicNetAPI struct {
 	net            *p2p.Server
@@ -47,6 +47,7 @@ type Backend interface {
 
 	// BlockChain API
 	SetHead(number uint64)
+	SetHeadByHash(hash common.Hash) error
 	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
 	BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error)
 	StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error)
@@ -0,0 +1,79 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+
+	"github.com/orangeAndSuns/essentia/common/hexutil"
+	"github.com/orangeAndSuns/essentia/contracts/checkpointoracle"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+)
+
+var errNoCheckpoint = errors.New("no local checkpoint available")
+
+// PrivateLightServerAPI provides an API to manage the capacity assigned to
+// the clients of a LES server.
+type PrivateLightServerAPI struct {
+	server *LesServer
+}
+
+// NewPrivateLightServerAPI creates a new LES server API.
+func NewPrivateLightServerAPI(server *LesServer) *PrivateLightServerAPI {
+	return &PrivateLightServerAPI{server: server}
+}
+
+// TotalCapacity returns the capacity shared by all clients.
+func (api *PrivateLightServerAPI) TotalCapacity() hexutil.Uint64 {
+	return hexutil.Uint64(api.server.clientPool.totalCap)
+}
+
+// FreeClientCapacity returns the capacity of a free client.
+func (api *PrivateLightServerAPI) FreeClientCapacity() hexutil.Uint64 {
+	return hexutil.Uint64(api.server.clientPool.freeCap)
+}
+
+// SetClientCapacity assigns capacity to a priority client. Zero capacity turns
+// it into a free client. The new capacity of a connected client is signaled to
+// it immediately.
+func (api *PrivateLightServerAPI) SetClientCapacity(id discover.ESSNodeID, cap uint64) error {
+	return api.server.clientPool.setCapacity(id, cap)
+}
+
+// PriorityClients returns the capacities assigned to priority clients.
+func (api *PrivateLightServerAPI) PriorityClients() map[discover.ESSNodeID]hexutil.Uint64 {
+	caps := make(map[discover.ESSNodeID]hexutil.Uint64)
+	for id, cap := range api.server.clientPool.priorityClients() {
+		caps[id] = hexutil.Uint64(cap)
+	}
+	return caps
+}
+
+// Clients returns the capacity and usage of the connected clients.
+func (api *PrivateLightServerAPI) Clients() map[discover.ESSNodeID]*poolClientInfo {
+	return api.server.clientPool.clients()
+}
+
+// LatestCheckpoint returns the latest checkpoint generated locally, which the
+// admins of a checkpoint oracle can sign and publish.
+func (api *PrivateLightServerAPI) LatestCheckpoint() (*checkpointoracle.Checkpoint, error) {
+	cp := api.server.localCheckpoint()
+	if cp == nil {
+		return nil, errNoCheckpoint
+	}
+	return cp, nil
+}
@@ -18,6 +18,9 @@ package les
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	gomath "math"
 	"math/big"
 
 	"github.com/orangeAndSuns/essentia/accounts"
@@ -56,10 +59,26 @@ func (b *LesApiBackend) SetHead(number uint64) {
 	b.ess.blockchain.SetHead(number)
 }
 
+func (b *LesApiBackend) SetHeadByHash(hash common.Hash) error {
+	header := b.ess.blockchain.GetHeaderByHash(hash)
+	if header == nil {
+		return fmt.Errorf("unknown header [%x…]", hash[:4])
+	}
+	if rawdb.ReadCanonicalHash(b.ess.chainDb, header.Number.Uint64()) != hash {
+		return errors.New("light client cannot rewind to side chain headers")
+	}
+	b.ess.protocolManager.downloader.Cancel()
+	b.ess.blockchain.SetHead(header.Number.Uint64())
+	return nil
+}
+
 func (b *LesApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
 	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
 		return b.ess.blockchain.CurrentHeader(), nil
 	}
+	if blockNr == rpc.FinalizedBlockNumber {
+		return nil, errors.New("finalized blocks are not tracked by light clients")
+	}
 	return b.ess.blockchain.GetHeaderByNumberOdr(ctx, uint64(blockNr))
 }
 
@@ -101,14 +120,51 @@ func (b *LesApiBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*typ
 	return nil, nil
 }
 
+// CanFilterLogs reports whether a connected server can filter logs.
+func (b *LesApiBackend) CanFilterLogs() bool {
+	for _, p := range b.ess.peers.AllPeers() {
+		if p.version >= lpv3 {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterLogs retrieves the logs matching the given criteria in the canonical
+// blocks begin..end, fetching only the receipts containing them.
+func (b *LesApiBackend) FilterLogs(ctx context.Context, begin, end uint64, addresses []common.Address, topics [][]common.Hash) ([]*types.Log, error) {
+	return light.GetFilteredLogs(ctx, b.ess.odr, begin, end, light.LogFilter{Addresses: addresses, Topics: topics})
+}
+
 func (b *LesApiBackend) GetTd(hash common.Hash) *big.Int {
 	return b.ess.blockchain.GetTdByHash(hash)
 }
 
-func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
-	state.SetBalance(msg.From(), math.MaxBig256)
+func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, statedb *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
 	context := core.NewEVMContext(msg, header, b.ess.blockchain, nil)
-	return vm.NewEVM(context, state, b.ess.chainConfig, vmCfg), state.Error, nil
+
+	// Execute the message speculatively first, fetching the accessed state in
+	// batches instead of one round trip per trie entry.
+	err := light.PrefetchState(ctx, header, b.ess.odr, func(spec *state.StateDB) error {
+		spec.SetBalance(msg.From(), math.MaxBig256)
+		evm := vm.NewEVM(context, spec, b.ess.chainConfig, vmCfg)
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				evm.Cancel()
+			case <-done:
+			}
+		}()
+		_, _, _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(gomath.MaxUint64))
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	statedb.SetBalance(msg.From(), math.MaxBig256)
+	return vm.NewEVM(context, statedb, b.ess.chainConfig, vmCfg), statedb.Error, nil
 }
 
 func (b *LesApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
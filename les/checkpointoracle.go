@@ -0,0 +1,193 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	essentia "github.com/orangeAndSuns/essentia"
+	"github.com/orangeAndSuns/essentia/accounts/abi/bind"
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/common/hexutil"
+	"github.com/orangeAndSuns/essentia/contracts/checkpointoracle"
+	"github.com/orangeAndSuns/essentia/ess"
+	"github.com/orangeAndSuns/essentia/internal/essapi"
+	"github.com/orangeAndSuns/essentia/light"
+	"github.com/orangeAndSuns/essentia/log"
+	"github.com/orangeAndSuns/essentia/rpc"
+)
+
+const (
+	// oracleCallTimeout is the time limit of reading the checkpoint oracle contract.
+	oracleCallTimeout = time.Second
+	// oracleRefreshInterval is the interval between two reads of the oracle
+	// contract by servers, which cache the latest checkpoint for handshakes.
+	oracleRefreshInterval = time.Minute
+)
+
+// signedCheckpoint is a checkpoint published in the oracle contract along with
+// the admin signatures approving it. Servers send it to clients during the
+// handshake, who only accept it if enough of their configured signers signed it.
+type signedCheckpoint struct {
+	Checkpoint checkpointoracle.Checkpoint
+	Signatures [][]byte
+}
+
+// checkpointOracle reads the latest checkpoint from the oracle contract on the
+// server side and verifies the checkpoints announced by servers on the client side.
+type checkpointOracle struct {
+	config *ess.CheckpointOracleConfig
+	oracle *checkpointoracle.CheckpointOracle // nil on clients
+
+	lock       sync.RWMutex
+	checkpoint *signedCheckpoint // latest checkpoint read from the contract
+}
+
+// newCheckpointOracle creates a checkpoint oracle handler for the given config.
+// Servers pass a caller executing contract calls on their local chain.
+func newCheckpointOracle(config *ess.CheckpointOracleConfig, caller bind.ContractCaller) *checkpointOracle {
+	if config == nil {
+		return nil
+	}
+	o := &checkpointOracle{config: config}
+	if caller != nil {
+		oracle, err := checkpointoracle.NewCheckpointOracle(config.Address, caller)
+		if err != nil {
+			log.Error("Failed to bind checkpoint oracle", "address", config.Address, "err", err)
+			return nil
+		}
+		o.oracle = oracle
+	}
+	return o
+}
+
+// start reads the oracle contract of a server now and then periodically until
+// quit is closed, so that handshakes don't have to.
+func (o *checkpointOracle) start(quit chan struct{}) {
+	if o == nil || o.oracle == nil {
+		return
+	}
+	o.refresh()
+	go func() {
+		ticker := time.NewTicker(oracleRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				o.refresh()
+			case <-quit:
+				return
+			}
+		}
+	}()
+}
+
+// refresh reads the latest checkpoint published in the oracle contract and
+// caches it. The cached checkpoint is kept if the contract can't be read.
+func (o *checkpointOracle) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), oracleCallTimeout)
+	defer cancel()
+
+	cp, sigs, err := o.oracle.LatestCheckpoint(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		log.Debug("Failed to read checkpoint oracle", "address", o.config.Address, "err", err)
+		return
+	}
+	var checkpoint *signedCheckpoint
+	if cp != nil {
+		checkpoint = &signedCheckpoint{Checkpoint: *cp, Signatures: sigs}
+	}
+	o.lock.Lock()
+	o.checkpoint = checkpoint
+	o.lock.Unlock()
+}
+
+// latest returns the cached latest checkpoint published in the oracle
+// contract, or nil if there is none or it wasn't read yet.
+func (o *checkpointOracle) latest() *signedCheckpoint {
+	if o == nil {
+		return nil
+	}
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.checkpoint
+}
+
+// verify reports whether the checkpoint is approved by the configured signers.
+func (o *checkpointOracle) verify(cp *signedCheckpoint) bool {
+	return checkpointoracle.VerifySigners(&cp.Checkpoint, o.config.Address, cp.Signatures, o.config.Signers, o.config.Threshold)
+}
+
+// applyCheckpoint adds the checkpoint announced by the peer to the trusted ones
+// if it is newer than the locally known sections and approved by the signers.
+func (pm *ProtocolManager) applyCheckpoint(p *peer) bool {
+	if pm.oracle == nil || p.checkpoint == nil {
+		return false
+	}
+	lc := pm.blockchain.(*light.LightChain)
+	cp := p.checkpoint.Checkpoint
+	if indexer := lc.Odr().ChtIndexer(); indexer != nil {
+		if sections, _, _ := indexer.Sections(); cp.SectionIndex < sections {
+			return false
+		}
+	}
+	if !pm.oracle.verify(p.checkpoint) {
+		p.Log().Debug("Rejected unapproved checkpoint", "section", cp.SectionIndex, "head", cp.SectionHead)
+		return false
+	}
+	lc.AddTrustedCheckpoint(light.TrustedCheckpoint{
+		Name:          "oracle",
+		SectionIdx:    cp.SectionIndex,
+		SectionHead:   cp.SectionHead,
+		ChtRoot:       cp.ChtRoot,
+		BloomTrieRoot: cp.BloomTrieRoot,
+	})
+	return true
+}
+
+// chainCaller executes read-only contract calls on the local chain through the
+// RPC API implementation, so that contract bindings can be used in-process.
+type chainCaller struct {
+	api *essapi.PublicBlockChainAPI
+}
+
+func callBlockNumber(number *big.Int) rpc.BlockNumber {
+	if number == nil {
+		return rpc.LatestBlockNumber
+	}
+	return rpc.BlockNumber(number.Int64())
+}
+
+// CodeAt implements bind.ContractCaller.
+func (c *chainCaller) CodeAt(ctx context.Context, contract common.Address, number *big.Int) ([]byte, error) {
+	return c.api.GetCode(ctx, contract, callBlockNumber(number))
+}
+
+// CallContract implements bind.ContractCaller.
+func (c *chainCaller) CallContract(ctx context.Context, call essentia.CallMsg, number *big.Int) ([]byte, error) {
+	args := essapi.CallArgs{From: call.From, To: call.To, Gas: hexutil.Uint64(call.Gas), Data: call.Data}
+	if call.GasPrice != nil {
+		args.GasPrice = hexutil.Big(*call.GasPrice)
+	}
+	if call.Value != nil {
+		args.Value = hexutil.Big(*call.Value)
+	}
+	return c.api.Call(ctx, args, callBlockNumber(number))
+}
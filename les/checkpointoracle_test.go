@@ -0,0 +1,135 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/accounts/abi/bind"
+	"github.com/orangeAndSuns/essentia/accounts/abi/bind/backends"
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/contracts/checkpointoracle"
+	"github.com/orangeAndSuns/essentia/core"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/ess"
+	"github.com/orangeAndSuns/essentia/essdb"
+	"github.com/orangeAndSuns/essentia/light"
+)
+
+// deployTestOracle deploys a checkpoint oracle administered by the given keys
+// on a simulated chain and publishes the checkpoint with all their signatures.
+func deployTestOracle(t *testing.T, cp *checkpointoracle.Checkpoint, keys ...*ecdsa.PrivateKey) (*backends.SimulatedBackend, *ess.CheckpointOracleConfig) {
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{testBankAddress: {Balance: big.NewInt(1000000000)}})
+	opts := bind.NewKeyedTransactor(testBankKey)
+
+	config := &ess.CheckpointOracleConfig{Threshold: len(keys)}
+	for _, key := range keys {
+		config.Signers = append(config.Signers, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	address, _, err := checkpointoracle.DeployCheckpointOracle(opts, backend, config.Signers, config.Threshold)
+	if err != nil {
+		t.Fatalf("failed to deploy oracle: %v", err)
+	}
+	backend.Commit()
+	config.Address = address
+
+	for _, key := range keys {
+		sig, err := cp.Sign(address, key)
+		if err != nil {
+			t.Fatalf("failed to sign checkpoint: %v", err)
+		}
+		if _, err := checkpointoracle.Publish(opts, backend, address, cp, sig); err != nil {
+			t.Fatalf("failed to publish checkpoint: %v", err)
+		}
+		backend.Commit()
+	}
+	return backend, config
+}
+
+func TestCheckpointOracle(t *testing.T) {
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	cp := &checkpointoracle.Checkpoint{
+		SectionIndex:  10,
+		SectionHead:   common.HexToHash("0x01"),
+		ChtRoot:       common.HexToHash("0x02"),
+		BloomTrieRoot: common.HexToHash("0x03"),
+	}
+	backend, config := deployTestOracle(t, cp, key1, key2)
+
+	// Connect a client to a server reading the oracle
+	server := newTestProtocolManagerMust(t, false, 4, testChainGen, nil, nil, essdb.NewMemDatabase())
+	server.server.oracle = newCheckpointOracle(config, backend)
+
+	// The checkpoint is only announced once the server read the contract
+	if cp := server.server.oracle.latest(); cp != nil {
+		t.Fatalf("checkpoint announced before reading the oracle: %+v", cp)
+	}
+	server.server.oracle.refresh()
+
+	client, odr := newOracleTestClient(t, config)
+	_, err1, lpeer, err2 := newTestPeerPair("peer", lpv2, server, client)
+	select {
+	case <-time.After(100 * time.Millisecond):
+	case err := <-err1:
+		t.Fatalf("server handshake error: %v", err)
+	case err := <-err2:
+		t.Fatalf("client handshake error: %v", err)
+	}
+	if lpeer.checkpoint == nil || !reflect.DeepEqual(lpeer.checkpoint.Checkpoint, *cp) {
+		t.Fatalf("checkpoint mismatch: have %+v, want %+v", lpeer.checkpoint, cp)
+	}
+	// The client adds the approved checkpoint when syncing with the server
+	client.synchronise(lpeer)
+	if sections, _, head := odr.ChtIndexer().Sections(); sections != cp.SectionIndex+1 || head != cp.SectionHead {
+		t.Fatalf("CHT sections mismatch: have %d/%x, want %d/%x", sections, head, cp.SectionIndex+1, cp.SectionHead)
+	}
+	if client.applyCheckpoint(lpeer) {
+		t.Fatalf("applied known checkpoint again")
+	}
+	// A client trusting other signers rejects the checkpoint
+	other, _ := crypto.GenerateKey()
+	client, odr = newOracleTestClient(t, &ess.CheckpointOracleConfig{
+		Address:   config.Address,
+		Signers:   []common.Address{config.Signers[0], crypto.PubkeyToAddress(other.PublicKey)},
+		Threshold: 2,
+	})
+	if client.applyCheckpoint(lpeer) {
+		t.Fatalf("applied checkpoint without enough trusted signatures")
+	}
+	if sections, _, _ := odr.ChtIndexer().Sections(); sections != 0 {
+		t.Fatalf("CHT sections mismatch: have %d, want 0", sections)
+	}
+}
+
+// newOracleTestClient creates a light client verifying checkpoints with the
+// given oracle config.
+func newOracleTestClient(t *testing.T, config *ess.CheckpointOracleConfig) (*ProtocolManager, *LesOdr) {
+	var (
+		peers  = newPeerSet()
+		ldb    = essdb.NewMemDatabase()
+		rm     = newRetrieveManager(peers, newRequestDistributor(peers, make(chan struct{})), nil)
+		odr    = NewLesOdr(ldb, light.NewChtIndexer(ldb, true), light.NewBloomTrieIndexer(ldb, true), nil, rm)
+		client = newTestProtocolManagerMust(t, true, 0, nil, peers, odr, ldb)
+	)
+	client.oracle = newCheckpointOracle(config, nil)
+	return client, odr
+}
@@ -0,0 +1,275 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common/mclock"
+	"github.com/orangeAndSuns/essentia/log"
+	"github.com/orangeAndSuns/essentia/p2p"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+)
+
+const (
+	// bufLimitRatio is the fixed ratio of a client's buffer limit to its
+	// capacity (minimum recharge rate).
+	bufLimitRatio = 6000
+
+	// minFreeConnTime is the time a free client can stay connected before it
+	// may be kicked out in favour of a new free client.
+	minFreeConnTime = 10 * time.Minute
+
+	// usageWeight scales the time a free client spent using its full capacity
+	// against its connection time when choosing a client to kick out.
+	usageWeight = 10
+)
+
+var (
+	errNoCapacity        = errors.New("not enough capacity")
+	errClientConnected   = errors.New("client already connected")
+	errCapacityTooLarge  = errors.New("capacity exceeds the total capacity")
+	errRecentlyKickedOut = errors.New("free client recently kicked out")
+)
+
+// poolPeer is the interface of a connected client used by the pool.
+type poolPeer interface {
+	ID() discover.ESSNodeID
+	servedCost() uint64        // cost of requests served so far
+	updateCapacity(cap uint64) // applies and signals a capacity change
+	Disconnect(p2p.DiscReason) // kicks the client out
+}
+
+// clientPool assigns capacity to connected light clients. Priority clients get
+// the capacity assigned to them through the API, free clients share the rest
+// with a fixed capacity each. When the pool is full, the free clients which
+// have been connected the longest and served the most are kicked out to make
+// room for priority clients and, after minFreeConnTime, for new free clients.
+type clientPool struct {
+	lock     sync.Mutex
+	now      func() mclock.AbsTime
+	totalCap uint64 // capacity shared by all clients
+	freeCap  uint64 // capacity of a single free client
+	usedCap  uint64 // sum of the capacities of connected clients
+
+	priority  map[discover.ESSNodeID]uint64 // capacities assigned to priority clients
+	connected map[discover.ESSNodeID]*poolClient
+	kicked    map[discover.ESSNodeID]mclock.AbsTime // recently kicked out free clients
+}
+
+type poolClient struct {
+	peer      poolPeer
+	capacity  uint64
+	priority  bool
+	connected mclock.AbsTime
+}
+
+// freeValue is the weighted connection time of a free client. Clients with a
+// higher value are kicked out first.
+func (c *poolClient) freeValue(now mclock.AbsTime, freeCap uint64) time.Duration {
+	used := time.Duration(c.peer.servedCost()/freeCap) * time.Millisecond
+	return time.Duration(now-c.connected) + used*usageWeight
+}
+
+// newClientPool creates a pool sharing totalCap between the clients, with
+// freeCap capacity for each free client.
+func newClientPool(totalCap, freeCap uint64) *clientPool {
+	return &clientPool{
+		now:       mclock.Now,
+		totalCap:  totalCap,
+		freeCap:   freeCap,
+		priority:  make(map[discover.ESSNodeID]uint64),
+		connected: make(map[discover.ESSNodeID]*poolClient),
+		kicked:    make(map[discover.ESSNodeID]mclock.AbsTime),
+	}
+}
+
+// connect adds a client to the pool, assigns its capacity and returns it. An
+// error is returned if the client can't be accepted.
+func (pool *clientPool) connect(p poolPeer) (uint64, error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	id, now := p.ID(), pool.now()
+	if pool.connected[id] != nil {
+		return 0, errClientConnected
+	}
+	pool.expireKicked(now)
+
+	client := &poolClient{peer: p, connected: now}
+	if cap, ok := pool.priority[id]; ok {
+		client.capacity, client.priority = cap, true
+	} else {
+		client.capacity = pool.freeCap
+		if _, ok := pool.kicked[id]; ok && pool.usedCap+pool.freeCap > pool.totalCap {
+			return 0, errRecentlyKickedOut
+		}
+	}
+	if !pool.makeRoom(client.capacity, now, !client.priority, nil) {
+		return 0, errNoCapacity
+	}
+	pool.connected[id] = client
+	pool.usedCap += client.capacity
+	p.updateCapacity(client.capacity)
+	return client.capacity, nil
+}
+
+// disconnect removes a client from the pool.
+func (pool *clientPool) disconnect(p poolPeer) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if client := pool.connected[p.ID()]; client != nil && client.peer == p {
+		delete(pool.connected, p.ID())
+		pool.usedCap -= client.capacity
+	}
+}
+
+// setCapacity assigns capacity to a priority client. Zero capacity turns the
+// client into a free client. Connected clients are notified of the change.
+func (pool *clientPool) setCapacity(id discover.ESSNodeID, cap uint64) error {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if cap > pool.totalCap {
+		return errCapacityTooLarge
+	}
+	client := pool.connected[id]
+	if cap == 0 {
+		delete(pool.priority, id)
+		if client != nil && client.priority {
+			// Demoted clients restart as free clients.
+			client.priority, client.connected = false, pool.now()
+			pool.changeCapacity(client, pool.freeCap)
+		}
+		return nil
+	}
+	if client != nil {
+		if cap > client.capacity && !pool.makeRoom(cap-client.capacity, pool.now(), false, client) {
+			return errNoCapacity
+		}
+		client.priority = true
+		pool.changeCapacity(client, cap)
+	}
+	pool.priority[id] = cap
+	return nil
+}
+
+// changeCapacity updates the capacity of a connected client. The pool lock
+// must be held.
+func (pool *clientPool) changeCapacity(client *poolClient, cap uint64) {
+	if cap == client.capacity {
+		return
+	}
+	pool.usedCap = pool.usedCap - client.capacity + cap
+	client.capacity = cap
+	client.peer.updateCapacity(cap)
+}
+
+// makeRoom kicks out free clients until the given capacity is available. If
+// forFree is set, only clients connected longer than minFreeConnTime are kicked
+// out. No client is kicked out if the capacity can't be freed. The pool lock
+// must be held.
+func (pool *clientPool) makeRoom(cap uint64, now mclock.AbsTime, forFree bool, keep *poolClient) bool {
+	if pool.usedCap+cap <= pool.totalCap {
+		return true
+	}
+	var candidates []*poolClient
+	for _, c := range pool.connected {
+		if c.priority || c == keep {
+			continue
+		}
+		if forFree && time.Duration(now-c.connected) < minFreeConnTime {
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].freeValue(now, pool.freeCap) > candidates[j].freeValue(now, pool.freeCap)
+	})
+	var (
+		freed uint64
+		kick  int
+	)
+	for kick < len(candidates) && pool.usedCap-freed+cap > pool.totalCap {
+		freed += candidates[kick].capacity
+		kick++
+	}
+	if pool.usedCap-freed+cap > pool.totalCap {
+		return false
+	}
+	for _, c := range candidates[:kick] {
+		id := c.peer.ID()
+		log.Debug("Kicking out free light client", "id", id, "connected", time.Duration(now-c.connected))
+		delete(pool.connected, id)
+		pool.usedCap -= c.capacity
+		pool.kicked[id] = now
+		go c.peer.Disconnect(p2p.DiscTooManyPeers)
+	}
+	return true
+}
+
+// expireKicked forgets free clients kicked out more than minFreeConnTime ago.
+// The pool lock must be held.
+func (pool *clientPool) expireKicked(now mclock.AbsTime) {
+	for id, t := range pool.kicked {
+		if time.Duration(now-t) > minFreeConnTime {
+			delete(pool.kicked, id)
+		}
+	}
+}
+
+// poolClientInfo describes a connected client.
+type poolClientInfo struct {
+	Capacity  uint64        `json:"capacity"`
+	Priority  bool          `json:"priority"`
+	Connected time.Duration `json:"connectedFor"`
+	Served    uint64        `json:"servedCost"`
+}
+
+// clients returns information about the connected clients.
+func (pool *clientPool) clients() map[discover.ESSNodeID]*poolClientInfo {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	now := pool.now()
+	infos := make(map[discover.ESSNodeID]*poolClientInfo, len(pool.connected))
+	for id, c := range pool.connected {
+		infos[id] = &poolClientInfo{
+			Capacity:  c.capacity,
+			Priority:  c.priority,
+			Connected: time.Duration(now - c.connected),
+			Served:    c.peer.servedCost(),
+		}
+	}
+	return infos
+}
+
+// priorityClients returns the capacities assigned to priority clients.
+func (pool *clientPool) priorityClients() map[discover.ESSNodeID]uint64 {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	caps := make(map[discover.ESSNodeID]uint64, len(pool.priority))
+	for id, cap := range pool.priority {
+		caps[id] = cap
+	}
+	return caps
+}
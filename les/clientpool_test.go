@@ -0,0 +1,187 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common/mclock"
+	"github.com/orangeAndSuns/essentia/essdb"
+	"github.com/orangeAndSuns/essentia/light"
+	"github.com/orangeAndSuns/essentia/p2p"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+)
+
+type poolTestPeer struct {
+	id     discover.ESSNodeID
+	served uint64
+
+	mu       sync.Mutex
+	capacity uint64
+	kicked   chan struct{}
+}
+
+func newPoolTestPeer(i byte) *poolTestPeer {
+	return &poolTestPeer{id: discover.ESSNodeID{i}, kicked: make(chan struct{})}
+}
+
+func (p *poolTestPeer) ID() discover.ESSNodeID { return p.id }
+func (p *poolTestPeer) servedCost() uint64     { return p.served }
+
+func (p *poolTestPeer) updateCapacity(cap uint64) {
+	p.mu.Lock()
+	p.capacity = cap
+	p.mu.Unlock()
+}
+
+func (p *poolTestPeer) Disconnect(reason p2p.DiscReason) {
+	close(p.kicked)
+}
+
+func (p *poolTestPeer) wasKicked() bool {
+	select {
+	case <-p.kicked:
+		return true
+	case <-time.After(100 * time.Millisecond):
+		return false
+	}
+}
+
+func newTestClientPool(totalCap, freeCap uint64) (*clientPool, *mclock.AbsTime) {
+	pool := newClientPool(totalCap, freeCap)
+	clock := new(mclock.AbsTime)
+	pool.now = func() mclock.AbsTime { return *clock }
+	return pool, clock
+}
+
+func TestClientPoolFreeClients(t *testing.T) {
+	pool, clock := newTestClientPool(30, 10)
+	peers := []*poolTestPeer{newPoolTestPeer(0), newPoolTestPeer(1), newPoolTestPeer(2)}
+	for i, p := range peers {
+		p.served = uint64(i) * 10 * 60000 // one more minute of full capacity each
+		if cap, err := pool.connect(p); err != nil || cap != 10 || p.capacity != 10 {
+			t.Fatalf("free client %d: capacity %d/%d, err %v", i, cap, p.capacity, err)
+		}
+	}
+
+	// The pool is full and nobody has been connected long enough.
+	newcomer := newPoolTestPeer(3)
+	if _, err := pool.connect(newcomer); err != errNoCapacity {
+		t.Fatalf("wrong error for new free client: %v", err)
+	}
+	// After minFreeConnTime, the client with the most usage is kicked out.
+	*clock += mclock.AbsTime(minFreeConnTime)
+	if _, err := pool.connect(newcomer); err != nil {
+		t.Fatalf("new free client rejected: %v", err)
+	}
+	if !peers[2].wasKicked() {
+		t.Fatal("client with the most usage was not kicked out")
+	}
+	// The kicked out client can't come back right away.
+	pool.disconnect(peers[2])
+	if _, err := pool.connect(peers[2]); err != errRecentlyKickedOut {
+		t.Fatalf("wrong error for kicked out client: %v", err)
+	}
+	// It can reconnect once there is free capacity.
+	pool.disconnect(peers[0])
+	if _, err := pool.connect(peers[2]); err != nil {
+		t.Fatalf("kicked out client rejected with free capacity: %v", err)
+	}
+}
+
+func TestClientPoolPriorityClients(t *testing.T) {
+	pool, _ := newTestClientPool(30, 10)
+	free := []*poolTestPeer{newPoolTestPeer(0), newPoolTestPeer(1), newPoolTestPeer(2)}
+	for _, p := range free {
+		if _, err := pool.connect(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prio := newPoolTestPeer(3)
+	if err := pool.setCapacity(prio.id, 15); err != nil {
+		t.Fatal(err)
+	}
+	// A priority client kicks out free clients immediately.
+	if cap, err := pool.connect(prio); err != nil || cap != 15 {
+		t.Fatalf("priority client: capacity %d, err %v", cap, err)
+	}
+	kicked := 0
+	for _, p := range free {
+		if p.wasKicked() {
+			kicked++
+		}
+	}
+	if kicked != 2 || pool.usedCap != 25 {
+		t.Fatalf("kicked %d free clients, used capacity %d; want 2, 25", kicked, pool.usedCap)
+	}
+
+	// Capacity changes are signaled to connected clients.
+	if err := pool.setCapacity(prio.id, 20); err != nil {
+		t.Fatal(err)
+	}
+	if prio.capacity != 20 {
+		t.Fatalf("capacity change not signaled: have %d, want 20", prio.capacity)
+	}
+	if err := pool.setCapacity(prio.id, 40); err != errCapacityTooLarge {
+		t.Fatalf("wrong error for too large capacity: %v", err)
+	}
+	// Demoted clients get the free client capacity.
+	if err := pool.setCapacity(prio.id, 0); err != nil {
+		t.Fatal(err)
+	}
+	if prio.capacity != 10 || len(pool.priorityClients()) != 0 {
+		t.Fatalf("demoted client has capacity %d", prio.capacity)
+	}
+}
+
+// This test checks that capacity changes are signaled to clients through the
+// protocol and applied to their flow control parameters.
+func TestClientCapacityUpdate(t *testing.T) {
+	peers := newPeerSet()
+	db, ldb := essdb.NewMemDatabase(), essdb.NewMemDatabase()
+	odr := NewLesOdr(ldb, light.NewChtIndexer(db, true), light.NewBloomTrieIndexer(db, true), nil, newRetrieveManager(peers, newRequestDistributor(peers, make(chan struct{})), nil))
+	pm := newTestProtocolManagerMust(t, false, 4, testChainGen, nil, nil, db)
+	lpm := newTestProtocolManagerMust(t, true, 0, nil, peers, odr, ldb)
+	pm.server.clientPool = newClientPool(100, 1)
+
+	speer, err1, lpeer, err2 := newTestPeerPair("peer", lpv2, pm, lpm)
+	select {
+	case <-time.After(100 * time.Millisecond):
+	case err := <-err1:
+		t.Fatalf("server handshake error: %v", err)
+	case err := <-err2:
+		t.Fatalf("client handshake error: %v", err)
+	}
+	if params := lpeer.fcServerParams; params.MinRecharge != 1 || params.BufLimit != bufLimitRatio {
+		t.Fatalf("wrong initial flow control params %+v", params)
+	}
+	if err := pm.server.clientPool.setCapacity(speer.ID(), 5); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		lpeer.lock.RLock()
+		params := lpeer.fcServerParams
+		lpeer.lock.RUnlock()
+		if params.MinRecharge == 5 && params.BufLimit == 5*bufLimitRatio {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("capacity update not received by the client")
+}
@@ -92,6 +92,7 @@ type fetcherTreeNode struct {
 type fetchRequest struct {
 	hash    common.Hash
 	amount  uint64
+	td      *big.Int // announced Td of the requested head
 	peer    *peer
 	sent    mclock.AbsTime
 	timeout bool
@@ -181,7 +182,7 @@ func (f *lightFetcher) syncLoop() {
 			if ok {
 				f.pm.serverPool.adjustResponseTime(req.peer.poolEntry, time.Duration(mclock.Now()-req.sent), true)
 				req.peer.Log().Debug("Fetching data timed out hard")
-				go f.pm.removePeer(req.peer.id)
+				go f.pm.dropPeer(req.peer.id)
 			}
 		case resp := <-f.deliverChn:
 			f.reqMu.Lock()
@@ -199,7 +200,7 @@ func (f *lightFetcher) syncLoop() {
 			f.lock.Lock()
 			if !ok || !(f.syncing || f.processResponse(req, resp)) {
 				resp.peer.Log().Debug("Failed processing response")
-				go f.pm.removePeer(resp.peer.id)
+				go f.pm.dropPeer(resp.peer.id)
 			}
 			f.lock.Unlock()
 		case p := <-f.syncDone:
@@ -256,7 +257,7 @@ func (f *lightFetcher) announce(p *peer, head *announceData) {
 	if fp.lastAnnounced != nil && head.Td.Cmp(fp.lastAnnounced.td) <= 0 {
 		// announced tds should be strictly monotonic
 		p.Log().Debug("Received non-monotonic td", "current", head.Td, "previous", fp.lastAnnounced.td)
-		go f.pm.removePeer(p.id)
+		go f.pm.dropPeer(p.id)
 		return
 	}
 
@@ -399,6 +400,9 @@ func (f *lightFetcher) requestedID(reqID uint64) bool {
 // nextRequest selects the peer and announced head to be requested next, amount
 // to be downloaded starting from the head backwards is also returned
 func (f *lightFetcher) nextRequest() (*distReq, uint64) {
+	if f.pm.ulc != nil {
+		return f.nextTrustedRequest()
+	}
 	var (
 		bestHash   common.Hash
 		bestAmount uint64
@@ -451,49 +455,101 @@ func (f *lightFetcher) nextRequest() (*distReq, uint64) {
 			},
 		}
 	} else {
-		rq = &distReq{
-			getCost: func(dp distPeer) uint64 {
-				p := dp.(*peer)
-				return p.GetRequestCost(GetBlockHeadersMsg, int(bestAmount))
-			},
-			canSend: func(dp distPeer) bool {
-				p := dp.(*peer)
-				f.lock.Lock()
-				defer f.lock.Unlock()
+		rq = f.newHeaderRequest(reqID, bestHash, bestAmount, bestTd)
+	}
+	return rq, reqID
+}
 
-				fp := f.peers[p]
-				if fp == nil {
-					return false
-				}
+// nextTrustedRequest selects the head with the highest Td which has been
+// announced by enough trusted servers of an ultra light client. Only this single
+// header is downloaded, its ancestors are not synced.
+func (f *lightFetcher) nextTrustedRequest() (*distReq, uint64) {
+	type candidate struct {
+		td        *big.Int
+		count     int
+		requested bool
+	}
+	candidates := make(map[common.Hash]*candidate)
+	for p, fp := range f.peers {
+		if !p.trusted {
+			continue
+		}
+		for hash, n := range fp.nodeByHash {
+			if f.checkKnownNode(p, n) {
+				continue
+			}
+			c := candidates[hash]
+			if c == nil {
+				c = &candidate{td: n.td}
+				candidates[hash] = c
+			}
+			if n.td.Cmp(c.td) == 0 {
+				c.count++
+			}
+			c.requested = c.requested || n.requested
+		}
+	}
+	var bestHash common.Hash
+	bestTd := f.maxConfirmedTd
+	for hash, c := range candidates {
+		if !c.requested && c.td.Cmp(bestTd) > 0 && f.pm.ulc.enoughAgreed(c.count) {
+			bestHash, bestTd = hash, c.td
+		}
+	}
+	if bestTd == f.maxConfirmedTd {
+		return nil, 0
+	}
+	reqID := genReqID()
+	return f.newHeaderRequest(reqID, bestHash, 1, bestTd), reqID
+}
+
+// newHeaderRequest creates a request downloading amount headers backwards from
+// an announced head with the given hash and Td.
+func (f *lightFetcher) newHeaderRequest(reqID uint64, bestHash common.Hash, bestAmount uint64, bestTd *big.Int) *distReq {
+	return &distReq{
+		getCost: func(dp distPeer) uint64 {
+			p := dp.(*peer)
+			return p.GetRequestCost(GetBlockHeadersMsg, int(bestAmount))
+		},
+		canSend: func(dp distPeer) bool {
+			p := dp.(*peer)
+			if f.pm.ulc != nil && !p.trusted {
+				return false
+			}
+			f.lock.Lock()
+			defer f.lock.Unlock()
+
+			fp := f.peers[p]
+			if fp == nil {
+				return false
+			}
+			n := fp.nodeByHash[bestHash]
+			return n != nil && !n.requested
+		},
+		request: func(dp distPeer) func() {
+			p := dp.(*peer)
+			f.lock.Lock()
+			fp := f.peers[p]
+			if fp != nil {
 				n := fp.nodeByHash[bestHash]
-				return n != nil && !n.requested
-			},
-			request: func(dp distPeer) func() {
-				p := dp.(*peer)
-				f.lock.Lock()
-				fp := f.peers[p]
-				if fp != nil {
-					n := fp.nodeByHash[bestHash]
-					if n != nil {
-						n.requested = true
-					}
+				if n != nil {
+					n.requested = true
 				}
-				f.lock.Unlock()
+			}
+			f.lock.Unlock()
 
-				cost := p.GetRequestCost(GetBlockHeadersMsg, int(bestAmount))
-				p.fcServer.QueueRequest(reqID, cost)
-				f.reqMu.Lock()
-				f.requested[reqID] = fetchRequest{hash: bestHash, amount: bestAmount, peer: p, sent: mclock.Now()}
-				f.reqMu.Unlock()
-				go func() {
-					time.Sleep(hardRequestTimeout)
-					f.timeoutChn <- reqID
-				}()
-				return func() { p.RequestHeadersByHash(reqID, cost, bestHash, int(bestAmount), 0, true) }
-			},
-		}
+			cost := p.GetRequestCost(GetBlockHeadersMsg, int(bestAmount))
+			p.fcServer.QueueRequest(reqID, cost)
+			f.reqMu.Lock()
+			f.requested[reqID] = fetchRequest{hash: bestHash, amount: bestAmount, td: bestTd, peer: p, sent: mclock.Now()}
+			f.reqMu.Unlock()
+			go func() {
+				time.Sleep(hardRequestTimeout)
+				f.timeoutChn <- reqID
+			}()
+			return func() { p.RequestHeadersByHash(reqID, cost, bestHash, int(bestAmount), 0, true) }
+		},
 	}
-	return rq, reqID
 }
 
 // deliverHeaders delivers header download request responses for processing
@@ -503,6 +559,9 @@ func (f *lightFetcher) deliverHeaders(peer *peer, reqID uint64, headers []*types
 
 // processResponse processes header download request responses, returns true if successful
 func (f *lightFetcher) processResponse(req fetchRequest, resp fetchResponse) bool {
+	if f.pm.ulc != nil {
+		return f.processTrustedResponse(req, resp)
+	}
 	if uint64(len(resp.headers)) != req.amount || resp.headers[0].Hash() != req.hash {
 		req.peer.Log().Debug("Response content mismatch", "requested", len(resp.headers), "reqfrom", resp.headers[0], "delivered", req.amount, "delfrom", req.hash)
 		return false
@@ -531,6 +590,23 @@ func (f *lightFetcher) processResponse(req fetchRequest, resp fetchResponse) boo
 	return true
 }
 
+// processTrustedResponse processes the response to a trusted head request of an
+// ultra light client. The header is accepted without verification if it matches
+// the hash announced by the trusted servers.
+func (f *lightFetcher) processTrustedResponse(req fetchRequest, resp fetchResponse) bool {
+	if len(resp.headers) != 1 || resp.headers[0].Hash() != req.hash {
+		req.peer.Log().Debug("Trusted header response mismatch", "requested", req.hash, "delivered", len(resp.headers))
+		return false
+	}
+	header := resp.headers[0]
+	if err := f.chain.InsertTrustedHeader(header, req.td); err != nil {
+		log.Debug("Failed to insert trusted header", "err", err)
+		return false
+	}
+	f.newHeaders([]*types.Header{header}, []*big.Int{req.td})
+	return true
+}
+
 // newHeaders updates the block trees of all active peers according to a newly
 // downloaded and validated batch or headers
 func (f *lightFetcher) newHeaders(headers []*types.Header, tds []*big.Int) {
@@ -538,7 +614,7 @@ func (f *lightFetcher) newHeaders(headers []*types.Header, tds []*big.Int) {
 	for p, fp := range f.peers {
 		if !f.checkAnnouncedHeaders(fp, headers, tds) {
 			p.Log().Debug("Inconsistent announcement")
-			go f.pm.removePeer(p.id)
+			go f.pm.dropPeer(p.id)
 		}
 		if fp.confirmedTd != nil && (maxTd == nil || maxTd.Cmp(fp.confirmedTd) > 0) {
 			maxTd = fp.confirmedTd
@@ -573,6 +649,10 @@ func (f *lightFetcher) checkAnnouncedHeaders(fp *fetcherPeerInfo, headers []*typ
 			td = f.chain.GetTd(hash, number)
 			header = f.chain.GetHeader(hash, number)
 			if header == nil || td == nil {
+				if f.pm.ulc != nil {
+					// ultra light clients don't download the ancestors of trusted heads
+					return true
+				}
 				log.Error("Missing parent of validated header", "hash", hash, "number", number)
 				return false
 			}
@@ -642,7 +722,7 @@ func (f *lightFetcher) checkSyncedHeaders(p *peer) {
 	// now n is the latest downloaded header after syncing
 	if n == nil {
 		p.Log().Debug("Synchronisation failed")
-		go f.pm.removePeer(p.id)
+		go f.pm.dropPeer(p.id)
 	} else {
 		header := f.chain.GetHeader(n.hash, n.number)
 		f.newHeaders([]*types.Header{header}, []*big.Int{td})
@@ -673,7 +753,7 @@ func (f *lightFetcher) checkKnownNode(p *peer, n *fetcherTreeNode) bool {
 	}
 	if !f.checkAnnouncedHeaders(fp, []*types.Header{header}, []*big.Int{td}) {
 		p.Log().Debug("Inconsistent announcement")
-		go f.pm.removePeer(p.id)
+		go f.pm.dropPeer(p.id)
 	}
 	if fp.confirmedTd != nil {
 		f.updateMaxConfirmedTd(fp.confirmedTd)
@@ -37,6 +37,7 @@ type ClientNode struct {
 	lock     sync.Mutex
 	cm       *ClientManager
 	cmNode   *cmNode
+	served   uint64 // sum of the costs of processed requests
 }
 
 func NewClientNode(cm *ClientManager, params *ServerParams) *ClientNode {
@@ -82,6 +83,7 @@ func (peer *ClientNode) RequestProcessed(cost uint64) (bv, realCost uint64) {
 	time := mclock.Now()
 	peer.recalcBV(time)
 	peer.bufValue -= cost
+	peer.served += cost
 	peer.recalcBV(time)
 	rcValue, rcost := peer.cm.processed(peer.cmNode, time)
 	if rcValue < peer.params.BufLimit {
@@ -93,6 +95,31 @@ func (peer *ClientNode) RequestProcessed(cost uint64) (bv, realCost uint64) {
 	return peer.bufValue, rcost
 }
 
+// UpdateParams changes the flow control parameters of the client. A raised
+// buffer limit is credited to the current buffer value.
+func (peer *ClientNode) UpdateParams(params *ServerParams) {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
+
+	peer.recalcBV(mclock.Now())
+	if params.BufLimit > peer.params.BufLimit {
+		peer.bufValue += params.BufLimit - peer.params.BufLimit
+	}
+	if peer.bufValue > params.BufLimit {
+		peer.bufValue = params.BufLimit
+	}
+	peer.params = params
+}
+
+// ServedCost returns the sum of the costs of all requests processed for the
+// client.
+func (peer *ClientNode) ServedCost() uint64 {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
+
+	return peer.served
+}
+
 type ServerNode struct {
 	bufEstimate uint64
 	lastTime    mclock.AbsTime
@@ -148,6 +175,22 @@ func (peer *ServerNode) CanSend(maxCost uint64) (time.Duration, float64) {
 	return peer.canSend(maxCost)
 }
 
+// UpdateParams changes the flow control parameters announced by the server.
+// A raised buffer limit is credited to the estimated buffer value.
+func (peer *ServerNode) UpdateParams(params *ServerParams) {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
+
+	peer.recalcBLE(mclock.Now())
+	if params.BufLimit > peer.params.BufLimit {
+		peer.bufEstimate += params.BufLimit - peer.params.BufLimit
+	}
+	if peer.bufEstimate > params.BufLimit {
+		peer.bufEstimate = params.BufLimit
+	}
+	peer.params = params
+}
+
 // QueueRequest should be called when the request has been assigned to the given
 // server node, before putting it in the send queue. It is mandatory that requests
 // are sent in the same order as the QueueRequest calls are made.
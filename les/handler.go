@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"net"
 	"sync"
@@ -33,6 +34,7 @@ import (
 	"github.com/orangeAndSuns/essentia/core/rawdb"
 	"github.com/orangeAndSuns/essentia/core/state"
 	"github.com/orangeAndSuns/essentia/core/types"
+	"github.com/orangeAndSuns/essentia/ess"
 	"github.com/orangeAndSuns/essentia/ess/downloader"
 	"github.com/orangeAndSuns/essentia/essdb"
 	"github.com/orangeAndSuns/essentia/event"
@@ -62,6 +64,10 @@ const (
 	MaxTxStatus              = 256 // Amount of transactions to queried per request
 
 	disableClientRemovePeer = false
+
+	// Peer score adjustments reported to the p2p server.
+	replyReward         = 1   // valid reply to one of our requests
+	invalidReplyPenalty = -10 // reply which failed validation
 )
 
 // errIncompatibleConfig is returned if the requested protocols and configs are
@@ -107,6 +113,8 @@ type ProtocolManager struct {
 	lesTopic    discv5.Topic
 	reqDist     *requestDistributor
 	retriever   *retrieveManager
+	ulc         *ulc              // trusted servers of an ultra light client, nil if disabled
+	oracle      *checkpointOracle // checkpoint oracle verifying announced checkpoints, nil if disabled
 
 	downloader *downloader.Downloader
 	fetcher    *lightFetcher
@@ -129,7 +137,11 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new essentia sub protocol manager. The Essentia sub protocol manages peers capable
 // with the essentia network.
-func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protocolVersions []uint, networkId uint64, mux *event.TypeMux, engine consensus.Engine, peers *peerSet, blockchain BlockChain, txpool txPool, chainDb essdb.Database, odr *LesOdr, txrelay *LesTxRelay, serverPool *serverPool, quitSync chan struct{}, wg *sync.WaitGroup) (*ProtocolManager, error) {
+func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protocolVersions []uint, networkId uint64, mux *event.TypeMux, engine consensus.Engine, peers *peerSet, blockchain BlockChain, txpool txPool, chainDb essdb.Database, odr *LesOdr, txrelay *LesTxRelay, serverPool *serverPool, quitSync chan struct{}, wg *sync.WaitGroup, ulcConfig *ess.ULCConfig) (*ProtocolManager, error) {
+	trusted, err := newULC(ulcConfig)
+	if err != nil {
+		return nil, err
+	}
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		lightSync:   lightSync,
@@ -147,6 +159,7 @@ func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protoco
 		quitSync:    quitSync,
 		wg:          wg,
 		noMorePeers: make(chan struct{}),
+		ulc:         trusted,
 	}
 	if odr != nil {
 		manager.retriever = odr.retriever
@@ -159,7 +172,7 @@ func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protoco
 		// Compatible, initialize the sub-protocol
 		version := version // Closure for the run
 		manager.SubProtocols = append(manager.SubProtocols, p2p.Protocol{
-			Name:    "les",
+			Name:    protocolName,
 			Version: version,
 			Length:  ProtocolLengths[version],
 			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
@@ -201,7 +214,7 @@ func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protoco
 		return nil, errIncompatibleConfig
 	}
 
-	removePeer := manager.removePeer
+	removePeer := manager.dropPeer
 	if disableClientRemovePeer {
 		removePeer = func(id string) {}
 	}
@@ -220,6 +233,14 @@ func (pm *ProtocolManager) removePeer(id string) {
 	pm.peers.Unregister(id)
 }
 
+// dropPeer penalizes a misbehaving peer and disconnects it.
+func (pm *ProtocolManager) dropPeer(id string) {
+	if peer := pm.peers.Peer(id); peer != nil {
+		peer.Report(protocolName, p2p.MisbehaviourPenalty)
+	}
+	pm.removePeer(id)
+}
+
 func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.maxPeers = maxPeers
 
@@ -257,7 +278,9 @@ func (pm *ProtocolManager) Stop() {
 }
 
 func (pm *ProtocolManager) newPeer(pv int, nv uint64, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
-	return newPeer(pv, nv, p, newMeteredMsgWriter(rw))
+	peer := newPeer(pv, nv, p, newMeteredMsgWriter(rw))
+	peer.trusted = pm.ulc.isTrusted(p.ID())
+	return peer
 }
 
 // handle is the callback invoked to manage the life cycle of a les peer. When
@@ -270,6 +293,15 @@ func (pm *ProtocolManager) handle(p *peer) error {
 
 	p.Log().Debug("Light Essentia peer connected", "name", p.Name())
 
+	// Assign capacity to clients of our server
+	if pm.server != nil && pm.server.clientPool != nil {
+		if _, err := pm.server.clientPool.connect(p); err != nil {
+			p.Log().Debug("Light client rejected", "err", err)
+			return p2p.DiscTooManyPeers
+		}
+		defer pm.server.clientPool.disconnect(p)
+	}
+
 	// Execute the LES handshake
 	var (
 		genesis = pm.blockchain.Genesis()
@@ -328,12 +360,15 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	for {
 		if err := pm.handleMsg(p); err != nil {
 			p.Log().Debug("Light Essentia message handling failed", "err", err)
+			if err != io.EOF {
+				p.Report(protocolName, p2p.MisbehaviourPenalty)
+			}
 			return err
 		}
 	}
 }
 
-var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg}
+var reqList = []uint64{GetBlockHeadersMsg, GetBlockBodiesMsg, GetCodeMsg, GetReceiptsMsg, GetProofsV1Msg, SendTxMsg, SendTxV2Msg, GetTxStatusMsg, GetHeaderProofsMsg, GetProofsV2Msg, GetHelperTrieProofsMsg, GetLogsMsg}
 
 // handleMsg is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
@@ -351,12 +386,13 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			return true
 		}
 		bufValue, _ := p.fcClient.AcceptRequest()
+		params := p.fcClientParams()
 		cost := costs.baseCost + reqCnt*costs.reqCost
-		if cost > pm.server.defParams.BufLimit {
-			cost = pm.server.defParams.BufLimit
+		if cost > params.BufLimit {
+			cost = params.BufLimit
 		}
 		if cost > bufValue {
-			recharge := time.Duration((cost - bufValue) * 1000000 / pm.server.defParams.MinRecharge)
+			recharge := time.Duration((cost - bufValue) * 1000000 / params.MinRecharge)
 			p.Log().Error("Request came too early", "recharge", common.PrettyDuration(recharge))
 			return true
 		}
@@ -380,14 +416,20 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	// Block header query, collect the requested headers and reply
 	case AnnounceMsg:
 		p.Log().Trace("Received announce message")
-		if p.requestAnnounceType == announceTypeNone {
-			return errResp(ErrUnexpectedResponse, "")
-		}
-
 		var req announceData
 		if err := msg.Decode(&req); err != nil {
 			return errResp(ErrDecode, "%v: %v", msg, err)
 		}
+		if req.Update != nil {
+			p.updateFlowControl(req.Update.decode())
+		}
+		if req.Hash == (common.Hash{}) {
+			// Flow control update without a block announcement
+			break
+		}
+		if p.requestAnnounceType == announceTypeNone {
+			return errResp(ErrUnexpectedResponse, "")
+		}
 
 		if p.requestAnnounceType == announceTypeSigned {
 			if err := req.checkSignature(p.pubKey); err != nil {
@@ -1108,6 +1150,51 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 
 		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		if pm.txrelay != nil {
+			pm.txrelay.deliverStatus(p, resp.ReqID, resp.Status)
+		}
+
+	case GetLogsMsg:
+		p.Log().Trace("Received logs request")
+		var req struct {
+			ReqID uint64
+			Req   LogsReq
+		}
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if req.Req.ToBlock < req.Req.FromBlock {
+			return errResp(ErrRequestRejected, "")
+		}
+		reqCnt := req.Req.ToBlock - req.Req.FromBlock + 1
+		if reject(reqCnt, light.MaxLogsRequestRange) {
+			return errResp(ErrRequestRejected, "")
+		}
+		resp := pm.filterLogs(req.Req)
+
+		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + reqCnt*costs.reqCost)
+		pm.server.fcCostStats.update(msg.Code, reqCnt, rcost)
+		return p.SendLogs(req.ReqID, bv, resp)
+
+	case LogsMsg:
+		if pm.odr == nil {
+			return errResp(ErrUnexpectedResponse, "")
+		}
+
+		p.Log().Trace("Received logs response")
+		var resp struct {
+			ReqID, BV uint64
+			Data      LogsResp
+		}
+		if err := msg.Decode(&resp); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		deliverMsg = &Msg{
+			MsgType: MsgLogs,
+			ReqID:   resp.ReqID,
+			Obj:     resp.Data,
+		}
 
 	default:
 		p.Log().Trace("Received unknown message", "code", msg.Code)
@@ -1117,10 +1204,13 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	if deliverMsg != nil {
 		err := pm.retriever.deliver(p, deliverMsg)
 		if err != nil {
+			p.Report(protocolName, invalidReplyPenalty)
 			p.responseErrors++
 			if p.responseErrors > maxResponseErrors {
 				return err
 			}
+		} else {
+			p.Report(protocolName, replyReward)
 		}
 	}
 	return nil
@@ -1184,6 +1274,68 @@ func (pm *ProtocolManager) txStatus(hashes []common.Hash) []txStatus {
 	return stats
 }
 
+// filterLogs collects the receipts with logs matching the requested filter
+// together with the proofs of the receipts and their transactions. The search
+// stops early if the response grows too large.
+func (pm *ProtocolManager) filterLogs(req LogsReq) LogsResp {
+	var (
+		resp  LogsResp
+		nodes = light.NewNodeSet()
+	)
+	resp.LastBlock = req.FromBlock - 1
+	for number := req.FromBlock; number <= req.ToBlock && nodes.DataSize() < softResponseLimit; number++ {
+		hash := rawdb.ReadCanonicalHash(pm.chainDb, number)
+		header := rawdb.ReadHeader(pm.chainDb, hash, number)
+		if header == nil {
+			break
+		}
+		resp.LastBlock = number
+		if !req.Filter.MatchesBloom(header.Bloom) {
+			continue
+		}
+		receipts := rawdb.ReadReceipts(pm.chainDb, hash, number)
+		block := BlockLogs{Hash: hash, Number: number}
+		var logIndex uint
+		for i, receipt := range receipts {
+			for _, l := range receipt.Logs {
+				if req.Filter.Matches(l) {
+					block.TxIndex = append(block.TxIndex, uint(i))
+					block.LogIndex = append(block.LogIndex, logIndex)
+					break
+				}
+			}
+			logIndex += uint(len(receipt.Logs))
+		}
+		if len(block.TxIndex) == 0 {
+			continue
+		}
+		body := rawdb.ReadBody(pm.chainDb, hash, number)
+		if body == nil {
+			break
+		}
+		receiptTrie, txTrie := deriveTrie(receipts), deriveTrie(types.Transactions(body.Transactions))
+		for _, i := range block.TxIndex {
+			key, _ := rlp.EncodeToBytes(i)
+			receiptTrie.Prove(key, 0, nodes)
+			txTrie.Prove(key, 0, nodes)
+		}
+		resp.Blocks = append(resp.Blocks, block)
+	}
+	resp.Proofs = nodes.NodeList()
+	return resp
+}
+
+// deriveTrie builds the trie of a block's transactions or receipts, whose root
+// is stored in the block header.
+func deriveTrie(list types.DerivableList) *trie.Trie {
+	t := new(trie.Trie)
+	for i := 0; i < list.Len(); i++ {
+		key, _ := rlp.EncodeToBytes(uint(i))
+		t.Update(key, list.GetRlp(i))
+	}
+	return t
+}
+
 // NodeInfo represents a short summary of the Essentia sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct {
@@ -45,6 +45,8 @@ var (
 	errCHTHashMismatch     = errors.New("cht hash mismatch")
 	errCHTNumberMismatch   = errors.New("cht number mismatch")
 	errUselessNodes        = errors.New("useless nodes in merkle proof nodeset")
+	errLogsRangeMismatch   = errors.New("logs response out of requested range")
+	errNoMatchingLogs      = errors.New("receipt without matching logs")
 )
 
 type LesOdrRequest interface {
@@ -62,12 +64,16 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*ReceiptsRequest)(r)
 	case *light.TrieRequest:
 		return (*TrieRequest)(r)
+	case *light.MultiTrieRequest:
+		return (*MultiTrieRequest)(r)
 	case *light.CodeRequest:
 		return (*CodeRequest)(r)
 	case *light.ChtRequest:
 		return (*ChtRequest)(r)
 	case *light.BloomRequest:
 		return (*BloomRequest)(r)
+	case *light.LogsRequest:
+		return (*LogsRequest)(r)
 	default:
 		return nil
 	}
@@ -193,7 +199,7 @@ func (r *TrieRequest) GetCost(peer *peer) uint64 {
 	switch peer.version {
 	case lpv1:
 		return peer.GetRequestCost(GetProofsV1Msg, 1)
-	case lpv2:
+	case lpv2, lpv3:
 		return peer.GetRequestCost(GetProofsV2Msg, 1)
 	default:
 		panic(nil)
@@ -256,6 +262,84 @@ func (r *TrieRequest) Validate(db essdb.Database, msg *Msg) error {
 	}
 }
 
+// ODR request type for batched state/storage trie entries, see LesOdrRequest interface
+type MultiTrieRequest light.MultiTrieRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *MultiTrieRequest) GetCost(peer *peer) uint64 {
+	switch peer.version {
+	case lpv1:
+		return peer.GetRequestCost(GetProofsV1Msg, len(r.Keys))
+	case lpv2, lpv3:
+		return peer.GetRequestCost(GetProofsV2Msg, len(r.Keys))
+	default:
+		panic(nil)
+	}
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *MultiTrieRequest) CanSend(peer *peer) bool {
+	if len(r.Keys) == 0 {
+		return false
+	}
+	return peer.HasBlock(r.Keys[0].Id.BlockHash, r.Keys[0].Id.BlockNumber)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *MultiTrieRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting trie proofs", "count", len(r.Keys))
+	reqs := make([]ProofReq, len(r.Keys))
+	for i, key := range r.Keys {
+		reqs[i] = ProofReq{
+			BHash:  key.Id.BlockHash,
+			AccKey: key.Id.AccKey,
+			Key:    key.Key,
+		}
+	}
+	return peer.RequestProofs(reqID, r.GetCost(peer), reqs)
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *MultiTrieRequest) Validate(db essdb.Database, msg *Msg) error {
+	log.Debug("Validating trie proofs", "count", len(r.Keys))
+
+	var nodeSet *light.NodeSet
+	switch msg.MsgType {
+	case MsgProofsV1:
+		proofs := msg.Obj.([]light.NodeList)
+		if len(proofs) != len(r.Keys) {
+			return errInvalidEntryCount
+		}
+		nodeSet = light.NewNodeSet()
+		for _, proof := range proofs {
+			proof.Store(nodeSet)
+		}
+
+	case MsgProofsV2:
+		// The server deduplicates the nodes shared by the proofs
+		nodeSet = msg.Obj.(light.NodeList).NodeSet()
+
+	default:
+		return errInvalidMessageType
+	}
+	// Verify all proofs and store if they check out
+	reads := &readTraceDB{db: nodeSet}
+	for _, key := range r.Keys {
+		if _, _, err := trie.VerifyProof(key.Id.Root, key.Key, reads); err != nil {
+			return fmt.Errorf("merkle proof verification failed: %v", err)
+		}
+	}
+	// check if all nodes have been read by VerifyProof
+	if len(reads.reads) != nodeSet.KeyCount() {
+		return errUselessNodes
+	}
+	r.Proof = nodeSet
+	return nil
+}
+
 type CodeReq struct {
 	BHash  common.Hash
 	AccKey []byte
@@ -353,7 +437,7 @@ func (r *ChtRequest) GetCost(peer *peer) uint64 {
 	switch peer.version {
 	case lpv1:
 		return peer.GetRequestCost(GetHeaderProofsMsg, 1)
-	case lpv2:
+	case lpv2, lpv3:
 		return peer.GetRequestCost(GetHelperTrieProofsMsg, 1)
 	default:
 		panic(nil)
@@ -543,6 +627,124 @@ func (r *BloomRequest) Validate(db essdb.Database, msg *Msg) error {
 	return nil
 }
 
+// LogsReq is a request for the logs matching a filter in a range of canonical
+// blocks.
+type LogsReq struct {
+	FromBlock, ToBlock uint64
+	Filter             light.LogFilter
+}
+
+// LogsResp contains the receipts with logs matching a filter and the proofs of
+// these receipts and their transactions in the tries of the block headers.
+type LogsResp struct {
+	Blocks    []BlockLogs
+	LastBlock uint64 // last block searched, less than ToBlock if the response limit was reached
+	Proofs    light.NodeList
+}
+
+// BlockLogs lists the receipts of a block which contain matching logs.
+type BlockLogs struct {
+	Hash     common.Hash
+	Number   uint64
+	TxIndex  []uint // positions of the receipts in the block
+	LogIndex []uint // positions of the first log of each receipt in the block, not covered by the proofs
+}
+
+// ODR request type for requesting the logs matching a filter, see LesOdrRequest interface
+type LogsRequest light.LogsRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *LogsRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetLogsMsg, int(r.ToBlock-r.FromBlock+1))
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *LogsRequest) CanSend(peer *peer) bool {
+	return peer.version >= lpv3 && peer.HasBlock(r.ToHash, r.ToBlock)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *LogsRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting filtered logs", "from", r.FromBlock, "to", r.ToBlock)
+	return peer.RequestLogs(reqID, r.GetCost(peer), LogsReq{FromBlock: r.FromBlock, ToBlock: r.ToBlock, Filter: r.Filter})
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+//
+// The proofs ensure that the returned logs are included in the canonical chain,
+// a server can however still withhold matching logs.
+func (r *LogsRequest) Validate(db essdb.Database, msg *Msg) error {
+	log.Debug("Validating filtered logs", "from", r.FromBlock, "to", r.ToBlock)
+
+	if msg.MsgType != MsgLogs {
+		return errInvalidMessageType
+	}
+	resp := msg.Obj.(LogsResp)
+	if resp.LastBlock < r.FromBlock || resp.LastBlock > r.ToBlock {
+		return errLogsRangeMismatch
+	}
+	nodeSet := resp.Proofs.NodeSet()
+	reads := &readTraceDB{db: nodeSet}
+
+	var (
+		logs []*types.Log
+		next = r.FromBlock
+		key  []byte
+	)
+	for _, block := range resp.Blocks {
+		if block.Number < next || block.Number > resp.LastBlock || len(block.TxIndex) != len(block.LogIndex) {
+			return errLogsRangeMismatch
+		}
+		next = block.Number + 1
+		if (block.Number == r.ToBlock && block.Hash != r.ToHash) || rawdb.ReadCanonicalHash(db, block.Number) != block.Hash {
+			return errHeaderUnavailable
+		}
+		header := rawdb.ReadHeader(db, block.Hash, block.Number)
+		if header == nil {
+			return errHeaderUnavailable
+		}
+		for i, txIndex := range block.TxIndex {
+			key, _ = rlp.EncodeToBytes(txIndex)
+			receiptData, _, err := trie.VerifyProof(header.ReceiptHash, key, reads)
+			if err != nil {
+				return fmt.Errorf("receipt proof verification failed: %v", err)
+			}
+			txData, _, err := trie.VerifyProof(header.TxHash, key, reads)
+			if err != nil {
+				return fmt.Errorf("transaction proof verification failed: %v", err)
+			}
+			var receipt types.Receipt
+			if err := rlp.DecodeBytes(receiptData, &receipt); err != nil {
+				return err
+			}
+			txHash := crypto.Keccak256Hash(txData)
+			matched := false
+			for j, l := range receipt.Logs {
+				l.BlockNumber = block.Number
+				l.BlockHash = block.Hash
+				l.TxHash = txHash
+				l.TxIndex = txIndex
+				l.Index = block.LogIndex[i] + uint(j)
+				if r.Filter.Matches(l) {
+					logs = append(logs, l)
+					matched = true
+				}
+			}
+			if !matched {
+				return errNoMatchingLogs
+			}
+		}
+	}
+	if len(reads.reads) != nodeSet.KeyCount() {
+		return errUselessNodes
+	}
+	r.LastBlock, r.Logs = resp.LastBlock, logs
+	return nil
+}
+
 // readTraceDB stores the keys of database reads. We use this to check that received node
 // sets contain only the trie nodes necessary to make proofs pass.
 type readTraceDB struct {
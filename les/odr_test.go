@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"math/big"
+	"reflect"
 	"testing"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/orangeAndSuns/essentia/core/state"
 	"github.com/orangeAndSuns/essentia/core/types"
 	"github.com/orangeAndSuns/essentia/core/vm"
+	"github.com/orangeAndSuns/essentia/crypto"
 	"github.com/orangeAndSuns/essentia/ess"
 	"github.com/orangeAndSuns/essentia/essdb"
 	"github.com/orangeAndSuns/essentia/light"
@@ -160,6 +162,83 @@ func odrContractCall(ctx context.Context, db essdb.Database, config *params.Chai
 	return res
 }
 
+func TestOdrMultiTrieLes1(t *testing.T) { testOdr(t, 1, 1, odrMultiTrie) }
+
+func TestOdrMultiTrieLes2(t *testing.T) { testOdr(t, 2, 1, odrMultiTrie) }
+
+// odrMultiTrie retrieves the accounts of odrAccounts in a single batched request
+// and reads them afterwards without ODR access.
+func odrMultiTrie(ctx context.Context, db essdb.Database, config *params.ChainConfig, bc *core.BlockChain, lc *light.LightChain, bhash common.Hash) []byte {
+	if bc != nil {
+		return odrAccounts(ctx, db, config, bc, lc, bhash)
+	}
+	dummyAddr := common.HexToAddress("1234567812345678123456781234567812345678")
+	acc := []common.Address{testBankAddress, acc1Addr, acc2Addr, dummyAddr}
+
+	header := lc.GetHeaderByHash(bhash)
+	id := light.StateTrieID(header)
+	req := &light.MultiTrieRequest{}
+	for _, addr := range acc {
+		req.Keys = append(req.Keys, light.TrieKey{Id: id, Key: crypto.Keccak256(addr[:])})
+	}
+	lc.Odr().Retrieve(ctx, req) // failure is fine if the data is available locally
+	var res []byte
+	st := light.NewState(cancelledContext(), header, lc.Odr())
+	for _, addr := range acc {
+		bal := st.GetBalance(addr)
+		if st.Error() != nil {
+			return res
+		}
+		rlp, _ := rlp.EncodeToBytes(bal)
+		res = append(res, rlp...)
+	}
+	return res
+}
+
+func TestOdrPrefetchCallLes1(t *testing.T) { testOdr(t, 1, 2, odrPrefetchCall) }
+
+func TestOdrPrefetchCallLes2(t *testing.T) { testOdr(t, 2, 2, odrPrefetchCall) }
+
+// odrPrefetchCall executes the calls of odrContractCall after prefetching the
+// accessed state, without ODR access.
+func odrPrefetchCall(ctx context.Context, db essdb.Database, config *params.ChainConfig, bc *core.BlockChain, lc *light.LightChain, bhash common.Hash) []byte {
+	if bc != nil {
+		return odrContractCall(ctx, db, config, bc, lc, bhash)
+	}
+	data := common.Hex2Bytes("60CD26850000000000000000000000000000000000000000000000000000000000000000")
+	header := lc.GetHeaderByHash(bhash)
+
+	var res []byte
+	for i := 0; i < 3; i++ {
+		data[35] = byte(i)
+		msg := callmsg{types.NewMessage(testBankAddress, &testContractAddr, 0, new(big.Int), 100000, new(big.Int), data, false)}
+		call := func(st *state.StateDB) []byte {
+			st.SetBalance(testBankAddress, math.MaxBig256)
+			context := core.NewEVMContext(msg, header, lc, nil)
+			vmenv := vm.NewEVM(context, st, config, vm.Config{})
+			gp := new(core.GasPool).AddGas(math.MaxUint64)
+			ret, _, _, _ := core.ApplyMessage(vmenv, msg, gp)
+			return ret
+		}
+		light.PrefetchState(ctx, header, lc.Odr(), func(st *state.StateDB) error {
+			call(st)
+			return nil
+		})
+		st := light.NewState(cancelledContext(), header, lc.Odr())
+		if ret := call(st); st.Error() == nil {
+			res = append(res, ret...)
+		}
+	}
+	return res
+}
+
+// cancelledContext returns a context which makes every ODR retrieval fail.
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
 func testOdr(t *testing.T, protocol int, expFail uint64, fn odrTestFn) {
 	// Assemble the test environment
 	peers := newPeerSet()
@@ -218,3 +297,69 @@ func testOdr(t *testing.T, protocol int, expFail uint64, fn odrTestFn) {
 	time.Sleep(time.Millisecond * 10) // ensure that all peerSetNotify callbacks are executed
 	test(5)
 }
+
+func TestOdrGetFilteredLogsLes3(t *testing.T) {
+	// Assemble the test environment
+	peers := newPeerSet()
+	dist := newRequestDistributor(peers, make(chan struct{}))
+	rm := newRetrieveManager(peers, dist, nil)
+	db := essdb.NewMemDatabase()
+	ldb := essdb.NewMemDatabase()
+	odr := NewLesOdr(ldb, light.NewChtIndexer(db, true), light.NewBloomTrieIndexer(db, true), ess.NewBloomIndexer(db, light.BloomTrieFrequency), rm)
+	pm := newTestProtocolManagerMust(t, false, 4, testChainGen, nil, nil, db)
+	lpm := newTestProtocolManagerMust(t, true, 0, nil, peers, odr, ldb)
+	_, err1, lpeer, err2 := newTestPeerPair("peer", lpv3, pm, lpm)
+	select {
+	case <-time.After(time.Millisecond * 100):
+	case err := <-err1:
+		t.Fatalf("peer 1 handshake error: %v", err)
+	case err := <-err2:
+		t.Fatalf("peer 1 handshake error: %v", err)
+	}
+	lpm.synchronise(lpeer)
+	lpeer.lock.Lock()
+	lpeer.hasBlock = func(common.Hash, uint64) bool { return true }
+	lpeer.lock.Unlock()
+
+	// Collect the matching logs from the full node's receipts
+	filter := light.LogFilter{Addresses: []common.Address{testEventEmitterAddr}}
+	head := pm.blockchain.CurrentHeader().Number.Uint64()
+
+	var want []*types.Log
+	for i := uint64(0); i <= head; i++ {
+		for _, receipt := range rawdb.ReadReceipts(db, rawdb.ReadCanonicalHash(db, i), i) {
+			for _, log := range receipt.Logs {
+				if filter.Matches(log) {
+					want = append(want, log)
+				}
+			}
+		}
+	}
+	if len(want) == 0 {
+		t.Fatalf("no logs emitted by the test chain")
+	}
+	// Retrieve them through the light client and check that they are identical
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	have, err := light.GetFilteredLogs(ctx, odr, 0, head, filter)
+	if err != nil {
+		t.Fatalf("failed to retrieve filtered logs: %v", err)
+	}
+	if len(have) != len(want) {
+		t.Fatalf("log count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(have[i], want[i]) {
+			t.Errorf("log %d mismatch: have %+v, want %+v", i, have[i], want[i])
+		}
+	}
+	// Without a server, the logs are not available
+	peers.Unregister(lpeer.id)
+	time.Sleep(time.Millisecond * 10) // ensure that all peerSetNotify callbacks are executed
+
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := light.GetFilteredLogs(ctx, odr, 0, head, filter); err == nil {
+		t.Errorf("retrieved filtered logs without a server")
+	}
+}
@@ -60,10 +60,12 @@ type peer struct {
 
 	announceType, requestAnnounceType uint64
 
-	id string
+	id      string
+	trusted bool // trusted server of an ultra light client
 
-	headInfo *announceData
-	lock     sync.RWMutex
+	headInfo   *announceData
+	checkpoint *signedCheckpoint // latest checkpoint of the server's checkpoint oracle
+	lock       sync.RWMutex
 
 	announceChn chan announceData
 	sendQueue   *execQueue
@@ -75,6 +77,7 @@ type peer struct {
 	fcClient       *flowcontrol.ClientNode // nil if the peer is server only
 	fcServer       *flowcontrol.ServerNode // nil if the peer is client only
 	fcServerParams *flowcontrol.ServerParams
+	fcParams       *flowcontrol.ServerParams // parameters assigned to the client by the client pool
 	fcCosts        requestCostTable
 }
 
@@ -174,6 +177,64 @@ func (p *peer) GetRequestCost(msgcode uint64, amount int) uint64 {
 	return cost
 }
 
+// fcClientParams returns the flow control parameters assigned to the client.
+func (p *peer) fcClientParams() *flowcontrol.ServerParams {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.fcParams
+}
+
+// servedCost returns the sum of the costs of the requests served to the client.
+func (p *peer) servedCost() uint64 {
+	p.lock.RLock()
+	fcClient := p.fcClient
+	p.lock.RUnlock()
+
+	if fcClient == nil {
+		return 0
+	}
+	return fcClient.ServedCost()
+}
+
+// updateCapacity changes the capacity of the client and signals the new flow
+// control parameters to it with an announcement. If the handshake hasn't been
+// done yet, the parameters are sent in the handshake instead.
+func (p *peer) updateCapacity(cap uint64) {
+	params := &flowcontrol.ServerParams{BufLimit: cap * bufLimitRatio, MinRecharge: cap}
+
+	p.lock.Lock()
+	p.fcParams = params
+	fcClient := p.fcClient
+	p.lock.Unlock()
+
+	if fcClient == nil {
+		return
+	}
+	fcClient.UpdateParams(params)
+
+	var update keyValueList
+	update = update.add("flowControl/BL", params.BufLimit)
+	update = update.add("flowControl/MRR", params.MinRecharge)
+	go p.SendAnnounce(announceData{Update: update})
+}
+
+// updateFlowControl applies the flow control parameters announced by a server.
+func (p *peer) updateFlowControl(update keyValueMap) {
+	if p.fcServer == nil {
+		return
+	}
+	params := &flowcontrol.ServerParams{}
+	if update.get("flowControl/BL", &params.BufLimit) != nil || update.get("flowControl/MRR", &params.MinRecharge) != nil {
+		return
+	}
+	p.lock.Lock()
+	p.fcServerParams = params
+	p.lock.Unlock()
+
+	p.fcServer.UpdateParams(params)
+}
+
 // HasBlock checks if the peer has a given block
 func (p *peer) HasBlock(hash common.Hash, number uint64) bool {
 	p.lock.RLock()
@@ -236,6 +297,11 @@ func (p *peer) SendTxStatus(reqID, bv uint64, stats []txStatus) error {
 	return sendResponse(p.rw, TxStatusMsg, reqID, bv, stats)
 }
 
+// SendLogs sends the receipts with logs matching a requested filter.
+func (p *peer) SendLogs(reqID, bv uint64, resp LogsResp) error {
+	return sendResponse(p.rw, LogsMsg, reqID, bv, resp)
+}
+
 // RequestHeadersByHash fetches a batch of blocks' headers corresponding to the
 // specified header query, based on the hash of an origin block.
 func (p *peer) RequestHeadersByHash(reqID, cost uint64, origin common.Hash, amount int, skip int, reverse bool) error {
@@ -276,7 +342,7 @@ func (p *peer) RequestProofs(reqID, cost uint64, reqs []ProofReq) error {
 	switch p.version {
 	case lpv1:
 		return sendRequest(p.rw, GetProofsV1Msg, reqID, cost, reqs)
-	case lpv2:
+	case lpv2, lpv3:
 		return sendRequest(p.rw, GetProofsV2Msg, reqID, cost, reqs)
 	default:
 		panic(nil)
@@ -298,13 +364,19 @@ func (p *peer) RequestHelperTrieProofs(reqID, cost uint64, reqs []HelperTrieReq)
 			reqsV1[i] = ChtReq{ChtNum: (req.TrieIdx + 1) * (light.CHTFrequencyClient / light.CHTFrequencyServer), BlockNum: blockNum, FromLevel: req.FromLevel}
 		}
 		return sendRequest(p.rw, GetHeaderProofsMsg, reqID, cost, reqsV1)
-	case lpv2:
+	case lpv2, lpv3:
 		return sendRequest(p.rw, GetHelperTrieProofsMsg, reqID, cost, reqs)
 	default:
 		panic(nil)
 	}
 }
 
+// RequestLogs fetches the logs matching a filter in a range of blocks from a remote node.
+func (p *peer) RequestLogs(reqID, cost uint64, req LogsReq) error {
+	p.Log().Debug("Fetching filtered logs", "from", req.FromBlock, "to", req.ToBlock)
+	return sendRequest(p.rw, GetLogsMsg, reqID, cost, req)
+}
+
 // RequestTxStatus fetches a batch of transaction status records from a remote node.
 func (p *peer) RequestTxStatus(reqID, cost uint64, txHashes []common.Hash) error {
 	p.Log().Debug("Requesting transaction status", "count", len(txHashes))
@@ -317,7 +389,7 @@ func (p *peer) SendTxs(reqID, cost uint64, txs types.Transactions) error {
 	switch p.version {
 	case lpv1:
 		return p2p.Send(p.rw, SendTxMsg, txs) // old message format does not include reqID
-	case lpv2:
+	case lpv2, lpv3:
 		return sendRequest(p.rw, SendTxV2Msg, reqID, cost, txs)
 	default:
 		panic(nil)
@@ -409,13 +481,23 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		send = send.add("serveChainSince", uint64(0))
 		send = send.add("serveStateSince", uint64(0))
 		send = send.add("txRelay", nil)
-		send = send.add("flowControl/BL", server.defParams.BufLimit)
-		send = send.add("flowControl/MRR", server.defParams.MinRecharge)
+		if p.fcParams == nil {
+			p.fcParams = server.defParams
+		}
+		send = send.add("flowControl/BL", p.fcParams.BufLimit)
+		send = send.add("flowControl/MRR", p.fcParams.MinRecharge)
 		list := server.fcCostStats.getCurrentList()
 		send = send.add("flowControl/MRC", list)
 		p.fcCosts = list.decode()
+		if cp := server.oracle.latest(); cp != nil {
+			send = send.add("checkpoint", cp)
+		}
 	} else {
-		p.requestAnnounceType = announceTypeSimple // set to default until "very light" client mode is implemented
+		p.requestAnnounceType = announceTypeSimple
+		if p.trusted {
+			// Ultra light clients only follow heads signed by trusted servers
+			p.requestAnnounceType = announceTypeSigned
+		}
 		send = send.add("announceType", p.requestAnnounceType)
 	}
 	recvList, err := p.sendReceiveHandshake(send)
@@ -464,7 +546,7 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		if recv.get("announceType", &p.announceType) != nil {
 			p.announceType = announceTypeSimple
 		}
-		p.fcClient = flowcontrol.NewClientNode(server.fcManager, server.defParams)
+		p.fcClient = flowcontrol.NewClientNode(server.fcManager, p.fcParams)
 	} else {
 		if recv.get("serveChainSince", nil) != nil {
 			return errResp(ErrUselessPeer, "peer cannot serve chain")
@@ -489,6 +571,11 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		p.fcServerParams = params
 		p.fcServer = flowcontrol.NewServerNode(params)
 		p.fcCosts = MRC.decode()
+
+		var cp signedCheckpoint
+		if recv.get("checkpoint", &cp) == nil {
+			p.checkpoint = &cp
+		}
 	}
 
 	p.headInfo = &announceData{Td: rTd, Hash: rHash, Number: rNum}
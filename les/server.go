@@ -24,17 +24,20 @@ import (
 	"sync"
 
 	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/contracts/checkpointoracle"
 	"github.com/orangeAndSuns/essentia/core"
 	"github.com/orangeAndSuns/essentia/core/rawdb"
 	"github.com/orangeAndSuns/essentia/core/types"
 	"github.com/orangeAndSuns/essentia/ess"
 	"github.com/orangeAndSuns/essentia/essdb"
+	"github.com/orangeAndSuns/essentia/internal/essapi"
 	"github.com/orangeAndSuns/essentia/les/flowcontrol"
 	"github.com/orangeAndSuns/essentia/light"
 	"github.com/orangeAndSuns/essentia/log"
 	"github.com/orangeAndSuns/essentia/p2p"
 	"github.com/orangeAndSuns/essentia/p2p/discv5"
 	"github.com/orangeAndSuns/essentia/rlp"
+	"github.com/orangeAndSuns/essentia/rpc"
 )
 
 type LesServer struct {
@@ -43,6 +46,8 @@ type LesServer struct {
 	fcManager       *flowcontrol.ClientManager // nil if our node is client only
 	fcCostStats     *requestCostStats
 	defParams       *flowcontrol.ServerParams
+	clientPool      *clientPool
+	oracle          *checkpointOracle
 	lesTopics       []discv5.Topic
 	privateKey      *ecdsa.PrivateKey
 	quitSync        chan struct{}
@@ -52,7 +57,7 @@ type LesServer struct {
 
 func NewLesServer(ess *ess.Essentia, config *ess.Config) (*LesServer, error) {
 	quitSync := make(chan struct{})
-	pm, err := NewProtocolManager(ess.BlockChain().Config(), false, ServerProtocolVersions, config.NetworkId, ess.EventMux(), ess.Engine(), newPeerSet(), ess.BlockChain(), ess.TxPool(), ess.ChainDb(), nil, nil, nil, quitSync, new(sync.WaitGroup))
+	pm, err := NewProtocolManager(ess.BlockChain().Config(), false, ServerProtocolVersions, config.NetworkId, ess.EventMux(), ess.Engine(), newPeerSet(), ess.BlockChain(), ess.TxPool(), ess.ChainDb(), nil, nil, nil, quitSync, new(sync.WaitGroup), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -93,20 +98,60 @@ func NewLesServer(ess *ess.Essentia, config *ess.Config) (*LesServer, error) {
 
 	srv.chtIndexer.Start(ess.BlockChain())
 	pm.server = srv
+	srv.oracle = newCheckpointOracle(config.CheckpointOracle, &chainCaller{essapi.NewPublicBlockChainAPI(ess.APIBackend)})
 
 	srv.defParams = &flowcontrol.ServerParams{
 		BufLimit:    300000000,
 		MinRecharge: 50000,
 	}
 	srv.fcManager = flowcontrol.NewClientManager(uint64(config.LightServ), 10, 1000000000)
+	srv.clientPool = newClientPool(uint64(config.LightPeers)*srv.defParams.MinRecharge, srv.defParams.MinRecharge)
 	srv.fcCostStats = newCostStats(ess.ChainDb())
 	return srv, nil
 }
 
+// localCheckpoint returns the latest section for which both the CHT and the
+// BloomTrie were generated locally, or nil if there is none.
+func (s *LesServer) localCheckpoint() *checkpointoracle.Checkpoint {
+	chtV1SectionCount, _, _ := s.chtIndexer.Sections()
+	sections := chtV1SectionCount / (light.CHTFrequencyClient / light.CHTFrequencyServer)
+	if bloomTrieSectionCount, _, _ := s.bloomTrieIndexer.Sections(); bloomTrieSectionCount < sections {
+		sections = bloomTrieSectionCount
+	}
+	if sections == 0 {
+		return nil
+	}
+	section := sections - 1
+	chtSectionHead := s.chtIndexer.SectionHead((section+1)*(light.CHTFrequencyClient/light.CHTFrequencyServer) - 1)
+	bloomTrieSectionHead := s.bloomTrieIndexer.SectionHead(section)
+	if chtSectionHead != bloomTrieSectionHead {
+		return nil
+	}
+	db := s.protocolManager.chainDb
+	return &checkpointoracle.Checkpoint{
+		SectionIndex:  section,
+		SectionHead:   chtSectionHead,
+		ChtRoot:       light.GetChtV2Root(db, section, chtSectionHead),
+		BloomTrieRoot: light.GetBloomTrieRoot(db, section, bloomTrieSectionHead),
+	}
+}
+
 func (s *LesServer) Protocols() []p2p.Protocol {
 	return s.protocolManager.SubProtocols
 }
 
+// APIs returns the RPC APIs of the LES server.
+func (s *LesServer) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightServerAPI(s),
+			Public:    false,
+		},
+	}
+}
+
 // Start starts the LES server
 func (s *LesServer) Start(srvr *p2p.Server) {
 	s.protocolManager.Start(s.config.LightPeers)
@@ -123,6 +168,7 @@ func (s *LesServer) Start(srvr *p2p.Server) {
 		}
 	}
 	s.privateKey = srvr.PrivateKey
+	s.oracle.start(s.quitSync)
 	s.protocolManager.blockLoop()
 }
 
@@ -121,9 +121,9 @@ type serverPool struct {
 
 	topic discv5.Topic
 
-	discSetPeriod chan time.Duration
-	discNodes     chan *discv5.Node
-	discLookups   chan bool
+	discIter      discover.Iterator
+	discNodes     chan *discover.Node
+	discConverged <-chan struct{}
 
 	entries              map[discover.ESSNodeID]*poolEntry
 	timeout, enableRetry chan *poolEntry
@@ -169,15 +169,27 @@ func (pool *serverPool) start(server *p2p.Server, topic discv5.Topic) {
 	pool.loadNodes()
 
 	if pool.server.DiscV5 != nil {
-		pool.discSetPeriod = make(chan time.Duration, 1)
-		pool.discNodes = make(chan *discv5.Node, 100)
-		pool.discLookups = make(chan bool, 100)
-		go pool.server.DiscV5.SearchTopic(pool.topic, pool.discSetPeriod, pool.discNodes, pool.discLookups)
+		search := pool.server.DiscV5.SearchTopicNodes(pool.topic)
+		pool.discIter = search
+		pool.discConverged = search.Converged()
+		pool.discNodes = make(chan *discover.Node)
+		go pool.readDiscovered()
 	}
 	pool.checkDial()
 	go pool.eventLoop()
 }
 
+// readDiscovered feeds the nodes found by discovery into the event loop.
+func (pool *serverPool) readDiscovered() {
+	for pool.discIter.Next() {
+		select {
+		case pool.discNodes <- pool.discIter.Node():
+		case <-pool.quit:
+			return
+		}
+	}
+}
+
 // connect should be called upon any incoming connection. If the connection has been
 // dialed by the server pool recently, the appropriate pool entry is returned.
 // Otherwise, the connection should be rejected.
@@ -259,12 +271,6 @@ func (pool *serverPool) adjustResponseTime(entry *poolEntry, time time.Duration,
 
 // eventLoop handles pool events and mutex locking for all internal functions
 func (pool *serverPool) eventLoop() {
-	lookupCnt := 0
-	var convTime mclock.AbsTime
-	if pool.discSetPeriod != nil {
-		pool.discSetPeriod <- time.Millisecond * 100
-	}
-
 	// disconnect updates service quality statistics depending on the connection time
 	// and disconnection initiator.
 	disconnect := func(req *disconnReq, stopped bool) {
@@ -320,22 +326,13 @@ func (pool *serverPool) eventLoop() {
 			}
 
 		case node := <-pool.discNodes:
-			entry := pool.findOrNewNode(discover.ESSNodeID(node.ID), node.IP, node.TCP)
+			entry := pool.findOrNewNode(node.ID, node.IP, node.TCP)
 			pool.updateCheckDial(entry)
 
-		case conv := <-pool.discLookups:
-			if conv {
-				if lookupCnt == 0 {
-					convTime = mclock.Now()
-				}
-				lookupCnt++
-				if pool.fastDiscover && (lookupCnt == 50 || time.Duration(mclock.Now()-convTime) > time.Minute) {
-					pool.fastDiscover = false
-					if pool.discSetPeriod != nil {
-						pool.discSetPeriod <- time.Minute
-					}
-				}
-			}
+		case <-pool.discConverged:
+			// Discovery has slowed down, stop preferring new nodes.
+			pool.fastDiscover = false
+			pool.discConverged = nil
 
 		case req := <-pool.connCh:
 			// Handle peer connection requests.
@@ -380,8 +377,8 @@ func (pool *serverPool) eventLoop() {
 			disconnect(req, req.stopped)
 
 		case <-pool.quit:
-			if pool.discSetPeriod != nil {
-				close(pool.discSetPeriod)
+			if pool.discIter != nil {
+				pool.discIter.Close()
 			}
 
 			// Spawn a goroutine to close the disconnCh after all connections are disconnected.
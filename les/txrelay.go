@@ -18,14 +18,31 @@ package les
 
 import (
 	"sync"
+	"time"
 
 	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/core"
 	"github.com/orangeAndSuns/essentia/core/types"
+	"github.com/orangeAndSuns/essentia/event"
+	"github.com/orangeAndSuns/essentia/light"
+	"github.com/orangeAndSuns/essentia/log"
+)
+
+const (
+	// txStatusTimeout is the time limit of a transaction status query.
+	txStatusTimeout = 5 * time.Second
+
+	// txDropThreshold is the number of consecutive status checks in which all
+	// queried servers have to report a pending transaction as unknown before it
+	// is considered dropped.
+	txDropThreshold = 3
 )
 
 type ltrInfo struct {
-	tx     *types.Transaction
-	sentTo map[*peer]struct{}
+	tx       *types.Transaction
+	sentTo   map[*peer]struct{}
+	rejected map[*peer]string // servers that refused to pool the transaction
+	missed   int              // consecutive status checks not finding the transaction
 }
 
 type LesTxRelay struct {
@@ -34,22 +51,45 @@ type LesTxRelay struct {
 	ps           *peerSet
 	peerList     []*peer
 	peerStartPos int
+	checking     bool // a status check is running
 	lock         sync.RWMutex
 
+	statusReqs map[uint64]func(*peer, []txStatus) // handlers of the expected TxStatusMsg replies
+	reqLock    sync.Mutex
+
+	dropFeed event.Feed
+	scope    event.SubscriptionScope
+	stop     chan struct{}
+
 	reqDist *requestDistributor
 }
 
 func NewLesTxRelay(ps *peerSet, reqDist *requestDistributor) *LesTxRelay {
 	r := &LesTxRelay{
-		txSent:    make(map[common.Hash]*ltrInfo),
-		txPending: make(map[common.Hash]struct{}),
-		ps:        ps,
-		reqDist:   reqDist,
+		txSent:     make(map[common.Hash]*ltrInfo),
+		txPending:  make(map[common.Hash]struct{}),
+		ps:         ps,
+		statusReqs: make(map[uint64]func(*peer, []txStatus)),
+		stop:       make(chan struct{}),
+		reqDist:    reqDist,
 	}
 	ps.notify(r)
 	return r
 }
 
+// Stop terminates the running status checks and the dropped tx subscriptions.
+func (self *LesTxRelay) Stop() {
+	close(self.stop)
+	self.scope.Close()
+}
+
+// SubscribeDroppedTxsEvent registers a subscription of light.DroppedTxsEvent,
+// posted when transactions are not known or accepted by any server anymore
+// (implementation of light.TxDropNotifier).
+func (self *LesTxRelay) SubscribeDroppedTxsEvent(ch chan<- light.DroppedTxsEvent) event.Subscription {
+	return self.scope.Track(self.dropFeed.Subscribe(ch))
+}
+
 func (self *LesTxRelay) registerPeer(p *peer) {
 	self.lock.Lock()
 	defer self.lock.Unlock()
@@ -79,8 +119,9 @@ func (self *LesTxRelay) send(txs types.Transactions, count int) {
 		ltr, ok := self.txSent[hash]
 		if !ok {
 			ltr = &ltrInfo{
-				tx:     tx,
-				sentTo: make(map[*peer]struct{}),
+				tx:       tx,
+				sentTo:   make(map[*peer]struct{}),
+				rejected: make(map[*peer]string),
 			}
 			self.txSent[hash] = ltr
 			self.txPending[hash] = struct{}{}
@@ -115,6 +156,13 @@ func (self *LesTxRelay) send(txs types.Transactions, count int) {
 		ll := list
 
 		reqID := genReqID()
+		if pp.version >= lpv2 {
+			// Servers reply with the status of the sent transactions
+			self.expectStatus(reqID, func(p *peer, stats []txStatus) {
+				self.sendResult(p, ll, stats)
+			})
+			time.AfterFunc(txStatusTimeout, func() { self.forgetStatus(reqID) })
+		}
 		rq := &distReq{
 			getCost: func(dp distPeer) uint64 {
 				peer := dp.(*peer)
@@ -141,6 +189,9 @@ func (self *LesTxRelay) Send(txs types.Transactions) {
 	self.send(txs, 3)
 }
 
+// NewHead updates the set of pending transactions and starts checking their
+// status on the servers they have been sent to, rebroadcasting the ones unknown
+// to the servers.
 func (self *LesTxRelay) NewHead(head common.Hash, mined []common.Hash, rollback []common.Hash) {
 	self.lock.Lock()
 	defer self.lock.Unlock()
@@ -150,17 +201,207 @@ func (self *LesTxRelay) NewHead(head common.Hash, mined []common.Hash, rollback
 	}
 
 	for _, hash := range rollback {
-		self.txPending[hash] = struct{}{}
+		if ltr, ok := self.txSent[hash]; ok {
+			ltr.missed = 0
+			self.txPending[hash] = struct{}{}
+		}
+	}
+
+	if len(self.txPending) > 0 && !self.checking {
+		self.checking = true
+		go self.checkStatus()
+	}
+}
+
+// checkStatus queries the status of the pending transactions from the servers
+// they have been sent to. Transactions unknown to all of them are sent to new
+// servers, and dropped after being missed by txDropThreshold checks or once all
+// connected servers rejected them.
+func (self *LesTxRelay) checkStatus() {
+	defer func() {
+		self.lock.Lock()
+		self.checking = false
+		self.lock.Unlock()
+	}()
+
+	// Assign the pending transactions to the servers able to report their status
+	self.lock.RLock()
+	connected := make(map[*peer]bool)
+	for _, p := range self.peerList {
+		connected[p] = p.version >= lpv2
+	}
+	var (
+		queries   = make(map[*peer][]common.Hash)
+		unqueried []common.Hash
+	)
+	for hash := range self.txPending {
+		queried := false
+		for p := range self.txSent[hash].sentTo {
+			if connected[p] && len(queries[p]) < MaxTxStatus {
+				queries[p] = append(queries[p], hash)
+				queried = true
+			}
+		}
+		if !queried {
+			unqueried = append(unqueried, hash)
+		}
+	}
+	self.lock.RUnlock()
+
+	// Query the servers and collect which transactions are known by any of them
+	type reply struct {
+		hashes []common.Hash
+		stats  []txStatus
+	}
+	replies := make(chan reply, len(queries))
+	for p, hashes := range queries {
+		hashes := hashes
+		self.requestStatus(p, hashes, func(stats []txStatus) {
+			replies <- reply{hashes, stats}
+		})
+	}
+	known := make(map[common.Hash]bool)
+	timeout := time.NewTimer(txStatusTimeout)
+	defer timeout.Stop()
+	for i := 0; i < len(queries); i++ {
+		select {
+		case r := <-replies:
+			for j, hash := range r.hashes {
+				known[hash] = known[hash] || r.stats[j].Status != core.TxStatusUnknown
+			}
+		case <-timeout.C:
+			i = len(queries)
+		case <-self.stop:
+			return
+		}
+	}
+
+	// Rebroadcast the missing transactions and drop the hopeless ones
+	self.lock.Lock()
+	var (
+		resend  types.Transactions
+		dropped []common.Hash
+	)
+	for hash, seen := range known {
+		if _, ok := self.txPending[hash]; !ok {
+			continue // mined or discarded in the meantime
+		}
+		ltr := self.txSent[hash]
+		if seen {
+			ltr.missed = 0
+			continue
+		}
+		ltr.missed++
+		if ltr.missed >= txDropThreshold || (len(ltr.rejected) == len(ltr.sentTo) && !self.hasUntried(ltr)) {
+			delete(self.txPending, hash)
+			dropped = append(dropped, hash)
+			continue
+		}
+		resend = append(resend, ltr.tx)
+	}
+	for _, hash := range unqueried {
+		if _, ok := self.txPending[hash]; ok {
+			resend = append(resend, self.txSent[hash].tx)
+		}
+	}
+	if len(resend) > 0 {
+		self.send(resend, 1)
+	}
+	self.lock.Unlock()
+
+	if len(dropped) > 0 {
+		log.Debug("Transactions dropped by the servers", "count", len(dropped))
+		self.dropFeed.Send(light.DroppedTxsEvent{Hashes: dropped})
+	}
+}
+
+// hasUntried reports whether the transaction can be sent to a connected server
+// it hasn't been sent to yet.
+func (self *LesTxRelay) hasUntried(ltr *ltrInfo) bool {
+	for _, p := range self.peerList {
+		if _, ok := ltr.sentTo[p]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// requestStatus queries the status of the given transactions from a server. The
+// callback is invoked with the reply unless it doesn't arrive in time.
+func (self *LesTxRelay) requestStatus(p *peer, hashes []common.Hash, callback func([]txStatus)) {
+	reqID := genReqID()
+	self.expectStatus(reqID, func(sender *peer, stats []txStatus) {
+		if sender == p && len(stats) == len(hashes) {
+			callback(stats)
+		}
+	})
+
+	rq := &distReq{
+		getCost: func(dp distPeer) uint64 {
+			return dp.(*peer).GetRequestCost(GetTxStatusMsg, len(hashes))
+		},
+		canSend: func(dp distPeer) bool {
+			return dp.(*peer) == p
+		},
+		request: func(dp distPeer) func() {
+			peer := dp.(*peer)
+			cost := peer.GetRequestCost(GetTxStatusMsg, len(hashes))
+			peer.fcServer.QueueRequest(reqID, cost)
+			return func() { peer.RequestTxStatus(reqID, cost, hashes) }
+		},
 	}
+	self.reqDist.queue(rq)
+	time.AfterFunc(txStatusTimeout, func() {
+		self.reqDist.cancel(rq)
+		self.forgetStatus(reqID)
+	})
+}
+
+// sendResult records the servers refusing to pool the sent transactions.
+func (self *LesTxRelay) sendResult(p *peer, txs types.Transactions, stats []txStatus) {
+	if len(stats) != len(txs) {
+		return
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
 
-	if len(self.txPending) > 0 {
-		txs := make(types.Transactions, len(self.txPending))
-		i := 0
-		for hash := range self.txPending {
-			txs[i] = self.txSent[hash].tx
-			i++
+	for i, tx := range txs {
+		if stats[i].Error == "" {
+			continue
+		}
+		if ltr, ok := self.txSent[tx.Hash()]; ok {
+			p.Log().Debug("Transaction rejected by server", "hash", tx.Hash(), "err", stats[i].Error)
+			ltr.rejected[p] = stats[i].Error
 		}
-		self.send(txs, 1)
+	}
+}
+
+// expectStatus registers the handler of a TxStatusMsg reply.
+func (self *LesTxRelay) expectStatus(reqID uint64, handler func(*peer, []txStatus)) {
+	self.reqLock.Lock()
+	defer self.reqLock.Unlock()
+
+	self.statusReqs[reqID] = handler
+}
+
+// forgetStatus removes the handler of a TxStatusMsg reply that didn't arrive.
+func (self *LesTxRelay) forgetStatus(reqID uint64) {
+	self.reqLock.Lock()
+	defer self.reqLock.Unlock()
+
+	delete(self.statusReqs, reqID)
+}
+
+// deliverStatus is called by the protocol manager when a TxStatusMsg arrives,
+// either as the reply of a status query or of sent transactions.
+func (self *LesTxRelay) deliverStatus(p *peer, reqID uint64, stats []txStatus) {
+	self.reqLock.Lock()
+	handler, ok := self.statusReqs[reqID]
+	delete(self.statusReqs, reqID)
+	self.reqLock.Unlock()
+
+	if ok {
+		handler(p, stats)
 	}
 }
 
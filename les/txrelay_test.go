@@ -0,0 +1,126 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/core"
+	"github.com/orangeAndSuns/essentia/core/types"
+	"github.com/orangeAndSuns/essentia/essdb"
+	"github.com/orangeAndSuns/essentia/light"
+	"github.com/orangeAndSuns/essentia/params"
+)
+
+// newRelayTestServer creates a server with a transaction pool accepting
+// transactions above the given gas price.
+func newRelayTestServer(t *testing.T, gasPrice *big.Int) (*ProtocolManager, *core.TxPool) {
+	pm := newTestProtocolManagerMust(t, false, 0, nil, nil, nil, essdb.NewMemDatabase())
+	config := core.DefaultTxPoolConfig
+	config.Journal = ""
+	txpool := core.NewTxPool(config, params.TestChainConfig, pm.blockchain.(*core.BlockChain))
+	txpool.SetGasPrice(gasPrice)
+	pm.txpool = txpool
+	return pm, txpool
+}
+
+// connectRelayTestServer connects the client to the server.
+func connectRelayTestServer(t *testing.T, server, client *ProtocolManager) {
+	_, err1, _, err2 := newTestPeerPair("peer", lpv2, server, client)
+	select {
+	case <-time.After(100 * time.Millisecond):
+	case err := <-err1:
+		t.Fatalf("server handshake error: %v", err)
+	case err := <-err2:
+		t.Fatalf("client handshake error: %v", err)
+	}
+}
+
+// waitRelay waits until the condition holds on the relay state.
+func waitRelay(t *testing.T, relay *LesTxRelay, what string, cond func() bool) {
+	for i := 0; i < 50; i++ {
+		relay.lock.RLock()
+		ok := cond() && !relay.checking
+		relay.lock.RUnlock()
+		if ok {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for %s", what)
+}
+
+func TestTxRelayRebroadcast(t *testing.T) {
+	var (
+		peers  = newPeerSet()
+		ldb    = essdb.NewMemDatabase()
+		dist   = newRequestDistributor(peers, make(chan struct{}))
+		odr    = NewLesOdr(ldb, nil, nil, nil, newRetrieveManager(peers, dist, nil))
+		client = newTestProtocolManagerMust(t, true, 0, nil, peers, odr, ldb)
+		relay  = NewLesTxRelay(peers, dist)
+	)
+	defer relay.Stop()
+	client.txrelay = relay
+
+	dropped := make(chan light.DroppedTxsEvent, 1)
+	sub := relay.SubscribeDroppedTxsEvent(dropped)
+	defer sub.Unsubscribe()
+
+	// The first server refuses cheap transactions, the second one accepts them
+	server1, _ := newRelayTestServer(t, big.NewInt(1000000000000))
+	server2, pool2 := newRelayTestServer(t, big.NewInt(1))
+	connectRelayTestServer(t, server1, client)
+
+	signer := types.HomesteadSigner{}
+	tx, _ := types.SignTx(types.NewTransaction(0, acc1Addr, big.NewInt(10000), params.TxGas, big.NewInt(100000000000), nil), signer, testBankKey)
+	relay.Send(types.Transactions{tx})
+	waitRelay(t, relay, "rejection", func() bool { return len(relay.txSent[tx.Hash()].rejected) == 1 })
+
+	// Unknown to the first server, the transaction is sent to the second one
+	connectRelayTestServer(t, server2, client)
+	relay.NewHead(common.Hash{}, nil, nil)
+	waitRelay(t, relay, "rebroadcast", func() bool { return len(relay.txSent[tx.Hash()].sentTo) == 2 })
+	for i := 0; i < 50 && pool2.Get(tx.Hash()) == nil; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if pool2.Get(tx.Hash()) == nil {
+		t.Fatalf("transaction not rebroadcast")
+	}
+	// Known to the second server, the transaction is not dropped
+	relay.NewHead(common.Hash{}, nil, nil)
+	waitRelay(t, relay, "status check", func() bool { return relay.txSent[tx.Hash()].missed == 0 })
+	if _, ok := relay.txPending[tx.Hash()]; !ok {
+		t.Fatalf("pending transaction dropped")
+	}
+
+	// A transaction rejected by all servers is dropped
+	cheap, _ := types.SignTx(types.NewTransaction(1, acc1Addr, big.NewInt(10000), params.TxGas, big.NewInt(0), nil), signer, testBankKey)
+	relay.Send(types.Transactions{cheap})
+	waitRelay(t, relay, "rejection", func() bool { return len(relay.txSent[cheap.Hash()].rejected) == 2 })
+	relay.NewHead(common.Hash{}, nil, nil)
+	select {
+	case ev := <-dropped:
+		if len(ev.Hashes) != 1 || ev.Hashes[0] != cheap.Hash() {
+			t.Fatalf("dropped transactions mismatch: have %x, want %x", ev.Hashes, cheap.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("rejected transaction not dropped")
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/orangeAndSuns/essentia/ess"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+)
+
+var errNoTrustedServers = errors.New("no trusted servers configured for ultra light client")
+
+// ulc holds the set of trusted servers of an ultra light client. Instead of
+// downloading and verifying the header chain, an ultra light client accepts a
+// head once enough trusted servers have announced it with a valid signature.
+type ulc struct {
+	trusted            map[discover.ESSNodeID]struct{}
+	minTrustedFraction int
+}
+
+// newULC creates the trusted server set from the given config. It returns nil
+// if the ultra light mode is not enabled.
+func newULC(config *ess.ULCConfig) (*ulc, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if len(config.TrustedServers) == 0 {
+		return nil, errNoTrustedServers
+	}
+	u := &ulc{
+		trusted:            make(map[discover.ESSNodeID]struct{}),
+		minTrustedFraction: config.MinTrustedFraction,
+	}
+	for _, url := range config.TrustedServers {
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted server %q: %v", url, err)
+		}
+		u.trusted[node.ID] = struct{}{}
+	}
+	if u.minTrustedFraction <= 0 || u.minTrustedFraction > 100 {
+		u.minTrustedFraction = ess.DefaultULCMinTrustedFraction
+	}
+	return u, nil
+}
+
+// isTrusted reports whether the node is one of the trusted servers.
+func (u *ulc) isTrusted(id discover.ESSNodeID) bool {
+	if u == nil {
+		return false
+	}
+	_, ok := u.trusted[id]
+	return ok
+}
+
+// enoughAgreed reports whether the given number of trusted servers is enough
+// to accept a head.
+func (u *ulc) enoughAgreed(count int) bool {
+	return count*100 >= u.minTrustedFraction*len(u.trusted)
+}
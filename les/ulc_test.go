@@ -0,0 +1,211 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/consensus/esshash"
+	"github.com/orangeAndSuns/essentia/core"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/ess"
+	"github.com/orangeAndSuns/essentia/essdb"
+	"github.com/orangeAndSuns/essentia/light"
+	"github.com/orangeAndSuns/essentia/p2p"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+	"github.com/orangeAndSuns/essentia/params"
+)
+
+func TestNewULC(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	id := discover.PubkeyID(&key.PublicKey)
+	url := discover.NewNode(id, nil, 0, 0).String()
+
+	u, err := newULC(&ess.ULCConfig{TrustedServers: []string{url}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !u.isTrusted(id) || u.isTrusted(discover.ESSNodeID{}) {
+		t.Fatal("wrong trusted servers")
+	}
+	if u.minTrustedFraction != ess.DefaultULCMinTrustedFraction {
+		t.Fatalf("wrong default fraction %d", u.minTrustedFraction)
+	}
+	if _, err := newULC(&ess.ULCConfig{}); err != errNoTrustedServers {
+		t.Fatalf("wrong error for empty config: %v", err)
+	}
+	if _, err := newULC(&ess.ULCConfig{TrustedServers: []string{"enode://invalid"}}); err == nil {
+		t.Fatal("no error for invalid server URL")
+	}
+}
+
+// ulcTestServer is a les test server with a node key, so that its signed
+// announcements can be checked by the client.
+type ulcTestServer struct {
+	pm  *ProtocolManager
+	key *ecdsa.PrivateKey
+}
+
+func newULCTestServer(t *testing.T) *ulcTestServer {
+	key, _ := crypto.GenerateKey()
+	pm := newTestProtocolManagerMust(t, false, 4, testChainGen, nil, nil, essdb.NewMemDatabase())
+	pm.server.privateKey = key
+	return &ulcTestServer{pm: pm, key: key}
+}
+
+func (s *ulcTestServer) url() string {
+	return discover.NewNode(discover.PubkeyID(&s.key.PublicKey), nil, 0, 0).String()
+}
+
+// newULCTestClient creates an ultra light client trusting the given servers.
+func newULCTestClient(t *testing.T, fraction int, servers ...*ulcTestServer) *ProtocolManager {
+	config := &ess.ULCConfig{MinTrustedFraction: fraction}
+	for _, s := range servers {
+		config.TrustedServers = append(config.TrustedServers, s.url())
+	}
+	var (
+		peers = newPeerSet()
+		dist  = newRequestDistributor(peers, make(chan struct{}))
+		rm    = newRetrieveManager(peers, dist, nil)
+		ldb   = essdb.NewMemDatabase()
+		odr   = NewLesOdr(ldb, light.NewChtIndexer(ldb, true), light.NewBloomTrieIndexer(ldb, true), nil, rm)
+		pm    = newTestProtocolManagerMust(t, true, 0, nil, peers, odr, ldb)
+	)
+	ulc, err := newULC(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm.ulc = ulc
+	return pm
+}
+
+// connectULC connects a client to a server using the server's node key. It
+// returns the server side peer and the client side error channel.
+func connectULC(t *testing.T, client *ProtocolManager, server *ulcTestServer) (*peer, <-chan error) {
+	app, net := p2p.MsgPipe()
+	id := discover.PubkeyID(&server.key.PublicKey)
+
+	speer := server.pm.newPeer(lpv2, NetworkId, p2p.NewPeer(discover.ESSNodeID{}, "client", nil), net)
+	cpeer := client.newPeer(lpv2, NetworkId, p2p.NewPeer(id, "server", nil), app)
+
+	serr, cerr := make(chan error, 1), make(chan error, 1)
+	go func() {
+		server.pm.newPeerCh <- speer
+		serr <- server.pm.handle(speer)
+	}()
+	go func() {
+		client.newPeerCh <- cpeer
+		cerr <- client.handle(cpeer)
+	}()
+	select {
+	case <-time.After(100 * time.Millisecond):
+	case err := <-serr:
+		t.Fatalf("server handshake error: %v", err)
+	case err := <-cerr:
+		t.Fatalf("client handshake error: %v", err)
+	}
+	if cpeer.requestAnnounceType != announceTypeSigned || speer.announceType != announceTypeSigned {
+		t.Fatalf("signed announcements not negotiated with trusted server")
+	}
+	return speer, cerr
+}
+
+func waitHead(client *ProtocolManager, number uint64) error {
+	for i := 0; i < 50; i++ {
+		if client.blockchain.CurrentHeader().Number.Uint64() == number {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("client head is %d, want %d", client.blockchain.CurrentHeader().Number, number)
+}
+
+// This test checks that an ultra light client only accepts a head once enough
+// trusted servers have announced it, and doesn't download its ancestors.
+func TestULCTrustedHead(t *testing.T) {
+	servers := []*ulcTestServer{newULCTestServer(t), newULCTestServer(t)}
+	client := newULCTestClient(t, 100, servers...)
+
+	speers := make([]*peer, len(servers))
+	speers[0], _ = connectULC(t, client, servers[0])
+	if err := waitHead(client, 0); err != nil {
+		t.Fatalf("head accepted from a single trusted server: %v", err)
+	}
+	speers[1], _ = connectULC(t, client, servers[1])
+	if err := waitHead(client, 4); err != nil {
+		t.Fatal(err)
+	}
+	head := servers[0].pm.blockchain.CurrentHeader()
+	if hash := client.blockchain.CurrentHeader().Hash(); hash != head.Hash() {
+		t.Fatalf("wrong head hash %x, want %x", hash, head.Hash())
+	}
+	if td := client.blockchain.GetTd(head.Hash(), 4); td == nil || td.Cmp(servers[0].pm.blockchain.GetTd(head.Hash(), 4)) != 0 {
+		t.Fatalf("wrong head td %v", td)
+	}
+	if client.blockchain.GetHeader(head.ParentHash, 3) != nil {
+		t.Fatal("ancestor of trusted head downloaded")
+	}
+
+	// Extend the server chains and announce the new head with signatures
+	announce := func(s *ulcTestServer, p *peer) {
+		chain := s.pm.blockchain.(*core.BlockChain)
+		blocks, _ := core.GenerateChain(params.TestChainConfig, chain.CurrentBlock(), esshash.NewFaker(), s.pm.chainDb, 1, nil)
+		if _, err := chain.InsertChain(blocks); err != nil {
+			t.Fatal(err)
+		}
+		head := blocks[0].Header()
+		a := announceData{Hash: head.Hash(), Number: head.Number.Uint64(), Td: chain.GetTd(head.Hash(), head.Number.Uint64())}
+		a.sign(s.key)
+		if err := p.SendAnnounce(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	announce(servers[0], speers[0])
+	if err := waitHead(client, 4); err != nil {
+		t.Fatalf("announcement accepted from a single trusted server: %v", err)
+	}
+	announce(servers[1], speers[1])
+	if err := waitHead(client, 5); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// This test checks that an ultra light client disconnects trusted servers which
+// send announcements without a valid signature.
+func TestULCUnsignedAnnouncement(t *testing.T) {
+	server := newULCTestServer(t)
+	client := newULCTestClient(t, 100, server)
+
+	speer, cerr := connectULC(t, client, server)
+	announce := announceData{Hash: common.Hash{1}, Number: 5, Td: big.NewInt(1000)}
+	if err := speer.SendAnnounce(announce); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-cerr:
+		if err == nil {
+			t.Fatal("no error for unsigned announcement")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("trusted server not disconnected after unsigned announcement")
+	}
+}
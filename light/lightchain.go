@@ -99,7 +99,7 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 		return nil, core.ErrNoGenesis
 	}
 	if cp, ok := trustedCheckpoints[bc.genesisBlock.Hash()]; ok {
-		bc.addTrustedCheckpoint(cp)
+		bc.AddTrustedCheckpoint(cp)
 	}
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
@@ -115,20 +115,20 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 	return bc, nil
 }
 
-// addTrustedCheckpoint adds a trusted checkpoint to the blockchain
-func (self *LightChain) addTrustedCheckpoint(cp trustedCheckpoint) {
+// AddTrustedCheckpoint adds a trusted checkpoint to the blockchain
+func (self *LightChain) AddTrustedCheckpoint(cp TrustedCheckpoint) {
 	if self.odr.ChtIndexer() != nil {
-		StoreChtRoot(self.chainDb, cp.sectionIdx, cp.sectionHead, cp.chtRoot)
-		self.odr.ChtIndexer().AddKnownSectionHead(cp.sectionIdx, cp.sectionHead)
+		StoreChtRoot(self.chainDb, cp.SectionIdx, cp.SectionHead, cp.ChtRoot)
+		self.odr.ChtIndexer().AddKnownSectionHead(cp.SectionIdx, cp.SectionHead)
 	}
 	if self.odr.BloomTrieIndexer() != nil {
-		StoreBloomTrieRoot(self.chainDb, cp.sectionIdx, cp.sectionHead, cp.bloomTrieRoot)
-		self.odr.BloomTrieIndexer().AddKnownSectionHead(cp.sectionIdx, cp.sectionHead)
+		StoreBloomTrieRoot(self.chainDb, cp.SectionIdx, cp.SectionHead, cp.BloomTrieRoot)
+		self.odr.BloomTrieIndexer().AddKnownSectionHead(cp.SectionIdx, cp.SectionHead)
 	}
 	if self.odr.BloomIndexer() != nil {
-		self.odr.BloomIndexer().AddKnownSectionHead(cp.sectionIdx, cp.sectionHead)
+		self.odr.BloomIndexer().AddKnownSectionHead(cp.SectionIdx, cp.SectionHead)
 	}
-	log.Info("Added trusted checkpoint", "chain", cp.name, "block", (cp.sectionIdx+1)*CHTFrequencyClient-1, "hash", cp.sectionHead)
+	log.Info("Added trusted checkpoint", "chain", cp.Name, "block", (cp.SectionIdx+1)*CHTFrequencyClient-1, "hash", cp.SectionHead)
 }
 
 func (self *LightChain) getProcInterrupt() bool {
@@ -391,6 +391,55 @@ func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 	return i, err
 }
 
+// InsertTrustedHeader writes a header together with its total difficulty without
+// validating it or requiring its ancestors to be known. It becomes the new head
+// if its total difficulty is higher than the current head's. This is used by
+// ultra light clients, which follow the heads announced by trusted servers
+// instead of downloading the header chain.
+func (self *LightChain) InsertTrustedHeader(header *types.Header, td *big.Int) error {
+	self.chainmu.Lock()
+	defer self.chainmu.Unlock()
+
+	self.wg.Add(1)
+	defer self.wg.Done()
+
+	self.mu.Lock()
+	var (
+		hash    = header.Hash()
+		number  = header.Number.Uint64()
+		current = self.hc.CurrentHeader()
+		localTd = self.hc.GetTd(current.Hash(), current.Number.Uint64())
+	)
+	if err := self.hc.WriteTd(hash, number, td); err != nil {
+		self.mu.Unlock()
+		return err
+	}
+	rawdb.WriteHeader(self.chainDb, header)
+
+	var events []interface{}
+	if localTd == nil || td.Cmp(localTd) > 0 {
+		// The ancestors are unknown, only remove the stale canonical
+		// assignments above the new head.
+		batch := self.chainDb.NewBatch()
+		for i := number + 1; i <= current.Number.Uint64(); i++ {
+			rawdb.DeleteCanonicalHash(batch, i)
+		}
+		batch.Write()
+		rawdb.WriteCanonicalHash(self.chainDb, hash, number)
+		self.hc.SetCurrentHeader(types.CopyHeader(header))
+
+		log.Debug("Inserted trusted header", "number", number, "hash", hash)
+		events = append(events, core.ChainEvent{Block: types.NewBlockWithHeader(header), Hash: hash})
+	} else {
+		log.Debug("Inserted forked trusted header", "number", number, "hash", hash)
+		events = append(events, core.ChainSideEvent{Block: types.NewBlockWithHeader(header)})
+	}
+	self.mu.Unlock()
+
+	self.postChainEvents(events)
+	return nil
+}
+
 // CurrentHeader retrieves the current head header of the canonical chain. The
 // header is retrieved from the HeaderChain's internal cache.
 func (self *LightChain) CurrentHeader() *types.Header {
@@ -29,6 +29,10 @@ import (
 	"github.com/orangeAndSuns/essentia/essdb"
 )
 
+// MaxLogsRequestRange is the maximum number of blocks searched by a single
+// logs request.
+const MaxLogsRequestRange = 1024
+
 // NoOdr is the default context passed to an ODR capable function when the ODR
 // service is not required.
 var NoOdr = context.Background()
@@ -90,6 +94,28 @@ func (req *TrieRequest) StoreResult(db essdb.Database) {
 	req.Proof.Store(db)
 }
 
+// MaxMultiTrieKeys is the maximum number of keys proven by a MultiTrieRequest.
+const MaxMultiTrieKeys = 64
+
+// TrieKey identifies an entry of a state or account storage trie by its hashed key
+type TrieKey struct {
+	Id  *TrieID
+	Key []byte
+}
+
+// MultiTrieRequest is the ODR request type for the proofs of multiple state
+// and storage trie entries of the same block, retrieved in a single round trip.
+type MultiTrieRequest struct {
+	OdrRequest
+	Keys  []TrieKey
+	Proof *NodeSet
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *MultiTrieRequest) StoreResult(db essdb.Database) {
+	req.Proof.Store(db)
+}
+
 // CodeRequest is the ODR request type for retrieving contract code
 type CodeRequest struct {
 	OdrRequest
@@ -170,3 +196,89 @@ func (req *BloomRequest) StoreResult(db essdb.Database) {
 		rawdb.WriteBloomBits(db, req.BitIdx, sectionIdx, sectionHead, req.BloomBits[i])
 	}
 }
+
+// LogFilter selects logs by their address and topics. An empty address list
+// matches any address, an empty topic list at a position matches any topic.
+type LogFilter struct {
+	Addresses []common.Address
+	Topics    [][]common.Hash
+}
+
+// Matches reports whether the log matches the filter.
+func (f *LogFilter) Matches(log *types.Log) bool {
+	if len(f.Addresses) > 0 && !containsAddress(f.Addresses, log.Address) {
+		return false
+	}
+	if len(f.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, topics := range f.Topics {
+		if len(topics) > 0 && !containsHash(topics, log.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesBloom reports whether a block with the given bloom may contain logs
+// matching the filter.
+func (f *LogFilter) MatchesBloom(bloom types.Bloom) bool {
+	if len(f.Addresses) > 0 {
+		var included bool
+		for _, addr := range f.Addresses {
+			if types.BloomLookup(bloom, addr) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, topics := range f.Topics {
+		included := len(topics) == 0
+		for _, topic := range topics {
+			if types.BloomLookup(bloom, topic) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// LogsRequest is the ODR request type for retrieving the logs matching a filter
+// in a range of canonical blocks. Only the receipts containing matching logs
+// are retrieved, together with Merkle proofs against the block headers.
+type LogsRequest struct {
+	OdrRequest
+	FromBlock, ToBlock uint64
+	ToHash             common.Hash // hash of the last block of the range
+	Filter             LogFilter
+	LastBlock          uint64 // last block searched by the server
+	Logs               []*types.Log
+}
+
+// StoreResult does nothing, the logs are not cached locally.
+func (req *LogsRequest) StoreResult(db essdb.Database) {}
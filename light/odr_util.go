@@ -173,6 +173,31 @@ func GetBlockLogs(ctx context.Context, odr OdrBackend, hash common.Hash, number
 	return logs, nil
 }
 
+// GetFilteredLogs retrieves the logs matching the filter in the canonical blocks
+// from..to. Instead of downloading all receipts of the blocks, only the receipts
+// containing matching logs are retrieved with Merkle proofs. The canonical
+// hashes of the range must be available locally.
+func GetFilteredLogs(ctx context.Context, odr OdrBackend, from, to uint64, filter LogFilter) ([]*types.Log, error) {
+	var logs []*types.Log
+	for from <= to {
+		end := to
+		if end-from >= MaxLogsRequestRange {
+			end = from + MaxLogsRequestRange - 1
+		}
+		hash := rawdb.ReadCanonicalHash(odr.Database(), end)
+		if hash == (common.Hash{}) {
+			return logs, ErrNoHeader
+		}
+		r := &LogsRequest{FromBlock: from, ToBlock: end, ToHash: hash, Filter: filter}
+		if err := odr.Retrieve(ctx, r); err != nil {
+			return logs, err
+		}
+		logs = append(logs, r.Logs...)
+		from = r.LastBlock + 1
+	}
+	return logs, nil
+}
+
 // GetBloomBits retrieves a batch of compressed bloomBits vectors belonging to the given bit index and section indexes
 func GetBloomBits(ctx context.Context, odr OdrBackend, bitIdx uint, sectionIdxList []uint64) ([][]byte, error) {
 	db := odr.Database()
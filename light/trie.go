@@ -35,13 +35,70 @@ func NewState(ctx context.Context, head *types.Header, odr OdrBackend) *state.St
 }
 
 func NewStateDatabase(ctx context.Context, head *types.Header, odr OdrBackend) state.Database {
-	return &odrDatabase{ctx, StateTrieID(head), odr}
+	return &odrDatabase{ctx: ctx, id: StateTrieID(head), backend: odr}
+}
+
+// maxPrefetchRounds is the maximum number of speculative executions done by
+// PrefetchState. Every round may reveal keys depending on the previous ones.
+const maxPrefetchRounds = 4
+
+// PrefetchState runs fn on a speculative state that collects the keys of the
+// missing trie entries instead of retrieving them one by one, then fetches the
+// proofs of all collected keys in batched requests. It is repeated as long as
+// the execution accesses new keys, so that a subsequent execution on a regular
+// light state finds most of the data it needs in the local database. The errors
+// returned by fn are ignored as they may be caused by the missing entries.
+func PrefetchState(ctx context.Context, head *types.Header, odr OdrBackend, fn func(*state.StateDB) error) error {
+	for i := 0; i < maxPrefetchRounds; i++ {
+		db := &odrDatabase{ctx: ctx, id: StateTrieID(head), backend: odr, recorder: new(keyRecorder)}
+		statedb, err := state.New(head.Root, db)
+		if err != nil {
+			return err
+		}
+		fn(statedb)
+
+		keys := db.recorder.keys
+		if len(keys) == 0 {
+			return nil
+		}
+		for len(keys) > 0 {
+			n := len(keys)
+			if n > MaxMultiTrieKeys {
+				n = MaxMultiTrieKeys
+			}
+			if err := odr.Retrieve(ctx, &MultiTrieRequest{Keys: keys[:n]}); err != nil {
+				return err
+			}
+			keys = keys[n:]
+		}
+	}
+	return nil
 }
 
 type odrDatabase struct {
-	ctx     context.Context
-	id      *TrieID
-	backend OdrBackend
+	ctx      context.Context
+	id       *TrieID
+	backend  OdrBackend
+	recorder *keyRecorder // collects missing keys instead of retrieving them if set
+}
+
+// keyRecorder collects the distinct trie keys accessed by a speculative execution.
+type keyRecorder struct {
+	keys []TrieKey
+	seen map[string]struct{}
+}
+
+// record adds the key of the given trie unless it has been recorded already.
+func (r *keyRecorder) record(id *TrieID, key []byte) {
+	if r.seen == nil {
+		r.seen = make(map[string]struct{})
+	}
+	k := string(id.Root[:]) + string(id.AccKey) + string(key)
+	if _, ok := r.seen[k]; ok {
+		return
+	}
+	r.seen[k] = struct{}{}
+	r.keys = append(r.keys, TrieKey{Id: id, Key: common.CopyBytes(key)})
 }
 
 func (db *odrDatabase) OpenTrie(root common.Hash) (state.Trie, error) {
@@ -159,6 +216,10 @@ func (t *odrTrie) do(key []byte, fn func() error) error {
 		if _, ok := err.(*trie.MissingNodeError); !ok {
 			return err
 		}
+		if t.db.recorder != nil {
+			t.db.recorder.record(t.id, key)
+			return err
+		}
 		r := &TrieRequest{Id: t.id, Key: key}
 		if err := t.db.backend.Retrieve(t.db.ctx, r); err != nil {
 			return err
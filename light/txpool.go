@@ -53,9 +53,12 @@ type TxPool struct {
 	signer       types.Signer
 	quit         chan bool
 	txFeed       event.Feed
+	statusFeed   event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan core.ChainHeadEvent
 	chainHeadSub event.Subscription
+	droppedCh    chan DroppedTxsEvent
+	droppedSub   event.Subscription
 	mu           sync.RWMutex
 	chain        *LightChain
 	odr          OdrBackend
@@ -85,6 +88,36 @@ type TxRelayBackend interface {
 	Discard(hashes []common.Hash)
 }
 
+// TxDropNotifier is an optional interface of tx relay backends which track the
+// status of the sent transactions on the network and detect the ones that have
+// been rejected or forgotten by all servers.
+type TxDropNotifier interface {
+	SubscribeDroppedTxsEvent(ch chan<- DroppedTxsEvent) event.Subscription
+}
+
+// DroppedTxsEvent is posted by a TxDropNotifier when transactions are dropped.
+type DroppedTxsEvent struct {
+	Hashes []common.Hash
+}
+
+// TxStatus is the status change of a local transaction reported by TxStatusEvent.
+type TxStatus int
+
+const (
+	TxIncluded TxStatus = iota // included in a block of the canonical chain
+	TxReorged                  // including block rolled back, pending again
+	TxDropped                  // dropped by the network and removed from the pool
+)
+
+// TxStatusEvent is posted when a pending local transaction is included in a
+// block, when its block is rolled back or when it is dropped.
+type TxStatusEvent struct {
+	Hash        common.Hash
+	Status      TxStatus
+	BlockHash   common.Hash // including block, only set for TxIncluded
+	BlockNumber uint64      // including block number, only set for TxIncluded
+}
+
 // NewTxPool creates a new light transaction pool
 func NewTxPool(config *params.ChainConfig, chain *LightChain, relay TxRelayBackend) *TxPool {
 	pool := &TxPool{
@@ -104,6 +137,10 @@ func NewTxPool(config *params.ChainConfig, chain *LightChain, relay TxRelayBacke
 	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
+	if notifier, ok := relay.(TxDropNotifier); ok {
+		pool.droppedCh = make(chan DroppedTxsEvent, chainHeadChanSize)
+		pool.droppedSub = notifier.SubscribeDroppedTxsEvent(pool.droppedCh)
+	}
 	go pool.eventLoop()
 
 	return pool
@@ -282,16 +319,20 @@ func (pool *TxPool) reorgOnNewHead(ctx context.Context, newHeader *types.Header)
 const blockCheckTimeout = time.Second * 3
 
 // eventLoop processes chain head events and also notifies the tx relay backend
-// about the new head hash and tx state changes
+// about the new head hash and tx state changes. The resulting status events are
+// posted outside of the pool lock, in the order of the changes.
 func (pool *TxPool) eventLoop() {
 	for {
 		select {
 		case ev := <-pool.chainHeadCh:
-			pool.setNewHead(ev.Block.Header())
+			pool.postStatus(pool.setNewHead(ev.Block.Header()))
 			// hack in order to avoid hogging the lock; this part will
 			// be replaced by a subsequent PR.
 			time.Sleep(time.Millisecond)
 
+		case ev := <-pool.droppedCh:
+			pool.postStatus(pool.dropTxs(ev.Hashes))
+
 		// System stopped
 		case <-pool.chainHeadSub.Err():
 			return
@@ -299,7 +340,7 @@ func (pool *TxPool) eventLoop() {
 	}
 }
 
-func (pool *TxPool) setNewHead(head *types.Header) {
+func (pool *TxPool) setNewHead(head *types.Header) []TxStatusEvent {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
@@ -311,6 +352,50 @@ func (pool *TxPool) setNewHead(head *types.Header) {
 	pool.relay.NewHead(pool.head, m, r)
 	pool.homestead = pool.config.IsHomestead(head.Number)
 	pool.signer = types.MakeSigner(pool.config, head.Number)
+
+	events := make([]TxStatusEvent, 0, len(m)+len(r))
+	for _, hash := range m {
+		blockHash, blockNumber, _ := rawdb.ReadTxLookupEntry(pool.chainDb, hash)
+		events = append(events, TxStatusEvent{Hash: hash, Status: TxIncluded, BlockHash: blockHash, BlockNumber: blockNumber})
+	}
+	for _, hash := range r {
+		events = append(events, TxStatusEvent{Hash: hash, Status: TxReorged})
+	}
+	return events
+}
+
+// dropTxs removes the pending transactions dropped by the network.
+func (pool *TxPool) dropTxs(hashes []common.Hash) []TxStatusEvent {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var (
+		events  []TxStatusEvent
+		removed []common.Hash
+	)
+	batch := pool.chainDb.NewBatch()
+	for _, hash := range hashes {
+		if _, ok := pool.pending[hash]; !ok {
+			continue // mined or removed in the meantime
+		}
+		delete(pool.pending, hash)
+		batch.Delete(hash.Bytes())
+		removed = append(removed, hash)
+		events = append(events, TxStatusEvent{Hash: hash, Status: TxDropped})
+	}
+	batch.Write()
+	if len(removed) > 0 {
+		log.Debug("Dropped transactions", "count", len(removed))
+		pool.relay.Discard(removed)
+	}
+	return events
+}
+
+// postStatus sends the given status events to the subscribers.
+func (pool *TxPool) postStatus(events []TxStatusEvent) {
+	for _, ev := range events {
+		pool.statusFeed.Send(ev)
+	}
 }
 
 // Stop stops the light transaction pool
@@ -319,6 +404,9 @@ func (pool *TxPool) Stop() {
 	pool.scope.Close()
 	// Unsubscribe subscriptions registered from blockchain
 	pool.chainHeadSub.Unsubscribe()
+	if pool.droppedSub != nil {
+		pool.droppedSub.Unsubscribe()
+	}
 	close(pool.quit)
 	log.Info("Transaction pool stopped")
 }
@@ -329,6 +417,12 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subsc
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeTxStatusEvent registers a subscription of TxStatusEvent, notifying
+// about the inclusion, rollback and dropping of the pending local transactions.
+func (pool *TxPool) SubscribeTxStatusEvent(ch chan<- TxStatusEvent) event.Subscription {
+	return pool.scope.Track(pool.statusFeed.Subscribe(ch))
+}
+
 // Stats returns the number of currently pending (locally created) transactions
 func (pool *TxPool) Stats() (pending int) {
 	pool.mu.RLock()
@@ -29,6 +29,7 @@ import (
 	"github.com/orangeAndSuns/essentia/core/types"
 	"github.com/orangeAndSuns/essentia/core/vm"
 	"github.com/orangeAndSuns/essentia/essdb"
+	"github.com/orangeAndSuns/essentia/event"
 	"github.com/orangeAndSuns/essentia/params"
 )
 
@@ -141,3 +142,86 @@ func TestTxPool(t *testing.T) {
 		}
 	}
 }
+
+// testDropRelay is a tx relay backend notifying about dropped transactions.
+type testDropRelay struct {
+	feed    event.Feed
+	discard chan []common.Hash
+}
+
+func (self *testDropRelay) Send(txs types.Transactions) {}
+
+func (self *testDropRelay) NewHead(head common.Hash, mined []common.Hash, rollback []common.Hash) {}
+
+func (self *testDropRelay) Discard(hashes []common.Hash) {
+	self.discard <- hashes
+}
+
+func (self *testDropRelay) SubscribeDroppedTxsEvent(ch chan<- DroppedTxsEvent) event.Subscription {
+	return self.feed.Subscribe(ch)
+}
+
+func TestTxPoolStatusEvents(t *testing.T) {
+	var txs types.Transactions
+	for i := 0; i < 3; i++ {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), acc1Addr, big.NewInt(10000), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+		txs = append(txs, tx)
+	}
+	var (
+		sdb     = essdb.NewMemDatabase()
+		ldb     = essdb.NewMemDatabase()
+		gspec   = core.Genesis{Alloc: core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}}
+		genesis = gspec.MustCommit(sdb)
+	)
+	gspec.MustCommit(ldb)
+	// Assemble a chain including the first transaction
+	blockchain, _ := core.NewBlockChain(sdb, nil, params.TestChainConfig, esshash.NewFullFaker(), vm.Config{})
+	gchain, _ := core.GenerateChain(params.TestChainConfig, genesis, esshash.NewFaker(), sdb, 1, func(i int, block *core.BlockGen) {
+		block.AddTx(txs[0])
+	})
+	if _, err := blockchain.InsertChain(gchain); err != nil {
+		panic(err)
+	}
+
+	relay := &testDropRelay{discard: make(chan []common.Hash, 1)}
+	lightchain, _ := NewLightChain(&testOdr{sdb: sdb, ldb: ldb}, params.TestChainConfig, esshash.NewFullFaker())
+	pool := NewTxPool(params.TestChainConfig, lightchain, relay)
+	defer pool.Stop()
+
+	events := make(chan TxStatusEvent, 10)
+	sub := pool.SubscribeTxStatusEvent(events)
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	for _, tx := range txs {
+		if err := pool.Add(ctx, tx); err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	expect := func(want TxStatusEvent) {
+		select {
+		case ev := <-events:
+			if ev != want {
+				t.Fatalf("status event mismatch: have %+v, want %+v", ev, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("status event %+v timeout", want)
+		}
+	}
+	// Including the first transaction
+	if _, err := lightchain.InsertHeaderChain([]*types.Header{gchain[0].Header()}, 1); err != nil {
+		panic(err)
+	}
+	expect(TxStatusEvent{Hash: txs[0].Hash(), Status: TxIncluded, BlockHash: gchain[0].Hash(), BlockNumber: 1})
+
+	// Dropping the second transaction
+	relay.feed.Send(DroppedTxsEvent{Hashes: []common.Hash{txs[1].Hash()}})
+	expect(TxStatusEvent{Hash: txs[1].Hash(), Status: TxDropped})
+	if hashes := <-relay.discard; len(hashes) != 1 || hashes[0] != txs[1].Hash() {
+		t.Fatalf("discarded transactions mismatch: have %x, want %x", hashes, txs[1].Hash())
+	}
+	if pending := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transaction count mismatch: have %d, want 1", pending)
+	}
+}
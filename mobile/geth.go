@@ -33,6 +33,7 @@ import (
 	"github.com/orangeAndSuns/essentia/les"
 	"github.com/orangeAndSuns/essentia/node"
 	"github.com/orangeAndSuns/essentia/p2p"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
 	"github.com/orangeAndSuns/essentia/p2p/nat"
 	"github.com/orangeAndSuns/essentia/params"
 	whisper "github.com/orangeAndSuns/essentia/whisper/whisperv6"
@@ -71,6 +72,15 @@ type NodeConfig struct {
 	// It has the form "nodename:secret@host:port"
 	EssentiaNetStats string
 
+	// UltraLightServers are the trusted LES servers of the ultra light client
+	// mode. If set, the node doesn't download the header chain but follows the
+	// heads announced by these servers.
+	UltraLightServers *Enodes
+
+	// UltraLightFraction is the percentage of ultra light servers which must
+	// announce a head before it is accepted.
+	UltraLightFraction int
+
 	// WhisperEnabled specifies whether the node should run the Whisper protocol.
 	WhisperEnabled bool
 
@@ -116,6 +126,18 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 		debug.StartPProf(config.PprofAddress)
 	}
 
+	// Ultra light servers are always dialed and exempt from the peer limit
+	var ulcServers []*discover.Node
+	if config.UltraLightServers != nil {
+		for _, n := range config.UltraLightServers.nodes {
+			server, err := discover.ParseNode(n.String())
+			if err != nil {
+				return nil, fmt.Errorf("invalid ultra light server: %v", err)
+			}
+			ulcServers = append(ulcServers, server)
+		}
+	}
+
 	// Create the empty networking stack
 	nodeConf := &node.Config{
 		Name:        clientIdentifier,
@@ -129,6 +151,8 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 			ListenAddr:       ":0",
 			NAT:              nat.Any(),
 			MaxPeers:         config.MaxPeers,
+			StaticNodes:      ulcServers,
+			TrustedNodes:     ulcServers,
 		},
 	}
 	rawStack, err := node.New(nodeConf)
@@ -160,6 +184,12 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 		ethConf.SyncMode = downloader.LightSync
 		ethConf.NetworkId = uint64(config.EssentiaNetworkID)
 		ethConf.DatabaseCache = config.EssentiaDatabaseCache
+		if len(ulcServers) > 0 {
+			ethConf.ULC = &ess.ULCConfig{MinTrustedFraction: config.UltraLightFraction}
+			for _, server := range ulcServers {
+				ethConf.ULC.TrustedServers = append(ethConf.ULC.TrustedServers, server.String())
+			}
+		}
 		if err := rawStack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 			return les.New(ctx, &ethConf)
 		}); err != nil {
@@ -21,6 +21,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/orangeAndSuns/essentia/p2p"
 	"github.com/orangeAndSuns/essentia/p2p/nat"
@@ -42,9 +43,10 @@ var DefaultConfig = Config{
 	WSPort:           DefaultWSPort,
 	WSModules:        []string{"net", "web3"},
 	P2P: p2p.Config{
-		ListenAddr: ":30303",
-		MaxPeers:   25,
-		NAT:        nat.Any(),
+		ListenAddr:          ":30303",
+		MaxPeers:            25,
+		NAT:                 nat.Any(),
+		InboundThrottleTime: 30 * time.Second,
 	},
 }
 
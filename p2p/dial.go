@@ -18,14 +18,15 @@ package p2p
 
 import (
 	"container/heap"
-	"crypto/rand"
 	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"time"
 
 	"github.com/orangeAndSuns/essentia/log"
 	"github.com/orangeAndSuns/essentia/p2p/discover"
+	"github.com/orangeAndSuns/essentia/p2p/enr"
 	"github.com/orangeAndSuns/essentia/p2p/netutil"
 )
 
@@ -34,10 +35,15 @@ const (
 	// redialing a certain node.
 	dialHistoryExpiration = 30 * time.Second
 
-	// Discovery lookups are throttled and can only run
+	// Reads from the discovery iterator are throttled and can only run
 	// once every few seconds.
 	lookupInterval = 4 * time.Second
 
+	// This is the number of dial candidates read from the discovery
+	// iterator by a single discoverTask, roughly the result size of
+	// one lookup.
+	discoverBatchSize = 16
+
 	// If no peers are found for this amount of time, the initial bootnodes are
 	// attempted to be connected.
 	fallbackInterval = 20 * time.Second
@@ -71,15 +77,20 @@ func (t TCPDialer) Dial(dest *discover.Node) (net.Conn, error) {
 type dialstate struct {
 	maxDynDials int
 	ntab        discoverTable
+	discmix     discover.Iterator // source of dynamic dial candidates
 	netrestrict *netutil.Netlist
 
 	lookupRunning bool
 	dialing       map[discover.ESSNodeID]connFlag
 	lookupBuf     []*discover.Node // current discovery lookup results
-	randomNodes   []*discover.Node // filled from Table
 	static        map[discover.ESSNodeID]*dialTask
 	hist          *dialHistory
 
+	// scorer ranks dynamic dial candidates if set. Candidates scoring
+	// below minScore are not dialed.
+	scorer   PeerScorer
+	minScore float64
+
 	start     time.Time        // time when the dialer was first used
 	bootnodes []*discover.Node // default dials when there are no peers
 }
@@ -88,8 +99,7 @@ type discoverTable interface {
 	Self() *discover.Node
 	Close()
 	Resolve(target discover.ESSNodeID) *discover.Node
-	Lookup(target discover.ESSNodeID) []*discover.Node
-	ReadRandomNodes([]*discover.Node) int
+	RequestENR(*discover.Node) (*enr.Record, error)
 }
 
 // the dial history remembers recent dials.
@@ -114,9 +124,8 @@ type dialTask struct {
 	resolveDelay time.Duration
 }
 
-// discoverTask runs discovery table operations.
+// discoverTask reads dial candidates from the discovery iterator.
 // Only one discoverTask is active at any time.
-// discoverTask.Do performs a random lookup.
 type discoverTask struct {
 	results []*discover.Node
 }
@@ -127,15 +136,15 @@ type waitExpireTask struct {
 	time.Duration
 }
 
-func newDialState(static []*discover.Node, bootnodes []*discover.Node, ntab discoverTable, maxdyn int, netrestrict *netutil.Netlist) *dialstate {
+func newDialState(static []*discover.Node, bootnodes []*discover.Node, ntab discoverTable, discmix discover.Iterator, maxdyn int, netrestrict *netutil.Netlist) *dialstate {
 	s := &dialstate{
 		maxDynDials: maxdyn,
 		ntab:        ntab,
+		discmix:     discmix,
 		netrestrict: netrestrict,
 		static:      make(map[discover.ESSNodeID]*dialTask),
 		dialing:     make(map[discover.ESSNodeID]connFlag),
 		bootnodes:   make([]*discover.Node, len(bootnodes)),
-		randomNodes: make([]*discover.Node, maxdyn/2),
 		hist:        new(dialHistory),
 	}
 	copy(s.bootnodes, bootnodes)
@@ -215,28 +224,21 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.ESSNodeID]*Peer, n
 			needDynDials--
 		}
 	}
-	// Use random nodes from the table for half of the necessary
-	// dynamic dials.
-	randomCandidates := needDynDials / 2
-	if randomCandidates > 0 {
-		n := s.ntab.ReadRandomNodes(s.randomNodes)
-		for i := 0; i < randomCandidates && i < n; i++ {
-			if addDial(dynDialedConn, s.randomNodes[i]) {
-				needDynDials--
-			}
-		}
-	}
-	// Create dynamic dials from random lookup results, removing tried
+	// Create dynamic dials from discovery results, removing tried
 	// items from the result buffer.
 	i := 0
 	for ; i < len(s.lookupBuf) && needDynDials > 0; i++ {
+		if s.scorer != nil && s.scorer.Score(s.lookupBuf[i].ID) < s.minScore {
+			log.Trace("Skipping low score dial candidate", "id", s.lookupBuf[i].ID)
+			continue
+		}
 		if addDial(dynDialedConn, s.lookupBuf[i]) {
 			needDynDials--
 		}
 	}
 	s.lookupBuf = s.lookupBuf[:copy(s.lookupBuf, s.lookupBuf[i:])]
-	// Launch a discovery lookup if more candidates are needed.
-	if len(s.lookupBuf) < needDynDials && !s.lookupRunning {
+	// Read more candidates from discovery if needed.
+	if len(s.lookupBuf) < needDynDials && !s.lookupRunning && s.discmix != nil {
 		s.lookupRunning = true
 		newtasks = append(newtasks, &discoverTask{})
 	}
@@ -285,9 +287,25 @@ func (s *dialstate) taskDone(t task, now time.Time) {
 	case *discoverTask:
 		s.lookupRunning = false
 		s.lookupBuf = append(s.lookupBuf, t.results...)
+		s.sortLookupBuf()
 	}
 }
 
+// sortLookupBuf orders the dial candidates by descending score, so that
+// nodes which behaved well before are dialed first.
+func (s *dialstate) sortLookupBuf() {
+	if s.scorer == nil {
+		return
+	}
+	scores := make(map[discover.ESSNodeID]float64, len(s.lookupBuf))
+	for _, n := range s.lookupBuf {
+		scores[n.ID] = s.scorer.Score(n.ID)
+	}
+	sort.SliceStable(s.lookupBuf, func(i, j int) bool {
+		return scores[s.lookupBuf[i].ID] > scores[s.lookupBuf[j].ID]
+	})
+}
+
 func (t *dialTask) Do(srv *Server) {
 	if t.dest.Incomplete() {
 		if !t.resolve(srv) {
@@ -340,6 +358,48 @@ func (t *dialTask) resolve(srv *Server) bool {
 	return true
 }
 
+// checkRecord fetches the node record of a discovered dial candidate and runs
+// it through the dial filters of the server's protocols. Nodes whose record
+// can't be retrieved are dialed anyway, the protocol handshake will sort them out.
+func (srv *Server) checkRecord(n *discover.Node) bool {
+	record, err := srv.ntab.RequestENR(n)
+	if err != nil {
+		log.Trace("Node record retrieval failed", "id", n.ID, "err", err)
+		return true
+	}
+	if !acceptRecord(srv.Protocols, record) {
+		log.Trace("Skipping dial, node record rejected", "id", n.ID)
+		return false
+	}
+	return true
+}
+
+// hasDialFilter reports whether any of the given protocols filters dials.
+func hasDialFilter(protocols []Protocol) bool {
+	for _, p := range protocols {
+		if p.DialFilter != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptRecord reports whether a node with the given record should be dialed:
+// either none of the protocols filter dials or at least one of them accepts it.
+func acceptRecord(protocols []Protocol, r *enr.Record) bool {
+	filtered := false
+	for _, p := range protocols {
+		if p.DialFilter == nil {
+			continue
+		}
+		if p.DialFilter(r) {
+			return true
+		}
+		filtered = true
+	}
+	return !filtered
+}
+
 type dialError struct {
 	error
 }
@@ -359,17 +419,15 @@ func (t *dialTask) String() string {
 }
 
 func (t *discoverTask) Do(srv *Server) {
-	// newTasks generates a lookup task whenever dynamic dials are
-	// necessary. Lookups need to take some time, otherwise the
-	// event loop spins too fast.
+	// newTasks generates a discover task whenever dynamic dials are
+	// necessary. Reads need to take some time, otherwise the event
+	// loop spins too fast on sources that return nodes immediately.
 	next := srv.lastLookup.Add(lookupInterval)
 	if now := time.Now(); now.Before(next) {
 		time.Sleep(next.Sub(now))
 	}
 	srv.lastLookup = time.Now()
-	var target discover.ESSNodeID
-	rand.Read(target[:])
-	t.results = srv.ntab.Lookup(target)
+	t.results = discover.ReadNodes(srv.discmix, discoverBatchSize)
 }
 
 func (t *discoverTask) String() string {
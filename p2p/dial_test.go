@@ -18,6 +18,7 @@ package p2p
 
 import (
 	"encoding/binary"
+	"errors"
 	"net"
 	"reflect"
 	"testing"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/orangeAndSuns/essentia/p2p/discover"
+	"github.com/orangeAndSuns/essentia/p2p/enr"
 	"github.com/orangeAndSuns/essentia/p2p/netutil"
 )
 
@@ -78,16 +80,17 @@ func runDialTest(t *testing.T, test dialtest) {
 
 type fakeTable []*discover.Node
 
-func (t fakeTable) Self() *discover.Node                       { return new(discover.Node) }
-func (t fakeTable) Close()                                     {}
-func (t fakeTable) Lookup(discover.ESSNodeID) []*discover.Node { return nil }
-func (t fakeTable) Resolve(discover.ESSNodeID) *discover.Node  { return nil }
-func (t fakeTable) ReadRandomNodes(buf []*discover.Node) int   { return copy(buf, t) }
+func (t fakeTable) Self() *discover.Node                      { return new(discover.Node) }
+func (t fakeTable) Close()                                    {}
+func (t fakeTable) Resolve(discover.ESSNodeID) *discover.Node { return nil }
+func (t fakeTable) RequestENR(*discover.Node) (*enr.Record, error) {
+	return nil, errors.New("no record")
+}
 
 // This test checks that dynamic dials are launched from discovery results.
 func TestDialStateDynDial(t *testing.T) {
 	runDialTest(t, dialtest{
-		init: newDialState(nil, nil, fakeTable{}, 5, nil),
+		init: newDialState(nil, nil, fakeTable{}, discover.IterNodes(nil), 5, nil),
 		rounds: []round{
 			// A discovery query is launched.
 			{
@@ -219,6 +222,67 @@ func TestDialStateDynDial(t *testing.T) {
 	})
 }
 
+// This test checks that dynamic dial candidates are dialed in order of their
+// score and that candidates with a low score are not dialed.
+func TestDialStateDynDialScore(t *testing.T) {
+	scorer := NewPeerScorer(0)
+	scorer.Report(uintID(3), "test", -200)
+	scorer.Report(uintID(4), "test", 5)
+	scorer.Report(uintID(5), "test", 10)
+
+	state := newDialState(nil, nil, fakeTable{}, discover.IterNodes(nil), 2, nil)
+	state.scorer, state.minScore = scorer, -100
+	runDialTest(t, dialtest{
+		init: state,
+		rounds: []round{
+			// A discovery query is launched.
+			{
+				new: []task{&discoverTask{}},
+			},
+			// The best scoring candidates are dialed when it completes.
+			{
+				done: []task{
+					&discoverTask{results: []*discover.Node{
+						{ID: uintID(3)}, // this one has a low score and is not dialed.
+						{ID: uintID(6)},
+						{ID: uintID(4)},
+						{ID: uintID(5)},
+					}},
+				},
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(5)}},
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(4)}},
+				},
+			},
+			// Unscored candidates are dialed once a dial slot is free.
+			{
+				peers: []*Peer{
+					{rw: &conn{flags: dynDialedConn, id: uintID(5)}},
+				},
+				done: []task{
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(5)}},
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(4)}},
+				},
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(6)}},
+				},
+			},
+			// The low score candidate is dropped and discovery is queried again.
+			{
+				peers: []*Peer{
+					{rw: &conn{flags: dynDialedConn, id: uintID(5)}},
+				},
+				done: []task{
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(6)}},
+				},
+				new: []task{
+					&discoverTask{},
+				},
+			},
+		},
+	})
+}
+
 // Tests that bootnodes are dialed if no peers are connectd, but not otherwise.
 func TestDialStateDynDialBootnode(t *testing.T) {
 	bootnodes := []*discover.Node{
@@ -226,18 +290,23 @@ func TestDialStateDynDialBootnode(t *testing.T) {
 		{ID: uintID(2)},
 		{ID: uintID(3)},
 	}
-	table := fakeTable{
-		{ID: uintID(4)},
-		{ID: uintID(5)},
-		{ID: uintID(6)},
-		{ID: uintID(7)},
-		{ID: uintID(8)},
-	}
 	runDialTest(t, dialtest{
-		init: newDialState(nil, bootnodes, table, 5, nil),
+		init: newDialState(nil, bootnodes, fakeTable{}, discover.IterNodes(nil), 5, nil),
 		rounds: []round{
-			// 2 dynamic dials attempted, bootnodes pending fallback interval
+			// Discovery is queried, bootnodes pending fallback interval
 			{
+				new: []task{
+					&discoverTask{},
+				},
+			},
+			// 2 dynamic dials attempted from discovery results, bootnodes still pending
+			{
+				done: []task{
+					&discoverTask{results: []*discover.Node{
+						{ID: uintID(4)},
+						{ID: uintID(5)},
+					}},
+				},
 				new: []task{
 					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(4)}},
 					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(5)}},
@@ -251,25 +320,21 @@ func TestDialStateDynDialBootnode(t *testing.T) {
 					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(5)}},
 				},
 			},
-			// No dials succeed, bootnodes still pending fallback interval
-			{},
-			// No dials succeed, 2 dynamic dials attempted and 1 bootnode too as fallback interval was reached
+			// No dials succeed, 1st bootnode is attempted as fallback interval was reached
 			{
 				new: []task{
 					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(1)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(4)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(5)}},
 				},
 			},
 			// No dials succeed, 2nd bootnode is attempted
 			{
 				done: []task{
 					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(1)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(4)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(5)}},
+					&discoverTask{},
 				},
 				new: []task{
 					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(2)}},
+					&discoverTask{},
 				},
 			},
 			// No dials succeed, 3rd bootnode is attempted
@@ -281,15 +346,20 @@ func TestDialStateDynDialBootnode(t *testing.T) {
 					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
 				},
 			},
-			// No dials succeed, 1st bootnode is attempted again, expired random nodes retried
+			// No dials succeed, 1st bootnode is attempted again, expired discovered nodes retried
 			{
 				done: []task{
 					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
+					&discoverTask{results: []*discover.Node{
+						{ID: uintID(4)},
+						{ID: uintID(5)},
+					}},
 				},
 				new: []task{
 					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(1)}},
 					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(4)}},
 					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(5)}},
+					&discoverTask{},
 				},
 			},
 			// Random dial succeeds, no more bootnodes are attempted
@@ -307,106 +377,9 @@ func TestDialStateDynDialBootnode(t *testing.T) {
 	})
 }
 
-func TestDialStateDynDialFromTable(t *testing.T) {
-	// This table always returns the same random nodes
-	// in the order given below.
-	table := fakeTable{
-		{ID: uintID(1)},
-		{ID: uintID(2)},
-		{ID: uintID(3)},
-		{ID: uintID(4)},
-		{ID: uintID(5)},
-		{ID: uintID(6)},
-		{ID: uintID(7)},
-		{ID: uintID(8)},
-	}
-
-	runDialTest(t, dialtest{
-		init: newDialState(nil, nil, table, 10, nil),
-		rounds: []round{
-			// 5 out of 8 of the nodes returned by ReadRandomNodes are dialed.
-			{
-				new: []task{
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(1)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(2)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(4)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(5)}},
-					&discoverTask{},
-				},
-			},
-			// Dialing nodes 1,2 succeeds. Dials from the lookup are launched.
-			{
-				peers: []*Peer{
-					{rw: &conn{flags: dynDialedConn, id: uintID(1)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(2)}},
-				},
-				done: []task{
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(1)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(2)}},
-					&discoverTask{results: []*discover.Node{
-						{ID: uintID(10)},
-						{ID: uintID(11)},
-						{ID: uintID(12)},
-					}},
-				},
-				new: []task{
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(10)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(11)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(12)}},
-					&discoverTask{},
-				},
-			},
-			// Dialing nodes 3,4,5 fails. The dials from the lookup succeed.
-			{
-				peers: []*Peer{
-					{rw: &conn{flags: dynDialedConn, id: uintID(1)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(2)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(10)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(11)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(12)}},
-				},
-				done: []task{
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(4)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(5)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(10)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(11)}},
-					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(12)}},
-				},
-			},
-			// Waiting for expiry. No waitExpireTask is launched because the
-			// discovery query is still running.
-			{
-				peers: []*Peer{
-					{rw: &conn{flags: dynDialedConn, id: uintID(1)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(2)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(10)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(11)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(12)}},
-				},
-			},
-			// Nodes 3,4 are not tried again because only the first two
-			// returned random nodes (nodes 1,2) are tried and they're
-			// already connected.
-			{
-				peers: []*Peer{
-					{rw: &conn{flags: dynDialedConn, id: uintID(1)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(2)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(10)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(11)}},
-					{rw: &conn{flags: dynDialedConn, id: uintID(12)}},
-				},
-			},
-		},
-	})
-}
-
 // This test checks that candidates that do not match the netrestrict list are not dialed.
 func TestDialStateNetRestrict(t *testing.T) {
-	// This table always returns the same random nodes
-	// in the order given below.
-	table := fakeTable{
+	nodes := []*discover.Node{
 		{ID: uintID(1), IP: net.ParseIP("127.0.0.1")},
 		{ID: uintID(2), IP: net.ParseIP("127.0.0.2")},
 		{ID: uintID(3), IP: net.ParseIP("127.0.0.3")},
@@ -420,11 +393,22 @@ func TestDialStateNetRestrict(t *testing.T) {
 	restrict.Add("127.0.2.0/24")
 
 	runDialTest(t, dialtest{
-		init: newDialState(nil, nil, table, 10, restrict),
+		init: newDialState(nil, nil, fakeTable{}, discover.IterNodes(nil), 10, restrict),
 		rounds: []round{
 			{
 				new: []task{
-					&dialTask{flags: dynDialedConn, dest: table[4]},
+					&discoverTask{},
+				},
+			},
+			{
+				done: []task{
+					&discoverTask{results: nodes},
+				},
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: nodes[4]},
+					&dialTask{flags: dynDialedConn, dest: nodes[5]},
+					&dialTask{flags: dynDialedConn, dest: nodes[6]},
+					&dialTask{flags: dynDialedConn, dest: nodes[7]},
 					&discoverTask{},
 				},
 			},
@@ -443,7 +427,7 @@ func TestDialStateStaticDial(t *testing.T) {
 	}
 
 	runDialTest(t, dialtest{
-		init: newDialState(wantStatic, nil, fakeTable{}, 0, nil),
+		init: newDialState(wantStatic, nil, fakeTable{}, nil, 0, nil),
 		rounds: []round{
 			// Static dials are launched for the nodes that
 			// aren't yet connected.
@@ -547,7 +531,7 @@ func TestDialStaticAfterReset(t *testing.T) {
 		},
 	}
 	dTest := dialtest{
-		init:   newDialState(wantStatic, nil, fakeTable{}, 0, nil),
+		init:   newDialState(wantStatic, nil, fakeTable{}, nil, 0, nil),
 		rounds: rounds,
 	}
 	runDialTest(t, dTest)
@@ -568,7 +552,7 @@ func TestDialStateCache(t *testing.T) {
 	}
 
 	runDialTest(t, dialtest{
-		init: newDialState(wantStatic, nil, fakeTable{}, 0, nil),
+		init: newDialState(wantStatic, nil, fakeTable{}, nil, 0, nil),
 		rounds: []round{
 			// Static dials are launched for the nodes that
 			// aren't yet connected.
@@ -630,7 +614,7 @@ func TestDialStateCache(t *testing.T) {
 func TestDialResolve(t *testing.T) {
 	resolved := discover.NewNode(uintID(1), net.IP{127, 0, 55, 234}, 3333, 4444)
 	table := &resolveMock{answer: resolved}
-	state := newDialState(nil, nil, table, 0, nil)
+	state := newDialState(nil, nil, table, nil, 0, nil)
 
 	// Check that the task is generated with an incomplete ID.
 	dest := discover.NewNode(uintID(1), nil, 0, 0)
@@ -655,6 +639,58 @@ func TestDialResolve(t *testing.T) {
 	}
 }
 
+// implements discoverTable for TestDialRecordFilter
+type recordMock struct {
+	fakeTable
+	record *enr.Record
+	err    error
+}
+
+func (t *recordMock) RequestENR(*discover.Node) (*enr.Record, error) {
+	return t.record, t.err
+}
+
+// This test checks that discovered dial candidates are skipped if their node
+// record is rejected by all protocol dial filters.
+func TestDialRecordFilter(t *testing.T) {
+	var (
+		good, bad enr.Record
+		key       = newkey()
+		node      = discover.NewNode(uintID(1), net.IP{127, 0, 0, 1}, 30303, 30303)
+	)
+	good.Set(enr.WithEntry("test", uint(1)))
+	bad.Set(enr.WithEntry("test", uint(2)))
+	enr.SignV4(&good, key)
+	enr.SignV4(&bad, key)
+
+	filter := func(r *enr.Record) bool {
+		var v uint
+		return r.Load(enr.WithEntry("test", &v)) == nil && v == 1
+	}
+	tests := []struct {
+		protocols []Protocol
+		record    *enr.Record
+		err       error
+		wantDial  bool
+	}{
+		{protocols: []Protocol{{Name: "a"}}, record: &bad, wantDial: true},
+		{protocols: []Protocol{{Name: "a", DialFilter: filter}}, record: &good, wantDial: true},
+		{protocols: []Protocol{{Name: "a", DialFilter: filter}}, record: &bad, wantDial: false},
+		{protocols: []Protocol{{Name: "a", DialFilter: filter}}, err: errors.New("timeout"), wantDial: true},
+		{protocols: []Protocol{{Name: "a", DialFilter: filter}, {Name: "b"}}, record: &bad, wantDial: false},
+	}
+	for i, tt := range tests {
+		srv := &Server{
+			Config: Config{Protocols: tt.protocols},
+			ntab:   &recordMock{record: tt.record, err: tt.err},
+		}
+		it := discover.Filter(discover.IterNodes([]*discover.Node{node}), srv.checkRecord)
+		if dialed := it.Next(); dialed != tt.wantDial {
+			t.Errorf("test %d: dialed %t, want %t", i, dialed, tt.wantDial)
+		}
+	}
+}
+
 // compares task lists but doesn't care about the order.
 func sametasks(a, b []task) bool {
 	if len(a) != len(b) {
@@ -689,8 +725,9 @@ func (t *resolveMock) Resolve(id discover.ESSNodeID) *discover.Node {
 	return t.answer
 }
 
-func (t *resolveMock) Self() *discover.Node                       { return new(discover.Node) }
-func (t *resolveMock) Close()                                     {}
-func (t *resolveMock) Bootstrap([]*discover.Node)                 {}
-func (t *resolveMock) Lookup(discover.ESSNodeID) []*discover.Node { return nil }
-func (t *resolveMock) ReadRandomNodes(buf []*discover.Node) int   { return 0 }
+func (t *resolveMock) Self() *discover.Node       { return new(discover.Node) }
+func (t *resolveMock) Close()                     {}
+func (t *resolveMock) Bootstrap([]*discover.Node) {}
+func (t *resolveMock) RequestENR(*discover.Node) (*enr.Record, error) {
+	return nil, errors.New("no record")
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/orangeAndSuns/essentia/common"
 	"github.com/orangeAndSuns/essentia/crypto"
 	"github.com/orangeAndSuns/essentia/log"
+	"github.com/orangeAndSuns/essentia/p2p/enr"
 	"github.com/orangeAndSuns/essentia/p2p/netutil"
 )
 
@@ -60,6 +61,7 @@ const (
 	seedMinTableTime    = 5 * time.Minute
 	seedCount           = 30
 	seedMaxAge          = 5 * 24 * time.Hour
+	minLookupInterval   = time.Second
 )
 
 type Table struct {
@@ -87,6 +89,7 @@ type Table struct {
 type transport interface {
 	ping(ESSNodeID, *net.UDPAddr) error
 	findnode(toid ESSNodeID, addr *net.UDPAddr, target ESSNodeID) ([]*Node, error)
+	requestENR(toid ESSNodeID, addr *net.UDPAddr) (*enr.Record, error)
 	close()
 }
 
@@ -251,6 +254,12 @@ func (tab *Table) Resolve(targetID ESSNodeID) *Node {
 	return nil
 }
 
+// RequestENR retrieves the node record of the given node. The returned record
+// is verified to be signed by the node's key.
+func (tab *Table) RequestENR(n *Node) (*enr.Record, error) {
+	return tab.net.requestENR(n.ID, n.addr())
+}
+
 // Lookup performs a network search for nodes close
 // to the given target. It approaches the target by querying
 // nodes that are closer to it on each iteration.
@@ -260,6 +269,55 @@ func (tab *Table) Lookup(targetID ESSNodeID) []*Node {
 	return tab.lookup(targetID, true)
 }
 
+// RandomNodes returns an iterator that finds random nodes in the network by
+// performing lookups of random targets. Lookups start at most once every
+// minLookupInterval, so an empty table doesn't cause the iterator to spin.
+func (tab *Table) RandomNodes() Iterator {
+	return &lookupIterator{tab: tab, closed: make(chan struct{})}
+}
+
+// lookupIterator performs lookups of random targets and returns their results.
+type lookupIterator struct {
+	tab        *Table
+	buffer     []*Node
+	cur        *Node
+	lastLookup time.Time
+	closeOnce  sync.Once
+	closed     chan struct{}
+}
+
+func (it *lookupIterator) Next() bool {
+	for len(it.buffer) == 0 {
+		if wait := minLookupInterval - time.Since(it.lastLookup); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-it.closed:
+				return false
+			}
+		}
+		it.lastLookup = time.Now()
+		var target ESSNodeID
+		crand.Read(target[:])
+		results := make(chan []*Node, 1)
+		go func() { results <- it.tab.Lookup(target) }()
+		select {
+		case it.buffer = <-results:
+		case <-it.closed:
+			return false
+		}
+	}
+	it.cur, it.buffer = it.buffer[0], it.buffer[1:]
+	return true
+}
+
+func (it *lookupIterator) Node() *Node {
+	return it.cur
+}
+
+func (it *lookupIterator) Close() {
+	it.closeOnce.Do(func() { close(it.closed) })
+}
+
 func (tab *Table) lookup(targetID ESSNodeID, refreshIfEmpty bool) []*Node {
 	var (
 		target         = crypto.Keccak256Hash(targetID[:])
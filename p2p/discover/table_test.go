@@ -30,6 +30,7 @@ import (
 
 	"github.com/orangeAndSuns/essentia/common"
 	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/p2p/enr"
 )
 
 func TestTable_pingReplace(t *testing.T) {
@@ -216,6 +217,10 @@ func (t *pingRecorder) ping(toid ESSNodeID, toaddr *net.UDPAddr) error {
 
 func (t *pingRecorder) close() {}
 
+func (t *pingRecorder) requestENR(toid ESSNodeID, toaddr *net.UDPAddr) (*enr.Record, error) {
+	return nil, errTimeout
+}
+
 func TestTable_closest(t *testing.T) {
 	t.Parallel()
 
@@ -570,7 +575,11 @@ func (tn *preminedTestnet) findnode(toid ESSNodeID, toaddr *net.UDPAddr, target
 	return result, nil
 }
 
-func (*preminedTestnet) close()                                         {}
+func (*preminedTestnet) close() {}
+
+func (*preminedTestnet) requestENR(toid ESSNodeID, toaddr *net.UDPAddr) (*enr.Record, error) {
+	return nil, errTimeout
+}
 func (*preminedTestnet) waitping(from ESSNodeID) error                  { return nil }
 func (*preminedTestnet) ping(toid ESSNodeID, toaddr *net.UDPAddr) error { return nil }
 
@@ -27,6 +27,7 @@ import (
 
 	"github.com/orangeAndSuns/essentia/crypto"
 	"github.com/orangeAndSuns/essentia/log"
+	"github.com/orangeAndSuns/essentia/p2p/enr"
 	"github.com/orangeAndSuns/essentia/p2p/nat"
 	"github.com/orangeAndSuns/essentia/p2p/netutil"
 	"github.com/orangeAndSuns/essentia/rlp"
@@ -42,6 +43,8 @@ var (
 	errTimeout          = errors.New("RPC timeout")
 	errClockWarp        = errors.New("reply deadline too far in the future")
 	errClosed           = errors.New("socket closed")
+	errNoRecord         = errors.New("no local node record")
+	errRecordMismatch   = errors.New("node record signed by wrong key")
 )
 
 // Timeouts
@@ -60,6 +63,8 @@ const (
 	pongPacket
 	findnodePacket
 	neighborsPacket
+	enrRequestPacket
+	enrResponsePacket
 )
 
 // RPC request structures
@@ -101,6 +106,21 @@ type (
 		Rest []rlp.RawValue `rlp:"tail"`
 	}
 
+	// enrRequest queries for the remote node's record.
+	enrRequest struct {
+		Expiration uint64
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
+	// enrResponse is the reply to enrRequest.
+	enrResponse struct {
+		ReplyTok []byte // Hash of the enrRequest packet.
+		Record   enr.Record
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
 	rpcNode struct {
 		IP  net.IP // len 4 for IPv4 or 16 for IPv6
 		UDP uint16 // for discovery protocol
@@ -167,6 +187,8 @@ type udp struct {
 	closing chan struct{}
 	nat     nat.Interface
 
+	localRecord func() *enr.Record // retrieves the local node record, may be nil
+
 	*Table
 }
 
@@ -224,6 +246,8 @@ type Config struct {
 	NetRestrict  *netutil.Netlist  // network whitelist
 	Bootnodes    []*Node           // list of bootstrap nodes
 	Unhandled    chan<- ReadPacket // unhandled packets are sent on this channel
+
+	LocalRecord func() *enr.Record // retrieves the local node record served to ENR requests
 }
 
 // ListenUDP returns a new table that listens for UDP packets on laddr.
@@ -241,6 +265,7 @@ func newUDP(c conn, cfg Config) (*Table, *udp, error) {
 		conn:        c,
 		priv:        cfg.PrivateKey,
 		netrestrict: cfg.NetRestrict,
+		localRecord: cfg.LocalRecord,
 		closing:     make(chan struct{}),
 		gotreply:    make(chan reply),
 		addpending:  make(chan *pending),
@@ -335,6 +360,40 @@ func (t *udp) findnode(toid ESSNodeID, toaddr *net.UDPAddr, target ESSNodeID) ([
 	return nodes, <-errc
 }
 
+// requestENR sends an enrRequest to the given node and waits for the response,
+// verifying that the returned record belongs to the queried node.
+func (t *udp) requestENR(toid ESSNodeID, toaddr *net.UDPAddr) (*enr.Record, error) {
+	req := &enrRequest{
+		Expiration: uint64(time.Now().Add(expiration).Unix()),
+	}
+	packet, hash, err := encodePacket(t.priv, enrRequestPacket, req)
+	if err != nil {
+		return nil, err
+	}
+	var record *enr.Record
+	errc := t.pending(toid, enrResponsePacket, func(r interface{}) bool {
+		resp := r.(*enrResponse)
+		if !bytes.Equal(resp.ReplyTok, hash) {
+			return false
+		}
+		record = &resp.Record
+		return true
+	})
+	t.write(toaddr, req.name(), packet)
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	// Ensure the record was signed by the node we queried
+	var pubkey enr.Secp256k1
+	if err := record.Load(&pubkey); err != nil {
+		return nil, err
+	}
+	if PubkeyID((*ecdsa.PublicKey)(&pubkey)) != toid {
+		return nil, errRecordMismatch
+	}
+	return record, nil
+}
+
 // pending adds a reply callback to the pending reply queue.
 // see the documentation of type pending for a detailed explanation.
 func (t *udp) pending(id ESSNodeID, ptype byte, callback func(interface{}) bool) <-chan error {
@@ -586,6 +645,10 @@ func decodePacket(buf []byte) (packet, ESSNodeID, []byte, error) {
 		req = new(findnode)
 	case neighborsPacket:
 		req = new(neighbors)
+	case enrRequestPacket:
+		req = new(enrRequest)
+	case enrResponsePacket:
+		req = new(enrResponse)
 	default:
 		return nil, fromID, hash, fmt.Errorf("unknown type: %d", ptype)
 	}
@@ -684,6 +747,40 @@ func (req *neighbors) handle(t *udp, from *net.UDPAddr, fromID ESSNodeID, mac []
 
 func (req *neighbors) name() string { return "NEIGHBORS/v4" }
 
+func (req *enrRequest) handle(t *udp, from *net.UDPAddr, fromID ESSNodeID, mac []byte) error {
+	if expired(req.Expiration) {
+		return errExpired
+	}
+	if !t.db.hasBond(fromID) {
+		// No endpoint proof pong exists, we don't process the packet to avoid
+		// being used for traffic amplification, same as with findnode.
+		return errUnknownNode
+	}
+	if t.localRecord == nil {
+		return errNoRecord
+	}
+	record := t.localRecord()
+	if record == nil {
+		return errNoRecord
+	}
+	t.send(from, enrResponsePacket, &enrResponse{
+		ReplyTok: mac,
+		Record:   *record,
+	})
+	return nil
+}
+
+func (req *enrRequest) name() string { return "ENRREQUEST/v4" }
+
+func (req *enrResponse) handle(t *udp, from *net.UDPAddr, fromID ESSNodeID, mac []byte) error {
+	if !t.handleReply(fromID, enrResponsePacket, req) {
+		return errUnsolicitedReply
+	}
+	return nil
+}
+
+func (req *enrResponse) name() string { return "ENRRESPONSE/v4" }
+
 func expired(ts uint64) bool {
 	return time.Unix(int64(ts), 0).Before(time.Now())
 }
@@ -36,6 +36,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/orangeAndSuns/essentia/common"
 	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/p2p/enr"
 	"github.com/orangeAndSuns/essentia/rlp"
 )
 
@@ -128,6 +129,7 @@ func TestUDP_packetErrors(t *testing.T) {
 	test.packetIn(errUnsolicitedReply, pongPacket, &pong{ReplyTok: []byte{}, Expiration: futureExp})
 	test.packetIn(errUnknownNode, findnodePacket, &findnode{Expiration: futureExp})
 	test.packetIn(errUnsolicitedReply, neighborsPacket, &neighbors{Expiration: futureExp})
+	test.packetIn(errUnknownNode, enrRequestPacket, &enrRequest{Expiration: futureExp})
 }
 
 func TestUDP_pingTimeout(t *testing.T) {
@@ -323,6 +325,75 @@ func TestUDP_findnodeMultiReply(t *testing.T) {
 	}
 }
 
+func TestUDP_enrRequest(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	var record enr.Record
+	record.Set(enr.IP{127, 0, 0, 1})
+	if err := enr.SignV4(&record, test.localkey); err != nil {
+		t.Fatal(err)
+	}
+	test.udp.localRecord = func() *enr.Record { return &record }
+
+	// requests from nodes without a bond are rejected.
+	test.packetIn(errUnknownNode, enrRequestPacket, &enrRequest{Expiration: futureExp})
+	test.packetIn(errExpired, enrRequestPacket, &enrRequest{Expiration: 1})
+
+	// check that the local record is returned once bonded.
+	test.table.db.updateLastPongReceived(PubkeyID(&test.remotekey.PublicKey), time.Now())
+	test.packetIn(nil, enrRequestPacket, &enrRequest{Expiration: futureExp})
+	test.waitPacketOut(func(p *enrResponse) {
+		reqhash := test.sent[len(test.sent)-1][:macSize]
+		if !bytes.Equal(p.ReplyTok, reqhash) {
+			t.Errorf("got enrResponse.ReplyTok %x, want %x", p.ReplyTok, reqhash)
+		}
+		if p.Record.Seq() != record.Seq() {
+			t.Errorf("wrong record seq: got %d, want %d", p.Record.Seq(), record.Seq())
+		}
+		var ip enr.IP
+		if err := p.Record.Load(&ip); err != nil || !net.IP(ip).Equal(net.IP{127, 0, 0, 1}) {
+			t.Errorf("wrong record IP: got %v (err %v)", net.IP(ip), err)
+		}
+	})
+}
+
+func TestUDP_requestENR(t *testing.T) {
+	run := func(remoteSigned bool, wantErr error) {
+		test := newUDPTest(t)
+		defer test.table.Close()
+
+		signer := newkey()
+		if remoteSigned {
+			signer = test.remotekey
+		}
+		var record enr.Record
+		if err := enr.SignV4(&record, signer); err != nil {
+			t.Fatal(err)
+		}
+		rid := PubkeyID(&test.remotekey.PublicKey)
+		errc := make(chan error, 1)
+		go func() {
+			_, err := test.udp.requestENR(rid, test.remoteaddr)
+			errc <- err
+		}()
+		hash, _ := test.waitPacketOut(func(p *enrRequest) {})
+		test.packetIn(nil, enrResponsePacket, &enrResponse{ReplyTok: hash, Record: record})
+
+		select {
+		case err := <-errc:
+			if err != wantErr {
+				t.Errorf("requestENR error mismatch: got %v, want %v", err, wantErr)
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("requestENR did not return within 5 seconds")
+		}
+	}
+	// records signed by the remote node are accepted, others are rejected.
+	run(true, nil)
+	run(false, errRecordMismatch)
+}
+
 func TestUDP_successfulPing(t *testing.T) {
 	test := newUDPTest(t)
 	added := make(chan *Node, 1)
@@ -0,0 +1,135 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package discv5
+
+import (
+	"sync"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common/mclock"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+)
+
+const (
+	fastSearchPeriod  = 100 * time.Millisecond // lookup period until the search converges
+	slowSearchPeriod  = time.Minute            // lookup period after convergence
+	fastSearchLookups = 50                     // converged lookups before slowing down
+	fastSearchTime    = time.Minute            // time after first convergence before slowing down
+)
+
+// TopicSearch is an iterator over the nodes advertising a topic. The search
+// runs with a short lookup period at first and is slowed down once the topic
+// radius has converged.
+type TopicSearch struct {
+	net       *Network
+	found     chan *Node
+	cur       *discover.Node
+	converged chan struct{}
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// SearchTopicNodes starts a search for the given topic. The search ends when
+// the returned iterator is closed.
+func (net *Network) SearchTopicNodes(topic Topic) *TopicSearch {
+	s := &TopicSearch{
+		net:       net,
+		found:     make(chan *Node, 100),
+		converged: make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	go s.loop(topic)
+	return s
+}
+
+// loop runs the search and adjusts its lookup period.
+func (s *TopicSearch) loop(topic Topic) {
+	var (
+		setPeriod = make(chan time.Duration, 1)
+		lookups   = make(chan bool, 100)
+		done      = make(chan struct{})
+		closed    = s.closed
+		fast      = true
+		lookupCnt int
+		convTime  mclock.AbsTime
+	)
+	go func() {
+		s.net.SearchTopic(topic, setPeriod, s.found, lookups)
+		close(done)
+	}()
+	setPeriod <- fastSearchPeriod
+
+	for {
+		select {
+		case conv := <-lookups:
+			if !conv || !fast {
+				continue
+			}
+			if lookupCnt == 0 {
+				convTime = mclock.Now()
+			}
+			lookupCnt++
+			if lookupCnt == fastSearchLookups || time.Duration(mclock.Now()-convTime) > fastSearchTime {
+				fast = false
+				close(s.converged)
+				if closed != nil {
+					select {
+					case setPeriod <- slowSearchPeriod:
+					case <-done:
+						return
+					}
+				}
+			}
+		case <-closed:
+			// Closing setPeriod ends the search. Lookups are still drained
+			// until SearchTopic returns because the network loop blocks on them.
+			close(setPeriod)
+			closed = nil
+		case <-done:
+			return
+		}
+	}
+}
+
+// Converged returns a channel which is closed when the search has slowed down
+// because the topic radius has converged.
+func (s *TopicSearch) Converged() <-chan struct{} {
+	return s.converged
+}
+
+// Next waits for the next node found by the search.
+func (s *TopicSearch) Next() bool {
+	select {
+	case n := <-s.found:
+		s.cur = discover.NewNode(discover.ESSNodeID(n.ID), n.IP, n.UDP, n.TCP)
+		return true
+	case <-s.closed:
+		return false
+	case <-s.net.closed:
+		return false
+	}
+}
+
+// Node returns the current node.
+func (s *TopicSearch) Node() *discover.Node {
+	return s.cur
+}
+
+// Close ends the search.
+func (s *TopicSearch) Close() {
+	s.closeOnce.Do(func() { close(s.closed) })
+}
@@ -0,0 +1,376 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package dnsdisc implements node discovery via DNS. Node lists are published
+// as a merkle tree of TXT records whose root is signed by the list operator.
+package dnsdisc
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/log"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+)
+
+// Resolver is a DNS resolver that can query TXT records. It is satisfied by
+// *net.Resolver, tests use a fake implementation.
+type Resolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+// Config holds the settings of a DNS discovery client.
+type Config struct {
+	Timeout         time.Duration // timeout used for each DNS lookup (default 5s)
+	RecheckInterval time.Duration // time between tree root update checks (default 30min)
+	CacheLimit      int           // maximum number of cached records (default 1000)
+	Resolver        Resolver      // the DNS resolver to use (defaults to system DNS)
+	Logger          log.Logger    // destination of client log messages (defaults to root logger)
+}
+
+func (cfg Config) withDefaults() Config {
+	const (
+		defaultTimeout = 5 * time.Second
+		defaultRecheck = 30 * time.Minute
+		defaultCache   = 1000
+	)
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.RecheckInterval == 0 {
+		cfg.RecheckInterval = defaultRecheck
+	}
+	if cfg.CacheLimit == 0 {
+		cfg.CacheLimit = defaultCache
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = new(net.Resolver)
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Root()
+	}
+	return cfg
+}
+
+// Client discovers nodes by querying DNS servers. Registered trees are synced
+// in the background and their nodes are served as dial candidates.
+type Client struct {
+	cfg     Config
+	entries *lru.Cache
+
+	lock    sync.RWMutex
+	urls    []string                    // registered tree URLs
+	nodes   map[string][]*discover.Node // nodes of all synced trees, by domain
+	seqs    map[string]uint             // last seen sequence numbers, by domain
+	updated chan struct{}               // closed and replaced when nodes change
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewClient creates a client.
+func NewClient(cfg Config) *Client {
+	cfg = cfg.withDefaults()
+	cache, err := lru.New(cfg.CacheLimit)
+	if err != nil {
+		panic(err)
+	}
+	return &Client{
+		cfg:     cfg,
+		entries: cache,
+		nodes:   make(map[string][]*discover.Node),
+		seqs:    make(map[string]uint),
+		updated: make(chan struct{}),
+	}
+}
+
+// AddTree registers a tree URL for periodic syncing.
+func (c *Client) AddTree(url string) error {
+	if _, err := parseLink(url); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.urls = append(c.urls, url)
+	return nil
+}
+
+// Start launches the background sync of all registered trees.
+func (c *Client) Start() {
+	c.quit = make(chan struct{})
+	c.wg.Add(1)
+	go c.loop()
+}
+
+// Stop terminates the background sync.
+func (c *Client) Stop() {
+	close(c.quit)
+	c.wg.Wait()
+}
+
+// RandomNodes returns an iterator over random nodes of the synced trees. Next
+// blocks until the first tree has been synced.
+func (c *Client) RandomNodes() discover.Iterator {
+	return &randomIterator{c: c, closed: make(chan struct{})}
+}
+
+// randomNode picks a random node of all synced trees. It also returns the
+// channel which is closed on the next update of the node set.
+func (c *Client) randomNode() (*discover.Node, <-chan struct{}) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	total := 0
+	for _, nodes := range c.nodes {
+		total += len(nodes)
+	}
+	if total == 0 {
+		return nil, c.updated
+	}
+	i := rand.Intn(total)
+	for _, nodes := range c.nodes {
+		if i < len(nodes) {
+			return nodes[i], c.updated
+		}
+		i -= len(nodes)
+	}
+	panic("unreachable")
+}
+
+// randomIterator traverses the nodes of a client's synced trees in random order.
+type randomIterator struct {
+	c         *Client
+	cur       *discover.Node
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (it *randomIterator) Next() bool {
+	for {
+		n, updated := it.c.randomNode()
+		if n != nil {
+			select {
+			case <-it.closed:
+				return false
+			default:
+				it.cur = n
+				return true
+			}
+		}
+		select {
+		case <-updated:
+		case <-it.closed:
+			return false
+		}
+	}
+}
+
+func (it *randomIterator) Node() *discover.Node {
+	return it.cur
+}
+
+func (it *randomIterator) Close() {
+	it.closeOnce.Do(func() { close(it.closed) })
+}
+
+// loop syncs all registered trees every RecheckInterval.
+func (c *Client) loop() {
+	defer c.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			c.syncAll()
+			timer.Reset(c.cfg.RecheckInterval)
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// syncAll syncs the registered trees and all trees linked from them.
+func (c *Client) syncAll() {
+	c.lock.RLock()
+	queue := append([]string{}, c.urls...)
+	c.lock.RUnlock()
+
+	seen := make(map[string]bool)
+	for len(queue) > 0 {
+		url := queue[0]
+		queue = queue[1:]
+		if seen[url] {
+			continue
+		}
+		seen[url] = true
+
+		t, err := c.SyncTree(url)
+		if err != nil {
+			c.cfg.Logger.Debug("DNS node list sync failed", "url", url, "err", err)
+			continue
+		}
+		domain, _, _ := ParseURL(url)
+		c.lock.Lock()
+		if c.seqs[domain] != t.Seq() {
+			c.cfg.Logger.Info("Updated DNS node list", "domain", domain, "seq", t.Seq(), "nodes", len(t.Nodes()))
+		}
+		c.seqs[domain] = t.Seq()
+		c.nodes[domain] = t.Nodes()
+		close(c.updated)
+		c.updated = make(chan struct{})
+		c.lock.Unlock()
+
+		queue = append(queue, t.Links()...)
+	}
+}
+
+// SyncTree downloads the entire node tree at the given URL.
+func (c *Client) SyncTree(url string) (*Tree, error) {
+	le, err := parseLink(url)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	root, err := c.resolveRoot(ctx, le)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tree{root: &root, entries: make(map[string]entry)}
+	if err := c.syncSubtree(ctx, le.domain, root.eroot, false, t.entries); err != nil {
+		return nil, err
+	}
+	if err := c.syncSubtree(ctx, le.domain, root.lroot, true, t.entries); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// syncSubtree resolves all entries below the given hash.
+func (c *Client) syncSubtree(ctx context.Context, domain, hash string, links bool, dest map[string]entry) error {
+	queue := []string{hash}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if _, ok := dest[hash]; ok {
+			continue
+		}
+		e, err := c.resolveEntry(ctx, domain, hash)
+		if err != nil {
+			return err
+		}
+		dest[hash] = e
+
+		switch e := e.(type) {
+		case *branchEntry:
+			queue = append(queue, e.children...)
+		case *nodeEntry:
+			if links {
+				return nameError{domain, errENRInLinkTree}
+			}
+		case *linkEntry:
+			if !links {
+				return nameError{domain, errLinkInENRTree}
+			}
+		}
+	}
+	return nil
+}
+
+// resolveRoot retrieves a root entry via DNS and verifies its signature.
+func (c *Client) resolveRoot(ctx context.Context, loc *linkEntry) (rootEntry, error) {
+	txts, err := c.lookupTXT(ctx, loc.domain)
+	c.cfg.Logger.Trace("Updating DNS discovery root", "tree", loc.domain, "err", err)
+	if err != nil {
+		return rootEntry{}, err
+	}
+	for _, txt := range txts {
+		if strings.HasPrefix(txt, rootPrefix) {
+			return parseAndVerifyRoot(txt, loc)
+		}
+	}
+	return rootEntry{}, nameError{loc.domain, errNoRoot}
+}
+
+// lookupTXT queries the TXT records of a name. Every lookup gets its own timeout,
+// so syncing a large tree isn't bounded by a single lookup timeout.
+func (c *Client) lookupTXT(ctx context.Context, name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+	return c.cfg.Resolver.LookupTXT(ctx, name)
+}
+
+func parseAndVerifyRoot(txt string, loc *linkEntry) (rootEntry, error) {
+	e, err := parseRoot(txt)
+	if err != nil {
+		return e, err
+	}
+	if !e.verifySignature(loc.pubkey) {
+		return e, entryError{typ: "root", err: errInvalidSig}
+	}
+	return e, nil
+}
+
+// resolveEntry retrieves an entry from the cache or fetches it from the network
+// if it isn't cached.
+func (c *Client) resolveEntry(ctx context.Context, domain, hash string) (entry, error) {
+	cacheKey := hash + "." + domain
+	if e, ok := c.entries.Get(cacheKey); ok {
+		return e.(entry), nil
+	}
+	e, err := c.doResolveEntry(ctx, domain, hash)
+	if err != nil {
+		return nil, err
+	}
+	c.entries.Add(cacheKey, e)
+	return e, nil
+}
+
+// doResolveEntry fetches an entry via DNS, checking that its hash matches the
+// subdomain it was found at.
+func (c *Client) doResolveEntry(ctx context.Context, domain, hash string) (entry, error) {
+	wantHash, err := b32format.DecodeString(hash)
+	if err != nil {
+		return nil, errInvalidChild
+	}
+	name := hash + "." + domain
+	txts, err := c.lookupTXT(ctx, name)
+	c.cfg.Logger.Trace("DNS discovery lookup", "name", name, "err", err)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		e, err := parseEntry(txt)
+		if err == errUnknownEntry {
+			continue
+		}
+		if !bytes.HasPrefix(crypto.Keccak256([]byte(txt)), wantHash) {
+			err = nameError{name, errHashMismatch}
+		} else if err != nil {
+			err = nameError{name, err}
+		}
+		return e, err
+	}
+	return nil, nameError{name, errNoEntry}
+}
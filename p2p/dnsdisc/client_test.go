@@ -0,0 +1,251 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+)
+
+// mapResolver is a fake DNS resolver serving TXT records from a map.
+type mapResolver map[string]string
+
+func newMapResolver(maps ...map[string]string) mapResolver {
+	mr := make(mapResolver)
+	for _, m := range maps {
+		mr.add(m)
+	}
+	return mr
+}
+
+func (mr mapResolver) add(m map[string]string) {
+	for k, v := range m {
+		mr[k] = v
+	}
+}
+
+func (mr mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if record, ok := mr[name]; ok {
+		return []string{record}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+// slowResolver is a fake DNS resolver answering every lookup after a delay.
+type slowResolver struct {
+	mapResolver
+	delay time.Duration
+}
+
+func (sr slowResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	select {
+	case <-time.After(sr.delay):
+		return sr.mapResolver.LookupTXT(ctx, name)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestClientSyncTree(t *testing.T) {
+	key := testKey()
+	nodes := testNodes(30)
+	tree, err := MakeTree(1, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _ := tree.Sign(key, "n")
+
+	c := NewClient(Config{Resolver: newMapResolver(tree.ToTXT("n"))})
+	stree, err := c.SyncTree(url)
+	if err != nil {
+		t.Fatal("sync error:", err)
+	}
+	if !reflect.DeepEqual(stree.Nodes(), tree.Nodes()) {
+		t.Errorf("wrong nodes in synced tree")
+	}
+	if stree.Seq() != tree.Seq() {
+		t.Errorf("synced tree has wrong seq: %d", stree.Seq())
+	}
+}
+
+// In this test, the tree is signed by a different key than the URL says.
+func TestClientSyncTreeBadSignature(t *testing.T) {
+	tree, err := MakeTree(1, testNodes(3), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Sign(testKey(), "n")
+	url := (&linkEntry{"n", &testKey().PublicKey}).url()
+
+	c := NewClient(Config{Resolver: newMapResolver(tree.ToTXT("n"))})
+	if _, err := c.SyncTree(url); err != (entryError{"root", errInvalidSig}) {
+		t.Fatalf("expected signature error, got %v", err)
+	}
+}
+
+// In this test, the tree contains an entry that doesn't match its subdomain.
+func TestClientSyncTreeBadHash(t *testing.T) {
+	key := testKey()
+	tree, err := MakeTree(1, testNodes(3), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _ := tree.Sign(key, "n")
+
+	records := tree.ToTXT("n")
+	other := testNodes(1)[0]
+	for name, txt := range records {
+		if strings.HasPrefix(txt, "essnode://") {
+			records[name] = other.String()
+			break
+		}
+	}
+	c := NewClient(Config{Resolver: newMapResolver(records)})
+	_, err = c.SyncTree(url)
+	if nerr, ok := err.(nameError); !ok || nerr.err != errHashMismatch {
+		t.Fatalf("expected hash mismatch error, got %v", err)
+	}
+}
+
+// In this test, syncing the tree takes longer than the lookup timeout, but every
+// single lookup is within it.
+func TestClientSyncTreeTimeout(t *testing.T) {
+	key := testKey()
+	tree, err := MakeTree(1, testNodes(30), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, _ := tree.Sign(key, "n")
+
+	resolver := slowResolver{newMapResolver(tree.ToTXT("n")), 10 * time.Millisecond}
+	c := NewClient(Config{Resolver: resolver, Timeout: 100 * time.Millisecond})
+	if _, err := c.SyncTree(url); err != nil {
+		t.Fatal("sync error:", err)
+	}
+
+	// a single lookup exceeding the timeout fails the sync
+	resolver.delay = 200 * time.Millisecond
+	c = NewClient(Config{Resolver: resolver, Timeout: 100 * time.Millisecond})
+	if _, err := c.SyncTree(url); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+}
+
+// This test checks that the client follows links and serves nodes of all trees
+// through its random node iterator.
+func TestClientLinks(t *testing.T) {
+	var (
+		key1, key2     = testKey(), testKey()
+		nodes1, nodes2 = testNodes(5), testNodes(5)
+	)
+	tree2, _ := MakeTree(1, nodes2, nil)
+	url2, _ := tree2.Sign(key2, "b")
+	tree1, err := MakeTree(1, nodes1, []string{url2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url1, _ := tree1.Sign(key1, "a")
+
+	c := NewClient(Config{
+		Resolver:        newMapResolver(tree1.ToTXT("a"), tree2.ToTXT("b")),
+		RecheckInterval: time.Hour,
+	})
+	if err := c.AddTree(url1); err != nil {
+		t.Fatal(err)
+	}
+	c.syncAll()
+
+	want := sortedNodes(append(append([]*discover.Node{}, nodes1...), nodes2...))
+	if have := readAllNodes(c, len(want)); !reflect.DeepEqual(have, want) {
+		t.Errorf("wrong nodes returned")
+	}
+}
+
+// This test checks that updates of a tree are picked up on resync.
+func TestClientUpdate(t *testing.T) {
+	key := testKey()
+	tree1, _ := MakeTree(1, testNodes(3), nil)
+	url, _ := tree1.Sign(key, "n")
+
+	resolver := newMapResolver(tree1.ToTXT("n"))
+	c := NewClient(Config{Resolver: resolver})
+	c.AddTree(url)
+	c.syncAll()
+
+	nodes2 := testNodes(4)
+	tree2, _ := MakeTree(2, nodes2, nil)
+	tree2.Sign(key, "n")
+	resolver.add(tree2.ToTXT("n"))
+	c.syncAll()
+
+	if have := readAllNodes(c, len(nodes2)); !reflect.DeepEqual(have, sortedNodes(nodes2)) {
+		t.Errorf("client didn't pick up tree update")
+	}
+}
+
+// This test checks that the random iterator blocks until a tree is synced and
+// can be interrupted by Close.
+func TestClientIteratorBlocking(t *testing.T) {
+	key := testKey()
+	tree, _ := MakeTree(1, testNodes(2), nil)
+	url, _ := tree.Sign(key, "n")
+	c := NewClient(Config{Resolver: newMapResolver(tree.ToTXT("n"))})
+	c.AddTree(url)
+
+	it := c.RandomNodes()
+	done := make(chan bool)
+	go func() { done <- it.Next() }()
+	select {
+	case <-done:
+		t.Fatal("Next returned before sync")
+	case <-time.After(50 * time.Millisecond):
+	}
+	c.syncAll()
+	if ok := <-done; !ok || it.Node() == nil {
+		t.Fatal("Next didn't return node after sync")
+	}
+
+	it2 := NewClient(Config{}).RandomNodes()
+	go func() { done <- it2.Next() }()
+	it2.Close()
+	if <-done {
+		t.Fatal("Next returned true after Close")
+	}
+}
+
+// readAllNodes reads random nodes from the client until n distinct nodes have
+// been seen. It returns them sorted by ID.
+func readAllNodes(c *Client, n int) []*discover.Node {
+	it := c.RandomNodes()
+	defer it.Close()
+
+	seen := make(map[discover.ESSNodeID]*discover.Node)
+	for i := 0; len(seen) < n && i < n*100 && it.Next(); i++ {
+		seen[it.Node().ID] = it.Node()
+	}
+	var nodes []*discover.Node
+	for _, node := range seen {
+		nodes = append(nodes, node)
+	}
+	return sortedNodes(nodes)
+}
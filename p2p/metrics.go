@@ -19,7 +19,9 @@
 package p2p
 
 import (
+	"fmt"
 	"net"
+	"sync"
 
 	"github.com/orangeAndSuns/essentia/metrics"
 )
@@ -29,8 +31,25 @@ var (
 	ingressTrafficMeter = metrics.NewRegisteredMeter("p2p/InboundTraffic", nil)
 	egressConnectMeter  = metrics.NewRegisteredMeter("p2p/OutboundConnects", nil)
 	egressTrafficMeter  = metrics.NewRegisteredMeter("p2p/OutboundTraffic", nil)
+
+	inboundRejectNetRestrictMeter = metrics.NewRegisteredMeter("p2p/InboundRejects/NetRestrict", nil)
+	inboundRejectThrottleMeter    = metrics.NewRegisteredMeter("p2p/InboundRejects/Throttle", nil)
+	inboundRejectIPMeter          = metrics.NewRegisteredMeter("p2p/InboundRejects/IPLimit", nil)
+	inboundRejectSubnetMeter      = metrics.NewRegisteredMeter("p2p/InboundRejects/SubnetLimit", nil)
 )
 
+// markInboundReject bumps the meter of the given inbound rejection reason.
+func markInboundReject(err error) {
+	switch err {
+	case errInboundThrottled:
+		inboundRejectThrottleMeter.Mark(1)
+	case errTooManyFromIP:
+		inboundRejectIPMeter.Mark(1)
+	case errTooManyFromSubnet:
+		inboundRejectSubnetMeter.Mark(1)
+	}
+}
+
 // meteredConn is a wrapper around a net.Conn that meters both the
 // inbound and outbound network traffic.
 type meteredConn struct {
@@ -69,3 +88,128 @@ func (c *meteredConn) Write(b []byte) (n int, err error) {
 	egressTrafficMeter.Mark(int64(n))
 	return
 }
+
+// MsgTraffic counts the messages of a single message code and their payload bytes.
+type MsgTraffic struct {
+	Count uint64 `json:"count"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// ProtocolTraffic contains the traffic of a protocol running on a peer
+// connection, keyed by protocol relative message code.
+type ProtocolTraffic struct {
+	Ingress map[uint64]MsgTraffic `json:"ingress"`
+	Egress  map[uint64]MsgTraffic `json:"egress"`
+}
+
+// protoTraffic accounts the messages of a single protocol on a peer connection.
+// If metrics are enabled, the traffic of all peers is also aggregated into
+// per-protocol and per-code meters of the default registry.
+type protoTraffic struct {
+	name    string
+	version uint
+
+	lock    sync.Mutex
+	ingress map[uint64]*MsgTraffic
+	egress  map[uint64]*MsgTraffic
+}
+
+func newProtoTraffic(name string, version uint) *protoTraffic {
+	return &protoTraffic{
+		name:    name,
+		version: version,
+		ingress: make(map[uint64]*MsgTraffic),
+		egress:  make(map[uint64]*MsgTraffic),
+	}
+}
+
+// mark records a message with the given protocol relative code and payload size.
+func (t *protoTraffic) mark(ingress bool, code uint64, size uint32) {
+	t.lock.Lock()
+	stats := t.egress
+	if ingress {
+		stats = t.ingress
+	}
+	if stats[code] == nil {
+		stats[code] = new(MsgTraffic)
+	}
+	stats[code].Count++
+	stats[code].Bytes += uint64(size)
+	t.lock.Unlock()
+
+	if metrics.Enabled {
+		meters := getProtoMeters(protoMeterKey{t.name, t.version, ingress, code})
+		meters.packets.Mark(1)
+		meters.bytes.Mark(int64(size))
+	}
+}
+
+// info returns a copy of the accumulated traffic.
+func (t *protoTraffic) info() *ProtocolTraffic {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	info := &ProtocolTraffic{
+		Ingress: make(map[uint64]MsgTraffic, len(t.ingress)),
+		Egress:  make(map[uint64]MsgTraffic, len(t.egress)),
+	}
+	for code, stats := range t.ingress {
+		info.Ingress[code] = *stats
+	}
+	for code, stats := range t.egress {
+		info.Egress[code] = *stats
+	}
+	return info
+}
+
+// protoMeterKey identifies the meters of a message code in one direction.
+type protoMeterKey struct {
+	name    string
+	version uint
+	ingress bool
+	code    uint64
+}
+
+// protoMeters are the packet and byte meters of a message code.
+type protoMeters struct {
+	packets metrics.Meter
+	bytes   metrics.Meter
+}
+
+var (
+	protoMetersLock sync.RWMutex
+	protoMetersMap  = make(map[protoMeterKey]*protoMeters)
+)
+
+// getProtoMeters returns the meters of a message code, registering them in the
+// default registry on first use. The meters are shared by all peers.
+func getProtoMeters(key protoMeterKey) *protoMeters {
+	protoMetersLock.RLock()
+	meters := protoMetersMap[key]
+	protoMetersLock.RUnlock()
+	if meters != nil {
+		return meters
+	}
+
+	protoMetersLock.Lock()
+	defer protoMetersLock.Unlock()
+	if meters = protoMetersMap[key]; meters == nil {
+		prefix := protoMeterPrefix(key.name, key.version, key.ingress, key.code)
+		meters = &protoMeters{
+			packets: metrics.GetOrRegisterMeter(prefix+"/Packets", nil),
+			bytes:   metrics.GetOrRegisterMeter(prefix+"/Bytes", nil),
+		}
+		protoMetersMap[key] = meters
+	}
+	return meters
+}
+
+// protoMeterPrefix returns the name prefix of the meters of a message code,
+// e.g. "p2p/ess/63/InboundTraffic/0x01".
+func protoMeterPrefix(name string, version uint, ingress bool, code uint64) string {
+	dir := "OutboundTraffic"
+	if ingress {
+		dir = "InboundTraffic"
+	}
+	return fmt.Sprintf("p2p/%s/%d/%s/0x%02x", name, version, dir, code)
+}
@@ -0,0 +1,45 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import "testing"
+
+// This test checks the names of the per-code protocol meters and that the
+// meters are registered once and shared.
+func TestProtoMeters(t *testing.T) {
+	for _, test := range []struct {
+		ingress bool
+		code    uint64
+		want    string
+	}{
+		{true, 0x01, "p2p/ess/63/InboundTraffic/0x01"},
+		{false, 0x10, "p2p/ess/63/OutboundTraffic/0x10"},
+		{true, 0x100, "p2p/ess/63/InboundTraffic/0x100"},
+	} {
+		if prefix := protoMeterPrefix("ess", 63, test.ingress, test.code); prefix != test.want {
+			t.Errorf("wrong prefix for code %#x: got %q, want %q", test.code, prefix, test.want)
+		}
+	}
+
+	key := protoMeterKey{"ess", 63, true, 0x01}
+	if getProtoMeters(key) != getProtoMeters(key) {
+		t.Error("meters of the same code are not shared")
+	}
+	if getProtoMeters(key) == getProtoMeters(protoMeterKey{"ess", 63, false, 0x01}) {
+		t.Error("inbound and outbound meters are shared")
+	}
+}
@@ -112,6 +112,10 @@ type Peer struct {
 
 	// events receives message send / receive events if set
 	events *event.Feed
+
+	// scorer tracks the reputation of the node if set
+	scorer   PeerScorer
+	minScore float64
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -158,6 +162,29 @@ func (p *Peer) Disconnect(reason DiscReason) {
 	}
 }
 
+// Report adjusts the score of the peer. Protocol handlers call this with a
+// positive delta for useful behaviour and a negative one for misbehaviour.
+// Peers whose score falls below the server's minimum are disconnected unless
+// they are trusted.
+func (p *Peer) Report(protocol string, delta float64) {
+	if p.scorer == nil {
+		return
+	}
+	score := p.scorer.Report(p.ID(), protocol, delta)
+	if score < p.minScore && !p.rw.is(trustedConn) {
+		p.log.Debug("Disconnecting peer with low score", "protocol", protocol, "score", score)
+		p.Disconnect(DiscUselessPeer)
+	}
+}
+
+// Score returns the current score of the peer.
+func (p *Peer) Score() float64 {
+	if p.scorer == nil {
+		return 0
+	}
+	return p.scorer.Score(p.ID())
+}
+
 // String implements fmt.Stringer.
 func (p *Peer) String() string {
 	return fmt.Sprintf("Peer %x %v", p.rw.id[:8], p.RemoteAddr())
@@ -327,7 +354,7 @@ outer:
 					offset -= old.Length
 				}
 				// Assign the new match
-				result[cap.Name] = &protoRW{Protocol: proto, offset: offset, in: make(chan Msg), w: rw}
+				result[cap.Name] = &protoRW{Protocol: proto, offset: offset, in: make(chan Msg), w: rw, traffic: newProtoTraffic(proto.Name, proto.Version)}
 				offset += proto.Length
 
 				continue outer
@@ -376,22 +403,27 @@ func (p *Peer) getProto(code uint64) (*protoRW, error) {
 
 type protoRW struct {
 	Protocol
-	in     chan Msg        // receives read messages
-	closed <-chan struct{} // receives when peer is shutting down
-	wstart <-chan struct{} // receives when write may start
-	werr   chan<- error    // for write results
-	offset uint64
-	w      MsgWriter
+	in      chan Msg        // receives read messages
+	closed  <-chan struct{} // receives when peer is shutting down
+	wstart  <-chan struct{} // receives when write may start
+	werr    chan<- error    // for write results
+	offset  uint64
+	w       MsgWriter
+	traffic *protoTraffic // per-code message accounting
 }
 
 func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 	if msg.Code >= rw.Length {
 		return newPeerError(errInvalidMsgCode, "not handled")
 	}
+	code := msg.Code
 	msg.Code += rw.offset
 	select {
 	case <-rw.wstart:
-		err = rw.w.WriteMsg(msg)
+		size := msg.Size
+		if err = rw.w.WriteMsg(msg); err == nil {
+			rw.traffic.mark(false, code, size)
+		}
 		// Report write status back to Peer.run. It will initiate
 		// shutdown if the error is non-nil and unblock the next write
 		// otherwise. The calling protocol code should exit for errors
@@ -407,6 +439,7 @@ func (rw *protoRW) ReadMsg() (Msg, error) {
 	select {
 	case msg := <-rw.in:
 		msg.Code -= rw.offset
+		rw.traffic.mark(true, msg.Code, msg.Size)
 		return msg, nil
 	case <-rw.closed:
 		return Msg{}, io.EOF
@@ -427,7 +460,9 @@ type PeerInfo struct {
 		Trusted       bool   `json:"trusted"`
 		Static        bool   `json:"static"`
 	} `json:"network"`
-	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+	Protocols map[string]interface{}      `json:"protocols"` // Sub-protocol specific metadata fields
+	Traffic   map[string]*ProtocolTraffic `json:"traffic"`   // Per-protocol message counts and payload bytes
+	Score     float64                     `json:"score"`     // Reputation of the node reported by the protocols
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -443,6 +478,8 @@ func (p *Peer) Info() *PeerInfo {
 		Name:      p.Name(),
 		Caps:      caps,
 		Protocols: make(map[string]interface{}),
+		Traffic:   make(map[string]*ProtocolTraffic),
+		Score:     p.Score(),
 	}
 	info.Network.LocalAddress = p.LocalAddr().String()
 	info.Network.RemoteAddress = p.RemoteAddr().String()
@@ -461,6 +498,7 @@ func (p *Peer) Info() *PeerInfo {
 			}
 		}
 		info.Protocols[proto.Name] = protoInfo
+		info.Traffic[proto.Name] = proto.traffic.info()
 	}
 	return info
 }
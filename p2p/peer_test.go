@@ -98,6 +98,80 @@ func TestPeerProtoReadMsg(t *testing.T) {
 	}
 }
 
+func TestPeerTraffic(t *testing.T) {
+	proto := Protocol{
+		Name:    "a",
+		Version: 1,
+		Length:  5,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			for i := 0; i < 2; i++ {
+				if err := ExpectMsg(rw, 2, []uint{1}); err != nil {
+					t.Error(err)
+				}
+			}
+			return SendItems(rw, 3, "foo")
+		},
+	}
+	closer, rw, peer, errc := testPeer([]Protocol{proto})
+	defer closer()
+
+	Send(rw, baseProtocolLength+2, []uint{1})
+	Send(rw, baseProtocolLength+2, []uint{1})
+	if err := ExpectMsg(rw, baseProtocolLength+3, []string{"foo"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errc:
+		if err != errProtocolReturned {
+			t.Errorf("peer returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("receive timeout")
+	}
+
+	traffic := peer.Info().Traffic["a"]
+	if traffic == nil {
+		t.Fatal("no traffic info for protocol")
+	}
+	wantIngress := map[uint64]MsgTraffic{2: {Count: 2, Bytes: 4}}
+	wantEgress := map[uint64]MsgTraffic{3: {Count: 1, Bytes: 5}}
+	if !reflect.DeepEqual(traffic.Ingress, wantIngress) {
+		t.Errorf("wrong ingress traffic: got %v, want %v", traffic.Ingress, wantIngress)
+	}
+	if !reflect.DeepEqual(traffic.Egress, wantEgress) {
+		t.Errorf("wrong egress traffic: got %v, want %v", traffic.Egress, wantEgress)
+	}
+}
+
+// This test checks that peers reported below the minimum score are disconnected.
+func TestPeerReport(t *testing.T) {
+	proto := Protocol{
+		Name:   "a",
+		Length: 5,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			peer.scorer, peer.minScore = NewPeerScorer(0), -10
+			peer.Report("a", -5)
+			if score := peer.Score(); !approxScore(score, -5) {
+				t.Errorf("wrong score after report: got %v, want -5", score)
+			}
+			peer.Report("a", -6)
+			<-peer.closed
+			return nil
+		},
+	}
+	closer, _, _, errc := testPeer([]Protocol{proto})
+	defer closer()
+
+	select {
+	case err := <-errc:
+		if err != DiscUselessPeer {
+			t.Errorf("peer returned wrong error: got %v, want %v", err, DiscUselessPeer)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("peer was not disconnected")
+	}
+}
+
 func TestPeerProtoEncodeMsg(t *testing.T) {
 	proto := Protocol{
 		Name:   "a",
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/orangeAndSuns/essentia/p2p/discover"
+	"github.com/orangeAndSuns/essentia/p2p/enr"
 )
 
 // Protocol represents a P2P subprotocol implementation.
@@ -52,6 +53,17 @@ type Protocol struct {
 	// about a certain peer in the network. If an info retrieval function is set,
 	// but returns nil, it is assumed that the protocol handshake is still running.
 	PeerInfo func(id discover.ESSNodeID) interface{}
+
+	// Attributes contains protocol specific information for the local node
+	// record. They are added when the server starts, protocols that need to
+	// change them later should use Server.SetRecordEntry.
+	Attributes []enr.Entry
+
+	// DialFilter is an optional helper method to decide, based on the node
+	// record of a discovered node, whether the node is worth dialing for this
+	// protocol. A dynamic dial is skipped if all protocols with a filter
+	// reject the record.
+	DialFilter func(r *enr.Record) bool
 }
 
 func (p Protocol) cap() Cap {
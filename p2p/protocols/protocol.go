@@ -141,6 +141,10 @@ type Spec struct {
 	// each message must have a single unique data type
 	Messages []interface{}
 
+	// Recorder receives every message sent and received by peers of this
+	// protocol if set
+	Recorder Recorder
+
 	initOnce sync.Once
 	codes    map[reflect.Type]uint64
 	types    map[uint64]reflect.Type
@@ -220,6 +224,7 @@ func (p *Peer) Run(handler func(ctx context.Context, msg interface{}) error) err
 			if err != io.EOF {
 				metrics.GetOrRegisterCounter("peer.handleincoming.error", nil).Inc(1)
 				log.Error("peer.handleIncoming", "err", err)
+				p.Report(p.spec.Name, p2p.MisbehaviourPenalty)
 			}
 
 			return err
@@ -227,10 +232,13 @@ func (p *Peer) Run(handler func(ctx context.Context, msg interface{}) error) err
 	}
 }
 
-// Drop disconnects a peer.
+// Drop disconnects a peer. A non-nil error is reported as misbehaviour.
 // TODO: may need to implement protocol drop only? don't want to kick off the peer
 // if they are useful for other protocols
 func (p *Peer) Drop(err error) {
+	if err != nil {
+		p.Report(p.spec.Name, p2p.MisbehaviourPenalty)
+	}
 	p.Disconnect(p2p.DiscSubprotocolError)
 }
 
@@ -278,7 +286,13 @@ func (p *Peer) Send(ctx context.Context, msg interface{}) error {
 	if !found {
 		return errorf(ErrInvalidMsgType, "%v", code)
 	}
-	return p2p.Send(p.rw, code, wmsg)
+	if err := p2p.Send(p.rw, code, wmsg); err != nil {
+		return err
+	}
+	if p.spec.Recorder != nil {
+		p.spec.Recorder.Record(newTraceEntry(p.spec, p.ID(), false, code, msg, r))
+	}
+	return nil
 }
 
 // handleIncoming(code)
@@ -335,6 +349,9 @@ func (p *Peer) handleIncoming(handle func(ctx context.Context, msg interface{})
 	if err := rlp.DecodeBytes(wmsg.Payload, val); err != nil {
 		return errorf(ErrDecode, "<= %v: %v", msg, err)
 	}
+	if p.spec.Recorder != nil {
+		p.spec.Recorder.Record(newTraceEntry(p.spec, p.ID(), true, msg.Code, val, wmsg.Payload))
+	}
 
 	// call the registered handler callbacks
 	// a registered callback take the decoded message as argument as an interface
@@ -0,0 +1,167 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package protocols
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common/hexutil"
+	"github.com/orangeAndSuns/essentia/log"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+	"github.com/orangeAndSuns/essentia/rlp"
+)
+
+// Recorder receives every message sent and received by the peers of a
+// protocol. Recording is enabled by setting Spec.Recorder.
+//
+// Implementations must be safe for concurrent use.
+type Recorder interface {
+	Record(entry *TraceEntry)
+}
+
+// TraceEntry is a single recorded protocol message.
+type TraceEntry struct {
+	Time     time.Time          `json:"time"`
+	Peer     discover.ESSNodeID `json:"peer"`     // remote end of the connection
+	Protocol string             `json:"protocol"` // name of the protocol spec
+	Version  uint               `json:"version"`  // version of the protocol spec
+	Received bool               `json:"received"` // true for incoming, false for outgoing messages
+	Code     uint64             `json:"code"`
+	Type     string             `json:"type"`    // name of the message type
+	Msg      interface{}        `json:"msg"`     // the decoded message, for reading the trace
+	Payload  hexutil.Bytes      `json:"payload"` // RLP encoding of the message, for replaying it
+}
+
+// newTraceEntry creates a trace entry for a message exchanged with the given peer.
+func newTraceEntry(spec *Spec, peer discover.ESSNodeID, received bool, code uint64, msg interface{}, payload []byte) *TraceEntry {
+	typ := reflect.TypeOf(msg)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return &TraceEntry{
+		Time:     time.Now(),
+		Peer:     peer,
+		Protocol: spec.Name,
+		Version:  spec.Version,
+		Received: received,
+		Code:     code,
+		Type:     typ.String(),
+		Msg:      msg,
+		Payload:  payload,
+	}
+}
+
+// Decode decodes the payload of the entry into the message type of the given
+// spec.
+func (e *TraceEntry) Decode(spec *Spec) (interface{}, error) {
+	val, ok := spec.NewMsg(e.Code)
+	if !ok {
+		return nil, errorf(ErrInvalidMsgCode, "%v", e.Code)
+	}
+	if err := rlp.DecodeBytes(e.Payload, val); err != nil {
+		return nil, errorf(ErrDecode, "trace entry %v: %v", e.Code, err)
+	}
+	return val, nil
+}
+
+// FileRecorder writes trace entries to a file, one JSON object per line.
+type FileRecorder struct {
+	mu   sync.Mutex
+	file *os.File
+	buf  *bufio.Writer
+	enc  *json.Encoder
+}
+
+// NewFileRecorder creates a recorder appending to the file at path.
+func NewFileRecorder(path string) (*FileRecorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(file)
+	return &FileRecorder{file: file, buf: buf, enc: json.NewEncoder(buf)}, nil
+}
+
+// Record implements Recorder.
+func (r *FileRecorder) Record(entry *TraceEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return
+	}
+	if err := r.enc.Encode(entry); err != nil {
+		log.Warn("Failed to record protocol message", "protocol", entry.Protocol, "code", entry.Code, "err", err)
+		return
+	}
+	// Flush on every entry so the trace is complete when the process dies.
+	if err := r.buf.Flush(); err != nil {
+		log.Warn("Failed to write protocol trace", "err", err)
+	}
+}
+
+// Close flushes and closes the trace file.
+func (r *FileRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.buf.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+	return err
+}
+
+// ReadTrace reads trace entries written by FileRecorder. The Msg field of the
+// returned entries holds the generic JSON form of the message, use Decode
+// to recover the typed message.
+func ReadTrace(r io.Reader) ([]*TraceEntry, error) {
+	var (
+		dec   = json.NewDecoder(r)
+		trace []*TraceEntry
+	)
+	for {
+		entry := new(TraceEntry)
+		if err := dec.Decode(entry); err == io.EOF {
+			return trace, nil
+		} else if err != nil {
+			return trace, fmt.Errorf("invalid trace entry %d: %v", len(trace), err)
+		}
+		trace = append(trace, entry)
+	}
+}
+
+// ReadTraceFile reads the trace entries contained in a file.
+func ReadTraceFile(path string) ([]*TraceEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadTrace(file)
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package protocols
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/orangeAndSuns/essentia/p2p"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+	"github.com/orangeAndSuns/essentia/rlp"
+)
+
+type memRecorder struct {
+	mu      sync.Mutex
+	entries []*TraceEntry
+}
+
+func (r *memRecorder) Record(entry *TraceEntry) {
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+}
+
+func TestRecorder(t *testing.T) {
+	var (
+		rec  = new(memRecorder)
+		spec = &Spec{
+			Name:       "test",
+			Version:    1,
+			MaxMsgSize: 1024,
+			Messages:   []interface{}{hs0{}, kill{}},
+			Recorder:   rec,
+		}
+		id1, id2 = discover.ESSNodeID{1}, discover.ESSNodeID{2}
+		rw1, rw2 = p2p.MsgPipe()
+		p1       = NewPeer(p2p.NewPeer(id2, "", nil), rw1, spec)
+		p2       = NewPeer(p2p.NewPeer(id1, "", nil), rw2, spec)
+	)
+	defer rw1.Close()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- p2.handleIncoming(func(ctx context.Context, msg interface{}) error { return nil })
+	}()
+	if err := p1.Send(context.Background(), &kill{C: id1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.entries) != 2 {
+		t.Fatalf("recorded %d entries, want 2", len(rec.entries))
+	}
+	sent, received := rec.entries[0], rec.entries[1]
+	if sent.Received {
+		sent, received = received, sent
+	}
+	for _, e := range []*TraceEntry{sent, received} {
+		if e.Protocol != "test" || e.Version != 1 || e.Code != 1 || e.Type != "protocols.kill" {
+			t.Errorf("wrong entry %+v", e)
+		}
+	}
+	if sent.Peer != id2 || received.Peer != id1 {
+		t.Errorf("wrong peers: sent to %x, received from %x", sent.Peer[:1], received.Peer[:1])
+	}
+	msg, err := received.Decode(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(msg, &kill{C: id1}) {
+		t.Errorf("wrong decoded message %v", msg)
+	}
+}
+
+func TestFileRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "protocols-trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "trace.json")
+
+	spec := &Spec{Name: "test", Version: 1, Messages: []interface{}{hs0{}, kill{}}}
+	rec, err := NewFileRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{&hs0{C: 42}, &kill{C: discover.ESSNodeID{3}}}
+	for i, msg := range want {
+		code, _ := spec.GetCode(msg)
+		payload, _ := rlp.EncodeToBytes(msg)
+		rec.Record(newTraceEntry(spec, discover.ESSNodeID{byte(i)}, i%2 == 0, code, msg, payload))
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	trace, err := ReadTraceFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trace) != len(want) {
+		t.Fatalf("read %d entries, want %d", len(trace), len(want))
+	}
+	for i, e := range trace {
+		if e.Peer != (discover.ESSNodeID{byte(i)}) || e.Received != (i%2 == 0) || e.Code != uint64(i) {
+			t.Errorf("entry %d: wrong metadata %+v", i, e)
+		}
+		msg, err := e.Decode(spec)
+		if err != nil {
+			t.Fatalf("entry %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(msg, want[i]) {
+			t.Errorf("entry %d: wrong message %v, want %v", i, msg, want[i])
+		}
+	}
+}
@@ -135,8 +135,9 @@ func (t *rlpx) doProtoHandshake(our *protoHandshake) (their *protoHandshake, err
 	if err := <-werr; err != nil {
 		return nil, fmt.Errorf("write error: %v", err)
 	}
-	// If the protocol version supports Snappy encoding, upgrade immediately
-	t.rw.snappy = their.Version >= snappyProtocolVersion
+	// If both sides support Snappy encoding, upgrade immediately. Peers with
+	// an older base protocol version keep exchanging plain payloads.
+	t.rw.snappy = our.Version >= snappyProtocolVersion && their.Version >= snappyProtocolVersion
 
 	return their, nil
 }
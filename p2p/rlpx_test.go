@@ -18,7 +18,9 @@ package p2p
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -224,6 +226,82 @@ func TestProtocolHandshake(t *testing.T) {
 	wg.Wait()
 }
 
+// This test checks that Snappy compression is only enabled if both sides of the
+// connection support it and that messages can be exchanged in all cases.
+func TestProtocolHandshakeSnappy(t *testing.T) {
+	tests := []struct {
+		v0, v1     uint64
+		wantSnappy bool
+	}{
+		{v0: snappyProtocolVersion, v1: snappyProtocolVersion, wantSnappy: true},
+		{v0: snappyProtocolVersion, v1: snappyProtocolVersion - 1, wantSnappy: false},
+		{v0: snappyProtocolVersion - 1, v1: snappyProtocolVersion, wantSnappy: false},
+		{v0: snappyProtocolVersion - 1, v1: snappyProtocolVersion - 1, wantSnappy: false},
+		{v0: snappyProtocolVersion + 1, v1: snappyProtocolVersion, wantSnappy: true},
+	}
+	for i, test := range tests {
+		snappy0, snappy1, err := testSnappyHandshake(test.v0, test.v1)
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if snappy0 != test.wantSnappy || snappy1 != test.wantSnappy {
+			t.Errorf("test %d: snappy enabled %t/%t, want %t", i, snappy0, snappy1, test.wantSnappy)
+		}
+	}
+}
+
+// testSnappyHandshake runs the handshakes between two peers announcing the given
+// base protocol versions, then sends a message in each direction. It returns
+// whether compression was enabled on both ends.
+func testSnappyHandshake(v0, v1 uint64) (snappy0, snappy1 bool, err error) {
+	var (
+		prv0, _ = crypto.GenerateKey()
+		prv1, _ = crypto.GenerateKey()
+		node1   = &discover.Node{ID: discover.PubkeyID(&prv1.PublicKey), IP: net.IP{5, 6, 7, 8}, TCP: 44}
+		hs0     = &protoHandshake{Version: v0, ID: discover.PubkeyID(&prv0.PublicKey)}
+		hs1     = &protoHandshake{Version: v1, ID: node1.ID}
+		payload = []interface{}{strings.Repeat("compressible", 100)}
+	)
+	fd0, fd1, err := pipes.TCPPipe()
+	if err != nil {
+		return false, false, err
+	}
+	defer fd0.Close()
+	defer fd1.Close()
+
+	run := func(fd net.Conn, prv *ecdsa.PrivateKey, dest *discover.Node, hs *protoHandshake, initiator bool) (bool, error) {
+		rlpx := newRLPX(fd).(*rlpx)
+		if _, err := rlpx.doEncHandshake(prv, dest); err != nil {
+			return false, fmt.Errorf("enc handshake failed: %v", err)
+		}
+		if _, err := rlpx.doProtoHandshake(hs); err != nil {
+			return false, fmt.Errorf("proto handshake failed: %v", err)
+		}
+		if initiator {
+			if err := Send(rlpx, 0x10, payload); err != nil {
+				return false, err
+			}
+			return rlpx.rw.snappy, ExpectMsg(rlpx, 0x11, payload)
+		}
+		if err := ExpectMsg(rlpx, 0x10, payload); err != nil {
+			return false, err
+		}
+		return rlpx.rw.snappy, Send(rlpx, 0x11, payload)
+	}
+	errc := make(chan error, 1)
+	go func() {
+		var err error
+		snappy1, err = run(fd1, prv1, nil, hs1, false)
+		errc <- err
+	}()
+	snappy0, err = run(fd0, prv0, node1, hs0, true)
+	if err1 := <-errc; err == nil {
+		err = err1
+	}
+	return snappy0, snappy1, err
+}
+
 func TestProtocolHandshakeErrors(t *testing.T) {
 	our := &protoHandshake{Version: 3, Caps: []Cap{{"foo", 2}, {"bar", 3}}, Name: "quux"}
 	tests := []struct {
@@ -313,6 +391,73 @@ ba628a4ba590cb43f7848f41c4382885
 	}
 }
 
+// This test checks that compressed messages survive the round trip and
+// shrink on the wire.
+func TestRLPXFrameRWSnappy(t *testing.T) {
+	rw1, rw2, conn := newTestFrameRWPair()
+	rw1.snappy, rw2.snappy = true, true
+
+	wmsg := []interface{}{strings.Repeat("test", 1000)}
+	wantPayload, _ := rlp.EncodeToBytes(wmsg)
+	if err := Send(rw1, 3, wmsg); err != nil {
+		t.Fatalf("WriteMsg error: %v", err)
+	}
+	if conn.Len() >= len(wantPayload) {
+		t.Errorf("payload not compressed: %d bytes written for %d byte payload", conn.Len(), len(wantPayload))
+	}
+	msg, err := rw2.ReadMsg()
+	if err != nil {
+		t.Fatalf("ReadMsg error: %v", err)
+	}
+	if msg.Code != 3 || msg.Size != uint32(len(wantPayload)) {
+		t.Fatalf("wrong message code/size: got %d/%d, want %d/%d", msg.Code, msg.Size, 3, len(wantPayload))
+	}
+	payload, _ := ioutil.ReadAll(msg.Payload)
+	if !bytes.Equal(payload, wantPayload) {
+		t.Fatalf("msg payload mismatch:\ngot  %x\nwant %x", payload, wantPayload)
+	}
+}
+
+// This test checks that compressed messages which would decompress to more than
+// the maximum message size are rejected before decompression.
+func TestRLPXFrameRWSnappyTooLarge(t *testing.T) {
+	rw1, rw2, _ := newTestFrameRWPair()
+	rw2.snappy = true
+
+	// The snappy block header is the varint encoded decoded length.
+	var header [binary.MaxVarintLen32]byte
+	n := binary.PutUvarint(header[:], uint64(maxUint24)+1)
+	payload := append(header[:n], 0)
+	if err := rw1.WriteMsg(Msg{Code: 1, Size: uint32(len(payload)), Payload: bytes.NewReader(payload)}); err != nil {
+		t.Fatalf("WriteMsg error: %v", err)
+	}
+	if _, err := rw2.ReadMsg(); err != errPlainMessageTooLarge {
+		t.Fatalf("wrong ReadMsg error: got %v, want %v", err, errPlainMessageTooLarge)
+	}
+}
+
+// newTestFrameRWPair creates two frame readers/writers with matching secrets
+// on a shared buffer.
+func newTestFrameRWPair() (rw1, rw2 *rlpxFrameRW, conn *bytes.Buffer) {
+	var (
+		aesSecret      = make([]byte, 16)
+		macSecret      = make([]byte, 16)
+		egressMACinit  = make([]byte, 32)
+		ingressMACinit = make([]byte, 32)
+	)
+	for _, s := range [][]byte{aesSecret, macSecret, egressMACinit, ingressMACinit} {
+		rand.Read(s)
+	}
+	conn = new(bytes.Buffer)
+	s1 := secrets{AES: aesSecret, MAC: macSecret, EgressMAC: sha3.NewKeccak256(), IngressMAC: sha3.NewKeccak256()}
+	s1.EgressMAC.Write(egressMACinit)
+	s1.IngressMAC.Write(ingressMACinit)
+	s2 := secrets{AES: aesSecret, MAC: macSecret, EgressMAC: sha3.NewKeccak256(), IngressMAC: sha3.NewKeccak256()}
+	s2.EgressMAC.Write(ingressMACinit)
+	s2.IngressMAC.Write(egressMACinit)
+	return newRLPXFrameRW(conn, s1), newRLPXFrameRW(conn, s2), conn
+}
+
 type fakeHash []byte
 
 func (fakeHash) Write(p []byte) (int, error) { return len(p), nil }
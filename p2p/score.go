@@ -0,0 +1,131 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common/mclock"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+)
+
+const (
+	// Default score below which peers are disconnected and refused.
+	defaultMinPeerScore = -100
+
+	// Default time after which half of a node's score is forgotten.
+	defaultScoreHalfLife = 10 * time.Minute
+
+	// Scores closer to zero than this are dropped from the scorer.
+	minTrackedScore = 0.5
+
+	// Number of tracked nodes above which decayed scores are swept.
+	scoreSweepThreshold = 4096
+)
+
+// MisbehaviourPenalty is the score delta protocols report for a peer sending
+// an invalid message or violating the protocol.
+const MisbehaviourPenalty = -50
+
+// PeerScorer keeps track of the behaviour of remote nodes. Protocol handlers
+// report useful behaviour with a positive delta and misbehaviour with a
+// negative one via Peer.Report. The server disconnects peers whose score
+// falls below Config.MinPeerScore and refuses to connect to them until the
+// score has recovered. Dynamic dial candidates with higher scores are dialed
+// first.
+//
+// Implementations must be safe for concurrent use.
+type PeerScorer interface {
+	// Report adjusts the score of a node and returns the new score.
+	Report(id discover.ESSNodeID, protocol string, delta float64) float64
+	// Score returns the current score of a node.
+	Score(id discover.ESSNodeID) float64
+}
+
+// decayingScorer is the default PeerScorer. Scores decay exponentially
+// towards zero, so nodes are forgiven after a while.
+type decayingScorer struct {
+	halfLife time.Duration
+	now      func() mclock.AbsTime // for testing
+
+	lock   sync.Mutex
+	scores map[discover.ESSNodeID]*nodeScore
+}
+
+type nodeScore struct {
+	value   float64
+	updated mclock.AbsTime
+}
+
+// NewPeerScorer creates a scorer which forgets half of a node's score during
+// the given time. A zero halfLife defaults to ten minutes.
+func NewPeerScorer(halfLife time.Duration) PeerScorer {
+	if halfLife <= 0 {
+		halfLife = defaultScoreHalfLife
+	}
+	return &decayingScorer{
+		halfLife: halfLife,
+		now:      mclock.Now,
+		scores:   make(map[discover.ESSNodeID]*nodeScore),
+	}
+}
+
+// Report implements PeerScorer.
+func (s *decayingScorer) Report(id discover.ESSNodeID, protocol string, delta float64) float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	value := s.current(id, now) + delta
+	if math.Abs(value) < minTrackedScore {
+		delete(s.scores, id)
+	} else {
+		s.scores[id] = &nodeScore{value: value, updated: now}
+	}
+	if len(s.scores) > scoreSweepThreshold {
+		for id := range s.scores {
+			s.current(id, now)
+		}
+	}
+	return value
+}
+
+// Score implements PeerScorer.
+func (s *decayingScorer) Score(id discover.ESSNodeID) float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.current(id, s.now())
+}
+
+// current returns the decayed score of a node, dropping it once it gets
+// close to zero. The lock must be held.
+func (s *decayingScorer) current(id discover.ESSNodeID, now mclock.AbsTime) float64 {
+	score := s.scores[id]
+	if score == nil {
+		return 0
+	}
+	elapsed := float64(now - score.updated)
+	value := score.value * math.Exp2(-elapsed/float64(s.halfLife))
+	if math.Abs(value) < minTrackedScore {
+		delete(s.scores, id)
+		return 0
+	}
+	return value
+}
@@ -0,0 +1,56 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common/mclock"
+)
+
+func TestPeerScorerDecay(t *testing.T) {
+	var (
+		clock  mclock.AbsTime
+		scorer = NewPeerScorer(time.Minute).(*decayingScorer)
+		id     = randomID()
+	)
+	scorer.now = func() mclock.AbsTime { return clock }
+
+	if score := scorer.Report(id, "a", -100); score != -100 {
+		t.Fatalf("wrong score after report: got %v, want -100", score)
+	}
+	clock += mclock.AbsTime(time.Minute)
+	if score := scorer.Score(id); !approxScore(score, -50) {
+		t.Fatalf("wrong score after one half-life: got %v, want -50", score)
+	}
+	if score := scorer.Report(id, "b", 20); !approxScore(score, -30) {
+		t.Fatalf("wrong score after second report: got %v, want -30", score)
+	}
+	clock += mclock.AbsTime(time.Hour)
+	if score := scorer.Score(id); score != 0 {
+		t.Fatalf("score not forgotten: got %v", score)
+	}
+	if len(scorer.scores) != 0 {
+		t.Fatalf("scorer still tracks %d nodes", len(scorer.scores))
+	}
+}
+
+func approxScore(x, y float64) bool {
+	return math.Abs(x-y) < 1e-3
+}
@@ -31,6 +31,8 @@ import (
 	"github.com/orangeAndSuns/essentia/log"
 	"github.com/orangeAndSuns/essentia/p2p/discover"
 	"github.com/orangeAndSuns/essentia/p2p/discv5"
+	"github.com/orangeAndSuns/essentia/p2p/dnsdisc"
+	"github.com/orangeAndSuns/essentia/p2p/enr"
 	"github.com/orangeAndSuns/essentia/p2p/nat"
 	"github.com/orangeAndSuns/essentia/p2p/netutil"
 )
@@ -43,12 +45,19 @@ const (
 	defaultMaxPendingPeers = 50
 	defaultDialRatio       = 3
 
+	// Maximum time the dialer waits for a node of the fairly chosen
+	// discovery source before taking one from any source.
+	discmixTimeout = 5 * time.Second
+
 	// Maximum time allowed for reading a complete message.
 	// This is effectively the amount of time a connection can be idle.
 	frameReadTimeout = 30 * time.Second
 
 	// Maximum amount of time allowed for writing a complete message.
 	frameWriteTimeout = 20 * time.Second
+
+	// Size of the network range limited by MaxPeersPerSubnet.
+	inboundSubnetBits = 24
 )
 
 var errServerStopped = errors.New("server stopped")
@@ -72,6 +81,29 @@ type Config struct {
 	// Setting DialRatio to zero defaults it to 3.
 	DialRatio int `toml:",omitempty"`
 
+	// MaxPeersPerIP limits the number of inbound connections, pending and
+	// established, from a single IP address. Zero means no limit.
+	MaxPeersPerIP int `toml:",omitempty"`
+
+	// MaxPeersPerSubnet limits the number of inbound connections, pending
+	// and established, from a single /24 network. Zero means no limit.
+	MaxPeersPerSubnet int `toml:",omitempty"`
+
+	// InboundThrottleTime is the minimum time between two inbound connection
+	// attempts from the same IP address. Attempts from LAN addresses are not
+	// throttled. Zero or a negative value disables the throttle.
+	InboundThrottleTime time.Duration `toml:",omitempty"`
+
+	// Scorer keeps track of the behaviour of remote nodes reported by the
+	// protocols. If nil, a scorer forgetting half of the score every ten
+	// minutes is used.
+	Scorer PeerScorer `toml:"-"`
+
+	// MinPeerScore is the score below which peers are disconnected and
+	// refused until their score recovers. Trusted peers are exempt. Zero
+	// defaults to -100.
+	MinPeerScore float64 `toml:",omitempty"`
+
 	// NoDiscovery can be used to disable the peer discovery mechanism.
 	// Disabling is useful for protocol debugging (manual topology).
 	NoDiscovery bool
@@ -93,6 +125,10 @@ type Config struct {
 	// protocol.
 	BootstrapNodesV5 []*discv5.Node `toml:",omitempty"`
 
+	// DNSDiscovery contains enrtree:// URLs of signed node lists published
+	// via DNS. Nodes from these lists are used as additional dial candidates.
+	DNSDiscovery []string `toml:",omitempty"`
+
 	// Static nodes are used as pre-configured connections which are always
 	// maintained and re-connected on disconnects.
 	StaticNodes []*discover.Node
@@ -161,6 +197,23 @@ type Server struct {
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
 	DiscV5       *discv5.Network
+	dnsClient    *dnsdisc.Client
+	discmix      discover.Iterator // dynamic dial candidates of all discovery sources
+	scorer       PeerScorer
+
+	// Local node record, served to discovery peers.
+	recordLock    sync.RWMutex
+	localRecord   *enr.Record
+	recordEntries []enr.Entry // protocol specific entries of the record
+	recordIP      net.IP
+	recordTCP     int
+	recordUDP     int
+
+	// Inbound connection limits, protected by inboundLock.
+	inboundLock    sync.Mutex
+	inboundIPs     netutil.DistinctNetSet
+	inboundSubnets netutil.DistinctNetSet
+	inboundHistory expHeap
 
 	// These are for Peers, PeerCount (and nothing else).
 	peerOp     chan peerOpFunc
@@ -351,6 +404,9 @@ func (srv *Server) Stop() {
 	}
 	close(srv.quit)
 	srv.loopWG.Wait()
+	if srv.dnsClient != nil {
+		srv.dnsClient.Stop()
+	}
 }
 
 // sharedUDPConn implements a shared connection. Write sends messages to the underlying connection while read returns
@@ -412,6 +468,13 @@ func (srv *Server) Start() (err error) {
 	srv.removestatic = make(chan *discover.Node)
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
+	srv.inboundIPs = netutil.DistinctNetSet{Subnet: 128, Limit: uint(srv.MaxPeersPerIP)}
+	srv.inboundSubnets = netutil.DistinctNetSet{Subnet: inboundSubnetBits, Limit: uint(srv.MaxPeersPerSubnet)}
+	srv.inboundHistory = nil
+	srv.scorer = srv.Scorer
+	if srv.scorer == nil {
+		srv.scorer = NewPeerScorer(0)
+	}
 
 	var (
 		conn      *net.UDPConn
@@ -447,6 +510,7 @@ func (srv *Server) Start() (err error) {
 	}
 
 	// node table
+	var dialSources []discover.Iterator
 	if !srv.NoDiscovery {
 		cfg := discover.Config{
 			PrivateKey:   srv.PrivateKey,
@@ -455,12 +519,14 @@ func (srv *Server) Start() (err error) {
 			NetRestrict:  srv.NetRestrict,
 			Bootnodes:    srv.BootstrapNodes,
 			Unhandled:    unhandled,
+			LocalRecord:  srv.LocalRecord,
 		}
 		ntab, err := discover.ListenUDP(conn, cfg)
 		if err != nil {
 			return err
 		}
 		srv.ntab = ntab
+		dialSources = append(dialSources, ntab.RandomNodes())
 	}
 
 	if srv.DiscoveryV5 {
@@ -482,8 +548,33 @@ func (srv *Server) Start() (err error) {
 		srv.DiscV5 = ntab
 	}
 
+	// DNS node lists
+	if len(srv.DNSDiscovery) > 0 {
+		srv.dnsClient = dnsdisc.NewClient(dnsdisc.Config{Logger: srv.log})
+		for _, url := range srv.DNSDiscovery {
+			if err := srv.dnsClient.AddTree(url); err != nil {
+				return fmt.Errorf("invalid DNS discovery URL %q: %v", url, err)
+			}
+		}
+		dialSources = append(dialSources, srv.dnsClient.RandomNodes())
+	}
+
+	// Dynamic dial candidates are drawn from all discovery sources and
+	// checked against the protocol dial filters.
+	if len(dialSources) > 0 {
+		mix := discover.NewFairMix(discmixTimeout)
+		for _, it := range dialSources {
+			mix.AddSource(it)
+		}
+		srv.discmix = mix
+		if srv.ntab != nil && hasDialFilter(srv.Protocols) {
+			srv.discmix = discover.Filter(mix, srv.checkRecord)
+		}
+	}
+
 	dynPeers := srv.maxDialedConns()
-	dialer := newDialState(srv.StaticNodes, srv.BootstrapNodes, srv.ntab, dynPeers, srv.NetRestrict)
+	dialer := newDialState(srv.StaticNodes, srv.BootstrapNodes, srv.ntab, srv.discmix, dynPeers, srv.NetRestrict)
+	dialer.scorer, dialer.minScore = srv.scorer, srv.minPeerScore()
 
 	// handshake
 	srv.ourHandshake = &protoHandshake{Version: baseProtocolVersion, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
@@ -499,6 +590,13 @@ func (srv *Server) Start() (err error) {
 	if srv.NoDial && srv.ListenAddr == "" {
 		srv.log.Warn("P2P server will be useless, neither dialing nor listening")
 	}
+	// local node record
+	if err := srv.setupLocalRecord(realaddr); err != nil {
+		return err
+	}
+	if srv.dnsClient != nil {
+		srv.dnsClient.Start()
+	}
 
 	srv.loopWG.Add(1)
 	go srv.run(dialer)
@@ -528,6 +626,107 @@ func (srv *Server) startListening() error {
 	return nil
 }
 
+// setupLocalRecord assembles and signs the initial local node record from the
+// discovery and listener endpoints and the protocol attributes.
+func (srv *Server) setupLocalRecord(udpAddr *net.UDPAddr) error {
+	srv.recordLock.Lock()
+	defer srv.recordLock.Unlock()
+
+	if udpAddr != nil {
+		srv.recordUDP = udpAddr.Port
+		if !udpAddr.IP.IsUnspecified() {
+			srv.recordIP = udpAddr.IP
+		}
+	}
+	if srv.listener != nil {
+		addr := srv.listener.Addr().(*net.TCPAddr)
+		srv.recordTCP = addr.Port
+		if srv.recordIP == nil && !addr.IP.IsUnspecified() {
+			srv.recordIP = addr.IP
+		}
+	}
+	// Entries set before startup take precedence over the protocol defaults.
+	for _, p := range srv.Protocols {
+		for _, e := range p.Attributes {
+			if !srv.hasRecordEntry(e.ENRKey()) {
+				srv.recordEntries = append(srv.recordEntries, e)
+			}
+		}
+	}
+	return srv.signLocalRecord()
+}
+
+// LocalRecord returns the current signed node record of the server, or nil
+// if the server has not been started yet. The returned record must not be
+// modified.
+func (srv *Server) LocalRecord() *enr.Record {
+	srv.recordLock.RLock()
+	defer srv.recordLock.RUnlock()
+
+	return srv.localRecord
+}
+
+// SetRecordEntry adds or replaces a protocol specific entry of the local node
+// record. If the server is running, the record is re-signed with an increased
+// sequence number.
+func (srv *Server) SetRecordEntry(e enr.Entry) error {
+	srv.recordLock.Lock()
+	defer srv.recordLock.Unlock()
+
+	replaced := false
+	for i, have := range srv.recordEntries {
+		if have.ENRKey() == e.ENRKey() {
+			srv.recordEntries[i], replaced = e, true
+		}
+	}
+	if !replaced {
+		srv.recordEntries = append(srv.recordEntries, e)
+	}
+	if srv.localRecord == nil {
+		return nil
+	}
+	return srv.signLocalRecord()
+}
+
+func (srv *Server) hasRecordEntry(key string) bool {
+	for _, e := range srv.recordEntries {
+		if e.ENRKey() == key {
+			return true
+		}
+	}
+	return false
+}
+
+// signLocalRecord creates a new local record from the current endpoint and
+// entries. The caller must hold recordLock.
+func (srv *Server) signLocalRecord() error {
+	// Sequence numbers are derived from the clock so that they keep increasing
+	// across restarts without being persisted.
+	seq := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	if srv.localRecord != nil && srv.localRecord.Seq() >= seq {
+		seq = srv.localRecord.Seq() + 1
+	}
+	var r enr.Record
+	if srv.recordIP != nil {
+		r.Set(enr.IP(srv.recordIP))
+	}
+	if srv.recordTCP != 0 {
+		r.Set(enr.TCP(srv.recordTCP))
+	}
+	if srv.recordUDP != 0 {
+		r.Set(enr.UDP(srv.recordUDP))
+	}
+	for _, e := range srv.recordEntries {
+		r.Set(e)
+	}
+	r.SetSeq(seq)
+	if err := enr.SignV4(&r, srv.PrivateKey); err != nil {
+		return err
+	}
+	srv.localRecord = &r
+	return nil
+}
+
 type dialer interface {
 	newTasks(running int, peers map[discover.ESSNodeID]*Peer, now time.Time) []task
 	taskDone(task, time.Time)
@@ -636,6 +835,7 @@ running:
 			if err == nil {
 				// The handshakes are done and it passed all checks.
 				p := newPeer(c, srv.Protocols)
+				p.scorer, p.minScore = srv.scorer, srv.minPeerScore()
 				// If message events are enabled, pass the peerFeed
 				// to the peer
 				if srv.EnableMsgEvents {
@@ -671,6 +871,9 @@ running:
 	srv.log.Trace("P2P networking is spinning down")
 
 	// Terminate discovery. If there is a running lookup it will terminate soon.
+	if srv.discmix != nil {
+		srv.discmix.Close()
+	}
 	if srv.ntab != nil {
 		srv.ntab.Close()
 	}
@@ -711,17 +914,26 @@ func (srv *Server) encHandshakeChecks(peers map[discover.ESSNodeID]*Peer, inboun
 		return DiscAlreadyConnected
 	case c.id == srv.Self().ID:
 		return DiscSelf
+	case !c.is(trustedConn) && srv.scorer.Score(c.id) < srv.minPeerScore():
+		return DiscUselessPeer
 	default:
 		return nil
 	}
 }
 
+func (srv *Server) minPeerScore() float64 {
+	if srv.MinPeerScore == 0 {
+		return defaultMinPeerScore
+	}
+	return srv.MinPeerScore
+}
+
 func (srv *Server) maxInboundConns() int {
 	return srv.MaxPeers - srv.maxDialedConns()
 }
 
 func (srv *Server) maxDialedConns() int {
-	if srv.NoDiscovery || srv.NoDial {
+	if (srv.NoDiscovery && len(srv.DNSDiscovery) == 0) || srv.NoDial {
 		return 0
 	}
 	r := srv.DialRatio
@@ -774,13 +986,23 @@ func (srv *Server) listenLoop() {
 		if srv.NetRestrict != nil {
 			if tcp, ok := fd.RemoteAddr().(*net.TCPAddr); ok && !srv.NetRestrict.Contains(tcp.IP) {
 				srv.log.Debug("Rejected conn (not whitelisted in NetRestrict)", "addr", fd.RemoteAddr())
+				inboundRejectNetRestrictMeter.Mark(1)
 				fd.Close()
 				slots <- struct{}{}
 				continue
 			}
 		}
+		// Reject hosts which connect too often or hold too many connections.
+		tracked, err := srv.checkInboundConn(fd, time.Now())
+		if err != nil {
+			srv.log.Debug("Rejected inbound connection", "addr", fd.RemoteAddr(), "err", err)
+			markInboundReject(err)
+			fd.Close()
+			slots <- struct{}{}
+			continue
+		}
 
-		fd = newMeteredConn(fd, true)
+		fd = newMeteredConn(tracked, true)
 		srv.log.Trace("Accepted connection", "addr", fd.RemoteAddr())
 		go func() {
 			srv.SetupConn(fd, inboundConn, nil)
@@ -789,6 +1011,81 @@ func (srv *Server) listenLoop() {
 	}
 }
 
+var (
+	errInboundThrottled  = errors.New("too many attempts")
+	errTooManyFromIP     = errors.New("too many connections from IP")
+	errTooManyFromSubnet = errors.New("too many connections from subnet")
+)
+
+// checkInboundConn applies the inbound throttle and the per-IP and per-subnet
+// limits to a freshly accepted connection. Admitted connections count against
+// the limits until they are closed.
+func (srv *Server) checkInboundConn(fd net.Conn, now time.Time) (net.Conn, error) {
+	tcp, ok := fd.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return fd, nil
+	}
+	ip := tcp.IP
+
+	srv.inboundLock.Lock()
+	defer srv.inboundLock.Unlock()
+
+	// Reject Internet hosts that try too often.
+	throttle := srv.InboundThrottleTime > 0 && !netutil.IsLAN(ip)
+	if throttle {
+		srv.inboundHistory.expire(now)
+		if srv.inboundHistory.contains(ip.String()) {
+			return nil, errInboundThrottled
+		}
+	}
+
+	// Enforce the connection limits.
+	if srv.MaxPeersPerIP > 0 && !srv.inboundIPs.Add(ip) {
+		return nil, errTooManyFromIP
+	}
+	if srv.MaxPeersPerSubnet > 0 && !srv.inboundSubnets.Add(ip) {
+		if srv.MaxPeersPerIP > 0 {
+			srv.inboundIPs.Remove(ip)
+		}
+		return nil, errTooManyFromSubnet
+	}
+	// Only attempts passing the limits count against the throttle, so that
+	// hosts rejected by the limits can retry as soon as a slot is free.
+	if throttle {
+		srv.inboundHistory.add(ip.String(), now.Add(srv.InboundThrottleTime))
+	}
+	if srv.MaxPeersPerIP == 0 && srv.MaxPeersPerSubnet == 0 {
+		return fd, nil
+	}
+	return &trackedConn{Conn: fd, release: func() { srv.releaseInbound(ip) }}, nil
+}
+
+// releaseInbound removes a closed connection from the inbound limits.
+func (srv *Server) releaseInbound(ip net.IP) {
+	srv.inboundLock.Lock()
+	defer srv.inboundLock.Unlock()
+
+	if srv.MaxPeersPerIP > 0 {
+		srv.inboundIPs.Remove(ip)
+	}
+	if srv.MaxPeersPerSubnet > 0 {
+		srv.inboundSubnets.Remove(ip)
+	}
+}
+
+// trackedConn is an inbound connection counted against the inbound limits.
+// The count is released when the connection is closed.
+type trackedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(c.release)
+	return c.Conn.Close()
+}
+
 // SetupConn runs the handshakes and attempts to add the connection
 // as a peer. It returns when the connection has been added as a peer
 // or the handshakes have failed.
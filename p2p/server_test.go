@@ -29,6 +29,8 @@ import (
 	"github.com/orangeAndSuns/essentia/crypto/sha3"
 	"github.com/orangeAndSuns/essentia/log"
 	"github.com/orangeAndSuns/essentia/p2p/discover"
+	"github.com/orangeAndSuns/essentia/p2p/enr"
+	"github.com/orangeAndSuns/essentia/p2p/netutil"
 )
 
 func init() {
@@ -122,6 +124,60 @@ func TestServerListen(t *testing.T) {
 	}
 }
 
+// This test checks that the local node record contains the listener endpoint
+// and protocol attributes, and that updating an entry re-signs the record.
+func TestServerLocalRecord(t *testing.T) {
+	srv := &Server{
+		Config: Config{
+			MaxPeers:    10,
+			ListenAddr:  "127.0.0.1:0",
+			PrivateKey:  newkey(),
+			NoDiscovery: true,
+			Protocols: []Protocol{{
+				Name:       "test",
+				Attributes: []enr.Entry{enr.WithEntry("test", uint(1))},
+			}},
+		},
+	}
+	if srv.LocalRecord() != nil {
+		t.Fatal("local record exists before start")
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	record := srv.LocalRecord()
+	if record == nil || !record.Signed() {
+		t.Fatal("local record missing or unsigned")
+	}
+	var (
+		tcp enr.TCP
+		v   uint
+		key enr.Secp256k1
+	)
+	if err := record.Load(&tcp); err != nil || int(tcp) != srv.listener.Addr().(*net.TCPAddr).Port {
+		t.Errorf("wrong TCP port in record: %d (err %v)", tcp, err)
+	}
+	if err := record.Load(enr.WithEntry("test", &v)); err != nil || v != 1 {
+		t.Errorf("wrong protocol attribute in record: %d (err %v)", v, err)
+	}
+	if err := record.Load(&key); err != nil || discover.PubkeyID((*ecdsa.PublicKey)(&key)) != discover.PubkeyID(&srv.PrivateKey.PublicKey) {
+		t.Errorf("record not signed by the server key (err %v)", err)
+	}
+	// Update the attribute and check that the record is re-signed.
+	if err := srv.SetRecordEntry(enr.WithEntry("test", uint(2))); err != nil {
+		t.Fatalf("could not update entry: %v", err)
+	}
+	updated := srv.LocalRecord()
+	if updated.Seq() <= record.Seq() {
+		t.Errorf("sequence number not increased: %d <= %d", updated.Seq(), record.Seq())
+	}
+	if err := updated.Load(enr.WithEntry("test", &v)); err != nil || v != 2 {
+		t.Errorf("wrong updated attribute in record: %d (err %v)", v, err)
+	}
+}
+
 func TestServerDial(t *testing.T) {
 	// run a one-shot TCP server to handle the connection.
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
@@ -366,6 +422,47 @@ func TestServerAtCap(t *testing.T) {
 
 }
 
+// This test checks that connections from nodes with a low score are refused.
+func TestServerLowScore(t *testing.T) {
+	var (
+		trustedID = randomID()
+		badID     = randomID()
+		scorer    = NewPeerScorer(0)
+	)
+	srv := &Server{
+		Config: Config{
+			PrivateKey:   newkey(),
+			MaxPeers:     10,
+			NoDial:       true,
+			TrustedNodes: []*discover.Node{{ID: trustedID}},
+			Scorer:       scorer,
+			MinPeerScore: -10,
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start: %v", err)
+	}
+	defer srv.Stop()
+
+	newconn := func(id discover.ESSNodeID) *conn {
+		fd, _ := net.Pipe()
+		tx := newTestTransport(id, fd)
+		return &conn{fd: fd, transport: tx, flags: inboundConn, id: id, cont: make(chan error)}
+	}
+	scorer.Report(badID, "a", -20)
+	scorer.Report(trustedID, "a", -20)
+
+	if err := srv.checkpoint(newconn(badID), srv.posthandshake); err != DiscUselessPeer {
+		t.Errorf("wrong error for low score conn: %v", err)
+	}
+	if err := srv.checkpoint(newconn(trustedID), srv.posthandshake); err != nil {
+		t.Errorf("unexpected error for trusted low score conn: %v", err)
+	}
+	if err := srv.checkpoint(newconn(randomID()), srv.posthandshake); err != nil {
+		t.Errorf("unexpected error for unscored conn: %v", err)
+	}
+}
+
 func TestServerSetupConn(t *testing.T) {
 	id := randomID()
 	srvkey := newkey()
@@ -453,6 +550,116 @@ func TestServerSetupConn(t *testing.T) {
 	}
 }
 
+// fakeAddrConn is a connection with a configurable remote address.
+type fakeAddrConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+func (c fakeAddrConn) RemoteAddr() net.Addr { return c.remoteAddr }
+
+func newInboundTestConn(ip string) net.Conn {
+	fd, _ := net.Pipe()
+	return fakeAddrConn{fd, &net.TCPAddr{IP: net.ParseIP(ip), Port: 30303}}
+}
+
+func newInboundTestServer(cfg Config) *Server {
+	srv := &Server{Config: cfg}
+	srv.inboundIPs = netutil.DistinctNetSet{Subnet: 128, Limit: uint(cfg.MaxPeersPerIP)}
+	srv.inboundSubnets = netutil.DistinctNetSet{Subnet: inboundSubnetBits, Limit: uint(cfg.MaxPeersPerSubnet)}
+	return srv
+}
+
+// This test checks that repeated inbound attempts from the same Internet host
+// are throttled, while LAN hosts are exempt.
+func TestServerInboundThrottle(t *testing.T) {
+	srv := newInboundTestServer(Config{InboundThrottleTime: 10 * time.Second})
+	now := time.Now()
+
+	if _, err := srv.checkInboundConn(newInboundTestConn("1.2.3.4"), now); err != nil {
+		t.Fatalf("first attempt rejected: %v", err)
+	}
+	if _, err := srv.checkInboundConn(newInboundTestConn("1.2.3.4"), now.Add(5*time.Second)); err != errInboundThrottled {
+		t.Fatalf("second attempt: got error %v, want %v", err, errInboundThrottled)
+	}
+	if _, err := srv.checkInboundConn(newInboundTestConn("1.2.3.5"), now.Add(5*time.Second)); err != nil {
+		t.Fatalf("attempt from other host rejected: %v", err)
+	}
+	if _, err := srv.checkInboundConn(newInboundTestConn("1.2.3.4"), now.Add(11*time.Second)); err != nil {
+		t.Fatalf("attempt after throttle time rejected: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := srv.checkInboundConn(newInboundTestConn("192.168.0.1"), now); err != nil {
+			t.Fatalf("LAN attempt %d rejected: %v", i, err)
+		}
+	}
+}
+
+// This test checks that a zero or negative throttle time disables the throttle.
+func TestServerInboundThrottleDisabled(t *testing.T) {
+	for _, throttleTime := range []time.Duration{0, -1} {
+		srv := newInboundTestServer(Config{InboundThrottleTime: throttleTime})
+		for i := 0; i < 3; i++ {
+			if _, err := srv.checkInboundConn(newInboundTestConn("1.2.3.4"), time.Now()); err != nil {
+				t.Fatalf("throttle time %v: attempt %d rejected: %v", throttleTime, i, err)
+			}
+		}
+	}
+}
+
+// This test checks that attempts rejected by the connection limits don't
+// count against the inbound throttle.
+func TestServerInboundThrottleAfterLimits(t *testing.T) {
+	srv := newInboundTestServer(Config{InboundThrottleTime: 10 * time.Second, MaxPeersPerSubnet: 1})
+	now := time.Now()
+
+	fd, err := srv.checkInboundConn(newInboundTestConn("1.2.3.4"), now)
+	if err != nil {
+		t.Fatalf("first attempt rejected: %v", err)
+	}
+	if _, err := srv.checkInboundConn(newInboundTestConn("1.2.3.5"), now); err != errTooManyFromSubnet {
+		t.Fatalf("attempt from same subnet: got error %v, want %v", err, errTooManyFromSubnet)
+	}
+	fd.Close()
+	if _, err := srv.checkInboundConn(newInboundTestConn("1.2.3.5"), now.Add(time.Second)); err != nil {
+		t.Fatalf("attempt after slot was freed rejected: %v", err)
+	}
+}
+
+// This test checks the per-IP and per-subnet inbound connection limits and
+// that closing a connection releases its slot.
+func TestServerInboundLimits(t *testing.T) {
+	srv := newInboundTestServer(Config{MaxPeersPerIP: 2, MaxPeersPerSubnet: 3})
+
+	var conns []net.Conn
+	check := func(ip string, wantErr error) {
+		t.Helper()
+		fd, err := srv.checkInboundConn(newInboundTestConn(ip), time.Now())
+		if err != wantErr {
+			t.Fatalf("connection from %s: got error %v, want %v", ip, err, wantErr)
+		}
+		if fd != nil {
+			conns = append(conns, fd)
+		}
+	}
+	check("10.0.0.1", nil)
+	check("10.0.0.1", nil)
+	check("10.0.0.1", errTooManyFromIP)
+	check("10.0.0.2", nil)
+	check("10.0.0.3", errTooManyFromSubnet)
+	check("10.0.1.1", nil)
+
+	// Closing a connection frees the slot. Closing it twice doesn't
+	// release more than one slot.
+	conns[0].Close()
+	conns[0].Close()
+	check("10.0.0.1", nil)
+	check("10.0.0.1", errTooManyFromIP)
+	if n := srv.inboundIPs.Len(); n != 4 {
+		t.Fatalf("wrong number of tracked IPs %d, want 4", n)
+	}
+}
+
 type setupTransport struct {
 	id              discover.ESSNodeID
 	encHandshakeErr error
@@ -0,0 +1,170 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package replay reproduces the behaviour of a protocol from a message trace
+// recorded with protocols.Spec.Recorder.
+package replay
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/p2p"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+	"github.com/orangeAndSuns/essentia/p2p/protocols"
+)
+
+// DefaultTimeout is the default time Run waits for a message the node sent in
+// the recorded trace.
+const DefaultTimeout = 5 * time.Second
+
+// Error is returned by Run when the node diverges from the trace.
+type Error struct {
+	Index int                   // index of the trace entry
+	Entry *protocols.TraceEntry // the expected message
+	Got   *protocols.WrappedMsg // the message sent instead, nil if none was sent
+	Code  uint64                // code of the message sent instead
+	Err   error                 // set if the connection failed
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Err != nil:
+		return fmt.Sprintf("trace entry %d (%s from %x): %v", e.Index, e.Entry.Type, e.Entry.Peer[:8], e.Err)
+	case e.Got == nil:
+		return fmt.Sprintf("trace entry %d: node did not send %s to %x", e.Index, e.Entry.Type, e.Entry.Peer[:8])
+	default:
+		return fmt.Sprintf("trace entry %d: node sent code %d payload %x to %x, want %s (code %d) payload %x",
+			e.Index, e.Code, e.Got.Payload, e.Entry.Peer[:8], e.Entry.Type, e.Entry.Code, e.Entry.Payload)
+	}
+}
+
+// Run feeds a recorded trace to the given protocol of a node in order to
+// reproduce its behaviour. Every remote peer of the trace is simulated by a
+// message pipe. Messages the node received are sent to it in the recorded
+// order, messages the node sent are expected to be sent again with the same
+// code and payload. Entries of other protocols are ignored.
+//
+// The timeout limits the time to wait for each expected message, zero means
+// DefaultTimeout. Run returns an *Error at the first divergence.
+func Run(proto p2p.Protocol, trace []*protocols.TraceEntry, timeout time.Duration) error {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	peers := make(map[discover.ESSNodeID]*replayPeer)
+	defer func() {
+		for _, p := range peers {
+			p.close()
+		}
+	}()
+
+	for i, entry := range trace {
+		if entry.Protocol != proto.Name {
+			continue
+		}
+		p := peers[entry.Peer]
+		if p == nil {
+			p = newReplayPeer(proto, entry.Peer)
+			peers[entry.Peer] = p
+		}
+		if entry.Received {
+			wmsg := protocols.WrappedMsg{Size: uint32(len(entry.Payload)), Payload: entry.Payload}
+			if err := p2p.Send(p.rw, entry.Code, wmsg); err != nil {
+				return &Error{Index: i, Entry: entry, Err: p.failure(err)}
+			}
+			continue
+		}
+		select {
+		case msg := <-p.sent:
+			if msg.code != entry.Code || !bytes.Equal(msg.wmsg.Payload, entry.Payload) {
+				return &Error{Index: i, Entry: entry, Got: &msg.wmsg, Code: msg.code}
+			}
+		case <-p.done:
+			return &Error{Index: i, Entry: entry, Err: p.failure(nil)}
+		case <-time.After(timeout):
+			return &Error{Index: i, Entry: entry}
+		}
+	}
+	return nil
+}
+
+// replayPeer runs the protocol on one end of a message pipe and collects the
+// messages sent by the node at the other end.
+type replayPeer struct {
+	rw   *p2p.MsgPipeRW
+	sent chan replayMsg
+	done chan struct{} // closed when the protocol or the reader exited
+	err  error         // the error which closed done
+}
+
+type replayMsg struct {
+	code uint64
+	wmsg protocols.WrappedMsg
+}
+
+func newReplayPeer(proto p2p.Protocol, id discover.ESSNodeID) *replayPeer {
+	var (
+		local, remote = p2p.MsgPipe()
+		peer          = p2p.NewPeer(id, "replay", []p2p.Cap{{Name: proto.Name, Version: proto.Version}})
+		p             = &replayPeer{rw: remote, sent: make(chan replayMsg, 100), done: make(chan struct{})}
+		errc          = make(chan error, 2)
+	)
+	go func() { errc <- proto.Run(peer, local) }()
+	go func() { errc <- p.readLoop() }()
+	go func() {
+		p.err = <-errc
+		close(p.done)
+	}()
+	return p
+}
+
+// readLoop collects the messages sent by the node.
+func (p *replayPeer) readLoop() error {
+	for {
+		msg, err := p.rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		var wmsg protocols.WrappedMsg
+		if err := msg.Decode(&wmsg); err != nil {
+			return err
+		}
+		select {
+		case p.sent <- replayMsg{msg.Code, wmsg}:
+		case <-p.done:
+			return nil
+		}
+	}
+}
+
+// failure returns the error which ended the simulated connection.
+func (p *replayPeer) failure(err error) error {
+	select {
+	case <-p.done:
+		if p.err != nil {
+			return p.err
+		}
+		return fmt.Errorf("protocol returned")
+	default:
+		return err
+	}
+}
+
+func (p *replayPeer) close() {
+	p.rw.Close()
+	<-p.done
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package replay
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/p2p"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+	"github.com/orangeAndSuns/essentia/p2p/protocols"
+)
+
+type ping struct{ N uint }
+type pong struct{ N uint }
+
+type memRecorder struct {
+	mu    sync.Mutex
+	trace []*protocols.TraceEntry
+}
+
+func (r *memRecorder) Record(entry *protocols.TraceEntry) {
+	r.mu.Lock()
+	r.trace = append(r.trace, entry)
+	r.mu.Unlock()
+}
+
+// newPingProtocol creates a protocol answering ping N with pong N+inc.
+func newPingProtocol(rec protocols.Recorder, inc uint) p2p.Protocol {
+	spec := &protocols.Spec{
+		Name:       "ping",
+		Version:    1,
+		MaxMsgSize: 1024,
+		Messages:   []interface{}{ping{}, pong{}},
+		Recorder:   rec,
+	}
+	return p2p.Protocol{
+		Name:    spec.Name,
+		Version: spec.Version,
+		Length:  spec.Length(),
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			peer := protocols.NewPeer(p, rw, spec)
+			return peer.Run(func(ctx context.Context, msg interface{}) error {
+				return peer.Send(ctx, &pong{msg.(*ping).N + inc})
+			})
+		},
+	}
+}
+
+// recordTrace runs the ping protocol against two remote peers and returns the
+// recorded trace.
+func recordTrace(t *testing.T) []*protocols.TraceEntry {
+	rec := new(memRecorder)
+	proto := newPingProtocol(rec, 1)
+	remoteSpec := &protocols.Spec{Name: "ping", Version: 1, MaxMsgSize: 1024, Messages: []interface{}{ping{}, pong{}}}
+
+	for _, id := range []discover.ESSNodeID{{1}, {2}} {
+		local, remote := p2p.MsgPipe()
+		go proto.Run(p2p.NewPeer(id, "", nil), local)
+		peer := protocols.NewPeer(p2p.NewPeer(discover.ESSNodeID{}, "", nil), remote, remoteSpec)
+		for n := uint(0); n < 3; n++ {
+			if err := peer.Send(context.Background(), &ping{n}); err != nil {
+				t.Fatal(err)
+			}
+			msg, err := remote.ReadMsg()
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg.Discard()
+		}
+		remote.Close()
+	}
+	// Wait for the node to record its last reply.
+	for i := 0; i < 100; i++ {
+		rec.mu.Lock()
+		n := len(rec.trace)
+		rec.mu.Unlock()
+		if n == 12 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.trace) != 12 {
+		t.Fatalf("recorded %d entries, want 12", len(rec.trace))
+	}
+	return rec.trace
+}
+
+func TestReplay(t *testing.T) {
+	trace := recordTrace(t)
+	if err := Run(newPingProtocol(nil, 1), trace, time.Second); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+}
+
+func TestReplayDivergence(t *testing.T) {
+	trace := recordTrace(t)
+	err := Run(newPingProtocol(nil, 2), trace, time.Second)
+	rerr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("wrong error type %T: %v", err, err)
+	}
+	if rerr.Index != 1 || rerr.Got == nil {
+		t.Fatalf("wrong divergence: %v", rerr)
+	}
+}
@@ -83,16 +83,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEsshashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(ESShashConfig), nil}
+	AllEsshashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(ESShashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Essentia core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(ESShashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(ESShashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -122,6 +122,9 @@ type ChainConfig struct {
 	// Various consensus engines
 	ESShash *ESShashConfig `json:"esshash,omitempty"`
 	Clique  *CliqueConfig  `json:"clique,omitempty"`
+
+	// Finality rules restricting chain reorganisations
+	Finality *FinalityConfig `json:"finality,omitempty"`
 }
 
 // ESShashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -143,6 +146,29 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// FinalityConfig defines the rules beyond which the local chain refuses to be
+// reorganised, either due to depth or due to hitting a finalized checkpoint.
+type FinalityConfig struct {
+	MaxReorgDepth uint64                 `json:"maxReorgDepth,omitempty"` // Maximum number of canonical blocks a reorg may drop (0 = unlimited)
+	Checkpoints   map[uint64]common.Hash `json:"checkpoints,omitempty"`   // Hard coded finalized block hashes
+	Signers       []common.Address       `json:"signers,omitempty"`       // Trusted signers allowed to finalize checkpoints
+	Threshold     uint64                 `json:"threshold,omitempty"`     // Number of signatures needed for a checkpoint (0 = majority of signers)
+}
+
+// String implements the stringer interface, returning the finality details.
+func (c *FinalityConfig) String() string {
+	return fmt.Sprintf("{MaxReorgDepth: %d Checkpoints: %d Signers: %d}", c.MaxReorgDepth, len(c.Checkpoints), len(c.Signers))
+}
+
+// SignerThreshold returns the number of distinct trusted signatures required to
+// accept a signed checkpoint.
+func (c *FinalityConfig) SignerThreshold() int {
+	if c.Threshold > 0 {
+		return int(c.Threshold)
+	}
+	return len(c.Signers)/2 + 1
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -0,0 +1,378 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/crypto/ecies"
+	"github.com/orangeAndSuns/essentia/swarm/log"
+	"github.com/orangeAndSuns/essentia/swarm/storage"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Access control of manifests
+//
+// The content of an access controlled manifest is referenced by the single
+// entry of a root manifest, whose hash is encrypted with an access key. The
+// Access field of the entry describes how the access key is derived:
+//
+//   - pass: from a passphrase with scrypt, using the salt and the parameters
+//     of the entry
+//   - pk: from the ECDH shared secret of the publisher and a single grantee
+//   - act: the access key is stored in an access control trie (ACT), a manifest
+//     with an entry per grantee, keyed by a lookup key derived from a session
+//     key of the grantee and containing the access key encrypted with another
+//     key derived from the same session key. The session key of a grantee is
+//     derived either from a shared secret with the publisher or a passphrase.
+//
+// The protected content itself should be uploaded encrypted, so that its
+// reference includes the decryption key of the content.
+
+// AccessType is the way an access key is derived.
+type AccessType string
+
+const (
+	AccessTypePass AccessType = "pass"
+	AccessTypePK   AccessType = "pk"
+	AccessTypeACT  AccessType = "act"
+)
+
+var (
+	// ErrDecrypt is returned when access controlled content can't be
+	// decrypted with the given credentials
+	ErrDecrypt = errors.New("cant decrypt - forbidden")
+
+	// ErrUnknownAccessType is returned for access entries of an unknown type
+	ErrUnknownAccessType = errors.New("unknown access type (or not implemented)")
+)
+
+const saltLength = 32
+
+// AccessEntry describes the access control of a manifest entry.
+type AccessEntry struct {
+	Type      AccessType `json:"type"`
+	Publisher string     `json:"publisher,omitempty"` // compressed public key of the publisher
+	Salt      []byte     `json:"salt"`
+	Act       string     `json:"act,omitempty"` // address of the access control trie
+	KdfParams *KdfParams `json:"kdf_params,omitempty"`
+}
+
+// KdfParams are the scrypt parameters deriving session keys from passphrases.
+type KdfParams struct {
+	N int `json:"n"`
+	P int `json:"p"`
+	R int `json:"r"`
+}
+
+// DefaultKdfParams are the scrypt parameters of new access entries.
+var DefaultKdfParams = &KdfParams{N: 262144, P: 1, R: 8}
+
+// NewAccessEntryPassword creates an access entry for passphrase protection.
+func NewAccessEntryPassword(salt []byte, kdfParams *KdfParams) (*AccessEntry, error) {
+	if len(salt) != saltLength {
+		return nil, fmt.Errorf("salt should be %d bytes long", saltLength)
+	}
+	return &AccessEntry{
+		Type:      AccessTypePass,
+		Salt:      salt,
+		KdfParams: kdfParams,
+	}, nil
+}
+
+// NewAccessEntryPK creates an access entry for a single grantee of the publisher.
+func NewAccessEntryPK(publisher string, salt []byte) (*AccessEntry, error) {
+	if len(publisher) != 66 {
+		return nil, fmt.Errorf("publisher should be 66 characters long, got %d", len(publisher))
+	}
+	if len(salt) != saltLength {
+		return nil, fmt.Errorf("salt should be %d bytes long", saltLength)
+	}
+	return &AccessEntry{
+		Type:      AccessTypePK,
+		Publisher: publisher,
+		Salt:      salt,
+	}, nil
+}
+
+// NewAccessEntryACT creates an access entry for the grantees of the access
+// control trie at the given address. The kdf parameters are only needed if some
+// grantees are identified by passphrases.
+func NewAccessEntryACT(publisher string, salt []byte, act string, kdfParams *KdfParams) (*AccessEntry, error) {
+	ae, err := NewAccessEntryPK(publisher, salt)
+	if err != nil {
+		return nil, err
+	}
+	ae.Type = AccessTypeACT
+	ae.Act = act
+	ae.KdfParams = kdfParams
+	return ae, nil
+}
+
+// NewSessionKeyPassword derives the session key of a passphrase for the entry.
+func NewSessionKeyPassword(password string, ae *AccessEntry) ([]byte, error) {
+	if ae.KdfParams == nil {
+		return nil, errors.New("access entry without kdf parameters")
+	}
+	return scrypt.Key([]byte(password), ae.Salt, ae.KdfParams.N, ae.KdfParams.R, ae.KdfParams.P, 32)
+}
+
+// NewSessionKeyPK derives the session key of a key pair from their ECDH shared
+// secret and the salt.
+func NewSessionKeyPK(private *ecdsa.PrivateKey, public *ecdsa.PublicKey, salt []byte) ([]byte, error) {
+	shared, err := ecies.ImportECDSA(private).GenerateShared(ecies.ImportECDSAPublic(public), 16, 16)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(salt, shared), nil
+}
+
+// actKeys derives the lookup key of a grantee in the access control trie and
+// the key encrypting the access key in the grantee's entry.
+func actKeys(sessionKey []byte) (lookupKey, accessKeyKey []byte) {
+	return crypto.Keccak256(sessionKey, []byte{0}), crypto.Keccak256(sessionKey, []byte{1})
+}
+
+// NewSalt generates a random salt for new access entries.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// NewAccessKey generates a random access key.
+func NewAccessKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// GenerateAccessControlManifest creates the root manifest referencing the
+// given content with its reference encrypted with the access key.
+func GenerateAccessControlManifest(ref string, accessKey []byte, ae *AccessEntry) (*Manifest, error) {
+	refBytes, err := hex.DecodeString(ref)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := NewRefEncryption(len(refBytes)).Encrypt(refBytes, accessKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Manifest{
+		Entries: []ManifestEntry{
+			{
+				Hash:        hex.EncodeToString(encrypted),
+				ContentType: ManifestType,
+				ModTime:     time.Now(),
+				Access:      ae,
+			},
+		},
+	}, nil
+}
+
+// ACTGrantee is a grantee of an access control trie, identified either by a
+// public key or by a passphrase.
+type ACTGrantee struct {
+	PublicKey *ecdsa.PublicKey
+	Password  string
+}
+
+// GenerateACT creates an access control trie manifest granting the access key
+// to the given grantees of the publisher, using the salt and kdf parameters of
+// the access entry.
+func GenerateACT(publisher *ecdsa.PrivateKey, ae *AccessEntry, accessKey []byte, grantees []ACTGrantee) (*Manifest, error) {
+	act := new(Manifest)
+	for _, grantee := range grantees {
+		var (
+			sessionKey []byte
+			err        error
+		)
+		if grantee.PublicKey != nil {
+			sessionKey, err = NewSessionKeyPK(publisher, grantee.PublicKey, ae.Salt)
+		} else {
+			sessionKey, err = NewSessionKeyPassword(grantee.Password, ae)
+		}
+		if err != nil {
+			return nil, err
+		}
+		lookupKey, accessKeyKey := actKeys(sessionKey)
+		encryptedAccessKey, err := NewRefEncryption(len(accessKey)).Encrypt(accessKey, accessKeyKey)
+		if err != nil {
+			return nil, err
+		}
+		act.Entries = append(act.Entries, ManifestEntry{
+			Hash:        hex.EncodeToString(encryptedAccessKey),
+			Path:        hex.EncodeToString(lookupKey),
+			ContentType: "application/octet-stream",
+		})
+	}
+	return act, nil
+}
+
+// RevokeACT removes the entries of the given grantees from an access control
+// trie manifest. Revoked grantees who have already read the access key keep
+// access to the content unless it is republished with a new access key.
+func RevokeACT(act *Manifest, publisher *ecdsa.PrivateKey, ae *AccessEntry, grantees []ACTGrantee) (*Manifest, error) {
+	revoked, err := GenerateACT(publisher, ae, make([]byte, 32), grantees)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]bool)
+	for _, entry := range revoked.Entries {
+		paths[entry.Path] = true
+	}
+	updated := new(Manifest)
+	for _, entry := range act.Entries {
+		if !paths[entry.Path] {
+			updated.Entries = append(updated.Entries, entry)
+		}
+	}
+	return updated, nil
+}
+
+// ResolveAccess returns the address of the content protected by the access
+// controlled root manifest at addr, decrypting its reference with the given
+// passphrase or with the node key, if the API was given one. Any other address
+// is returned unchanged. ErrDecrypt is returned if the credentials don't grant
+// access.
+func (a *API) ResolveAccess(ctx context.Context, addr storage.Address, password string) (storage.Address, error) {
+	trie, err := loadManifest(ctx, a.fileStore, addr, nil)
+	if err != nil {
+		return addr, nil // not a manifest
+	}
+	entry := trie.entries[256]
+	if entry == nil || entry.Access == nil {
+		return addr, nil
+	}
+	log.Debug("resolving access controlled manifest", "key", addr, "type", entry.Access.Type)
+
+	accessKey, err := a.accessKey(ctx, entry.Access, password)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := hex.DecodeString(entry.Hash)
+	if err != nil || len(ref) < 8 {
+		return nil, fmt.Errorf("invalid encrypted reference in access controlled manifest %v", addr)
+	}
+	decrypted, err := NewRefEncryption(len(ref)-8).Decrypt(ref, accessKey)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return storage.Address(decrypted), nil
+}
+
+// accessKey derives or looks up the access key of an access entry.
+func (a *API) accessKey(ctx context.Context, ae *AccessEntry, password string) ([]byte, error) {
+	var publisher *ecdsa.PublicKey
+	if ae.Type == AccessTypePK || ae.Type == AccessTypeACT {
+		pub, err := hex.DecodeString(ae.Publisher)
+		if err != nil {
+			return nil, err
+		}
+		if publisher, err = crypto.DecompressPubkey(pub); err != nil {
+			return nil, err
+		}
+	}
+	switch ae.Type {
+	case AccessTypePass:
+		if password == "" {
+			return nil, ErrDecrypt
+		}
+		return NewSessionKeyPassword(password, ae)
+
+	case AccessTypePK:
+		if a.privateKey == nil {
+			return nil, ErrDecrypt
+		}
+		return NewSessionKeyPK(a.privateKey, publisher, ae.Salt)
+
+	case AccessTypeACT:
+		act := storage.Address(common.Hex2Bytes(ae.Act))
+		if a.privateKey != nil {
+			sessionKey, err := NewSessionKeyPK(a.privateKey, publisher, ae.Salt)
+			if err != nil {
+				return nil, err
+			}
+			if key, err := a.lookupACT(ctx, act, sessionKey); err != ErrDecrypt {
+				return key, err
+			}
+		}
+		if password != "" && ae.KdfParams != nil {
+			sessionKey, err := NewSessionKeyPassword(password, ae)
+			if err != nil {
+				return nil, err
+			}
+			return a.lookupACT(ctx, act, sessionKey)
+		}
+		return nil, ErrDecrypt
+	}
+	return nil, ErrUnknownAccessType
+}
+
+// lookupACT retrieves and decrypts the access key of a grantee from the access
+// control trie at the given address.
+func (a *API) lookupACT(ctx context.Context, act storage.Address, sessionKey []byte) ([]byte, error) {
+	trie, err := loadManifest(ctx, a.fileStore, act, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't load access control trie %v: %v", act, err)
+	}
+	lookupKey, accessKeyKey := actKeys(sessionKey)
+	path := hex.EncodeToString(lookupKey)
+	entry, fullpath := trie.getEntry(path)
+	if entry == nil || fullpath != path || entry.ContentType == ManifestType {
+		return nil, ErrDecrypt
+	}
+	return decryptACTEntry(entry.Hash, accessKeyKey)
+}
+
+// ACTAccessKey returns the access key granted to the given session key by an
+// access control trie manifest.
+func ACTAccessKey(act *Manifest, sessionKey []byte) ([]byte, error) {
+	lookupKey, accessKeyKey := actKeys(sessionKey)
+	path := hex.EncodeToString(lookupKey)
+	for _, entry := range act.Entries {
+		if entry.Path == path {
+			return decryptACTEntry(entry.Hash, accessKeyKey)
+		}
+	}
+	return nil, ErrDecrypt
+}
+
+// decryptACTEntry decrypts the access key of an access control trie entry.
+func decryptACTEntry(hash string, accessKeyKey []byte) ([]byte, error) {
+	encrypted, err := hex.DecodeString(hash)
+	if err != nil || len(encrypted) < 8 {
+		return nil, ErrDecrypt
+	}
+	key, err := NewRefEncryption(len(encrypted)-8).Decrypt(encrypted, accessKeyKey)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return key, nil
+}
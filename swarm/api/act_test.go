@@ -0,0 +1,184 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/swarm/storage"
+)
+
+// testKdfParams are cheap scrypt parameters to keep the tests fast.
+var testKdfParams = &KdfParams{N: 16, P: 1, R: 1}
+
+func putManifest(t *testing.T, api *API, m *Manifest) storage.Address {
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.TODO()
+	addr, wait, err := api.Store(ctx, bytes.NewReader(data), int64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func checkAccess(t *testing.T, api *API, root, content storage.Address, password string, want error) {
+	addr, err := api.ResolveAccess(context.TODO(), root, password)
+	if err != want {
+		t.Fatalf("error mismatch (password %q, key %v): have %v, want %v", password, api.privateKey != nil, err, want)
+	}
+	if err == nil && !bytes.Equal(addr, content) {
+		t.Fatalf("address mismatch (password %q, key %v): have %v, want %v", password, api.privateKey != nil, addr, content)
+	}
+}
+
+func TestAccessPassword(t *testing.T) {
+	testAPI(t, func(api *API, toEncrypt bool) {
+		content, _, _ := api.Put(context.TODO(), "secret", "text/plain", toEncrypt)
+		salt, _ := NewSalt()
+		ae, err := NewAccessEntryPassword(salt, testKdfParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accessKey, err := NewSessionKeyPassword("pass", ae)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := GenerateAccessControlManifest(content.Hex(), accessKey, ae)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root := putManifest(t, api, m)
+
+		checkAccess(t, api, root, content, "pass", nil)
+		checkAccess(t, api, root, content, "wrong", ErrDecrypt)
+		api.privateKey, _ = crypto.GenerateKey()
+		checkAccess(t, api, root, content, "", ErrDecrypt)
+		api.privateKey = nil
+
+		// Manifests without access control resolve to themselves
+		checkAccess(t, api, content, content, "", nil)
+	})
+}
+
+func TestAccessPK(t *testing.T) {
+	publisher, _ := crypto.GenerateKey()
+	grantee, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	testAPI(t, func(api *API, toEncrypt bool) {
+		content, _, _ := api.Put(context.TODO(), "secret", "text/plain", toEncrypt)
+		salt, _ := NewSalt()
+		ae, err := NewAccessEntryPK(hex.EncodeToString(crypto.CompressPubkey(&publisher.PublicKey)), salt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accessKey, err := NewSessionKeyPK(publisher, &grantee.PublicKey, salt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := GenerateAccessControlManifest(content.Hex(), accessKey, ae)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root := putManifest(t, api, m)
+
+		api.privateKey = grantee
+		checkAccess(t, api, root, content, "", nil)
+
+		api.privateKey = other
+		checkAccess(t, api, root, content, "", ErrDecrypt)
+
+		// without the node key the content can't be decrypted
+		api.privateKey = nil
+		checkAccess(t, api, root, content, "", ErrDecrypt)
+	})
+}
+
+func TestAccessACT(t *testing.T) {
+	publisher, _ := crypto.GenerateKey()
+	grantee, _ := crypto.GenerateKey()
+	revoked, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	testAPI(t, func(api *API, toEncrypt bool) {
+		content, _, _ := api.Put(context.TODO(), "secret", "text/plain", toEncrypt)
+		salt, _ := NewSalt()
+		ae, err := NewAccessEntryACT(hex.EncodeToString(crypto.CompressPubkey(&publisher.PublicKey)), salt, "", testKdfParams)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accessKey, _ := NewAccessKey()
+		grantees := []ACTGrantee{
+			{PublicKey: &publisher.PublicKey},
+			{PublicKey: &grantee.PublicKey},
+			{PublicKey: &revoked.PublicKey},
+			{Password: "pass"},
+		}
+		act, err := GenerateACT(publisher, ae, accessKey, grantees)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ae.Act = putManifest(t, api, act).Hex()
+		m, err := GenerateAccessControlManifest(content.Hex(), accessKey, ae)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root := putManifest(t, api, m)
+
+		for _, key := range []*ecdsa.PrivateKey{publisher, grantee, revoked} {
+			api.privateKey = key
+			checkAccess(t, api, root, content, "", nil)
+		}
+		api.privateKey = other
+		checkAccess(t, api, root, content, "", ErrDecrypt)
+		checkAccess(t, api, root, content, "pass", nil)
+		checkAccess(t, api, root, content, "wrong", ErrDecrypt)
+
+		// The publisher can read back the access key to manage the grantees
+		sessionKey, _ := NewSessionKeyPK(publisher, &publisher.PublicKey, salt)
+		if key, err := ACTAccessKey(act, sessionKey); err != nil || !bytes.Equal(key, accessKey) {
+			t.Fatalf("publisher access key mismatch: have %x (%v), want %x", key, err, accessKey)
+		}
+		updated, err := RevokeACT(act, publisher, ae, []ACTGrantee{{PublicKey: &revoked.PublicKey}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(updated.Entries) != len(act.Entries)-1 {
+			t.Fatalf("revoked entries mismatch: have %d, want %d", len(act.Entries)-len(updated.Entries), 1)
+		}
+		ae.Act = putManifest(t, api, updated).Hex()
+		m, _ = GenerateAccessControlManifest(content.Hex(), accessKey, ae)
+		root = putManifest(t, api, m)
+
+		api.privateKey = revoked
+		checkAccess(t, api, root, content, "", ErrDecrypt)
+		api.privateKey = grantee
+		checkAccess(t, api, root, content, "", nil)
+	})
+}
@@ -18,11 +18,16 @@ package api
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"context"
+	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/big"
 	"net/http"
+	"os"
 	"path"
 	"strings"
 
@@ -40,7 +45,8 @@ import (
 	"github.com/orangeAndSuns/essentia/swarm/multihash"
 	"github.com/orangeAndSuns/essentia/swarm/spancontext"
 	"github.com/orangeAndSuns/essentia/swarm/storage"
-	"github.com/orangeAndSuns/essentia/swarm/storage/mru"
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed"
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed/lookup"
 )
 
 var (
@@ -61,6 +67,10 @@ var (
 	apiGetTarFail          = metrics.NewRegisteredCounter("api.gettar.fail", nil)
 	apiUploadTarCount      = metrics.NewRegisteredCounter("api.uploadtar.count", nil)
 	apiUploadTarFail       = metrics.NewRegisteredCounter("api.uploadtar.fail", nil)
+	apiGetZipCount         = metrics.NewRegisteredCounter("api.getzip.count", nil)
+	apiGetZipFail          = metrics.NewRegisteredCounter("api.getzip.fail", nil)
+	apiUploadZipCount      = metrics.NewRegisteredCounter("api.uploadzip.count", nil)
+	apiUploadZipFail       = metrics.NewRegisteredCounter("api.uploadzip.fail", nil)
 	apiModifyCount         = metrics.NewRegisteredCounter("api.modify.count", nil)
 	apiModifyFail          = metrics.NewRegisteredCounter("api.modify.fail", nil)
 	apiAddFileCount        = metrics.NewRegisteredCounter("api.addfile.count", nil)
@@ -224,17 +234,19 @@ on top of the FileStore
 it is the public interface of the FileStore which is included in the essentia stack
 */
 type API struct {
-	resource  *mru.Handler
-	fileStore *storage.FileStore
-	dns       Resolver
+	feed       *feed.Handler
+	fileStore  *storage.FileStore
+	dns        Resolver
+	privateKey *ecdsa.PrivateKey // decrypts access controlled content granted to the node
 }
 
 // NewAPI the api constructor initialises a new API instance.
-func NewAPI(fileStore *storage.FileStore, dns Resolver, resourceHandler *mru.Handler) (self *API) {
+func NewAPI(fileStore *storage.FileStore, dns Resolver, feedHandler *feed.Handler, privateKey *ecdsa.PrivateKey) (self *API) {
 	self = &API{
-		fileStore: fileStore,
-		dns:       dns,
-		resource:  resourceHandler,
+		fileStore:  fileStore,
+		dns:        dns,
+		feed:       feedHandler,
+		privateKey: privateKey,
 	}
 	return
 }
@@ -348,62 +360,63 @@ func (a *API) Get(ctx context.Context, manifestAddr storage.Address, path string
 
 	if entry != nil {
 		log.Debug("trie got entry", "key", manifestAddr, "path", path, "entry.Hash", entry.Hash)
-		// we need to do some extra work if this is a mutable resource manifest
 		if entry.ContentType == ResourceContentType {
+			apiGetInvalid.Inc(1)
+			status = http.StatusGone
+			return reader, mimeType, status, nil, ErrResourceRemoved
+		}
+		// we need to do some extra work if this is a Swarm feed manifest
+		if entry.ContentType == FeedContentType {
+			if entry.Feed == nil {
+				apiGetInvalid.Inc(1)
+				status = http.StatusUnprocessableEntity
+				err = fmt.Errorf("feed manifest %s does not describe a feed", manifestAddr)
+				return reader, mimeType, status, nil, err
+			}
 
-			// get the resource rootAddr
-			log.Trace("resource type", "menifestAddr", manifestAddr, "hash", entry.Hash)
+			log.Trace("feed type", "menifestAddr", manifestAddr, "topic", entry.Feed.Topic.Hex(), "user", entry.Feed.User)
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			rootAddr := storage.Address(common.FromHex(entry.Hash))
-			rsrc, err := a.resource.Load(ctx, rootAddr)
-			if err != nil {
-				apiGetNotFound.Inc(1)
-				status = http.StatusNotFound
-				log.Debug(fmt.Sprintf("get resource content error: %v", err))
-				return reader, mimeType, status, nil, err
-			}
 
-			// use this key to retrieve the latest update
-			params := mru.LookupLatest(rootAddr)
-			rsrc, err = a.resource.Lookup(ctx, params)
+			// retrieve the latest update
+			feedUpdate, err := a.feed.Lookup(ctx, feed.NewQueryLatest(entry.Feed, lookup.NoClue))
 			if err != nil {
 				apiGetNotFound.Inc(1)
 				status = http.StatusNotFound
-				log.Debug(fmt.Sprintf("get resource content error: %v", err))
+				log.Debug(fmt.Sprintf("get feed update content error: %v", err))
 				return reader, mimeType, status, nil, err
 			}
 
 			// if it's multihash, we will transparently serve the content this multihash points to
 			// \TODO this resolve is rather expensive all in all, review to see if it can be achieved cheaper
-			if rsrc.Multihash() {
+			if feedUpdate.Multihash() {
 
 				// get the data of the update
-				_, rsrcData, err := a.resource.GetContent(rootAddr)
+				_, feedData, err := a.feed.GetContent(entry.Feed)
 				if err != nil {
 					apiGetNotFound.Inc(1)
 					status = http.StatusNotFound
-					log.Warn(fmt.Sprintf("get resource content error: %v", err))
+					log.Warn(fmt.Sprintf("get feed update content error: %v", err))
 					return reader, mimeType, status, nil, err
 				}
 
 				// validate that data as multihash
-				decodedMultihash, err := multihash.FromMultihash(rsrcData)
+				decodedMultihash, err := multihash.FromMultihash(feedData)
 				if err != nil {
 					apiGetInvalid.Inc(1)
 					status = http.StatusUnprocessableEntity
-					log.Warn("invalid resource multihash", "err", err)
+					log.Warn("invalid feed update multihash", "err", err)
 					return reader, mimeType, status, nil, err
 				}
 				manifestAddr = storage.Address(decodedMultihash)
-				log.Trace("resource is multihash", "key", manifestAddr)
+				log.Trace("feed update is multihash", "key", manifestAddr)
 
 				// get the manifest the multihash digest points to
 				trie, err := loadManifest(ctx, a.fileStore, manifestAddr, nil)
 				if err != nil {
 					apiGetNotFound.Inc(1)
 					status = http.StatusNotFound
-					log.Warn(fmt.Sprintf("loadManifestTrie (resource multihash) error: %v", err))
+					log.Warn(fmt.Sprintf("loadManifestTrie (feed update multihash) error: %v", err))
 					return reader, mimeType, status, nil, err
 				}
 
@@ -413,18 +426,18 @@ func (a *API) Get(ctx context.Context, manifestAddr storage.Address, path string
 				if entry == nil {
 					status = http.StatusNotFound
 					apiGetNotFound.Inc(1)
-					err = fmt.Errorf("manifest (resource multihash) entry for '%s' not found", path)
-					log.Trace("manifest (resource multihash) entry not found", "key", manifestAddr, "path", path)
+					err = fmt.Errorf("manifest (feed update multihash) entry for '%s' not found", path)
+					log.Trace("manifest (feed update multihash) entry not found", "key", manifestAddr, "path", path)
 					return reader, mimeType, status, nil, err
 				}
 
 			} else {
 				// data is returned verbatim since it's not a multihash
-				return rsrc, "application/octet-stream", http.StatusOK, nil, nil
+				return feedUpdate, "application/octet-stream", http.StatusOK, nil, nil
 			}
 		}
 
-		// regardless of resource update manifests or normal manifests we will converge at this point
+		// regardless of feed update manifests or normal manifests we will converge at this point
 		// get the key the manifest entry points to and serve it if it's unambiguous
 		contentAddr = common.Hex2Bytes(entry.Hash)
 		status = entry.Status
@@ -537,6 +550,75 @@ func (a *API) GetDirectoryTar(ctx context.Context, uri *URI) (io.ReadCloser, err
 	return piper, nil
 }
 
+// GetDirectoryZip fetches a requested directory as a zip archive stream
+// it returns an io.Reader and an error. Do not forget to Close() the returned ReadCloser
+func (a *API) GetDirectoryZip(ctx context.Context, uri *URI) (io.ReadCloser, error) {
+	apiGetZipCount.Inc(1)
+	addr, err := a.Resolve(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	walker, err := a.NewManifestWalker(ctx, addr, nil)
+	if err != nil {
+		apiGetZipFail.Inc(1)
+		return nil, err
+	}
+
+	piper, pipew := io.Pipe()
+
+	zw := zip.NewWriter(pipew)
+
+	go func() {
+		err := walker.Walk(func(entry *ManifestEntry) error {
+			// ignore manifests (walk will recurse into them)
+			if entry.ContentType == ManifestType {
+				return nil
+			}
+
+			// retrieve the entry's key and size
+			reader, _ := a.Retrieve(ctx, storage.Address(common.Hex2Bytes(entry.Hash)))
+			size, err := reader.Size(ctx, nil)
+			if err != nil {
+				return err
+			}
+
+			// write a zip header for the entry
+			hdr := &zip.FileHeader{
+				Name:     entry.Path,
+				Method:   zip.Deflate,
+				Modified: entry.ModTime,
+			}
+			hdr.SetMode(os.FileMode(entry.Mode))
+			fw, err := zw.CreateHeader(hdr)
+			if err != nil {
+				return err
+			}
+
+			// copy the file into the zip stream
+			n, err := io.Copy(fw, io.LimitReader(reader, size))
+			if err != nil {
+				return err
+			} else if n != size {
+				return fmt.Errorf("error writing %s: expected %d bytes but sent %d", entry.Path, size, n)
+			}
+
+			return nil
+		})
+		if err == nil {
+			// write the central directory
+			err = zw.Close()
+		}
+		if err != nil {
+			apiGetZipFail.Inc(1)
+			pipew.CloseWithError(err)
+		} else {
+			pipew.Close()
+		}
+	}()
+
+	return piper, nil
+}
+
 // GetManifestList lists the manifest entries for the specified address and prefix
 // and returns it as a ManifestList
 func (a *API) GetManifestList(ctx context.Context, addr storage.Address, prefix string) (list ManifestList, err error) {
@@ -738,6 +820,60 @@ func (a *API) UploadTar(ctx context.Context, bodyReader io.ReadCloser, manifestP
 	return contentKey, nil
 }
 
+// UploadZip adds the regular files of a zip archive to the manifest under
+// manifestPath, with content types guessed from the file extensions. The
+// archive is buffered to a temporary file as its central directory comes last.
+func (a *API) UploadZip(ctx context.Context, bodyReader io.ReadCloser, manifestPath string, mw *ManifestWriter) (storage.Address, error) {
+	apiUploadZipCount.Inc(1)
+	defer bodyReader.Close()
+	tmp, err := ioutil.TempFile("", "swarm-zip")
+	if err != nil {
+		apiUploadZipFail.Inc(1)
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, bodyReader)
+	if err != nil {
+		apiUploadZipFail.Inc(1)
+		return nil, fmt.Errorf("error buffering zip archive: %s", err)
+	}
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		apiUploadZipFail.Inc(1)
+		return nil, fmt.Errorf("error reading zip archive: %s", err)
+	}
+
+	var contentKey storage.Address
+	for _, f := range zr.File {
+		// only store regular files
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			apiUploadZipFail.Inc(1)
+			return nil, fmt.Errorf("error reading zip archive: %s", err)
+		}
+
+		// add the entry under the path from the request
+		entry := &ManifestEntry{
+			Path:        path.Join(manifestPath, f.Name),
+			ContentType: mime.TypeByExtension(path.Ext(f.Name)),
+			Mode:        int64(f.Mode().Perm()),
+			Size:        int64(f.UncompressedSize64),
+			ModTime:     f.Modified,
+		}
+		contentKey, err = mw.AddEntry(ctx, rc, entry)
+		rc.Close()
+		if err != nil {
+			apiUploadZipFail.Inc(1)
+			return nil, fmt.Errorf("error adding manifest entry from zip archive: %s", err)
+		}
+	}
+	return contentKey, nil
+}
+
 // RemoveFile removes a file entry in a manifest.
 func (a *API) RemoveFile(ctx context.Context, mhash string, path string, fname string, nameresolver bool) (string, error) {
 	apiRmFileCount.Inc(1)
@@ -890,57 +1026,91 @@ func (a *API) BuildDirectoryTree(ctx context.Context, mhash string, nameresolver
 	return addr, manifestEntryMap, nil
 }
 
-// ResourceLookup finds mutable resource updates at specific periods and versions
-func (a *API) ResourceLookup(ctx context.Context, params *mru.LookupParams) (string, []byte, error) {
-	var err error
-	rsrc, err := a.resource.Load(ctx, params.RootAddr())
-	if err != nil {
-		return "", nil, err
-	}
-	_, err = a.resource.Lookup(ctx, params)
-	if err != nil {
-		return "", nil, err
-	}
-	var data []byte
-	_, data, err = a.resource.GetContent(params.RootAddr())
+// FeedsLookup finds Swarm feed updates at specific points in time, or the latest update
+func (a *API) FeedsLookup(ctx context.Context, query *feed.Query) ([]byte, error) {
+	feedUpdate, err := a.feed.Lookup(ctx, query)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
-	return rsrc.Name(), data, nil
+	return feedUpdate.Data(), nil
 }
 
-// Create Mutable resource
-func (a *API) ResourceCreate(ctx context.Context, request *mru.Request) error {
-	return a.resource.New(ctx, request)
+// FeedsNewRequest creates a Request object to update a specific feed
+func (a *API) FeedsNewRequest(ctx context.Context, feed *feed.Feed) (*feed.Request, error) {
+	return a.feed.NewRequest(ctx, feed)
 }
 
-// ResourceNewRequest creates a Request object to update a specific mutable resource
-func (a *API) ResourceNewRequest(ctx context.Context, rootAddr storage.Address) (*mru.Request, error) {
-	return a.resource.NewUpdateRequest(ctx, rootAddr)
+// FeedsUpdate publishes a new update on the given feed
+func (a *API) FeedsUpdate(ctx context.Context, request *feed.Request) (storage.Address, error) {
+	return a.feed.Update(ctx, request)
 }
 
-// ResourceUpdate updates a Mutable Resource with arbitrary data.
-// Upon retrieval the update will be retrieved verbatim as bytes.
-func (a *API) ResourceUpdate(ctx context.Context, request *mru.SignedResourceUpdate) (storage.Address, error) {
-	return a.resource.Update(ctx, request)
+// FeedsHashSize returned the size of the digest produced by Swarm feeds' hashing function
+func (a *API) FeedsHashSize() int {
+	return a.feed.HashSize
 }
 
-// ResourceHashSize returned the size of the digest produced by the Mutable Resource hashing function
-func (a *API) ResourceHashSize() int {
-	return a.resource.HashSize
-}
+// ErrCannotLoadFeedManifest is returned when looking up a feeds manifest fails
+var ErrCannotLoadFeedManifest = errors.New("Cannot load feed manifest")
+
+// ErrNotAFeedManifest is returned when the address provided returned something other than a valid manifest
+var ErrNotAFeedManifest = errors.New("Not a feed manifest")
 
-// ResolveResourceManifest retrieves the Mutable Resource manifest for the given address, and returns the address of the metadata chunk.
-func (a *API) ResolveResourceManifest(ctx context.Context, addr storage.Address) (storage.Address, error) {
+// ErrResourceRemoved is returned when resolving a mutable resource, since they were replaced by feeds
+var ErrResourceRemoved = errors.New("Mutable resources were replaced by feeds, use bzz-feed")
+
+// ResolveFeedManifest retrieves the Swarm feed manifest for the given address, and returns the referenced Feed.
+func (a *API) ResolveFeedManifest(ctx context.Context, addr storage.Address) (*feed.Feed, error) {
 	trie, err := loadManifest(ctx, a.fileStore, addr, nil)
 	if err != nil {
-		return nil, fmt.Errorf("cannot load resource manifest: %v", err)
+		return nil, ErrCannotLoadFeedManifest
 	}
 
 	entry, _ := trie.getEntry("")
-	if entry.ContentType != ResourceContentType {
-		return nil, fmt.Errorf("not a resource manifest: %s", addr)
+	if entry != nil && entry.ContentType == ResourceContentType {
+		return nil, ErrResourceRemoved
+	}
+	if entry == nil || entry.ContentType != FeedContentType || entry.Feed == nil {
+		return nil, ErrNotAFeedManifest
 	}
 
-	return storage.Address(common.FromHex(entry.Hash)), nil
+	return entry.Feed, nil
+}
+
+// ErrCannotResolveFeedURI is returned when the ENS resolver is not able to translate a name to a Swarm feed
+var ErrCannotResolveFeedURI = errors.New("Cannot resolve Feed URI")
+
+// ErrCannotResolveFeed is returned when values provided are not enough or invalid to recreate a
+// feed out of them.
+var ErrCannotResolveFeed = errors.New("Cannot resolve Feed")
+
+// ResolveFeed attempts to extract feed information out of the manifest, if provided
+// If not, it attempts to extract the feed out of a set of key-value pairs
+func (a *API) ResolveFeed(ctx context.Context, uri *URI, values feed.Values) (*feed.Feed, error) {
+	var fd *feed.Feed
+	var err error
+	if uri.Addr != "" {
+		// resolve the content key.
+		manifestAddr := uri.Address()
+		if manifestAddr == nil {
+			manifestAddr, err = a.Resolve(ctx, uri)
+			if err != nil {
+				return nil, ErrCannotResolveFeedURI
+			}
+		}
+
+		// get the Swarm feed from the manifest
+		fd, err = a.ResolveFeedManifest(ctx, manifestAddr)
+		if err != nil {
+			return nil, err
+		}
+		log.Debug("handle.get.feed: resolved", "manifestkey", manifestAddr, "feed", fd.Hex())
+	} else {
+		var f feed.Feed
+		if err := f.FromValues(values); err != nil {
+			return nil, ErrCannotResolveFeed
+		}
+		fd = &f
+	}
+	return fd, nil
 }
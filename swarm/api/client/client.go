@@ -28,6 +28,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -35,7 +36,8 @@ import (
 	"strings"
 
 	"github.com/orangeAndSuns/essentia/swarm/api"
-	"github.com/orangeAndSuns/essentia/swarm/storage/mru"
+	"github.com/orangeAndSuns/essentia/swarm/storage"
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed"
 )
 
 var (
@@ -52,6 +54,21 @@ func NewClient(gateway string) *Client {
 // Client wraps interaction with a swarm HTTP gateway.
 type Client struct {
 	Gateway string
+
+	// Tag is the uid of the tag counting the chunks of the uploads made
+	// with the client (see CreateTag). If zero, the gateway creates a new
+	// tag for every upload.
+	Tag uint32
+}
+
+// swarmTagHeaderName is the header carrying the uid of the tag of an upload
+const swarmTagHeaderName = "X-Swarm-Tag"
+
+// setTag sets the tag header of an upload request if the client has a tag
+func (c *Client) setTag(req *http.Request) {
+	if c.Tag != 0 {
+		req.Header.Set(swarmTagHeaderName, strconv.FormatUint(uint64(c.Tag), 10))
+	}
 }
 
 // UploadRaw uploads raw data to swarm and returns the resulting hash. If toEncrypt is true it
@@ -69,6 +86,7 @@ func (c *Client) UploadRaw(r io.Reader, size int64, toEncrypt bool) (string, err
 		return "", err
 	}
 	req.ContentLength = size
+	c.setTag(req)
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "", err
@@ -458,6 +476,7 @@ func (c *Client) TarUpload(hash string, uploader Uploader, toEncrypt bool) (stri
 		return "", err
 	}
 	req.Header.Set("Content-Type", "application/x-tar")
+	c.setTag(req)
 
 	// use 'Expect: 100-continue' so we don't send the request body if
 	// the server refuses the request
@@ -524,6 +543,7 @@ func (c *Client) MultipartUpload(hash string, uploader Uploader) (string, error)
 
 	mw := multipart.NewWriter(reqW)
 	req.Header.Set("Content-Type", fmt.Sprintf("multipart/form-data; boundary=%q", mw.Boundary()))
+	c.setTag(req)
 
 	// define an UploadFn which adds files to the multipart form
 	uploadFn := func(file *File) error {
@@ -564,13 +584,15 @@ func (c *Client) MultipartUpload(hash string, uploader Uploader) (string, error)
 	return string(data), nil
 }
 
-// CreateResource creates a Mutable Resource with the given name and frequency, initializing it with the provided
-// data. Data is interpreted as multihash or not depending on the multihash parameter.
-// startTime=0 means "now"
-// Returns the resulting Mutable Resource manifest address that you can use to include in an ENS Resolver (setContent)
-// or reference future updates (Client.UpdateResource)
-func (c *Client) CreateResource(request *mru.Request) (string, error) {
-	responseStream, err := c.updateResource(request)
+// ErrNoFeedUpdatesFound is returned when Swarm cannot find updates of the given feed
+var ErrNoFeedUpdatesFound = errors.New("No updates found for this feed")
+
+// CreateFeedWithManifest creates a feed manifest, initializing it with the provided
+// data
+// Returns the resulting feed manifest address that you can use to include in an ENS Resolver (setContent)
+// or reference future updates (Client.UpdateFeed)
+func (c *Client) CreateFeedWithManifest(request *feed.Request) (string, error) {
+	responseStream, err := c.updateFeed(request, true)
 	if err != nil {
 		return "", err
 	}
@@ -588,19 +610,26 @@ func (c *Client) CreateResource(request *mru.Request) (string, error) {
 	return manifestAddress, nil
 }
 
-// UpdateResource allows you to set a new version of your content
-func (c *Client) UpdateResource(request *mru.Request) error {
-	_, err := c.updateResource(request)
-	return err
+// UpdateFeed allows you to set a new version of your content
+func (c *Client) UpdateFeed(request *feed.Request) error {
+	responseStream, err := c.updateFeed(request, false)
+	if err != nil {
+		return err
+	}
+	return responseStream.Close()
 }
 
-func (c *Client) updateResource(request *mru.Request) (io.ReadCloser, error) {
+func (c *Client) updateFeed(request *feed.Request, createManifest bool) (io.ReadCloser, error) {
 	body, err := request.MarshalJSON()
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", c.Gateway+"/bzz-resource:/", bytes.NewBuffer(body))
+	uri := c.Gateway + "/bzz-feed:/"
+	if createManifest {
+		uri += "?manifest=true"
+	}
+	req, err := http.NewRequest("POST", uri, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
@@ -609,30 +638,68 @@ func (c *Client) updateResource(request *mru.Request) (io.ReadCloser, error) {
 	if err != nil {
 		return nil, err
 	}
-
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		msg, _ := ioutil.ReadAll(res.Body)
+		return nil, fmt.Errorf("unexpected HTTP status: %s: %s", res.Status, msg)
+	}
 	return res.Body, nil
-
 }
 
-// GetResource returns a byte stream with the raw content of the resource
-// manifestAddressOrDomain is the address you obtained in CreateResource or an ENS domain whose Resolver
+// QueryFeed returns a byte stream with the raw content of the feed update
+// manifestAddressOrDomain is the address you obtained in CreateFeedWithManifest or an ENS domain whose Resolver
 // points to that address
-func (c *Client) GetResource(manifestAddressOrDomain string) (io.ReadCloser, error) {
+func (c *Client) QueryFeed(query *feed.Query, manifestAddressOrDomain string) (io.ReadCloser, error) {
+	return c.queryFeed(query, manifestAddressOrDomain, false)
+}
 
-	res, err := http.Get(c.Gateway + "/bzz-resource:/" + manifestAddressOrDomain)
+// queryFeed returns a byte stream with the raw content of the feed update
+// manifestAddressOrDomain is the address you obtained in CreateFeedWithManifest or an ENS domain whose Resolver
+// points to that address
+// meta set to true will instruct the node return feed metainformation instead
+func (c *Client) queryFeed(query *feed.Query, manifestAddressOrDomain string, meta bool) (io.ReadCloser, error) {
+	URL, err := url.Parse(c.Gateway)
 	if err != nil {
 		return nil, err
 	}
-	return res.Body, nil
+	URL.Path = "/bzz-feed:/" + manifestAddressOrDomain
+	values := URL.Query()
+	if query != nil {
+		query.AppendValues(values) //adds query parameters
+	}
+	if meta {
+		values.Set("meta", "true")
+	}
+	URL.RawQuery = values.Encode()
+	res, err := http.Get(URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		if res.StatusCode == http.StatusNotFound {
+			return nil, ErrNoFeedUpdatesFound
+		}
+		errorMessageBytes, err := ioutil.ReadAll(res.Body)
+		var errorMessage string
+		if err != nil {
+			errorMessage = "cannot retrieve error message: " + err.Error()
+		} else {
+			errorMessage = string(errorMessageBytes)
+		}
+		return nil, fmt.Errorf("Error retrieving feed updates: %s", errorMessage)
+	}
 
+	return res.Body, nil
 }
 
-// GetResourceMetadata returns a structure that describes the Mutable Resource
-// manifestAddressOrDomain is the address you obtained in CreateResource or an ENS domain whose Resolver
+// GetFeedRequest returns a structure that describes the referenced feed status
+// manifestAddressOrDomain is the address you obtained in CreateFeedWithManifest or an ENS domain whose Resolver
 // points to that address
-func (c *Client) GetResourceMetadata(manifestAddressOrDomain string) (*mru.Request, error) {
+func (c *Client) GetFeedRequest(query *feed.Query, manifestAddressOrDomain string) (*feed.Request, error) {
 
-	responseStream, err := c.GetResource(manifestAddressOrDomain + "/meta")
+	responseStream, err := c.queryFeed(query, manifestAddressOrDomain, true)
 	if err != nil {
 		return nil, err
 	}
@@ -643,9 +710,95 @@ func (c *Client) GetResourceMetadata(manifestAddressOrDomain string) (*mru.Reque
 		return nil, err
 	}
 
-	var metadata mru.Request
+	var metadata feed.Request
 	if err := metadata.UnmarshalJSON(body); err != nil {
 		return nil, err
 	}
 	return &metadata, nil
 }
+
+// Pin pins the content at the given hash in the local store of the node, so
+// that it is never garbage collected. Unless raw is set, the hash is a
+// manifest whose entries are pinned along with it.
+func (c *Client) Pin(hash string, raw bool) error {
+	uri := c.Gateway + "/bzz-pin:/" + hash
+	if raw {
+		uri += "?raw=true"
+	}
+	return c.pinRequest("POST", uri)
+}
+
+// Unpin unpins the content at the given hash.
+func (c *Client) Unpin(hash string) error {
+	return c.pinRequest("DELETE", c.Gateway+"/bzz-pin:/"+hash)
+}
+
+func (c *Client) pinRequest(method, uri string) error {
+	req, err := http.NewRequest(method, uri, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected HTTP status: %s", res.Status)
+	}
+	return nil
+}
+
+// PinnedRoots returns the content pinned in the local store of the node.
+func (c *Client) PinnedRoots() ([]*storage.PinnedRoot, error) {
+	res, err := http.DefaultClient.Get(c.Gateway + "/bzz-pin:/")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status: %s", res.Status)
+	}
+	var roots []*storage.PinnedRoot
+	if err := json.NewDecoder(res.Body).Decode(&roots); err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
+// CreateTag creates a tag with the given name on the node. Setting the Tag
+// of the client to the uid of the tag makes the uploads of the client
+// counted by it.
+func (c *Client) CreateTag(name string) (*storage.TagStatus, error) {
+	res, err := http.DefaultClient.Post(c.Gateway+"/bzz-tag:/?name="+url.QueryEscape(name), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status: %s", res.Status)
+	}
+	status := new(storage.TagStatus)
+	if err := json.NewDecoder(res.Body).Decode(status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// TagStatus returns the status of the tag with the given uid, counting the
+// chunks of an upload as they are stored and synced.
+func (c *Client) TagStatus(uid uint32) (*storage.TagStatus, error) {
+	res, err := http.DefaultClient.Get(fmt.Sprintf("%s/bzz-tag:/%d", c.Gateway, uid))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status: %s", res.Status)
+	}
+	status := new(storage.TagStatus)
+	if err := json.NewDecoder(res.Body).Decode(status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
@@ -18,6 +18,7 @@ package client
 
 import (
 	"bytes"
+	"crypto/rand"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -30,7 +31,8 @@ import (
 	"github.com/orangeAndSuns/essentia/swarm/api"
 	swarmhttp "github.com/orangeAndSuns/essentia/swarm/api/http"
 	"github.com/orangeAndSuns/essentia/swarm/multihash"
-	"github.com/orangeAndSuns/essentia/swarm/storage/mru"
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed"
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed/lookup"
 	"github.com/orangeAndSuns/essentia/swarm/testutil"
 )
 
@@ -242,7 +244,128 @@ func TestClientUploadDownloadDirectory(t *testing.T) {
 	}
 }
 
+// TestClientPin tests pinning and unpinning content
+func TestClientPin(t *testing.T) {
+	testClientPin(false, t)
+}
+func TestClientPinEncrypted(t *testing.T) {
+	testClientPin(true, t)
+}
+
+func testClientPin(toEncrypt bool, t *testing.T) {
+	srv := testutil.NewTestSwarmServer(t, serverFunc)
+	defer srv.Close()
+
+	dir := newTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	client := NewClient(srv.URL)
+	manifest, err := client.UploadDirectory(dir, "", "", toEncrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// three data chunks under a root chunk
+	data := make([]byte, 10000)
+	rand.Read(data)
+	raw, err := client.UploadRaw(bytes.NewReader(data), int64(len(data)), toEncrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Pin(manifest, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Pin(raw, true); err != nil {
+		t.Fatal(err)
+	}
+	roots, err := client.PinnedRoots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 2 {
+		t.Fatalf("expected 2 pinned roots, got %d", len(roots))
+	}
+	for _, root := range roots {
+		switch root.Addr.Hex() {
+		case manifest:
+			if root.Raw || root.Chunks < uint64(len(testDirFiles)+1) {
+				t.Fatalf("manifest pinned with raw %v and %d chunks", root.Raw, root.Chunks)
+			}
+		case raw:
+			if !root.Raw || root.Chunks != 4 {
+				t.Fatalf("raw content pinned with raw %v and %d chunks", root.Raw, root.Chunks)
+			}
+		default:
+			t.Fatalf("unexpected pinned root %s", root.Addr)
+		}
+	}
+
+	if err := client.Unpin(manifest); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Unpin(manifest); err == nil {
+		t.Fatal("expected error unpinning content which is not pinned")
+	}
+	if roots, err = client.PinnedRoots(); err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 || roots[0].Addr.Hex() != raw {
+		t.Fatalf("expected only the raw content to be pinned, got %v", roots)
+	}
+}
+
 // TestClientFileList tests listing files in a swarm manifest
+// TestClientTags checks that the uploads made by a client with a tag are
+// counted by the tag.
+func TestClientTags(t *testing.T) {
+	srv := testutil.NewTestSwarmServer(t, serverFunc)
+	defer srv.Close()
+
+	dir := newTestDirectory(t)
+	defer os.RemoveAll(dir)
+
+	client := NewClient(srv.URL)
+	tag, err := client.CreateTag("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Tag = tag.Uid
+
+	// three data chunks under a root chunk
+	data := make([]byte, 10000)
+	rand.Read(data)
+	if _, err := client.UploadRaw(bytes.NewReader(data), int64(len(data)), false); err != nil {
+		t.Fatal(err)
+	}
+	status, err := client.TagStatus(tag.Uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Name != "test" || status.Total != 4 || status.Stored > status.Total {
+		t.Fatalf("unexpected tag status %+v", status)
+	}
+
+	// further uploads add up
+	manifest, err := client.UploadDirectory(dir, "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err = client.TagStatus(tag.Uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Total <= 4 || status.Split != status.Total {
+		t.Fatalf("unexpected tag status %+v", status)
+	}
+	if status.Address.Hex() != manifest {
+		t.Fatalf("expected tag address %s, got %s", manifest, status.Address.Hex())
+	}
+
+	if _, err := client.TagStatus(tag.Uid + 1); err == nil {
+		t.Fatal("expected getting an unknown tag to fail")
+	}
+}
+
 func TestClientFileList(t *testing.T) {
 	testClientFileList(false, t)
 }
@@ -359,20 +482,20 @@ func TestClientMultipartUpload(t *testing.T) {
 	}
 }
 
-func newTestSigner() (*mru.GenericSigner, error) {
+func newTestSigner() (*feed.GenericSigner, error) {
 	privKey, err := crypto.HexToECDSA("deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef")
 	if err != nil {
 		return nil, err
 	}
-	return mru.NewGenericSigner(privKey), nil
+	return feed.NewGenericSigner(privKey), nil
 }
 
-// test the transparent resolving of multihash resource types with bzz:// scheme
+// test the transparent resolving of multihash feed updates with bzz:// scheme
 //
-// first upload data, and store the multihash to the resulting manifest in a resource update
+// first upload data, and store the multihash to the resulting manifest in a feed update
 // retrieving the update with the multihash should return the manifest pointing directly to the data
 // and raw retrieve of that hash should return the data
-func TestClientCreateResourceMultihash(t *testing.T) {
+func TestClientCreateFeedMultihash(t *testing.T) {
 
 	signer, _ := newTestSigner()
 
@@ -391,37 +514,35 @@ func TestClientCreateResourceMultihash(t *testing.T) {
 	s := common.FromHex(swarmHash)
 	mh := multihash.ToMultihash(s)
 
-	// our mutable resource "name"
-	resourceName := "foo.ess"
+	// our feed topic
+	topic, _ := feed.NewTopic("foo.ess", nil)
 
-	createRequest, err := mru.NewCreateUpdateRequest(&mru.ResourceMetadata{
-		Name:      resourceName,
-		Frequency: 13,
-		StartTime: srv.GetCurrentTime(),
-		Owner:     signer.Address(),
-	})
-	if err != nil {
-		t.Fatal(err)
-	}
+	createRequest := feed.NewFirstRequest(topic)
 	createRequest.SetData(mh, true)
 	if err := createRequest.Sign(signer); err != nil {
 		t.Fatalf("Error signing update: %s", err)
 	}
 
-	resourceManifestHash, err := client.CreateResource(createRequest)
+	feedManifestHash, err := client.CreateFeedWithManifest(createRequest)
 
 	if err != nil {
-		t.Fatalf("Error creating resource: %s", err)
+		t.Fatalf("Error creating feed manifest: %s", err)
+	}
+
+	correctManifestAddrHex := "a9c14670798931beaf718f16c48ea3d303c3e0b47341810063d87923fa912152"
+	if feedManifestHash != correctManifestAddrHex {
+		t.Fatalf("Response feed manifest mismatch, expected '%s', got '%s'", correctManifestAddrHex, feedManifestHash)
 	}
 
-	correctManifestAddrHex := "6d3bc4664c97d8b821cb74bcae43f592494fb46d2d9cd31e69f3c7c802bbbd8e"
-	if resourceManifestHash != correctManifestAddrHex {
-		t.Fatalf("Response resource key mismatch, expected '%s', got '%s'", correctManifestAddrHex, resourceManifestHash)
+	// Check we get a not found error when trying to get feed updates with a made-up manifest
+	_, err = client.QueryFeed(nil, "bee2d3a0b0a1c6e2b8f0ba2d3b3a4c1e0d5d0a3e2f1c0b9a8f7e6d5c4b3a2910")
+	if err != ErrNoFeedUpdatesFound {
+		t.Fatalf("Expected to receive ErrNoFeedUpdatesFound error. Got: %s", err)
 	}
 
-	reader, err := client.GetResource(correctManifestAddrHex)
+	reader, err := client.QueryFeed(nil, correctManifestAddrHex)
 	if err != nil {
-		t.Fatalf("Error retrieving resource: %s", err)
+		t.Fatalf("Error retrieving feed updates: %s", err)
 	}
 	defer reader.Close()
 	gotData, err := ioutil.ReadAll(reader)
@@ -434,8 +555,8 @@ func TestClientCreateResourceMultihash(t *testing.T) {
 
 }
 
-// TestClientCreateUpdateResource will check that mutable resources can be created and updated via the HTTP client.
-func TestClientCreateUpdateResource(t *testing.T) {
+// TestClientCreateUpdateFeed will check that feeds can be created and updated via the HTTP client.
+func TestClientCreateUpdateFeed(t *testing.T) {
 
 	signer, _ := newTestSigner()
 
@@ -443,36 +564,31 @@ func TestClientCreateUpdateResource(t *testing.T) {
 	client := NewClient(srv.URL)
 	defer srv.Close()
 
-	// set raw data for the resource
+	// set raw data for the feed update
 	databytes := []byte("En un lugar de La Mancha, de cuyo nombre no quiero acordarme...")
 
-	// our mutable resource name
-	resourceName := "El Quijote"
+	// our feed topic name
+	topic, _ := feed.NewTopic("El Quijote", nil)
+	createRequest := feed.NewFirstRequest(topic)
 
-	createRequest, err := mru.NewCreateUpdateRequest(&mru.ResourceMetadata{
-		Name:      resourceName,
-		Frequency: 13,
-		StartTime: srv.GetCurrentTime(),
-		Owner:     signer.Address(),
-	})
-	if err != nil {
-		t.Fatal(err)
-	}
 	createRequest.SetData(databytes, false)
 	if err := createRequest.Sign(signer); err != nil {
 		t.Fatalf("Error signing update: %s", err)
 	}
 
-	resourceManifestHash, err := client.CreateResource(createRequest)
+	feedManifestHash, err := client.CreateFeedWithManifest(createRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	correctManifestAddrHex := "cc7904c17b49f9679e2d8006fe25e87e3f5c2072c2b49cab50f15e544471b30a"
-	if resourceManifestHash != correctManifestAddrHex {
-		t.Fatalf("Response resource key mismatch, expected '%s', got '%s'", correctManifestAddrHex, resourceManifestHash)
+	correctManifestAddrHex := "0e9b645ebc3da167b1d56399adc3276f7a08229301b72a03336be0e7d4b71882"
+	if feedManifestHash != correctManifestAddrHex {
+		t.Fatalf("Response feed manifest mismatch, expected '%s', got '%s'", correctManifestAddrHex, feedManifestHash)
 	}
 
-	reader, err := client.GetResource(correctManifestAddrHex)
+	reader, err := client.QueryFeed(nil, correctManifestAddrHex)
 	if err != nil {
-		t.Fatalf("Error retrieving resource: %s", err)
+		t.Fatalf("Error retrieving feed updates: %s", err)
 	}
 	defer reader.Close()
 	gotData, err := ioutil.ReadAll(reader)
@@ -486,7 +602,7 @@ func TestClientCreateUpdateResource(t *testing.T) {
 	// define different data
 	databytes = []byte("... no ha mucho tiempo que vivía un hidalgo de los de lanza en astillero ...")
 
-	updateRequest, err := client.GetResourceMetadata(correctManifestAddrHex)
+	updateRequest, err := client.GetFeedRequest(nil, correctManifestAddrHex)
 	if err != nil {
 		t.Fatalf("Error retrieving update request template: %s", err)
 	}
@@ -496,13 +612,13 @@ func TestClientCreateUpdateResource(t *testing.T) {
 		t.Fatalf("Error signing update: %s", err)
 	}
 
-	if err = client.UpdateResource(updateRequest); err != nil {
-		t.Fatalf("Error updating resource: %s", err)
+	if err = client.UpdateFeed(updateRequest); err != nil {
+		t.Fatalf("Error updating feed: %s", err)
 	}
 
-	reader, err = client.GetResource(correctManifestAddrHex)
+	reader, err = client.QueryFeed(nil, correctManifestAddrHex)
 	if err != nil {
-		t.Fatalf("Error retrieving resource: %s", err)
+		t.Fatalf("Error retrieving feed updates: %s", err)
 	}
 	defer reader.Close()
 	gotData, err = ioutil.ReadAll(reader)
@@ -513,4 +629,24 @@ func TestClientCreateUpdateResource(t *testing.T) {
 		t.Fatalf("Expected: %v, got %v", databytes, gotData)
 	}
 
+	// now try retrieving feed updates without a manifest
+
+	fd := &feed.Feed{
+		Topic: topic,
+		User:  signer.Address(),
+	}
+
+	lookupParams := feed.NewQueryLatest(fd, lookup.NoClue)
+	reader, err = client.QueryFeed(lookupParams, "")
+	if err != nil {
+		t.Fatalf("Error retrieving feed updates: %s", err)
+	}
+	defer reader.Close()
+	gotData, err = ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(databytes, gotData) {
+		t.Fatalf("Expected: %v, got %v", databytes, gotData)
+	}
 }
@@ -63,6 +63,7 @@ type Config struct {
 	SwapEnabled       bool
 	SyncEnabled       bool
 	DeliverySkipCheck bool
+	AccessNodeKey     bool // decrypt access controlled content granted to the node key for every API request
 	SyncUpdateDelay   time.Duration
 	SwapAPI           string
 	Cors              string
@@ -90,6 +91,7 @@ func NewConfig() (c *Config) {
 		SwapEnabled:       false,
 		SyncEnabled:       true,
 		DeliverySkipCheck: false,
+		AccessNodeKey:     false,
 		SyncUpdateDelay:   15 * time.Second,
 		SwapAPI:           "",
 		BootNodes:         "",
@@ -0,0 +1,72 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/orangeAndSuns/essentia/crypto/sha3"
+	"github.com/orangeAndSuns/essentia/swarm/storage/encryption"
+)
+
+// RefEncryption encrypts references of a given size, prefixed by their
+// encrypted size, so that decryption with a wrong key can be detected.
+type RefEncryption struct {
+	spanEncryption encryption.Encryption
+	dataEncryption encryption.Encryption
+	span           []byte
+}
+
+// NewRefEncryption creates an encryption for references of the given size.
+func NewRefEncryption(refSize int) *RefEncryption {
+	span := make([]byte, 8)
+	binary.LittleEndian.PutUint64(span, uint64(refSize))
+	return &RefEncryption{
+		spanEncryption: encryption.New(0, uint32(refSize/32), sha3.NewKeccak256),
+		dataEncryption: encryption.New(refSize, 0, sha3.NewKeccak256),
+		span:           span,
+	}
+}
+
+// Encrypt encrypts the reference with the given key.
+func (re *RefEncryption) Encrypt(ref []byte, key []byte) ([]byte, error) {
+	encryptedSpan, err := re.spanEncryption.Encrypt(re.span, key)
+	if err != nil {
+		return nil, err
+	}
+	encryptedData, err := re.dataEncryption.Encrypt(ref, key)
+	if err != nil {
+		return nil, err
+	}
+	return append(encryptedSpan, encryptedData...), nil
+}
+
+// Decrypt decrypts the encrypted reference with the given key.
+func (re *RefEncryption) Decrypt(ref []byte, key []byte) ([]byte, error) {
+	if len(ref) < 8 {
+		return nil, errors.New("encrypted reference too short")
+	}
+	span, err := re.spanEncryption.Decrypt(ref[:8], key)
+	if err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint64(span) != uint64(len(ref)-8) {
+		return nil, errors.New("invalid span in encrypted reference")
+	}
+	return re.dataEncryption.Decrypt(ref[8:], key)
+}
@@ -22,6 +22,7 @@ package http
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -29,10 +30,10 @@ import (
 	"io/ioutil"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"os"
 	"path"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -44,18 +45,12 @@ import (
 	"github.com/orangeAndSuns/essentia/swarm/log"
 	"github.com/orangeAndSuns/essentia/swarm/spancontext"
 	"github.com/orangeAndSuns/essentia/swarm/storage"
-	"github.com/orangeAndSuns/essentia/swarm/storage/mru"
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed"
 
 	"github.com/pborman/uuid"
 	"github.com/rs/cors"
 )
 
-type resourceResponse struct {
-	Manifest storage.Address `json:"manifest"`
-	Resource string          `json:"resource"`
-	Update   storage.Address `json:"update"`
-}
-
 var (
 	postRawCount    = metrics.NewRegisteredCounter("api.http.post.raw.count", nil)
 	postRawFail     = metrics.NewRegisteredCounter("api.http.post.raw.fail", nil)
@@ -70,8 +65,18 @@ var (
 	getFileFail     = metrics.NewRegisteredCounter("api.http.get.file.fail", nil)
 	getListCount    = metrics.NewRegisteredCounter("api.http.get.list.count", nil)
 	getListFail     = metrics.NewRegisteredCounter("api.http.get.list.fail", nil)
+	pinCount        = metrics.NewRegisteredCounter("api.http.pin.count", nil)
+	pinFail         = metrics.NewRegisteredCounter("api.http.pin.fail", nil)
 )
 
+// SwarmTagHeaderName is the header carrying the uid of the tag which counts
+// the chunks of an upload
+const SwarmTagHeaderName = "X-Swarm-Tag"
+
+// immutableCacheControl is the Cache-Control header of responses to requests
+// addressing content by its hash, which never changes
+const immutableCacheControl = "max-age=2147483648, immutable"
+
 func NewServer(api *api.API, corsString string) *Server {
 	var allowedOrigins []string
 	for _, domain := range strings.Split(corsString, ",") {
@@ -91,7 +96,10 @@ func NewServer(api *api.API, corsString string) *Server {
 	mux.HandleFunc("/bzz-immutable:/", server.WrapHandler(true, server.HandleBzzImmutable))
 	mux.HandleFunc("/bzz-hash:/", server.WrapHandler(true, server.HandleBzzHash))
 	mux.HandleFunc("/bzz-list:/", server.WrapHandler(true, server.HandleBzzList))
+	mux.HandleFunc("/bzz-feed:/", server.WrapHandler(true, server.HandleBzzFeed))
 	mux.HandleFunc("/bzz-resource:/", server.WrapHandler(true, server.HandleBzzResource))
+	mux.HandleFunc("/bzz-pin:/", server.WrapHandler(true, server.HandleBzzPin))
+	mux.HandleFunc("/bzz-tag:/", server.WrapHandler(true, server.HandleBzzTag))
 
 	mux.HandleFunc("/", server.WrapHandler(false, server.HandleRootPaths))
 	mux.HandleFunc("/robots.txt", server.WrapHandler(false, server.HandleRootPaths))
@@ -139,19 +147,17 @@ func (s *Server) HandleBzz(w http.ResponseWriter, r *Request) {
 	switch r.Method {
 	case http.MethodGet:
 		log.Debug("handleGetBzz")
-		if r.Header.Get("Accept") == "application/x-tar" {
-			reader, err := s.api.GetDirectoryTar(r.Context(), r.uri)
-			if err != nil {
-				Respond(w, r, fmt.Sprintf("Had an error building the tarball: %v", err), http.StatusInternalServerError)
-			}
-			defer reader.Close()
-
-			w.Header().Set("Content-Type", "application/x-tar")
-			w.WriteHeader(http.StatusOK)
-			io.Copy(w, reader)
-			return
+		// the response depends on whether an archive of the directory
+		// is requested
+		w.Header().Set("Vary", "Accept")
+		switch r.Header.Get("Accept") {
+		case "application/x-tar":
+			s.handleGetArchive(w, r, "application/x-tar", s.api.GetDirectoryTar)
+		case "application/zip":
+			s.handleGetArchive(w, r, "application/zip", s.api.GetDirectoryZip)
+		default:
+			s.HandleGetFile(w, r)
 		}
-		s.HandleGetFile(w, r)
 	case http.MethodPost:
 		log.Debug("handlePostFiles")
 		s.HandlePostFiles(w, r)
@@ -201,18 +207,49 @@ func (s *Server) HandleBzzList(w http.ResponseWriter, r *Request) {
 		Respond(w, r, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+func (s *Server) HandleBzzFeed(w http.ResponseWriter, r *Request) {
+	switch r.Method {
+	case http.MethodGet:
+		log.Debug("handleGetFeed")
+		s.HandleGetFeed(w, r)
+	case http.MethodPost:
+		log.Debug("handlePostFeed")
+		s.HandlePostFeed(w, r)
+	default:
+		Respond(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+// HandleBzzResource answers requests to the bzz-resource scheme of mutable
+// resources with 410 Gone, since they were replaced by feeds
 func (s *Server) HandleBzzResource(w http.ResponseWriter, r *Request) {
+	Respond(w, r, api.ErrResourceRemoved.Error(), http.StatusGone)
+}
+func (s *Server) HandleBzzPin(w http.ResponseWriter, r *Request) {
 	switch r.Method {
 	case http.MethodGet:
-		log.Debug("handleGetResource")
-		s.HandleGetResource(w, r)
+		log.Debug("handleGetPin")
+		s.HandleGetPin(w, r)
+	case http.MethodPost, http.MethodDelete:
+		log.Debug("handlePin")
+		s.HandlePin(w, r)
+	default:
+		Respond(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) HandleBzzTag(w http.ResponseWriter, r *Request) {
+	switch r.Method {
+	case http.MethodGet:
+		log.Debug("handleGetTag")
+		s.HandleGetTag(w, r)
 	case http.MethodPost:
-		log.Debug("handlePostResource")
-		s.HandlePostResource(w, r)
+		log.Debug("handlePostTag")
+		s.HandlePostTag(w, r)
 	default:
 		Respond(w, r, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+
 func (s *Server) WrapHandler(parseBzzUri bool, h func(http.ResponseWriter, *Request)) http.HandlerFunc {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		defer metrics.GetOrRegisterResettingTimer(fmt.Sprintf("http.request.%s.time", r.Method), nil).UpdateSince(time.Now())
@@ -292,6 +329,16 @@ func (s *Server) HandlePostRaw(w http.ResponseWriter, r *Request) {
 		return
 	}
 
+	tag, err := s.uploadTag(r)
+	if err != nil {
+		postRawFail.Inc(1)
+		Respond(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if tag != nil {
+		ctx = storage.WithTag(ctx, tag)
+	}
+
 	addr, _, err := s.api.Store(ctx, r.Body, r.ContentLength, toEncrypt)
 	if err != nil {
 		postRawFail.Inc(1)
@@ -301,6 +348,10 @@ func (s *Server) HandlePostRaw(w http.ResponseWriter, r *Request) {
 
 	log.Debug("stored content", "ruid", r.ruid, "key", addr)
 
+	if tag != nil {
+		tag.DoneSplit(addr)
+		w.Header().Set(SwarmTagHeaderName, strconv.FormatUint(uint64(tag.Uid), 10))
+	}
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprint(w, addr)
@@ -308,13 +359,25 @@ func (s *Server) HandlePostRaw(w http.ResponseWriter, r *Request) {
 
 // HandlePostFiles handles a POST request to
 // bzz:/<hash>/<path> which contains either a single file or multiple files
-// (either a tar archive or multipart form), adds those files either to an
+// (either a tar or zip archive or multipart form), adds those files either to an
 // existing manifest or to a new manifest under <path> and returns the
 // resulting manifest hash as a text/plain response
 func (s *Server) HandlePostFiles(w http.ResponseWriter, r *Request) {
 	log.Debug("handle.post.files", "ruid", r.ruid)
 	postFilesCount.Inc(1)
 
+	// the tag is carried by the request context, so that it counts the
+	// chunks of all the entries as well as those of the manifest
+	tag, err := s.uploadTag(r)
+	if err != nil {
+		postFilesFail.Inc(1)
+		Respond(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if tag != nil {
+		r.Request = *r.WithContext(storage.WithTag(r.Context(), tag))
+	}
+
 	var sp opentracing.Span
 	ctx := r.Context()
 	ctx, sp = spancontext.StartSpan(
@@ -363,6 +426,9 @@ func (s *Server) HandlePostFiles(w http.ResponseWriter, r *Request) {
 				return err
 			}
 			return nil
+		case "application/zip":
+			_, err := s.handleZipUpload(r, mw)
+			return err
 		case "multipart/form-data":
 			return s.handleMultipartUpload(r, params["boundary"], mw)
 
@@ -378,6 +444,10 @@ func (s *Server) HandlePostFiles(w http.ResponseWriter, r *Request) {
 
 	log.Debug("stored content", "ruid", r.ruid, "key", newAddr)
 
+	if tag != nil {
+		tag.DoneSplit(newAddr)
+		w.Header().Set(SwarmTagHeaderName, strconv.FormatUint(uint64(tag.Uid), 10))
+	}
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprint(w, newAddr)
@@ -393,6 +463,16 @@ func (s *Server) handleTarUpload(r *Request, mw *api.ManifestWriter) (storage.Ad
 	return key, nil
 }
 
+func (s *Server) handleZipUpload(r *Request, mw *api.ManifestWriter) (storage.Address, error) {
+	log.Debug("handle.zip.upload", "ruid", r.ruid)
+
+	key, err := s.api.UploadZip(r.Context(), r.Body, r.uri.Path, mw)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
 func (s *Server) handleMultipartUpload(req *Request, boundary string, mw *api.ManifestWriter) error {
 	log.Debug("handle.multipart.upload", "ruid", req.ruid)
 	mr := multipart.NewReader(req.Body, boundary)
@@ -485,106 +565,84 @@ func (s *Server) HandleDelete(w http.ResponseWriter, r *Request) {
 	fmt.Fprint(w, newKey)
 }
 
-// Parses a resource update post url to corresponding action
-// possible combinations:
-// /			add multihash update to existing hash
-// /raw 		add raw update to existing hash
-// /#			create new resource with first update as mulitihash
-// /raw/#		create new resource with first update raw
-func resourcePostMode(path string) (isRaw bool, frequency uint64, err error) {
-	re, err := regexp.Compile("^(raw)?/?([0-9]+)?$")
-	if err != nil {
-		return isRaw, frequency, err
-	}
-	m := re.FindAllStringSubmatch(path, 2)
-	var freqstr = "0"
-	if len(m) > 0 {
-		if m[0][1] != "" {
-			isRaw = true
-		}
-		if m[0][2] != "" {
-			freqstr = m[0][2]
-		}
-	} else if len(path) > 0 {
-		return isRaw, frequency, fmt.Errorf("invalid path")
-	}
-	frequency, err = strconv.ParseUint(freqstr, 10, 64)
-	return isRaw, frequency, err
-}
-
-// Handles creation of new mutable resources and adding updates to existing mutable resources
+// HandlePostFeed handles publishing updates on Swarm feeds and creating feed manifests
+// The POST request admits a JSON structure as defined in the feed package: `feed.updateRequestJSON`
 // There are two types of updates available, "raw" and "multihash."
-// If the latter is used, a subsequent bzz:// GET call to the manifest of the resource will return
+// If the latter is used, a subsequent bzz:// GET call to the manifest of the feed will return
 // the page that the multihash is pointing to, as if it held a normal swarm content manifest
 //
-// The POST request admits a JSON structure as defined in the mru package: `mru.updateRequestJSON`
-// The requests can be to a) create a resource, b) update a resource or c) both a+b: create a resource and set the initial content
-func (s *Server) HandlePostResource(w http.ResponseWriter, r *Request) {
-	log.Debug("handle.post.resource", "ruid", r.ruid)
+// bzz-feed://               - publish the signed update in the request body
+// bzz-feed://?manifest=true - publish the update, if signed, and respond with the address
+//                             of a new manifest pointing to the feed of the request
+// bzz-feed://<manifest address or ENS name> - check that the update belongs to the feed
+//                             of the manifest before publishing it
+func (s *Server) HandlePostFeed(w http.ResponseWriter, r *Request) {
+	log.Debug("handle.post.feed", "ruid", r.ruid)
 
 	var sp opentracing.Span
 	ctx := r.Context()
 	ctx, sp = spancontext.StartSpan(
 		ctx,
-		"http.post.resource")
+		"http.post.feed")
 	defer sp.Finish()
 
-	var err error
-
-	// Creation and update must send mru.updateRequestJSON JSON structure
+	// Updates must send feed.updateRequestJSON JSON structure
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		Respond(w, r, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	var updateRequest mru.Request
+	var updateRequest feed.Request
 	if err := updateRequest.UnmarshalJSON(body); err != nil { // decodes request JSON
 		Respond(w, r, err.Error(), http.StatusBadRequest) //TODO: send different status response depending on error
 		return
 	}
 
-	if updateRequest.IsUpdate() {
-		// Verify that the signature is intact and that the signer is authorized
-		// to update this resource
-		// Check this early, to avoid creating a resource and then not being able to set its first update.
-		if err = updateRequest.Verify(); err != nil {
-			Respond(w, r, err.Error(), http.StatusForbidden)
+	if r.uri.Addr != "" {
+		fd, err := s.api.ResolveFeed(ctx, r.uri, r.URL.Query())
+		if err != nil {
+			Respond(w, r, fmt.Sprintf("cannot resolve feed of %s: %v", r.uri.Addr, err), http.StatusNotFound)
 			return
 		}
-	}
-
-	if updateRequest.IsNew() {
-		err = s.api.ResourceCreate(r.Context(), &updateRequest)
-		if err != nil {
-			code, err2 := s.translateResourceError(w, r, "resource creation fail", err)
-			Respond(w, r, err2.Error(), code)
+		if *fd != updateRequest.Feed {
+			Respond(w, r, fmt.Sprintf("update does not belong to the feed of %s", r.uri.Addr), http.StatusBadRequest)
 			return
 		}
 	}
 
+	createManifest := r.URL.Query().Get("manifest") == "true"
+	if !updateRequest.IsUpdate() && !createManifest {
+		Respond(w, r, "feed update is not signed", http.StatusBadRequest)
+		return
+	}
+
 	if updateRequest.IsUpdate() {
-		_, err = s.api.ResourceUpdate(r.Context(), &updateRequest.SignedResourceUpdate)
+		// Verify that the signature is intact and that the signer is authorized
+		// to update this feed
+		// Check this early, to avoid creating a manifest and then not being able to publish the update.
+		if err = updateRequest.Verify(); err != nil {
+			Respond(w, r, err.Error(), http.StatusForbidden)
+			return
+		}
+		_, err = s.api.FeedsUpdate(ctx, &updateRequest)
 		if err != nil {
-			Respond(w, r, err.Error(), http.StatusInternalServerError)
+			code, err2 := s.translateFeedError(w, r, "feed update fail", err)
+			Respond(w, r, err2.Error(), code)
 			return
 		}
 	}
 
-	// at this point both possible operations (create, update or both) were successful
-	// so in case it was a new resource, then create a manifest and send it over.
-
-	if updateRequest.IsNew() {
-		// we create a manifest so we can retrieve the resource with bzz:// later
-		// this manifest has a special "resource type" manifest, and its hash is the key of the mutable resource
-		// metadata chunk (rootAddr)
-		m, err := s.api.NewResourceManifest(r.Context(), updateRequest.RootAddr().Hex())
+	if createManifest {
+		// we create a manifest so we can retrieve feed updates with bzz:// later
+		// this manifest has a special "feed type" manifest, and saves the
+		// feed identification used to retrieve feed updates later
+		m, err := s.api.NewFeedManifest(ctx, &updateRequest.Feed)
 		if err != nil {
-			Respond(w, r, fmt.Sprintf("failed to create resource manifest: %v", err), http.StatusInternalServerError)
+			Respond(w, r, fmt.Sprintf("failed to create feed manifest: %v", err), http.StatusInternalServerError)
 			return
 		}
 
 		// the key to the manifest will be passed back to the client
-		// the client can access the root chunk key directly through its Hash member
 		// the manifest key should be set as content in the resolver of the ENS name
 		// \TODO update manifest key automatically in ENS
 		outdata, err := json.Marshal(m)
@@ -592,119 +650,83 @@ func (s *Server) HandlePostResource(w http.ResponseWriter, r *Request) {
 			Respond(w, r, fmt.Sprintf("failed to create json response: %s", err), http.StatusInternalServerError)
 			return
 		}
+		w.Header().Add("Content-type", "application/json")
 		fmt.Fprint(w, string(outdata))
+		return
 	}
-	w.Header().Add("Content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
 }
 
-// Retrieve mutable resource updates:
-// bzz-resource://<id> - get latest update
-// bzz-resource://<id>/<n> - get latest update on period n
-// bzz-resource://<id>/<n>/<m> - get update version m of period n
-// bzz-resource://<id>/meta - get metadata and next version information
-// <id> = ens name or hash
-// TODO: Enable pass maxPeriod parameter
-func (s *Server) HandleGetResource(w http.ResponseWriter, r *Request) {
-	log.Debug("handle.get.resource", "ruid", r.ruid)
-	var err error
-
-	// resolve the content key.
-	manifestAddr := r.uri.Address()
-	if manifestAddr == nil {
-		manifestAddr, err = s.api.Resolve(r.Context(), r.uri)
-		if err != nil {
-			getFail.Inc(1)
-			Respond(w, r, fmt.Sprintf("cannot resolve %s: %s", r.uri.Addr, err), http.StatusNotFound)
-			return
-		}
-	} else {
-		w.Header().Set("Cache-Control", "max-age=2147483648")
-	}
-
-	// get the root chunk rootAddr from the manifest
-	rootAddr, err := s.api.ResolveResourceManifest(r.Context(), manifestAddr)
+// HandleGetFeed retrieves Swarm feed updates:
+// bzz-feed://<manifest address or ENS name> - get latest feed update, given a manifest address
+// - or -
+// specify user + topic (optional), name (optional) and relatedcontent (optional) via query parameters:
+// bzz-feed://?user=0x...&topic=0x... - get latest feed update of the given user on the given topic
+// bzz-feed://?user=0x...&name=...&relatedcontent=0x... - same, building the topic out of a name and related content
+//
+// Optional query parameters:
+// time=xx - get the latest update before time (in epoch seconds)
+// hint.time=xx - hint the lookup algorithm looking for updates at around that time
+// hint.level=xx - hint the lookup algorithm looking for updates at around this frequency level
+// meta=true - get an unsigned request for the next update of the feed instead of performing a feed query
+func (s *Server) HandleGetFeed(w http.ResponseWriter, r *Request) {
+	log.Debug("handle.get.feed", "ruid", r.ruid)
+
+	fd, err := s.api.ResolveFeed(r.Context(), r.uri, r.URL.Query())
 	if err != nil {
 		getFail.Inc(1)
-		Respond(w, r, fmt.Sprintf("error resolving resource root chunk for %s: %s", r.uri.Addr, err), http.StatusNotFound)
-		return
-	}
-
-	log.Debug("handle.get.resource: resolved", "ruid", r.ruid, "manifestkey", manifestAddr, "rootchunk addr", rootAddr)
-
-	// determine if the query specifies period and version or it is a metadata query
-	var params []string
-	if len(r.uri.Path) > 0 {
-		if r.uri.Path == "meta" {
-			unsignedUpdateRequest, err := s.api.ResourceNewRequest(r.Context(), rootAddr)
-			if err != nil {
-				getFail.Inc(1)
-				Respond(w, r, fmt.Sprintf("cannot retrieve resource metadata for rootAddr=%s: %s", rootAddr.Hex(), err), http.StatusNotFound)
-				return
-			}
-			rawResponse, err := unsignedUpdateRequest.MarshalJSON()
-			if err != nil {
-				Respond(w, r, fmt.Sprintf("cannot encode unsigned UpdateRequest: %v", err), http.StatusInternalServerError)
-				return
-			}
-			w.Header().Add("Content-type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			fmt.Fprint(w, string(rawResponse))
-			return
-
+		code := http.StatusNotFound
+		if err == api.ErrResourceRemoved {
+			code = http.StatusGone
 		}
-
-		params = strings.Split(r.uri.Path, "/")
-
+		Respond(w, r, fmt.Sprintf("cannot resolve feed of %s: %v", r.uri.Addr, err), code)
+		return
 	}
-	var name string
-	var data []byte
-	now := time.Now()
 
-	switch len(params) {
-	case 0: // latest only
-		name, data, err = s.api.ResourceLookup(r.Context(), mru.LookupLatest(rootAddr))
-	case 2: // specific period and version
-		var version uint64
-		var period uint64
-		version, err = strconv.ParseUint(params[1], 10, 32)
+	// determine if this is a metadata query or a lookup
+	if r.URL.Query().Get("meta") == "true" {
+		unsignedUpdateRequest, err := s.api.FeedsNewRequest(r.Context(), fd)
 		if err != nil {
-			break
-		}
-		period, err = strconv.ParseUint(params[0], 10, 32)
-		if err != nil {
-			break
+			getFail.Inc(1)
+			Respond(w, r, fmt.Sprintf("cannot retrieve feed metadata for feed=%s: %s", fd.Hex(), err), http.StatusNotFound)
+			return
 		}
-		name, data, err = s.api.ResourceLookup(r.Context(), mru.LookupVersion(rootAddr, uint32(period), uint32(version)))
-	case 1: // last version of specific period
-		var period uint64
-		period, err = strconv.ParseUint(params[0], 10, 32)
+		rawResponse, err := unsignedUpdateRequest.MarshalJSON()
 		if err != nil {
-			break
+			Respond(w, r, fmt.Sprintf("cannot encode unsigned feed update request: %v", err), http.StatusInternalServerError)
+			return
 		}
-		name, data, err = s.api.ResourceLookup(r.Context(), mru.LookupLatestVersionInPeriod(rootAddr, uint32(period)))
-	default: // bogus
-		err = mru.NewError(storage.ErrInvalidValue, "invalid mutable resource request")
+		w.Header().Add("Content-type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, string(rawResponse))
+		return
 	}
 
-	// any error from the switch statement will end up here
+	lookupParams := &feed.Query{Feed: *fd}
+	if err = lookupParams.FromValues(r.URL.Query()); err != nil { // parse time and hint
+		Respond(w, r, fmt.Sprintf("invalid feed update request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	data, err := s.api.FeedsLookup(r.Context(), lookupParams)
 	if err != nil {
-		code, err2 := s.translateResourceError(w, r, "mutable resource lookup fail", err)
+		code, err2 := s.translateFeedError(w, r, "feed lookup fail", err)
 		Respond(w, r, err2.Error(), code)
 		return
 	}
 
 	// All ok, serve the retrieved update
-	log.Debug("Found update", "name", name, "ruid", r.ruid)
+	log.Debug("Found update", "feed", fd.Hex(), "ruid", r.ruid)
 	w.Header().Set("Content-Type", "application/octet-stream")
-	http.ServeContent(w, &r.Request, "", now, bytes.NewReader(data))
+	http.ServeContent(w, &r.Request, "", time.Now(), bytes.NewReader(data))
 }
 
-func (s *Server) translateResourceError(w http.ResponseWriter, r *Request, supErr string, err error) (int, error) {
+func (s *Server) translateFeedError(w http.ResponseWriter, r *Request, supErr string, err error) (int, error) {
 	code := 0
 	defaultErr := fmt.Errorf("%s: %v", supErr, err)
-	rsrcErr, ok := err.(*mru.Error)
-	if !ok && rsrcErr != nil {
-		code = rsrcErr.Code()
+	feedErr, ok := err.(*feed.Error)
+	if ok && feedErr != nil {
+		code = feedErr.Code()
 	}
 	switch code {
 	case storage.ErrInvalidValue:
@@ -745,8 +767,6 @@ func (s *Server) HandleGet(w http.ResponseWriter, r *Request) {
 			Respond(w, r, fmt.Sprintf("cannot resolve %s: %s", r.uri.Addr, err), http.StatusNotFound)
 			return
 		}
-	} else {
-		w.Header().Set("Cache-Control", "max-age=2147483648, immutable") // url was of type bzz://<hex key>/path, so we are sure it is immutable.
 	}
 
 	log.Debug("handle.get: resolved", "ruid", r.ruid, "key", addr)
@@ -754,6 +774,10 @@ func (s *Server) HandleGet(w http.ResponseWriter, r *Request) {
 	// if path is set, interpret <key> as a manifest and return the
 	// raw entry at the given path
 	if r.uri.Path != "" {
+		if addr, err = s.resolveAccess(w, r, addr); err != nil {
+			getFail.Inc(1)
+			return
+		}
 		walker, err := s.api.NewManifestWalker(r.Context(), addr, nil)
 		if err != nil {
 			getFail.Inc(1)
@@ -791,14 +815,9 @@ func (s *Server) HandleGet(w http.ResponseWriter, r *Request) {
 		}
 		addr = storage.Address(common.Hex2Bytes(entry.Hash))
 	}
-	etag := common.Bytes2Hex(addr)
-	noneMatchEtag := r.Header.Get("If-None-Match")
-	w.Header().Set("ETag", fmt.Sprintf("%q", etag)) // set etag to manifest key or raw entry key.
-	if noneMatchEtag != "" {
-		if bytes.Equal(storage.Address(common.Hex2Bytes(noneMatchEtag)), addr) {
-			Respond(w, r, "Not Modified", http.StatusNotModified)
-			return
-		}
+	// set etag to manifest key or raw entry key
+	if notModified(w, r, addr) {
+		return
 	}
 
 	// check the root chunk exists by retrieving the file's size
@@ -810,6 +829,7 @@ func (s *Server) HandleGet(w http.ResponseWriter, r *Request) {
 	}
 
 	w.Header().Set("X-Decrypted", fmt.Sprintf("%v", isEncrypted))
+	setImmutable(w, r)
 
 	switch {
 	case r.uri.Raw():
@@ -820,7 +840,7 @@ func (s *Server) HandleGet(w http.ResponseWriter, r *Request) {
 			contentType = typ
 		}
 		w.Header().Set("Content-Type", contentType)
-		http.ServeContent(w, &r.Request, "", time.Now(), reader)
+		http.ServeContent(w, &r.Request, "", time.Time{}, reader)
 	case r.uri.Hash():
 		w.Header().Set("Content-Type", "text/plain")
 		w.WriteHeader(http.StatusOK)
@@ -856,12 +876,26 @@ func (s *Server) HandleGetList(w http.ResponseWriter, r *Request) {
 	}
 	log.Debug("handle.get.list: resolved", "ruid", r.ruid, "key", addr)
 
+	// the list is rendered as HTML or JSON depending on the Accept header
+	w.Header().Set("Vary", "Accept")
+
+	if addr, err = s.resolveAccess(w, r, addr); err != nil {
+		getListFail.Inc(1)
+		return
+	}
+
+	// set etag to manifest key
+	if notModified(w, r, addr) {
+		return
+	}
+
 	list, err := s.api.GetManifestList(ctx, addr, r.uri.Path)
 	if err != nil {
 		getListFail.Inc(1)
 		Respond(w, r, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	setImmutable(w, r)
 
 	// if the client wants HTML (e.g. a browser) then render the list as a
 	// HTML index with relative URLs
@@ -915,29 +949,24 @@ func (s *Server) HandleGetFile(w http.ResponseWriter, r *Request) {
 			sp.Finish()
 			return
 		}
-	} else {
-		w.Header().Set("Cache-Control", "max-age=2147483648, immutable") // url was of type bzz://<hex key>/path, so we are sure it is immutable.
 	}
 
 	log.Debug("handle.get.file: resolved", "ruid", r.ruid, "key", manifestAddr)
-	reader, contentType, status, contentKey, err := s.api.Get(r.Context(), manifestAddr, r.uri.Path)
 
-	etag := common.Bytes2Hex(contentKey)
-	noneMatchEtag := r.Header.Get("If-None-Match")
-	w.Header().Set("ETag", fmt.Sprintf("%q", etag)) // set etag to actual content key.
-	if noneMatchEtag != "" {
-		if bytes.Equal(storage.Address(common.Hex2Bytes(noneMatchEtag)), contentKey) {
-			Respond(w, r, "Not Modified", http.StatusNotModified)
-			sp.Finish()
-			return
-		}
+	if manifestAddr, err = s.resolveAccess(w, r, manifestAddr); err != nil {
+		getFileFail.Inc(1)
+		sp.Finish()
+		return
 	}
-
+	reader, contentType, status, contentKey, err := s.api.Get(r.Context(), manifestAddr, r.uri.Path)
 	if err != nil {
 		switch status {
 		case http.StatusNotFound:
 			getFileNotFound.Inc(1)
 			Respond(w, r, err.Error(), http.StatusNotFound)
+		case http.StatusGone:
+			getFileFail.Inc(1)
+			Respond(w, r, err.Error(), http.StatusGone)
 		default:
 			getFileFail.Inc(1)
 			Respond(w, r, err.Error(), http.StatusInternalServerError)
@@ -964,6 +993,12 @@ func (s *Server) HandleGetFile(w http.ResponseWriter, r *Request) {
 		return
 	}
 
+	// set etag to actual content key
+	if notModified(w, r, contentKey) {
+		sp.Finish()
+		return
+	}
+
 	// check the root chunk exists by retrieving the file's size
 	if _, err := reader.Size(ctx, nil); err != nil {
 		getFileNotFound.Inc(1)
@@ -984,7 +1019,237 @@ func (s *Server) HandleGetFile(w http.ResponseWriter, r *Request) {
 	sp.Finish()
 
 	w.Header().Set("Content-Type", contentType)
-	http.ServeContent(w, &r.Request, "", time.Now(), bytes.NewReader(buf))
+	setImmutable(w, r)
+	http.ServeContent(w, &r.Request, "", time.Time{}, bytes.NewReader(buf))
+}
+
+// handleGetArchive responds with the files of the manifest of the request
+// in an archive of the given content type
+func (s *Server) handleGetArchive(w http.ResponseWriter, r *Request, contentType string, getArchive func(context.Context, *api.URI) (io.ReadCloser, error)) {
+	reader, err := getArchive(r.Context(), r.uri)
+	if err != nil {
+		Respond(w, r, fmt.Sprintf("Had an error building the archive: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, reader)
+}
+
+// notModified sets the ETag of the response to the content address and
+// responds with 304 Not Modified if the If-None-Match header of the request
+// matches it, in which case it returns true
+func notModified(w http.ResponseWriter, r *Request, addr storage.Address) bool {
+	// content resolved through feeds has no content address
+	if len(addr) == 0 {
+		return false
+	}
+	etag := common.Bytes2Hex(addr)
+	w.Header().Set("ETag", fmt.Sprintf("%q", etag))
+	noneMatch := r.Header.Get("If-None-Match")
+	if noneMatch == "" {
+		return false
+	}
+	for _, match := range strings.Split(noneMatch, ",") {
+		// weak comparison, also accepting unquoted content addresses
+		match = strings.Trim(strings.TrimPrefix(strings.TrimSpace(match), "W/"), `"`)
+		if match == "*" || match == etag {
+			setImmutable(w, r)
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// setImmutable sets the Cache-Control header of a successful response to a
+// request addressing content by its hash, so we are sure it is immutable
+func setImmutable(w http.ResponseWriter, r *Request) {
+	if r.uri.Address() != nil {
+		w.Header().Set("Cache-Control", immutableCacheControl)
+	}
+}
+
+// resolveAccess resolves the address of the content protected by an access
+// controlled root manifest, using the password of the request's basic auth
+// credentials or the node key, if the node is configured to decrypt content
+// granted to it. The error response is written if access is not granted.
+func (s *Server) resolveAccess(w http.ResponseWriter, r *Request, addr storage.Address) (storage.Address, error) {
+	_, password, _ := r.BasicAuth()
+	resolved, err := s.api.ResolveAccess(r.Context(), addr, password)
+	switch {
+	case err == api.ErrDecrypt:
+		w.Header().Set("WWW-Authenticate", `Basic realm="Swarm"`)
+		Respond(w, r, fmt.Sprintf("access to %s denied", addr), http.StatusUnauthorized)
+	case err != nil:
+		Respond(w, r, fmt.Sprintf("cannot resolve access to %s: %s", addr, err), http.StatusInternalServerError)
+	}
+	return resolved, err
+}
+
+// isLocalRequest reports whether the request comes from a loopback address.
+func isLocalRequest(r *Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// HandlePin handles a POST or DELETE request to bzz-pin:/<addr>, pinning or
+// unpinning the content at <addr> in the local store. The content is pinned as
+// raw content rather than as a manifest if the raw query parameter is set.
+// Only requests from the local host are accepted.
+func (s *Server) HandlePin(w http.ResponseWriter, r *Request) {
+	log.Debug("handle.pin", "ruid", r.ruid, "method", r.Method)
+	pinCount.Inc(1)
+
+	if !isLocalRequest(r) {
+		pinFail.Inc(1)
+		Respond(w, r, "pinning is only allowed from the local host", http.StatusForbidden)
+		return
+	}
+	if r.uri.Addr == "" || r.uri.Path != "" {
+		pinFail.Inc(1)
+		Respond(w, r, "pin request must only contain an address", http.StatusBadRequest)
+		return
+	}
+	addr, err := s.api.Resolve(r.Context(), r.uri)
+	if err != nil {
+		pinFail.Inc(1)
+		Respond(w, r, fmt.Sprintf("cannot resolve %s: %s", r.uri.Addr, err), http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		err = s.api.Pin(r.Context(), addr, r.URL.Query().Get("raw") == "true")
+	} else {
+		err = s.api.Unpin(r.Context(), addr)
+	}
+	switch {
+	case err == storage.ErrNotPinned:
+		pinFail.Inc(1)
+		Respond(w, r, fmt.Sprintf("%s is not pinned", addr), http.StatusNotFound)
+		return
+	case err != nil:
+		pinFail.Inc(1)
+		Respond(w, r, fmt.Sprintf("cannot pin %s: %s", addr, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, addr)
+}
+
+// HandleGetPin handles a GET request to bzz-pin:/ and responds with the list
+// of pinned roots, or to bzz-pin:/<addr> and responds with the pinned root at
+// <addr>, as JSON.
+func (s *Server) HandleGetPin(w http.ResponseWriter, r *Request) {
+	log.Debug("handle.get.pin", "ruid", r.ruid)
+
+	var result interface{}
+	if r.uri.Addr == "" {
+		roots, err := s.api.PinnedRoots()
+		if err != nil {
+			Respond(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if roots == nil {
+			roots = []*storage.PinnedRoot{}
+		}
+		result = roots
+	} else {
+		addr, err := s.api.Resolve(r.Context(), r.uri)
+		if err != nil {
+			Respond(w, r, fmt.Sprintf("cannot resolve %s: %s", r.uri.Addr, err), http.StatusNotFound)
+			return
+		}
+		root, err := s.api.PinnedRoot(addr)
+		switch {
+		case err == storage.ErrNotPinned:
+			Respond(w, r, fmt.Sprintf("%s is not pinned", addr), http.StatusNotFound)
+			return
+		case err != nil:
+			Respond(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result = root
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// uploadTag returns the tag given by uid in the X-Swarm-Tag header of an
+// upload request, or a new tag if the request has a tag query parameter,
+// named after its value or else the path of the upload. Uploads are not
+// counted by a tag otherwise, and nil is returned.
+func (s *Server) uploadTag(r *Request) (*storage.Tag, error) {
+	header := r.Header.Get(SwarmTagHeaderName)
+	if header == "" {
+		query := r.URL.Query()
+		if _, ok := query["tag"]; !ok {
+			return nil, nil
+		}
+		name := query.Get("tag")
+		if name == "" {
+			name = r.uri.Path
+		}
+		return s.api.NewTag(name), nil
+	}
+	uid, err := strconv.ParseUint(header, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header %q", SwarmTagHeaderName, header)
+	}
+	tag, err := s.api.Tag(uint32(uid))
+	if err != nil {
+		return nil, fmt.Errorf("cannot get tag %d: %v", uid, err)
+	}
+	return tag, nil
+}
+
+// HandlePostTag handles a POST request to bzz-tag:/?name=<name>, creates a
+// tag to count the chunks of uploads sending its uid in the X-Swarm-Tag
+// header and responds with the status of the tag as JSON.
+func (s *Server) HandlePostTag(w http.ResponseWriter, r *Request) {
+	log.Debug("handle.post.tag", "ruid", r.ruid)
+
+	if r.uri.Addr != "" {
+		Respond(w, r, "tag POST request cannot contain an address", http.StatusBadRequest)
+		return
+	}
+	tag := s.api.NewTag(r.URL.Query().Get("name"))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag.Status())
+}
+
+// HandleGetTag handles a GET request to bzz-tag:/<uid> and responds with
+// the status of the tag with the given uid as JSON.
+func (s *Server) HandleGetTag(w http.ResponseWriter, r *Request) {
+	log.Debug("handle.get.tag", "ruid", r.ruid)
+
+	if r.uri.Addr == "" {
+		Respond(w, r, "missing tag uid", http.StatusBadRequest)
+		return
+	}
+	uid, err := strconv.ParseUint(r.uri.Addr, 10, 32)
+	if err != nil {
+		Respond(w, r, fmt.Sprintf("invalid tag uid %q", r.uri.Addr), http.StatusBadRequest)
+		return
+	}
+	tag, err := s.api.Tag(uint32(uid))
+	if err != nil {
+		Respond(w, r, fmt.Sprintf("cannot get tag %d: %v", uid, err), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag.Status())
 }
 
 // The size of buffer used for bufio.Reader on LazyChunkReader passed to
@@ -18,6 +18,7 @@ package http
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"context"
 	"crypto/rand"
@@ -40,7 +41,7 @@ import (
 	swarm "github.com/orangeAndSuns/essentia/swarm/api/client"
 	"github.com/orangeAndSuns/essentia/swarm/multihash"
 	"github.com/orangeAndSuns/essentia/swarm/storage"
-	"github.com/orangeAndSuns/essentia/swarm/storage/mru"
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed"
 	"github.com/orangeAndSuns/essentia/swarm/testutil"
 )
 
@@ -50,65 +51,24 @@ func init() {
 	log.Root().SetHandler(log.CallerFileHandler(log.LvlFilterHandler(log.Lvl(*loglevel), log.StreamHandler(os.Stderr, log.TerminalFormat(true)))))
 }
 
-func TestResourcePostMode(t *testing.T) {
-	path := ""
-	errstr := "resourcePostMode for '%s' should be raw %v frequency %d, was raw %v, frequency %d"
-	r, f, err := resourcePostMode(path)
-	if err != nil {
-		t.Fatal(err)
-	} else if r || f != 0 {
-		t.Fatalf(errstr, path, false, 0, r, f)
-	}
-
-	path = "raw"
-	r, f, err = resourcePostMode(path)
-	if err != nil {
-		t.Fatal(err)
-	} else if !r || f != 0 {
-		t.Fatalf(errstr, path, true, 0, r, f)
-	}
-
-	path = "13"
-	r, f, err = resourcePostMode(path)
-	if err != nil {
-		t.Fatal(err)
-	} else if r || f == 0 {
-		t.Fatalf(errstr, path, false, 13, r, f)
-	}
-
-	path = "raw/13"
-	r, f, err = resourcePostMode(path)
-	if err != nil {
-		t.Fatal(err)
-	} else if !r || f == 0 {
-		t.Fatalf(errstr, path, true, 13, r, f)
-	}
-
-	path = "foo/13"
-	r, f, err = resourcePostMode(path)
-	if err == nil {
-		t.Fatal("resourcePostMode for 'foo/13' should fail, returned error nil")
-	}
-}
-
 func serverFunc(api *api.API) testutil.TestServer {
 	return NewServer(api, "")
 }
 
-func newTestSigner() (*mru.GenericSigner, error) {
+func newTestSigner() (*feed.GenericSigner, error) {
 	privKey, err := crypto.HexToECDSA("deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef")
 	if err != nil {
 		return nil, err
 	}
-	return mru.NewGenericSigner(privKey), nil
+	return feed.NewGenericSigner(privKey), nil
 }
 
-// test the transparent resolving of multihash resource types with bzz:// scheme
+// test the transparent resolving of multihash feed updates with bzz:// scheme
 //
-// first upload data, and store the multihash to the resulting manifest in a resource update
+// first upload data, and store the multihash to the resulting manifest in a feed update
 // retrieving the update with the multihash should return the manifest pointing directly to the data
 // and raw retrieve of that hash should return the data
-func TestBzzResourceMultihash(t *testing.T) {
+func TestBzzFeedMultihash(t *testing.T) {
 
 	signer, _ := newTestSigner()
 
@@ -136,18 +96,8 @@ func TestBzzResourceMultihash(t *testing.T) {
 
 	log.Info("added data", "manifest", string(b), "data", common.ToHex(mh))
 
-	// our mutable resource "name"
-	keybytes := "foo.ess"
-
-	updateRequest, err := mru.NewCreateUpdateRequest(&mru.ResourceMetadata{
-		Name:      keybytes,
-		Frequency: 13,
-		StartTime: srv.GetCurrentTime(),
-		Owner:     signer.Address(),
-	})
-	if err != nil {
-		t.Fatal(err)
-	}
+	topic, _ := feed.NewTopic("foo.ess", nil)
+	updateRequest := feed.NewFirstRequest(topic)
 	updateRequest.SetData(mh, true)
 
 	if err := updateRequest.Sign(signer); err != nil {
@@ -160,8 +110,8 @@ func TestBzzResourceMultihash(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// create the multihash update
-	url = fmt.Sprintf("%s/bzz-resource:/", srv.URL)
+	// create the multihash update and a manifest pointing to the feed
+	url = fmt.Sprintf("%s/bzz-feed:/?manifest=true", srv.URL)
 	resp, err = http.Post(url, "application/json", bytes.NewReader(body))
 	if err != nil {
 		t.Fatal(err)
@@ -180,12 +130,12 @@ func TestBzzResourceMultihash(t *testing.T) {
 		t.Fatalf("data %s could not be unmarshaled: %v", b, err)
 	}
 
-	correctManifestAddrHex := "6d3bc4664c97d8b821cb74bcae43f592494fb46d2d9cd31e69f3c7c802bbbd8e"
+	correctManifestAddrHex := "a9c14670798931beaf718f16c48ea3d303c3e0b47341810063d87923fa912152"
 	if rsrcResp.Hex() != correctManifestAddrHex {
-		t.Fatalf("Response resource key mismatch, expected '%s', got '%s'", correctManifestAddrHex, rsrcResp.Hex())
+		t.Fatalf("Response feed manifest address mismatch, expected '%s', got '%s'", correctManifestAddrHex, rsrcResp.Hex())
 	}
 
-	// get bzz manifest transparent resource resolve
+	// get bzz manifest transparent feed update resolve
 	url = fmt.Sprintf("%s/bzz:/%s", srv.URL, rsrcResp)
 	resp, err = http.Get(url)
 	if err != nil {
@@ -204,15 +154,15 @@ func TestBzzResourceMultihash(t *testing.T) {
 	}
 }
 
-// Test resource updates using the raw update methods
-func TestBzzResource(t *testing.T) {
+// Test feed updates using the raw update methods
+func TestBzzFeed(t *testing.T) {
 	srv := testutil.NewTestSwarmServer(t, serverFunc)
 	signer, _ := newTestSigner()
 
 	defer srv.Close()
 
-	// our mutable resource "name"
-	keybytes := "foo.ess"
+	// our feed topic
+	topic, _ := feed.NewTopic("foo.ess", nil)
 
 	// data of update 1
 	databytes := make([]byte, 666)
@@ -221,29 +171,36 @@ func TestBzzResource(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	updateRequest, err := mru.NewCreateUpdateRequest(&mru.ResourceMetadata{
-		Name:      keybytes,
-		Frequency: 13,
-		StartTime: srv.GetCurrentTime(),
-		Owner:     signer.Address(),
-	})
+	updateRequest := feed.NewFirstRequest(topic)
+	updateRequest.SetData(databytes, false)
+
+	// an unsigned update can only be used to create a manifest
+	body, err := updateRequest.MarshalJSON()
 	if err != nil {
 		t.Fatal(err)
 	}
-	updateRequest.SetData(databytes, false)
+	url := fmt.Sprintf("%s/bzz-feed:/", srv.URL)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected unsigned update to fail with StatusBadRequest (400), got %d", resp.StatusCode)
+	}
 
 	if err := updateRequest.Sign(signer); err != nil {
 		t.Fatal(err)
 	}
 
-	body, err := updateRequest.MarshalJSON()
+	body, err = updateRequest.MarshalJSON()
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// creates resource and sets update 1
-	url := fmt.Sprintf("%s/bzz-resource:/", srv.URL)
-	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	// creates feed manifest and sets update 1
+	url = fmt.Sprintf("%s/bzz-feed:/?manifest=true", srv.URL)
+	resp, err = http.Post(url, "application/json", bytes.NewReader(body))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -261,9 +218,9 @@ func TestBzzResource(t *testing.T) {
 		t.Fatalf("data %s could not be unmarshaled: %v", b, err)
 	}
 
-	correctManifestAddrHex := "6d3bc4664c97d8b821cb74bcae43f592494fb46d2d9cd31e69f3c7c802bbbd8e"
+	correctManifestAddrHex := "a9c14670798931beaf718f16c48ea3d303c3e0b47341810063d87923fa912152"
 	if rsrcResp.Hex() != correctManifestAddrHex {
-		t.Fatalf("Response resource key mismatch, expected '%s', got '%s'", correctManifestAddrHex, rsrcResp.Hex())
+		t.Fatalf("Response feed manifest address mismatch, expected '%s', got '%s'", correctManifestAddrHex, rsrcResp.Hex())
 	}
 
 	// get the manifest
@@ -288,12 +245,11 @@ func TestBzzResource(t *testing.T) {
 	if len(manifest.Entries) != 1 {
 		t.Fatalf("Manifest has %d entries", len(manifest.Entries))
 	}
-	correctRootKeyHex := "68f7ba07ac8867a4c841a4d4320e3cdc549df23702dc7285fcb6acf65df48562"
-	if manifest.Entries[0].Hash != correctRootKeyHex {
-		t.Fatalf("Expected manifest path '%s', got '%s'", correctRootKeyHex, manifest.Entries[0].Hash)
+	if manifest.Entries[0].Feed == nil || *manifest.Entries[0].Feed != updateRequest.Feed {
+		t.Fatalf("Expected manifest feed '%v', got '%v'", updateRequest.Feed, manifest.Entries[0].Feed)
 	}
 
-	// get bzz manifest transparent resource resolve
+	// get bzz manifest transparent feed update resolve
 	url = fmt.Sprintf("%s/bzz:/%s", srv.URL, rsrcResp)
 	resp, err = http.Get(url)
 	if err != nil {
@@ -307,23 +263,26 @@ func TestBzzResource(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	if !bytes.Equal(databytes, b) {
+		t.Fatalf("Expected body '%x', got '%x'", databytes, b)
+	}
 
 	// get non-existent name, should fail
-	url = fmt.Sprintf("%s/bzz-resource:/bar", srv.URL)
+	url = fmt.Sprintf("%s/bzz-feed:/bar", srv.URL)
 	resp, err = http.Get(url)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	if resp.StatusCode != http.StatusNotFound {
-		t.Fatalf("Expected get non-existent resource to fail with StatusNotFound (404), got %d", resp.StatusCode)
+		t.Fatalf("Expected get non-existent feed manifest to fail with StatusNotFound (404), got %d", resp.StatusCode)
 	}
 
 	resp.Body.Close()
 
-	// get latest update (1.1) through resource directly
+	// get latest update through bzz-feed directly
 	log.Info("get update latest = 1.1", "addr", correctManifestAddrHex)
-	url = fmt.Sprintf("%s/bzz-resource:/%s", srv.URL, correctManifestAddrHex)
+	url = fmt.Sprintf("%s/bzz-feed:/%s", srv.URL, correctManifestAddrHex)
 	resp, err = http.Get(url)
 	if err != nil {
 		t.Fatal(err)
@@ -343,23 +302,23 @@ func TestBzzResource(t *testing.T) {
 	// update 2
 	log.Info("update 2")
 
-	// 1.- get metadata about this resource
-	url = fmt.Sprintf("%s/bzz-resource:/%s/", srv.URL, correctManifestAddrHex)
-	resp, err = http.Get(url + "meta")
+	// 1.- get metadata about this feed
+	url = fmt.Sprintf("%s/bzz-feed:/%s/", srv.URL, correctManifestAddrHex)
+	resp, err = http.Get(url + "?meta=true")
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("Get resource metadata returned %s", resp.Status)
+		t.Fatalf("Get feed metadata returned %s", resp.Status)
 	}
 	b, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
 		t.Fatal(err)
 	}
-	updateRequest = &mru.Request{}
+	updateRequest = &feed.Request{}
 	if err = updateRequest.UnmarshalJSON(b); err != nil {
-		t.Fatalf("Error decoding resource metadata: %s", err)
+		t.Fatalf("Error decoding feed metadata: %s", err)
 	}
 	data := []byte("foo")
 	updateRequest.SetData(data, false)
@@ -380,9 +339,9 @@ func TestBzzResource(t *testing.T) {
 		t.Fatalf("Update returned %s", resp.Status)
 	}
 
-	// get latest update (1.2) through resource directly
+	// get latest update (1.2) through bzz-feed directly
 	log.Info("get update 1.2")
-	url = fmt.Sprintf("%s/bzz-resource:/%s", srv.URL, correctManifestAddrHex)
+	url = fmt.Sprintf("%s/bzz-feed:/%s", srv.URL, correctManifestAddrHex)
 	resp, err = http.Get(url)
 	if err != nil {
 		t.Fatal(err)
@@ -399,9 +358,10 @@ func TestBzzResource(t *testing.T) {
 		t.Fatalf("Expected body '%x', got '%x'", data, b)
 	}
 
-	// get latest update (1.2) with specified period
+	// get latest update (1.2) with specified time, identifying the feed by topic and user
 	log.Info("get update latest = 1.2")
-	url = fmt.Sprintf("%s/bzz-resource:/%s/1", srv.URL, correctManifestAddrHex)
+	now := srv.GetCurrentTime().Time
+	url = fmt.Sprintf("%s/bzz-feed:/?topic=%s&user=%s&time=%d", srv.URL, topic.Hex(), signer.Address().Hex(), now)
 	resp, err = http.Get(url)
 	if err != nil {
 		t.Fatal(err)
@@ -418,23 +378,48 @@ func TestBzzResource(t *testing.T) {
 		t.Fatalf("Expected body '%x', got '%x'", data, b)
 	}
 
-	// get first update (1.1) with specified period and version
-	log.Info("get first update 1.1")
-	url = fmt.Sprintf("%s/bzz-resource:/%s/1/1", srv.URL, correctManifestAddrHex)
+	// there were no updates before the feed was created
+	url = fmt.Sprintf("%s/bzz-feed:/?topic=%s&user=%s&time=%d", srv.URL, topic.Hex(), signer.Address().Hex(), now-1)
 	resp, err = http.Get(url)
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("err %s", resp.Status)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected lookup before the first update to fail with StatusNotFound (404), got %d", resp.StatusCode)
 	}
-	b, err = ioutil.ReadAll(resp.Body)
+}
+
+// TestBzzResourceRemoved checks that requests to mutable resources, which
+// were replaced by feeds, are answered with 410 Gone
+func TestBzzResourceRemoved(t *testing.T) {
+	srv := testutil.NewTestSwarmServer(t, serverFunc)
+	defer srv.Close()
+
+	// the manifest of a resource created before feeds
+	manifest := fmt.Sprintf(`{"entries":[{"hash":"%s","contentType":"%s"}]}`, strings.Repeat("ab", 32), api.ResourceContentType)
+	ctx := context.TODO()
+	addr, wait, err := srv.FileStore.Store(ctx, strings.NewReader(manifest), int64(len(manifest)), false)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !bytes.Equal(databytes, b) {
-		t.Fatalf("Expected body '%x', got '%x'", databytes, b)
+	if err := wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, url := range []string{
+		srv.URL + "/bzz-resource:/" + addr.Hex(),
+		srv.URL + "/bzz:/" + addr.Hex() + "/",
+		srv.URL + "/bzz-feed:/" + addr.Hex(),
+	} {
+		res, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusGone {
+			t.Fatalf("expected status %d for %s, got %d", http.StatusGone, url, res.StatusCode)
+		}
 	}
 }
 
@@ -800,6 +785,134 @@ func testBzzTar(encrypted bool, t *testing.T) {
 	}
 }
 
+func TestBzzZip(t *testing.T) {
+	testBzzZip(false, t)
+	testBzzZip(true, t)
+}
+
+func testBzzZip(encrypted bool, t *testing.T) {
+	srv := testutil.NewTestSwarmServer(t, serverFunc)
+	defer srv.Close()
+	fileNames := []string{"tmp1.txt", "tmp2.lock", "dir/tmp3.html"}
+	fileContents := []string{"tmp1textfilevalue", "tmp2lockfilelocked", "<p>tmp3isjustanhtmlfile</p>"}
+
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for i, v := range fileNames {
+		hdr := &zip.FileHeader{
+			Name:     v,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		}
+		hdr.SetMode(0644)
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(fw, fileContents[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	//post zip archive
+	url := srv.URL + "/bzz:/"
+	if encrypted {
+		url = url + "encrypt"
+	}
+	req, err := http.NewRequest("POST", url, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Content-Type", "application/zip")
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swarmHash, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("err %s: %s", resp.Status, swarmHash)
+	}
+
+	// the content type of the files is guessed from their extension
+	resp, err = http.Get(fmt.Sprintf("%s/bzz:/%s/dir/tmp3.html", srv.URL, swarmHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("err %s", resp.Status)
+	}
+	if string(body) != fileContents[2] {
+		t.Fatalf("expected file content %q, got %q", fileContents[2], body)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("expected content type text/html, got %q", ct)
+	}
+
+	// now do a GET to get a zip archive back
+	req, err = http.NewRequest("GET", fmt.Sprintf("%s/bzz:/%s", srv.URL, swarmHash), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Add("Accept", "application/zip")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("error getting zip archive: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("err %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/zip" {
+		t.Fatalf("expected content type application/zip, got %q", ct)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != len(fileNames) {
+		t.Fatalf("expected %d files in zip archive, got %d", len(fileNames), len(zr.File))
+	}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		bb, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		passed := false
+		for i, v := range fileNames {
+			if v == f.Name && string(bb) == fileContents[i] {
+				passed = true
+				break
+			}
+		}
+		if !passed {
+			t.Fatalf("file %s did not pass content assertion", f.Name)
+		}
+	}
+}
+
 // TestBzzRootRedirect tests that getting the root path of a manifest without
 // a trailing slash gets redirected to include the trailing slash so that
 // relative URLs work as expected.
@@ -865,6 +978,366 @@ func testBzzRootRedirect(toEncrypt bool, t *testing.T) {
 	}
 }
 
+func TestBzzAccessPassword(t *testing.T) {
+	srv := testutil.NewTestSwarmServer(t, serverFunc)
+	defer srv.Close()
+
+	client := swarm.NewClient(srv.URL)
+	data := []byte("secret")
+	file := &swarm.File{
+		ReadCloser: ioutil.NopCloser(bytes.NewReader(data)),
+		ManifestEntry: api.ManifestEntry{
+			Path:        "",
+			ContentType: "text/plain",
+			Size:        int64(len(data)),
+		},
+	}
+	ref, err := client.Upload(file, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// protect the uploaded manifest with a password
+	salt, _ := api.NewSalt()
+	ae, err := api.NewAccessEntryPassword(salt, &api.KdfParams{N: 16, P: 1, R: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessKey, err := api.NewSessionKeyPassword("pass", ae)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := api.GenerateAccessControlManifest(ref, accessKey, ae)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := client.UploadManifest(m, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		password string
+		status   int
+	}{
+		{"", http.StatusUnauthorized},
+		{"wrong", http.StatusUnauthorized},
+		{"pass", http.StatusOK},
+	} {
+		req, err := http.NewRequest("GET", srv.URL+"/bzz:/"+root+"/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.password != "" {
+			req.SetBasicAuth("", test.password)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != test.status {
+			t.Fatalf("password %q: expected status %d, got %d", test.password, test.status, res.StatusCode)
+		}
+		if res.StatusCode == http.StatusUnauthorized && res.Header.Get("WWW-Authenticate") == "" {
+			t.Fatalf("password %q: missing authentication challenge", test.password)
+		}
+		if res.StatusCode == http.StatusOK && !bytes.Equal(body, data) {
+			t.Fatalf("expected response to equal %q, got %q", data, body)
+		}
+	}
+}
+
+// TestBzzTag checks that uploads are counted by tags, either created for an
+// upload with the tag query parameter or given in the X-Swarm-Tag header, and
+// that their status can be queried.
+func TestBzzTag(t *testing.T) {
+	srv := testutil.NewTestSwarmServer(t, serverFunc)
+	defer srv.Close()
+
+	getStatus := func(uid string) *storage.TagStatus {
+		res, err := http.Get(srv.URL + "/bzz-tag:/" + uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status getting tag %s: %s", uid, res.Status)
+		}
+		status := new(storage.TagStatus)
+		if err := json.NewDecoder(res.Body).Decode(status); err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	// a raw upload is not counted by a tag unless asked to
+	data := make([]byte, 3*4096)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Post(srv.URL+"/bzz-raw:/", "application/octet-stream", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status uploading: %s", res.Status)
+	}
+	if header := res.Header.Get(SwarmTagHeaderName); header != "" {
+		t.Fatalf("expected no tag in upload response, got %s", header)
+	}
+
+	// a raw upload with the tag query parameter gets a new tag
+	res, err = http.Post(srv.URL+"/bzz-raw:/?tag=raw", "application/octet-stream", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status uploading: %s", res.Status)
+	}
+	uid := res.Header.Get(SwarmTagHeaderName)
+	if uid == "" {
+		t.Fatal("missing tag header in upload response")
+	}
+	status := getStatus(uid)
+	if status.Name != "raw" {
+		t.Fatalf("expected tag name raw, got %q", status.Name)
+	}
+	if status.Address.Hex() != string(hash) {
+		t.Fatalf("expected tag address %s, got %s", hash, status.Address.Hex())
+	}
+	// 3 data chunks and their root
+	if status.Total != 4 || status.Split != 4 {
+		t.Fatalf("expected 4 chunks split, got %+v", status)
+	}
+
+	// a tag created beforehand counts the uploads sending its uid
+	res, err = http.Post(srv.URL+"/bzz-tag:/?name=files", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := new(storage.TagStatus)
+	err = json.NewDecoder(res.Body).Decode(created)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Name != "files" || created.Total != 0 {
+		t.Fatalf("unexpected new tag %+v", created)
+	}
+	req, err := http.NewRequest("POST", srv.URL+"/bzz:/", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set(SwarmTagHeaderName, fmt.Sprint(created.Uid))
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status uploading: %s", res.Status)
+	}
+	if header := res.Header.Get(SwarmTagHeaderName); header != fmt.Sprint(created.Uid) {
+		t.Fatalf("expected tag %d in upload response, got %s", created.Uid, header)
+	}
+	status = getStatus(fmt.Sprint(created.Uid))
+	// the chunks of the file and of the manifest are all counted
+	if status.Total <= 4 || status.Split != status.Total {
+		t.Fatalf("expected the chunks of the file and of the manifest, got %+v", status)
+	}
+
+	// the tags are not listed
+	res, err = http.Get(srv.URL + "/bzz-tag:/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected listing the tags to fail with StatusBadRequest (400), got %d", res.StatusCode)
+	}
+
+	// unknown tags are refused
+	req, err = http.NewRequest("POST", srv.URL+"/bzz-raw:/", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(SwarmTagHeaderName, "1000")
+	res, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected upload with unknown tag to fail with StatusBadRequest (400), got %d", res.StatusCode)
+	}
+	res, err = http.Get(srv.URL + "/bzz-tag:/1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected get unknown tag to fail with StatusNotFound (404), got %d", res.StatusCode)
+	}
+}
+
+// TestBzzCaching tests that responses to requests addressing content by its
+// hash can be cached, and that conditional requests are answered with 304
+// Not Modified if the content address matches the ETag
+func TestBzzCaching(t *testing.T) {
+	srv := testutil.NewTestSwarmServer(t, serverFunc)
+	defer srv.Close()
+
+	data := []byte("immutable content")
+	ctx := context.TODO()
+	contentAddr, wait, err := srv.FileStore.Store(ctx, bytes.NewReader(data), int64(len(data)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Post(srv.URL+"/bzz:/", "text/plain", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestHash, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("err %s: %s", resp.Status, manifestHash)
+	}
+
+	// checkContent checks that the response body is the uploaded content
+	checkContent := func(url string, body []byte) {
+		if !bytes.Equal(body, data) {
+			t.Fatalf("%s: expected content %q, got %q", url, data, body)
+		}
+	}
+	// checkList checks that the response body lists the uploaded content
+	checkList := func(url string, body []byte) {
+		var list api.ManifestList
+		if err := json.Unmarshal(body, &list); err != nil {
+			t.Fatalf("%s: error decoding list: %v", url, err)
+		}
+		if len(list.Entries) != 1 || list.Entries[0].Hash != contentAddr.Hex() {
+			t.Fatalf("%s: expected list of %s, got %s", url, contentAddr, body)
+		}
+	}
+
+	for _, c := range []struct {
+		url   string
+		etag  string
+		check func(string, []byte)
+	}{
+		{
+			url:   fmt.Sprintf("%s/bzz-raw:/%s", srv.URL, contentAddr),
+			etag:  fmt.Sprintf("%q", contentAddr.Hex()),
+			check: checkContent,
+		},
+		{
+			url:   fmt.Sprintf("%s/bzz:/%s/", srv.URL, manifestHash),
+			etag:  fmt.Sprintf("%q", contentAddr.Hex()),
+			check: checkContent,
+		},
+		{
+			url:   fmt.Sprintf("%s/bzz-immutable:/%s/", srv.URL, manifestHash),
+			etag:  fmt.Sprintf("%q", manifestHash),
+			check: checkList,
+		},
+	} {
+		url, etag := c.url, c.etag
+
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: err %s", url, resp.Status)
+		}
+		c.check(url, body)
+		if got := resp.Header.Get("ETag"); got != etag {
+			t.Fatalf("%s: expected ETag %s, got %s", url, etag, got)
+		}
+		if got := resp.Header.Get("Cache-Control"); got != immutableCacheControl {
+			t.Fatalf("%s: expected Cache-Control %q, got %q", url, immutableCacheControl, got)
+		}
+		if got := resp.Header.Get("Last-Modified"); got != "" {
+			t.Fatalf("%s: expected no Last-Modified header, got %q", url, got)
+		}
+
+		for noneMatch, expStatus := range map[string]int{
+			etag:             http.StatusNotModified,
+			"W/" + etag:      http.StatusNotModified,
+			`"foo", ` + etag: http.StatusNotModified,
+			"*":              http.StatusNotModified,
+			`"foo"`:          http.StatusOK,
+			etag[2:]:         http.StatusOK,
+		} {
+			req, err := http.NewRequest("GET", url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("If-None-Match", noneMatch)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != expStatus {
+				t.Fatalf("%s: expected status %d with If-None-Match %s, got %d", url, expStatus, noneMatch, resp.StatusCode)
+			}
+			if expStatus == http.StatusNotModified && len(body) != 0 {
+				t.Fatalf("%s: expected no body with status 304, got %q", url, body)
+			}
+			if got := resp.Header.Get("Cache-Control"); got != immutableCacheControl {
+				t.Fatalf("%s: expected Cache-Control %q with If-None-Match %s, got %q", url, immutableCacheControl, noneMatch, got)
+			}
+		}
+	}
+
+	// failed requests must not be cached, even if addressed by hash
+	for _, url := range []string{
+		fmt.Sprintf("%s/bzz:/%s/", srv.URL, contentAddr),
+		fmt.Sprintf("%s/bzz-immutable:/%s/", srv.URL, contentAddr),
+	} {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Fatalf("%s: expected request of a non manifest to fail", url)
+		}
+		if got := resp.Header.Get("Cache-Control"); got != "" {
+			t.Fatalf("%s: expected no Cache-Control on status %d, got %q", url, resp.StatusCode, got)
+		}
+	}
+}
+
 func TestMethodsNotAllowed(t *testing.T) {
 	srv := testutil.NewTestSwarmServer(t, serverFunc)
 	defer srv.Close()
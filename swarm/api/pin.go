@@ -0,0 +1,176 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"path"
+
+	"github.com/orangeAndSuns/essentia/metrics"
+	"github.com/orangeAndSuns/essentia/swarm/log"
+	"github.com/orangeAndSuns/essentia/swarm/storage"
+)
+
+var (
+	apiPinCount   = metrics.NewRegisteredCounter("api.pin.count", nil)
+	apiPinFail    = metrics.NewRegisteredCounter("api.pin.fail", nil)
+	apiUnpinCount = metrics.NewRegisteredCounter("api.unpin.count", nil)
+	apiUnpinFail  = metrics.NewRegisteredCounter("api.unpin.fail", nil)
+)
+
+// Pin pins the content at addr in the local store, so that its chunks are
+// never garbage collected. Missing chunks are retrieved first. Unless raw is
+// set, addr is a manifest and the content of all its entries, including
+// submanifests, is pinned along with it.
+func (a *API) Pin(ctx context.Context, addr storage.Address, raw bool) error {
+	apiPinCount.Inc(1)
+	ps, err := a.fileStore.PinStore()
+	if err != nil {
+		apiPinFail.Inc(1)
+		return err
+	}
+	addrs, err := a.pinnedChunks(ctx, addr, raw)
+	if err != nil {
+		apiPinFail.Inc(1)
+		return err
+	}
+	log.Debug("api.pin", "addr", addr, "raw", raw, "chunks", len(addrs))
+	return ps.Pin(&storage.PinnedRoot{Addr: addr, Raw: raw}, addrs)
+}
+
+// Unpin releases the chunks of content pinned at addr to garbage collection,
+// unless they are shared with other pinned content.
+func (a *API) Unpin(ctx context.Context, addr storage.Address) error {
+	apiUnpinCount.Inc(1)
+	ps, err := a.fileStore.PinStore()
+	if err != nil {
+		apiUnpinFail.Inc(1)
+		return err
+	}
+	root, err := ps.PinnedRoot(addr)
+	if err != nil {
+		apiUnpinFail.Inc(1)
+		return err
+	}
+	addrs, err := a.pinnedChunks(ctx, addr, root.Raw)
+	if err != nil {
+		apiUnpinFail.Inc(1)
+		return err
+	}
+	log.Debug("api.unpin", "addr", addr, "raw", root.Raw, "chunks", len(addrs))
+	return ps.Unpin(addr, addrs)
+}
+
+// PinnedRoot returns the pinned root at addr.
+func (a *API) PinnedRoot(addr storage.Address) (*storage.PinnedRoot, error) {
+	ps, err := a.fileStore.PinStore()
+	if err != nil {
+		return nil, err
+	}
+	return ps.PinnedRoot(addr)
+}
+
+// PinnedRoots returns all the pinned roots.
+func (a *API) PinnedRoots() ([]*storage.PinnedRoot, error) {
+	ps, err := a.fileStore.PinStore()
+	if err != nil {
+		return nil, err
+	}
+	return ps.PinnedRoots()
+}
+
+// pinnedChunks returns the addresses of the chunks pinned for the content at
+// addr.
+func (a *API) pinnedChunks(ctx context.Context, addr storage.Address, raw bool) ([]storage.Address, error) {
+	addrs, err := a.fileStore.ChunkAddresses(ctx, storage.Reference(addr))
+	if err != nil || raw {
+		return addrs, err
+	}
+	walker, err := a.NewManifestWalker(ctx, addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = walker.Walk(func(entry *ManifestEntry) error {
+		ref, err := hex.DecodeString(entry.Hash)
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.Access != nil:
+			// the reference is encrypted, only the manifest itself is pinned
+			if entry.ContentType == ManifestType {
+				return ErrSkipManifest
+			}
+			return nil
+		case entry.ContentType == FeedContentType:
+			// feed updates are mutable, there is nothing to pin
+			return nil
+		}
+		chunks, err := a.fileStore.ChunkAddresses(ctx, storage.Reference(ref))
+		if err != nil {
+			return err
+		}
+		addrs = append(addrs, chunks...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+// PinAPI exposes content pinning over RPC.
+type PinAPI struct {
+	api *API
+}
+
+func NewPinAPI(api *API) *PinAPI {
+	return &PinAPI{api}
+}
+
+// Pin pins the content at the given hash or ENS name. Unless raw is set, the
+// content is a manifest whose entries are pinned along with it.
+func (p *PinAPI) Pin(ctx context.Context, addr string, raw bool) error {
+	root, err := p.resolve(ctx, addr)
+	if err != nil {
+		return err
+	}
+	return p.api.Pin(ctx, root, raw)
+}
+
+// Unpin unpins the content at the given hash or ENS name.
+func (p *PinAPI) Unpin(ctx context.Context, addr string) error {
+	root, err := p.resolve(ctx, addr)
+	if err != nil {
+		return err
+	}
+	return p.api.Unpin(ctx, root)
+}
+
+// PinnedRoots returns the pinned content.
+func (p *PinAPI) PinnedRoots() ([]*storage.PinnedRoot, error) {
+	return p.api.PinnedRoots()
+}
+
+func (p *PinAPI) resolve(ctx context.Context, addr string) (storage.Address, error) {
+	uri, err := Parse(path.Join("bzz:/", addr))
+	if err != nil {
+		return nil, err
+	}
+	return p.api.Resolve(ctx, uri)
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"github.com/orangeAndSuns/essentia/swarm/storage"
+)
+
+// NewTag creates a tag counting the chunks of an upload. Content stored with
+// a context carrying the tag (see storage.WithTag) is counted by it.
+func (a *API) NewTag(name string) *storage.Tag {
+	return a.fileStore.Tags.New(name)
+}
+
+// Tag returns the tag with the given uid.
+func (a *API) Tag(uid uint32) (*storage.Tag, error) {
+	return a.fileStore.Tags.Get(uid)
+}
+
+// Tags returns all the tags.
+func (a *API) Tags() []*storage.Tag {
+	return a.fileStore.Tags.All()
+}
+
+// TagAPI exposes the progress of uploads over RPC.
+type TagAPI struct {
+	api *API
+}
+
+func NewTagAPI(api *API) *TagAPI {
+	return &TagAPI{api}
+}
+
+// Tag returns the status of the tag with the given uid.
+func (t *TagAPI) Tag(uid uint32) (*storage.TagStatus, error) {
+	tag, err := t.api.Tag(uid)
+	if err != nil {
+		return nil, err
+	}
+	return tag.Status(), nil
+}
+
+// Tags returns the status of all the tags.
+func (t *TagAPI) Tags() []*storage.TagStatus {
+	tags := t.api.Tags()
+	statuses := make([]*storage.TagStatus, len(tags))
+	for i, tag := range tags {
+		statuses[i] = tag.Status()
+	}
+	return statuses
+}
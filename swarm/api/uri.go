@@ -39,6 +39,11 @@ type URI struct {
 	// * bzz-immutable - immutable URI of an entry in a swarm manifest
 	//                   (address is not resolved)
 	// * bzz-list      -  list of all files contained in a swarm manifest
+	// * bzz-feed      - a Swarm feed update
+	// * bzz-resource  - a mutable resource update, answered with 410 Gone
+	//                   since mutable resources were replaced by feeds
+	// * bzz-pin       - content pinned in the local store
+	// * bzz-tag       - progress of the uploads to swarm
 	//
 	Scheme string
 
@@ -63,7 +68,7 @@ type URI struct {
 // * <scheme>://<addr>
 // * <scheme>://<addr>/<path>
 //
-// with scheme one of bzz, bzz-raw, bzz-immutable, bzz-list or bzz-hash
+// with scheme one of bzz, bzz-raw, bzz-immutable, bzz-list, bzz-hash, bzz-feed, bzz-resource, bzz-pin or bzz-tag
 func Parse(rawuri string) (*URI, error) {
 	u, err := url.Parse(rawuri)
 	if err != nil {
@@ -73,7 +78,7 @@ func Parse(rawuri string) (*URI, error) {
 
 	// check the scheme is valid
 	switch uri.Scheme {
-	case "bzz", "bzz-raw", "bzz-immutable", "bzz-list", "bzz-hash", "bzz-resource":
+	case "bzz", "bzz-raw", "bzz-immutable", "bzz-list", "bzz-hash", "bzz-feed", "bzz-resource", "bzz-pin", "bzz-tag":
 	default:
 		return nil, fmt.Errorf("unknown scheme %q", u.Scheme)
 	}
@@ -95,8 +100,8 @@ func Parse(rawuri string) (*URI, error) {
 	}
 	return uri, nil
 }
-func (u *URI) Resource() bool {
-	return u.Scheme == "bzz-resource"
+func (u *URI) Feed() bool {
+	return u.Scheme == "bzz-feed"
 }
 
 func (u *URI) Raw() bool {
@@ -115,6 +120,14 @@ func (u *URI) Hash() bool {
 	return u.Scheme == "bzz-hash"
 }
 
+func (u *URI) Pin() bool {
+	return u.Scheme == "bzz-pin"
+}
+
+func (u *URI) Tag() bool {
+	return u.Scheme == "bzz-tag"
+}
+
 func (u *URI) String() string {
 	return u.Scheme + ":/" + u.Addr + "/" + u.Path
 }
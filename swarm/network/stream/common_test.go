@@ -150,6 +150,12 @@ func localStoreCleanup() {
 }
 
 func newStreamerTester(t *testing.T) (*p2ptest.ProtocolTester, *Registry, *storage.LocalStore, func(), error) {
+	return newStreamerTesterWithOptions(t, &RegistryOptions{
+		SkipCheck: defaultSkipCheck,
+	})
+}
+
+func newStreamerTesterWithOptions(t *testing.T, options *RegistryOptions) (*p2ptest.ProtocolTester, *Registry, *storage.LocalStore, func(), error) {
 	// setup
 	addr := network.RandomAddr() // tested peers peer address
 	to := network.NewKademlia(addr.OAddr, network.NewKadParams())
@@ -174,9 +180,7 @@ func newStreamerTester(t *testing.T) (*p2ptest.ProtocolTester, *Registry, *stora
 
 	db := storage.NewDBAPI(localStore)
 	delivery := NewDelivery(to, db)
-	streamer := NewRegistry(addr, delivery, db, state.NewInmemoryStore(), &RegistryOptions{
-		SkipCheck: defaultSkipCheck,
-	})
+	streamer := NewRegistry(addr, delivery, db, state.NewInmemoryStore(), options)
 	teardown := func() {
 		streamer.Close()
 		removeDataDir()
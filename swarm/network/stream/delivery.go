@@ -147,6 +147,11 @@ func (d *Delivery) handleRetrieveRequestMsg(ctx context.Context, sp *Peer, req *
 		"retrieve.request")
 	defer osp.Finish()
 
+	// chunks are not delivered for free to peers which should pay for them
+	if err := sp.checkSwap(); err != nil {
+		return err
+	}
+
 	s, err := sp.getServer(NewStream(swarmChunkServerStreamName, "", false))
 	if err != nil {
 		return err
@@ -184,7 +189,10 @@ func (d *Delivery) handleRetrieveRequestMsg(ctx context.Context, sp *Peer, req *
 			chunk.SetErrored(nil)
 
 			if req.SkipCheck {
-				err := sp.Deliver(ctx, chunk, s.priority)
+				err := sp.swapAdd(1)
+				if err == nil {
+					err = sp.Deliver(ctx, chunk, s.priority)
+				}
 				if err != nil {
 					log.Warn("ERROR in handleRetrieveRequestMsg, DROPPING peer!", "err", err)
 					sp.Drop(err)
@@ -200,6 +208,9 @@ func (d *Delivery) handleRetrieveRequestMsg(ctx context.Context, sp *Peer, req *
 		if length := len(chunk.SData); length < 9 {
 			log.Error("Chunk.SData to deliver is too short", "len(chunk.SData)", length, "address", chunk.Addr)
 		}
+		if err := sp.swapAdd(1); err != nil {
+			return err
+		}
 		return sp.Deliver(ctx, chunk, s.priority)
 	}
 	streamer.deliveryC <- chunk.Addr[:]
@@ -220,6 +231,11 @@ func (d *Delivery) handleChunkDeliveryMsg(ctx context.Context, sp *Peer, req *Ch
 	defer osp.Finish()
 
 	req.peer = sp
+	if sp.delivered(req.Addr) {
+		if err := sp.swapAdd(-1); err != nil {
+			log.Warn("SWAP accounting of chunk delivery", "peer", sp.ID(), "addr", req.Addr, "err", err)
+		}
+	}
 	d.receiveC <- req
 	return nil
 }
@@ -275,6 +291,7 @@ func (d *Delivery) RequestFromPeers(ctx context.Context, hash []byte, skipCheck
 			log.Warn("Delivery.RequestFromPeers: peer not found", "id", spId)
 			return true
 		}
+		sp.requested(hash)
 		err = sp.SendPriority(ctx, &RetrieveRequestMsg{
 			Addr:      hash,
 			SkipCheck: skipCheck,
@@ -318,11 +318,25 @@ func (p *Peer) handleWantedHashesMsg(ctx context.Context, req *WantedHashesMsg)
 	if err != nil {
 		return fmt.Errorf("error initiaising bitvector of length %v: %v", l, err)
 	}
+
+	// count the syncing of the chunks of tagged uploads
+	var tags *storage.Tags
+	if req.Stream.Name == "SYNC" {
+		tags = p.streamer.tags
+	}
+	if tags != nil {
+		// the downstream peer only asks for this batch once all the chunks
+		// delivered from the previous one are stored
+		for i := 0; i < len(s.sentBatch); i += HashSize {
+			tags.Synced(storage.Address(s.sentBatch[i : i+HashSize]))
+		}
+		s.sentBatch = nil
+	}
 	for i := 0; i < l; i++ {
+		hash := hashes[i*HashSize : (i+1)*HashSize]
 		if want.Get(i) {
 			metrics.GetOrRegisterCounter("peer.handlewantedhashesmsg.actualget", nil).Inc(1)
 
-			hash := hashes[i*HashSize : (i+1)*HashSize]
 			data, err := s.GetData(ctx, hash)
 			if err != nil {
 				return fmt.Errorf("handleWantedHashesMsg get data %x: %v", hash, err)
@@ -332,9 +346,22 @@ func (p *Peer) handleWantedHashesMsg(ctx context.Context, req *WantedHashesMsg)
 			if length := len(chunk.SData); length < 9 {
 				log.Error("Chunk.SData to sync is too short", "len(chunk.SData)", length, "address", chunk.Addr)
 			}
+			if req.Stream.Name == swarmChunkServerStreamName {
+				// chunks delivered upon retrieve requests are paid for
+				if err := p.swapAdd(1); err != nil {
+					return err
+				}
+			}
 			if err := p.Deliver(ctx, chunk, s.priority); err != nil {
 				return err
 			}
+			if tags != nil {
+				tags.Sent(chunk.Addr)
+				s.sentBatch = append(s.sentBatch, hash...)
+			}
+		} else if tags != nil {
+			// the downstream peer already has the chunk
+			tags.Synced(storage.Address(hash))
 		}
 	}
 	return nil
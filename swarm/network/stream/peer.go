@@ -28,6 +28,7 @@ import (
 	"github.com/orangeAndSuns/essentia/swarm/log"
 	pq "github.com/orangeAndSuns/essentia/swarm/network/priorityqueue"
 	"github.com/orangeAndSuns/essentia/swarm/network/stream/intervals"
+	"github.com/orangeAndSuns/essentia/swarm/services/swap/swap"
 	"github.com/orangeAndSuns/essentia/swarm/spancontext"
 	"github.com/orangeAndSuns/essentia/swarm/state"
 	"github.com/orangeAndSuns/essentia/swarm/storage"
@@ -62,6 +63,12 @@ type Peer struct {
 	// on creating a new client in offered hashes handler.
 	clientParams map[Stream]*clientParams
 	quit         chan struct{}
+	swapMu       sync.Mutex // protects swap and retrievals
+	swap         *swap.Swap // set once the SWAP profile of the peer is received
+	// retrievals holds the addresses of the chunks requested from the peer
+	// that are accounted on delivery, and when they were requested
+	retrievals       map[string]time.Time
+	retrievalsPruned time.Time // last time expired retrievals were removed
 }
 
 type WrappedPriorityMsg struct {
@@ -351,4 +358,7 @@ func (p *Peer) close() {
 	for _, s := range p.servers {
 		s.Close()
 	}
+	if s := p.getSwap(); s != nil {
+		s.Stop()
+	}
 }
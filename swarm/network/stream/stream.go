@@ -24,6 +24,7 @@ import (
 	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/orangeAndSuns/essentia/contracts/chequebook"
 	"github.com/orangeAndSuns/essentia/metrics"
 	"github.com/orangeAndSuns/essentia/p2p"
 	"github.com/orangeAndSuns/essentia/p2p/discover"
@@ -33,6 +34,7 @@ import (
 	"github.com/orangeAndSuns/essentia/swarm/network"
 	"github.com/orangeAndSuns/essentia/swarm/network/stream/intervals"
 	"github.com/orangeAndSuns/essentia/swarm/pot"
+	bzzswap "github.com/orangeAndSuns/essentia/swarm/services/swap"
 	"github.com/orangeAndSuns/essentia/swarm/spancontext"
 	"github.com/orangeAndSuns/essentia/swarm/state"
 	"github.com/orangeAndSuns/essentia/swarm/storage"
@@ -62,6 +64,9 @@ type Registry struct {
 	delivery       *Delivery
 	intervalsStore state.Store
 	doRetrieve     bool
+	tags           *storage.Tags
+	swap           *bzzswap.LocalProfile
+	swapBackend    chequebook.Backend
 }
 
 // RegistryOptions holds optional values for NewRegistry constructor.
@@ -70,6 +75,11 @@ type RegistryOptions struct {
 	DoSync          bool
 	DoRetrieve      bool
 	SyncUpdateDelay time.Duration
+	Tags            *storage.Tags // if set, syncing of the chunks of tagged uploads is counted
+	// if Swap and SwapBackend are set, retrieved chunks are accounted
+	// with peers and settled with cheques
+	Swap        *bzzswap.LocalProfile
+	SwapBackend chequebook.Backend
 }
 
 // NewRegistry is Streamer constructor
@@ -89,6 +99,11 @@ func NewRegistry(addr *network.BzzAddr, delivery *Delivery, db *storage.DBAPI, i
 		delivery:       delivery,
 		intervalsStore: intervalsStore,
 		doRetrieve:     options.DoRetrieve,
+		tags:           options.Tags,
+	}
+	if options.Swap != nil && options.SwapBackend != nil {
+		streamer.swap = options.Swap
+		streamer.swapBackend = options.SwapBackend
 	}
 	streamer.api = NewAPI(streamer)
 	delivery.getPeer = streamer.getPeer
@@ -335,6 +350,20 @@ func (r *Registry) Retrieve(ctx context.Context, chunk *storage.Chunk) error {
 	return r.delivery.RequestFromPeers(ctx, chunk.Addr[:], r.skipCheck)
 }
 
+// SwapBalance returns the SWAP balance with the peer in chunks,
+// positive if the peer owes us
+func (r *Registry) SwapBalance(peerId discover.ESSNodeID) (int, error) {
+	peer := r.getPeer(peerId)
+	if peer == nil {
+		return 0, fmt.Errorf("peer not found %v", peerId)
+	}
+	s := peer.getSwap()
+	if s == nil {
+		return 0, fmt.Errorf("SWAP not set up with peer %v", peerId)
+	}
+	return s.Balance(), nil
+}
+
 func (r *Registry) NodeInfo() interface{} {
 	return nil
 }
@@ -383,6 +412,12 @@ func (r *Registry) Run(p *network.BzzPeer) error {
 	defer close(sp.quit)
 	defer sp.close()
 
+	if r.swap != nil {
+		if err := sp.sendSwapProfile(context.TODO()); err != nil {
+			return err
+		}
+	}
+
 	if r.doRetrieve {
 		err := r.Subscribe(p.ID(), NewStream(swarmChunkServerStreamName, "", false), nil, Top)
 		if err != nil {
@@ -501,6 +536,12 @@ func (p *Peer) HandleMsg(ctx context.Context, msg interface{}) error {
 	case *QuitMsg:
 		return p.handleQuitMsg(msg)
 
+	case *SwapProfileMsg:
+		return p.handleSwapProfileMsg(msg)
+
+	case *PaymentMsg:
+		return p.handlePaymentMsg(msg)
+
 	default:
 		return fmt.Errorf("unknown message type: %T", msg)
 	}
@@ -511,6 +552,7 @@ type server struct {
 	stream       Stream
 	priority     uint8
 	currentBatch []byte
+	sentBatch    []byte // hashes of the chunks delivered from the previous batch
 }
 
 // Server interface for outgoing peer Streamer
@@ -653,7 +695,7 @@ func (c *clientParams) clientCreated() {
 // Spec is the spec of the streamer protocol
 var Spec = &protocols.Spec{
 	Name:       "stream",
-	Version:    5,
+	Version:    6,
 	MaxMsgSize: 10 * 1024 * 1024,
 	Messages: []interface{}{
 		UnsubscribeMsg{},
@@ -666,6 +708,8 @@ var Spec = &protocols.Spec{
 		SubscribeErrorMsg{},
 		RequestSubscriptionMsg{},
 		QuitMsg{},
+		SwapProfileMsg{},
+		PaymentMsg{},
 	},
 }
 
@@ -745,3 +789,7 @@ func (api *API) SubscribeStream(peerId discover.ESSNodeID, s Stream, history *Ra
 func (api *API) UnsubscribeStream(peerId discover.ESSNodeID, s Stream) error {
 	return api.streamer.Unsubscribe(peerId, s)
 }
+
+func (api *API) SwapBalance(peerId discover.ESSNodeID) (int, error) {
+	return api.streamer.SwapBalance(peerId)
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/orangeAndSuns/essentia/crypto/sha3"
 	p2ptest "github.com/orangeAndSuns/essentia/p2p/testing"
+	"github.com/orangeAndSuns/essentia/swarm/storage"
 )
 
 func TestStreamerSubscribe(t *testing.T) {
@@ -556,6 +557,167 @@ func TestStreamerDownstreamOfferedHashesMsgExchange(t *testing.T) {
 
 }
 
+// testSyncServer offers the given batches of hashes one after the other and
+// serves the data of the chunks from the local store
+type testSyncServer struct {
+	store   *storage.LocalStore
+	batches [][]byte
+}
+
+func (s *testSyncServer) SetNextBatch(from uint64, to uint64) ([]byte, uint64, uint64, *HandoverProof, error) {
+	if len(s.batches) == 0 {
+		return nil, 0, 0, nil, nil
+	}
+	batch := s.batches[0]
+	s.batches = s.batches[1:]
+	return batch, from + 1, to + 1, nil, nil
+}
+
+func (s *testSyncServer) GetData(ctx context.Context, hash []byte) ([]byte, error) {
+	chunk, err := s.store.Get(ctx, storage.Address(hash))
+	if err != nil {
+		return nil, err
+	}
+	return chunk.SData, nil
+}
+
+func (s *testSyncServer) Close() {}
+
+// TestStreamerUpstreamSyncTagExchange tests that the chunks of a tagged
+// upload are counted as sent when they are delivered by syncing, and as
+// synced when the downstream peer does not want them or asks for the next
+// batch after storing them.
+func TestStreamerUpstreamSyncTagExchange(t *testing.T) {
+	tags := storage.NewTags()
+	tester, streamer, localStore, teardown, err := newStreamerTesterWithOptions(t, &RegistryOptions{
+		SkipCheck: defaultSkipCheck,
+		Tags:      tags,
+	})
+	defer teardown()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// upload two single chunk files counted by the same tag
+	tag := tags.New("test")
+	ctx := storage.WithTag(context.TODO(), tag)
+	fileStore := storage.NewFileStore(localStore, storage.NewFileStoreParams())
+	var addrs []storage.Address
+	for _, data := range []string{"wanted", "not wanted"} {
+		addr, wait, err := fileStore.Store(ctx, bytes.NewReader([]byte(data)), int64(len(data)), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		addrs = append(addrs, addr)
+	}
+	hashes := append(append([]byte{}, addrs[0]...), addrs[1]...)
+	chunk, err := localStore.Get(ctx, addrs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	streamer.RegisterServerFunc("SYNC", func(p *Peer, t string, live bool) (Server, error) {
+		return &testSyncServer{store: localStore, batches: [][]byte{hashes}}, nil
+	})
+
+	// the counters are updated after the messages are sent
+	checkCounters := func(sent, synced int64) {
+		deadline := time.Now().Add(time.Second)
+		for tag.Get(storage.StateSent) != sent || tag.Get(storage.StateSynced) != synced {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d sent and %d synced, got %+v", sent, synced, tag.Status())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	stream := NewStream("SYNC", "", false)
+	peerID := tester.IDs[0]
+
+	err = tester.TestExchanges(p2ptest.Exchange{
+		Label: "Subscribe message",
+		Triggers: []p2ptest.Trigger{
+			{
+				Code: 4,
+				Msg: &SubscribeMsg{
+					Stream:   stream,
+					Priority: Top,
+				},
+				Peer: peerID,
+			},
+		},
+		Expects: []p2ptest.Expect{
+			{
+				Code: 1,
+				Msg: &OfferedHashesMsg{
+					Stream: stream,
+					HandoverProof: &HandoverProof{
+						Handover: &Handover{},
+					},
+					Hashes: hashes,
+					From:   1,
+					To:     1,
+				},
+				Peer: peerID,
+			},
+		},
+	},
+		p2ptest.Exchange{
+			Label: "WantedHashes message",
+			Triggers: []p2ptest.Trigger{
+				{
+					Code: 2,
+					Msg: &WantedHashesMsg{
+						Stream: stream,
+						Want:   []byte{1},
+						From:   1,
+						To:     1,
+					},
+					Peer: peerID,
+				},
+			},
+			Expects: []p2ptest.Expect{
+				{
+					Code: 6,
+					Msg: &ChunkDeliveryMsg{
+						Addr:  addrs[0],
+						SData: chunk.SData,
+					},
+					Peer: peerID,
+				},
+			},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// both chunks are sent, the one not wanted is already synced
+	checkCounters(2, 1)
+
+	err = tester.TestExchanges(p2ptest.Exchange{
+		Label: "WantedHashes message for the next batch",
+		Triggers: []p2ptest.Trigger{
+			{
+				Code: 2,
+				Msg: &WantedHashesMsg{
+					Stream: stream,
+					Want:   []byte{0},
+					From:   2,
+					To:     2,
+				},
+				Peer: peerID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the delivered chunk is synced once the next batch is asked for
+	checkCounters(2, 2)
+}
+
 func TestStreamerRequestSubscriptionQuitMsgExchange(t *testing.T) {
 	tester, streamer, _, teardown, err := newStreamerTester(t)
 	defer teardown()
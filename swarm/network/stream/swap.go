@@ -0,0 +1,220 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/contracts/chequebook"
+	"github.com/orangeAndSuns/essentia/swarm/log"
+	bzzswap "github.com/orangeAndSuns/essentia/swarm/services/swap"
+	"github.com/orangeAndSuns/essentia/swarm/services/swap/swap"
+	"github.com/orangeAndSuns/essentia/swarm/storage"
+)
+
+// SWAP accounting on streamer peer connections
+// * nodes with SWAP enabled send their SwapProfileMsg on connection
+// * once the profile of the remote peer is received, chunks delivered upon
+//   retrieve requests are accounted with the peer: +1 unit for each chunk
+//   delivered to the peer, -1 unit for each chunk retrieved from it
+// * a peer requesting chunks before its profile is received is dropped
+// * a peer whose debt reaches the local disconnect threshold (DropAt) is dropped
+// * debts reaching the payment threshold of the remote peer (PayAt) are settled
+//   with a cheque of the local chequebook sent in a PaymentMsg
+
+var (
+	errSwapDrop           = errors.New("SWAP balance with peer exceeds the disconnect threshold")
+	errSwapProfileMissing = errors.New("SWAP profile of peer not received")
+)
+
+// retrievalTimeout is how long a retrieve request sent to a peer is kept to
+// account the delivery of the chunk. Peers forwarding the request wait for
+// the chunk as long (see handleRetrieveRequestMsg).
+const retrievalTimeout = 10 * time.Minute
+
+// SwapProfileMsg is the protocol msg announcing the SWAP profile of a node:
+// the prices it trades chunks at, its balance thresholds and the chequebook
+// it pays with
+type SwapProfileMsg struct {
+	PublicKey   string         // public key the cheques are signed with
+	Contract    common.Address // address of the chequebook contract
+	Beneficiary common.Address // recipient address for payments
+	BuyAt       *big.Int       // accepted max price for chunk
+	SellAt      *big.Int       // offered sale price for chunk
+	PayAt       uint           // balance that triggers payment
+	DropAt      uint           // balance that triggers disconnect
+}
+
+// PaymentMsg is the protocol msg settling units of service with a cheque
+type PaymentMsg struct {
+	Units   uint               // units actually paid for (checked against the amount by swap)
+	Promise *chequebook.Cheque // payment with cheque
+}
+
+// swapProtocol implements swap.Protocol for the SWAP instance of a peer
+type swapProtocol struct {
+	peer *Peer
+}
+
+// Pay sends the cheque issued for units to the peer
+func (sp swapProtocol) Pay(units int, promise swap.Promise) {
+	msg := &PaymentMsg{
+		Units:   uint(units),
+		Promise: promise.(*chequebook.Cheque),
+	}
+	// Pay is called with the swap lock held, do not block on the queue
+	go func() {
+		if err := sp.peer.SendPriority(context.TODO(), msg, Top); err != nil {
+			log.Warn("sending SWAP payment", "peer", sp.peer.ID(), "units", units, "err", err)
+		}
+	}()
+}
+
+// Drop disconnects the peer
+func (sp swapProtocol) Drop() {
+	sp.peer.Drop(errSwapDrop)
+}
+
+func (sp swapProtocol) String() string {
+	return sp.peer.ID().String()
+}
+
+// sendSwapProfile sends the local SWAP profile to the peer
+func (p *Peer) sendSwapProfile(ctx context.Context) error {
+	local := p.streamer.swap
+	return p.SendPriority(ctx, &SwapProfileMsg{
+		PublicKey:   local.PublicKey,
+		Contract:    local.Contract,
+		Beneficiary: local.Beneficiary,
+		BuyAt:       local.BuyAt,
+		SellAt:      local.SellAt,
+		PayAt:       local.PayAt,
+		DropAt:      local.DropAt,
+	}, Top)
+}
+
+// handleSwapProfileMsg sets up SWAP with the peer given its profile
+func (p *Peer) handleSwapProfileMsg(req *SwapProfileMsg) error {
+	local := p.streamer.swap
+	if local == nil {
+		// SWAP is not enabled, chunks are exchanged for free
+		return nil
+	}
+	if local.Chequebook() == nil {
+		// the chequebook is set up when swarm starts
+		return errors.New("SWAP chequebook not set up")
+	}
+	remote := &bzzswap.RemoteProfile{
+		Profile: &swap.Profile{
+			BuyAt:  req.BuyAt,
+			SellAt: req.SellAt,
+			PayAt:  req.PayAt,
+			DropAt: req.DropAt,
+		},
+		PayProfile: &bzzswap.PayProfile{
+			PublicKey:   req.PublicKey,
+			Contract:    req.Contract,
+			Beneficiary: req.Beneficiary,
+		},
+	}
+	s, err := bzzswap.NewSwap(local, remote, p.streamer.swapBackend, swapProtocol{p})
+	if err != nil {
+		return err
+	}
+	p.swapMu.Lock()
+	defer p.swapMu.Unlock()
+	if p.swap != nil {
+		s.Stop()
+		return errors.New("SWAP profile already received")
+	}
+	p.swap = s
+	p.retrievals = make(map[string]time.Time)
+	return nil
+}
+
+// handlePaymentMsg credits the peer with the units paid by the cheque
+func (p *Peer) handlePaymentMsg(req *PaymentMsg) error {
+	s := p.getSwap()
+	if s == nil || !s.Sells {
+		return errors.New("unexpected SWAP payment")
+	}
+	return s.Receive(int(req.Units), req.Promise)
+}
+
+func (p *Peer) getSwap() *swap.Swap {
+	p.swapMu.Lock()
+	defer p.swapMu.Unlock()
+	return p.swap
+}
+
+// checkSwap returns an error if SWAP is enabled locally but the profile of
+// the peer is not received yet, so chunks can't be accounted with it
+func (p *Peer) checkSwap() error {
+	if p.streamer.swap != nil && p.getSwap() == nil {
+		return errSwapProfileMissing
+	}
+	return nil
+}
+
+// swapAdd accounts n units of service with the peer
+// n > 0 for chunks delivered to the peer
+// n < 0 for chunks retrieved from the peer
+func (p *Peer) swapAdd(n int) error {
+	s := p.getSwap()
+	if s == nil {
+		return p.checkSwap()
+	}
+	return s.Add(n)
+}
+
+// requested records a retrieve request sent to the peer so that the delivery
+// of the chunk can be accounted. Requests older than retrievalTimeout, which
+// timed out or were answered by another peer, are removed.
+func (p *Peer) requested(addr storage.Address) {
+	p.swapMu.Lock()
+	defer p.swapMu.Unlock()
+	if p.swap == nil {
+		return
+	}
+	now := time.Now()
+	if now.Sub(p.retrievalsPruned) > retrievalTimeout {
+		for a, t := range p.retrievals {
+			if now.Sub(t) > retrievalTimeout {
+				delete(p.retrievals, a)
+			}
+		}
+		p.retrievalsPruned = now
+	}
+	p.retrievals[string(addr)] = now
+}
+
+// delivered returns true if the chunk delivered by the peer was retrieved
+// from it with a retrieve request which has not expired
+func (p *Peer) delivered(addr storage.Address) bool {
+	p.swapMu.Lock()
+	defer p.swapMu.Unlock()
+	t, ok := p.retrievals[string(addr)]
+	if !ok {
+		return false
+	}
+	delete(p.retrievals, string(addr))
+	return time.Since(t) <= retrievalTimeout
+}
@@ -0,0 +1,646 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package stream
+
+import (
+	"context"
+	"crypto/ecdsa"
+	crand "crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/orangeAndSuns/essentia/accounts/abi/bind"
+	"github.com/orangeAndSuns/essentia/accounts/abi/bind/backends"
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/contracts/chequebook"
+	"github.com/orangeAndSuns/essentia/contracts/chequebook/contract"
+	"github.com/orangeAndSuns/essentia/core"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/node"
+	"github.com/orangeAndSuns/essentia/p2p/discover"
+	"github.com/orangeAndSuns/essentia/p2p/simulations"
+	"github.com/orangeAndSuns/essentia/p2p/simulations/adapters"
+	p2ptest "github.com/orangeAndSuns/essentia/p2p/testing"
+	"github.com/orangeAndSuns/essentia/swarm/log"
+	"github.com/orangeAndSuns/essentia/swarm/network"
+	streamTesting "github.com/orangeAndSuns/essentia/swarm/network/stream/testing"
+	bzzswap "github.com/orangeAndSuns/essentia/swarm/services/swap"
+	"github.com/orangeAndSuns/essentia/swarm/services/swap/swap"
+	"github.com/orangeAndSuns/essentia/swarm/state"
+	"github.com/orangeAndSuns/essentia/swarm/storage"
+)
+
+var (
+	swapKey0, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	swapKey1, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	swapAddr0   = crypto.PubkeyToAddress(swapKey0.PublicKey)
+	swapAddr1   = crypto.PubkeyToAddress(swapKey1.PublicKey)
+)
+
+func newSwapTestBackend(keys ...*ecdsa.PrivateKey) *backends.SimulatedBackend {
+	alloc := make(core.GenesisAlloc)
+	for _, key := range keys {
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{Balance: big.NewInt(1000000000)}
+	}
+	return backends.NewSimulatedBackend(alloc)
+}
+
+// deployChequebook deploys a chequebook contract owned by key with amount funds
+func deployChequebook(key *ecdsa.PrivateKey, amount *big.Int, backend *backends.SimulatedBackend) (common.Address, error) {
+	opts := bind.NewKeyedTransactor(key)
+	opts.Value = amount
+	addr, _, _, err := contract.DeployChequebook(opts, backend)
+	if err != nil {
+		return common.Address{}, err
+	}
+	backend.Commit()
+	return addr, nil
+}
+
+// newTestSwapProfile creates a SWAP profile trading chunks for 1 wei
+// with a chequebook owned by key
+func newTestSwapProfile(key *ecdsa.PrivateKey, backend *backends.SimulatedBackend, dir string) (*bzzswap.LocalProfile, error) {
+	addr, err := deployChequebook(key, big.NewInt(1000), backend)
+	if err != nil {
+		return nil, err
+	}
+	profile := bzzswap.NewDefaultSwapParams()
+	profile.BuyAt = big.NewInt(1)
+	profile.SellAt = big.NewInt(1)
+	profile.PayAt = 1
+	profile.DropAt = 2
+	// no auto deposits, the chequebook is funded on deployment
+	profile.AutoDepositThreshold = big.NewInt(0)
+	profile.Init(addr, key)
+	if err := profile.SetChequebook(context.Background(), backend, dir); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func newSwapProfileMsg(profile *bzzswap.LocalProfile) *SwapProfileMsg {
+	return &SwapProfileMsg{
+		PublicKey:   profile.PublicKey,
+		Contract:    profile.Contract,
+		Beneficiary: profile.Beneficiary,
+		BuyAt:       profile.BuyAt,
+		SellAt:      profile.SellAt,
+		PayAt:       profile.PayAt,
+		DropAt:      profile.DropAt,
+	}
+}
+
+// waitSwapBalance waits until the SWAP balance with the peer is the expected one
+func waitSwapBalance(r *Registry, peer *Peer, expected int) error {
+	var balance int
+	var err error
+	for i := 0; i < 100; i++ {
+		balance, err = r.SwapBalance(peer.ID())
+		if err == nil && balance == expected {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return fmt.Errorf("expected SWAP balance %v, got %v (error: %v)", expected, balance, err)
+}
+
+// TestSwapAccounting checks that chunks delivered upon retrieve requests are
+// accounted with the peer, that debts are settled with cheques and that a
+// peer is dropped once its debt reaches the disconnect threshold
+func TestSwapAccounting(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swap-accounting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backend := newSwapTestBackend(swapKey0, swapKey1)
+	local, err := newTestSwapProfile(swapKey0, backend, filepath.Join(dir, "local"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Chequebook().Stop()
+	remote, err := newTestSwapProfile(swapKey1, backend, filepath.Join(dir, "remote"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Chequebook().Stop()
+
+	tester, streamer, localStore, teardown, err := newStreamerTesterWithOptions(t, &RegistryOptions{
+		SkipCheck:   defaultSkipCheck,
+		Swap:        local,
+		SwapBackend: backend,
+	})
+	defer teardown()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peerID := tester.IDs[0]
+	peer := streamer.getPeer(peerID)
+
+	err = tester.TestExchanges(p2ptest.Exchange{
+		Label: "SwapProfileMsg",
+		Triggers: []p2ptest.Trigger{
+			{
+				Code: 10,
+				Msg:  newSwapProfileMsg(remote),
+				Peer: peerID,
+			},
+		},
+		Expects: []p2ptest.Expect{
+			{
+				Code: 10,
+				Msg:  newSwapProfileMsg(local),
+				Peer: peerID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peer.handleSubscribeMsg(context.TODO(), &SubscribeMsg{
+		Stream:   NewStream(swarmChunkServerStreamName, "", false),
+		History:  nil,
+		Priority: Top,
+	})
+	for _, hash := range []storage.Address{hash0[:], hash1[:]} {
+		chunk := storage.NewChunk(hash, nil)
+		chunk.SData = hash
+		localStore.Put(context.TODO(), chunk)
+		chunk.WaitToStore()
+	}
+
+	// the peer retrieves a chunk and pays for it
+	err = tester.TestExchanges(p2ptest.Exchange{
+		Label: "RetrieveRequestMsg",
+		Triggers: []p2ptest.Trigger{
+			{
+				Code: 5,
+				Msg: &RetrieveRequestMsg{
+					Addr:      hash0[:],
+					SkipCheck: true,
+				},
+				Peer: peerID,
+			},
+		},
+		Expects: []p2ptest.Expect{
+			{
+				Code: 6,
+				Msg: &ChunkDeliveryMsg{
+					Addr:  hash0[:],
+					SData: hash0[:],
+				},
+				Peer: peerID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := waitSwapBalance(streamer, peer, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	cheque, err := remote.Chequebook().Issue(swapAddr0, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tester.TestExchanges(p2ptest.Exchange{
+		Label: "PaymentMsg",
+		Triggers: []p2ptest.Trigger{
+			{
+				Code: 11,
+				Msg: &PaymentMsg{
+					Units:   1,
+					Promise: cheque,
+				},
+				Peer: peerID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := waitSwapBalance(streamer, peer, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// a chunk is retrieved from the peer and paid for
+	hash := storage.Address(hash2[:])
+	streamer.delivery.db.GetOrCreateRequest(context.TODO(), hash)
+	if err := streamer.delivery.RequestFromPeers(context.TODO(), hash, true); err != nil {
+		t.Fatal(err)
+	}
+	// the cheque the local chequebook is expected to issue
+	chbook, err := chequebook.NewChequebook(filepath.Join(dir, "expected.json"), local.Contract, swapKey0, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := chbook.Issue(swapAddr1, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tester.TestExchanges(p2ptest.Exchange{
+		Label: "RetrieveRequestMsg",
+		Expects: []p2ptest.Expect{
+			{
+				Code: 5,
+				Msg: &RetrieveRequestMsg{
+					Addr:      hash,
+					SkipCheck: true,
+				},
+				Peer: peerID,
+			},
+		},
+	}, p2ptest.Exchange{
+		Label: "ChunkDeliveryMsg",
+		Triggers: []p2ptest.Trigger{
+			{
+				Code: 6,
+				Msg: &ChunkDeliveryMsg{
+					Addr:  hash,
+					SData: hash,
+				},
+				Peer: peerID,
+			},
+		},
+		Expects: []p2ptest.Expect{
+			{
+				Code: 11,
+				Msg: &PaymentMsg{
+					Units:   1,
+					Promise: expected,
+				},
+				Peer: peerID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := waitSwapBalance(streamer, peer, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// the peer is dropped once its debt reaches the disconnect threshold
+	err = tester.TestExchanges(p2ptest.Exchange{
+		Label: "RetrieveRequestMsg",
+		Triggers: []p2ptest.Trigger{
+			{
+				Code: 5,
+				Msg: &RetrieveRequestMsg{
+					Addr:      hash0[:],
+					SkipCheck: true,
+				},
+				Peer: peerID,
+			},
+		},
+		Expects: []p2ptest.Expect{
+			{
+				Code: 6,
+				Msg: &ChunkDeliveryMsg{
+					Addr:  hash0[:],
+					SData: hash0[:],
+				},
+				Peer: peerID,
+			},
+		},
+	}, p2ptest.Exchange{
+		Label: "RetrieveRequestMsg",
+		Triggers: []p2ptest.Trigger{
+			{
+				Code: 5,
+				Msg: &RetrieveRequestMsg{
+					Addr:      hash1[:],
+					SkipCheck: true,
+				},
+				Peer: peerID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; streamer.getPeer(peerID) != nil; i++ {
+		if i == 100 {
+			t.Fatal("expected peer to be dropped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestSwapProfileMissing checks that with SWAP enabled, a peer requesting a
+// chunk before sending its SWAP profile is dropped instead of getting the
+// chunk for free
+func TestSwapProfileMissing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swap-profile-missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backend := newSwapTestBackend(swapKey0)
+	local, err := newTestSwapProfile(swapKey0, backend, filepath.Join(dir, "local"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Chequebook().Stop()
+
+	tester, streamer, localStore, teardown, err := newStreamerTesterWithOptions(t, &RegistryOptions{
+		SkipCheck:   defaultSkipCheck,
+		Swap:        local,
+		SwapBackend: backend,
+	})
+	defer teardown()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peerID := tester.IDs[0]
+	peer := streamer.getPeer(peerID)
+
+	peer.handleSubscribeMsg(context.TODO(), &SubscribeMsg{
+		Stream:   NewStream(swarmChunkServerStreamName, "", false),
+		History:  nil,
+		Priority: Top,
+	})
+	chunk := storage.NewChunk(hash0[:], nil)
+	chunk.SData = hash0[:]
+	localStore.Put(context.TODO(), chunk)
+	chunk.WaitToStore()
+
+	err = tester.TestExchanges(p2ptest.Exchange{
+		Label: "RetrieveRequestMsg",
+		Triggers: []p2ptest.Trigger{
+			{
+				Code: 5,
+				Msg: &RetrieveRequestMsg{
+					Addr:      hash0[:],
+					SkipCheck: true,
+				},
+				Peer: peerID,
+			},
+		},
+		Expects: []p2ptest.Expect{
+			{
+				Code: 10,
+				Msg:  newSwapProfileMsg(local),
+				Peer: peerID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; streamer.getPeer(peerID) != nil; i++ {
+		if i == 100 {
+			t.Fatal("expected peer to be dropped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestSwapRetrievalsExpire checks that retrieve requests are only accounted
+// on delivery until they expire, and that expired ones are removed
+func TestSwapRetrievalsExpire(t *testing.T) {
+	peer := &Peer{
+		swap:       &swap.Swap{},
+		retrievals: make(map[string]time.Time),
+	}
+	expired := time.Now().Add(-2 * retrievalTimeout)
+
+	// requests which timed out or were answered by another peer are removed
+	peer.retrievals[string(hash1[:])] = expired
+	peer.requested(hash0[:])
+	if len(peer.retrievals) != 1 {
+		t.Fatalf("expected 1 retrieval, got %v", len(peer.retrievals))
+	}
+	if !peer.delivered(hash0[:]) {
+		t.Fatal("expected delivery of requested chunk to be accounted")
+	}
+	if peer.delivered(hash0[:]) {
+		t.Fatal("expected delivery of chunk to be accounted only once")
+	}
+
+	// deliveries of expired requests are not accounted
+	peer.retrievals[string(hash2[:])] = expired
+	if peer.delivered(hash2[:]) {
+		t.Fatal("expected delivery of expired request not to be accounted")
+	}
+	if len(peer.retrievals) != 0 {
+		t.Fatalf("expected no retrievals, got %v", len(peer.retrievals))
+	}
+}
+
+var (
+	swapProfiles map[discover.ESSNodeID]*bzzswap.LocalProfile
+	swapBackend  chequebook.Backend
+)
+
+func newSwapStreamerService(ctx *adapters.ServiceContext) (node.Service, error) {
+	id := ctx.Config.ID
+	addr := toAddr(id)
+	kad := network.NewKademlia(addr.Over(), network.NewKadParams())
+	store := stores[id].(*storage.LocalStore)
+	db := storage.NewDBAPI(store)
+	delivery := NewDelivery(kad, db)
+	deliveries[id] = delivery
+	r := NewRegistry(addr, delivery, db, state.NewInmemoryStore(), &RegistryOptions{
+		SkipCheck:   defaultSkipCheck,
+		Swap:        swapProfiles[id],
+		SwapBackend: swapBackend,
+	})
+	go func() {
+		waitPeerErrC <- waitForPeers(r, 1*time.Second, peerCount(id))
+	}()
+	fileStore := storage.NewFileStore(storage.NewNetStore(store, getRetrieveFunc(id)), storage.NewFileStoreParams())
+	testRegistry := &TestRegistry{Registry: r, fileStore: fileStore}
+	registries[id] = testRegistry
+	return testRegistry, nil
+}
+
+// TestSwapRetrieval retrieves a file from a peer on a simulated network of
+// two nodes with SWAP enabled and checks that the chunks retrieved are paid
+// for with cheques and that both nodes agree on the balance
+func TestSwapRetrieval(t *testing.T) {
+	defer setDefaultSkipCheck(defaultSkipCheck)
+	defaultSkipCheck = true
+	toAddr = network.NewAddrFromESSNodeID
+	createStoreFunc = createTestLocalStorageFromSim
+	conf := &streamTesting.RunConfig{
+		Adapter:   "sim",
+		NodeCount: 2,
+		ConnLevel: 1,
+		ToAddr:    toAddr,
+		Services: adapters.Services{
+			"swapStreamer": newSwapStreamerService,
+		},
+		DefaultService: "swapStreamer",
+	}
+	sim, teardown, err := streamTesting.NewSimulation(conf)
+	defer teardown()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "swap-retrieval")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backend := newSwapTestBackend(swapKey0, swapKey1)
+	swapBackend = backend
+	swapProfiles = make(map[discover.ESSNodeID]*bzzswap.LocalProfile)
+	for i, key := range []*ecdsa.PrivateKey{swapKey0, swapKey1} {
+		profile, err := newTestSwapProfile(key, backend, filepath.Join(dir, fmt.Sprint(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer profile.Chequebook().Stop()
+		// pay every 4 chunks, the disconnect threshold is never reached
+		profile.PayAt = 4
+		profile.DropAt = 100
+		swapProfiles[sim.IDs[i]] = profile
+	}
+
+	stores = make(map[discover.ESSNodeID]storage.ChunkStore)
+	for i, id := range sim.IDs {
+		stores[id] = sim.Stores[i]
+	}
+	registries = make(map[discover.ESSNodeID]*TestRegistry)
+	deliveries = make(map[discover.ESSNodeID]*Delivery)
+	peerCount = func(id discover.ESSNodeID) int {
+		return 1
+	}
+
+	// the file to retrieve is stored on the second node
+	size := 10 * chunkSize
+	fileStore := storage.NewFileStore(sim.Stores[1], storage.NewFileStoreParams())
+	ctx := context.TODO()
+	fileHash, wait, err := fileStore.Store(ctx, io.LimitReader(crand.Reader, int64(size)), int64(size), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	errc := make(chan error, 1)
+	waitPeerErrC = make(chan error)
+	quitC := make(chan struct{})
+	defer close(quitC)
+
+	action := func(ctx context.Context) error {
+		for i := 0; i < len(sim.IDs); i++ {
+			if err := <-waitPeerErrC; err != nil {
+				return fmt.Errorf("error waiting for peers: %s", err)
+			}
+		}
+		// wait for the SWAP profiles to be exchanged
+		for {
+			_, err0 := registries[sim.IDs[0]].SwapBalance(sim.IDs[1])
+			_, err1 := registries[sim.IDs[1]].SwapBalance(sim.IDs[0])
+			if err0 == nil && err1 == nil {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		if err := registries[sim.IDs[0]].Subscribe(sim.IDs[1], NewStream(swarmChunkServerStreamName, "", false), nil, Top); err != nil {
+			return err
+		}
+
+		// retrieve the file on the first node
+		delivery := deliveries[sim.IDs[0]]
+		retrieveFunc := func(ctx context.Context, chunk *storage.Chunk) error {
+			return delivery.RequestFromPeers(ctx, chunk.Addr[:], true)
+		}
+		netStore := storage.NewNetStore(sim.Stores[0].(*storage.LocalStore), retrieveFunc)
+		fileStore := storage.NewFileStore(netStore, storage.NewFileStoreParams())
+		go func() {
+			if _, err := readAll(fileStore, fileHash); err != nil {
+				errc <- fmt.Errorf("requesting chunks action error: %v", err)
+			}
+		}()
+		return nil
+	}
+	check := func(ctx context.Context, id discover.ESSNodeID) (bool, error) {
+		select {
+		case err := <-errc:
+			return false, err
+		case <-ctx.Done():
+			return false, ctx.Err()
+		default:
+		}
+		total, err := registries[id].ReadAll(common.BytesToHash(fileHash))
+		if err != nil || total != int64(size) {
+			return false, nil
+		}
+		// the balances of the nodes mirror each other once the payments arrived
+		balance0, err := registries[sim.IDs[0]].SwapBalance(sim.IDs[1])
+		if err != nil {
+			return false, err
+		}
+		balance1, err := registries[sim.IDs[1]].SwapBalance(sim.IDs[0])
+		if err != nil {
+			return false, err
+		}
+		log.Info("SWAP balances", "balance0", balance0, "balance1", balance1)
+		if balance0 != -balance1 || balance0 >= 0 || balance0 <= -4 {
+			return false, nil
+		}
+		// the retrieved chunks not in the balance are paid for
+		paid := new(big.Int).Sub(big.NewInt(1000), swapProfiles[sim.IDs[0]].Chequebook().Balance())
+		if paid.Sign() <= 0 || paid.Int64()%4 != 0 {
+			return false, fmt.Errorf("unexpected amount paid: %v", paid)
+		}
+		return true, nil
+	}
+
+	conf.Step = &simulations.Step{
+		Action:  action,
+		Trigger: streamTesting.Trigger(10*time.Millisecond, quitC, sim.IDs[0]),
+		Expect: &simulations.Expectation{
+			Nodes: sim.IDs[0:1],
+			Check: check,
+		},
+	}
+	startedAt := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	result, err := sim.Run(ctx, conf)
+	finishedAt := time.Now()
+	if err != nil {
+		t.Fatalf("Setting up simulation failed: %v", err)
+	}
+	if result.Error != nil {
+		t.Fatalf("Simulation failed: %s", result.Error)
+	}
+	streamTesting.CheckResult(t, result, startedAt, finishedAt)
+}
@@ -0,0 +1,46 @@
+// Package feed defines Swarm feeds.
+// A Swarm feed is a stream of signed updates that a user publishes on a topic,
+// allowing content to change without resorting to ENS on each update.
+// The update scheme is built on swarm chunks with chunk keys following
+// a predictable, versionable pattern.
+//
+// A feed is identified by its topic and the address of the user that publishes
+// updates on it. The topic is a 32 byte array, usually built out of a
+// human-readable name and, optionally, the address of related swarm content
+// (see NewTopic). There is no need to create a feed before publishing updates,
+// anyone can look for updates as soon as they know the (topic, user) pair.
+//
+// Updates are not bound to a fixed frequency. Instead, time is divided into a
+// grid of epochs of different sizes. An epoch is a time slot whose length is a
+// power of two, given by its level: an epoch of level n starts at a base time
+// which is a multiple of 2^n seconds and lasts 2^n seconds. Level 25 epochs
+// last about a year, level 0 epochs last a second.
+//
+// Each update is placed in an epoch which is determined by the epoch of the
+// previous update and the current time (see lookup.GetNextEpoch):
+// the first update is placed at the highest level, and subsequent updates
+// that happen in the same epoch as the previous one are placed in epochs of
+// increasingly lower levels. Updates made after a long silence go back up to
+// the level of the largest epoch that doesn't contain the previous update.
+// This allows to update a feed at any time, while a reader can find the
+// latest update before a given time by probing a few epochs, using the epoch
+// of a known update as a hint to start the search (see lookup.Lookup).
+//
+// The keys of the update chunks are the hash of the feed and the epoch they are
+// placed at:
+//
+// updateAddr = H(topic, user, epochBaseTime, epochLevel)
+// where H is the SHA3 hash function
+//
+// the feed update data is:
+// updatedata = protocolVersion|flags|topic|user|epochTime|epochLevel|data
+// where flags is a 1-byte flags field. Flag 0 is set to 1 to indicate multihash
+//
+// the full update data that goes in the chunk payload is:
+// updatedata|sign(updatedata)
+//
+// Since the update chunk carries the signature of the update data, and the
+// update data contains the user address, nodes can check that the chunk was
+// signed by the user of the feed and that its key matches the feed and epoch
+// it claims to be placed at.
+package feed
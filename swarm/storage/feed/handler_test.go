@@ -0,0 +1,376 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/crypto"
+	"github.com/orangeAndSuns/essentia/swarm/multihash"
+	"github.com/orangeAndSuns/essentia/swarm/storage"
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed/lookup"
+)
+
+var (
+	startTime = uint64(4200)
+	topic, _  = NewTopic("føø.bar", nil)
+)
+
+// simulated timeProvider
+type fakeTimeProvider struct {
+	currentTime uint64
+}
+
+func (f *fakeTimeProvider) Tick() {
+	f.currentTime++
+}
+
+func (f *fakeTimeProvider) Now() Timestamp {
+	return Timestamp{
+		Time: f.currentTime,
+	}
+}
+
+func setupTest(timeProvider timestampProvider) (fh *TestHandler, teardown func(), err error) {
+	datadir, err := ioutil.TempDir("", "fh")
+	if err != nil {
+		return nil, nil, err
+	}
+	TimestampProvider = timeProvider
+	fh, err = NewTestHandler(datadir, &HandlerParams{})
+	if err != nil {
+		os.RemoveAll(datadir)
+		return nil, nil, err
+	}
+	teardown = func() {
+		fh.Close()
+		os.RemoveAll(datadir)
+		TimestampProvider = NewDefaultTimestampProvider()
+	}
+	return fh, teardown, nil
+}
+
+func newAliceSigner() *GenericSigner {
+	privKey, _ := crypto.HexToECDSA("deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef")
+	return NewGenericSigner(privKey)
+}
+
+func newBobSigner() *GenericSigner {
+	privKey, _ := crypto.HexToECDSA("accedeaccedeaccedeaccedeaccedeaccedeaccedeaccedeaccedeaccedecaca")
+	return NewGenericSigner(privKey)
+}
+
+// update publishes data on the feed of the signer at the current time
+func update(t *testing.T, fh *TestHandler, signer Signer, data []byte, multihash bool) storage.Address {
+	ctx := context.Background()
+	request, err := fh.NewRequest(ctx, &Feed{Topic: topic, User: signer.Address()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.SetData(data, multihash)
+	if err := request.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	addr, err := fh.Update(ctx, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func checkLookup(t *testing.T, fh *TestHandler, query *Query, want []byte) {
+	entry, err := fh.Lookup(context.Background(), query)
+	if err != nil {
+		t.Fatalf("lookup at %d failed: %v", query.TimeLimit, err)
+	}
+	if !bytes.Equal(entry.data, want) {
+		t.Fatalf("lookup at %d: have data %q, want %q", query.TimeLimit, entry.data, want)
+	}
+}
+
+// TestFeedsHandler publishes updates at irregular intervals, then looks up
+// the latest update and historical updates
+func TestFeedsHandler(t *testing.T) {
+	timeProvider := &fakeTimeProvider{currentTime: startTime}
+	fh, teardown, err := setupTest(timeProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	signer := newAliceSigner()
+	feed := &Feed{Topic: topic, User: signer.Address()}
+
+	if _, err := fh.Lookup(context.Background(), NewQueryLatest(feed, lookup.NoClue)); err == nil || err.(*Error).Code() != ErrNotFound {
+		t.Fatalf("expected lookup on a feed without updates to fail with ErrNotFound, got %v", err)
+	}
+
+	updates := []string{"blinky", "pinky", "inky", "clyde", "sue"}
+	offsets := []uint64{0, 1, 13, 2000000, 3}
+	var times []uint64
+	var lastAddr storage.Address
+	for i, data := range updates {
+		timeProvider.currentTime += offsets[i]
+		times = append(times, timeProvider.currentTime)
+		lastAddr = update(t, fh, signer, []byte(data), false)
+	}
+
+	// the latest update is found by the handler which published it...
+	checkLookup(t, fh, NewQueryLatest(feed, lookup.NoClue), []byte("sue"))
+	addr, data, err := fh.GetContent(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(addr, lastAddr) || string(data) != "sue" {
+		t.Fatalf("content mismatch: have %v %q, want %v %q", addr, data, lastAddr, "sue")
+	}
+
+	// ...and by a handler without cached state
+	fh2, err := NewHandler(&HandlerParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fh2.SetStore(fh.chunkStore)
+	fh = &TestHandler{fh2}
+	checkLookup(t, fh, NewQueryLatest(feed, lookup.NoClue), []byte("sue"))
+
+	// historical lookups find the latest update before the given time
+	for i, data := range updates {
+		checkLookup(t, fh, NewQuery(feed, times[i], lookup.NoClue), []byte(data))
+		if i+1 < len(updates) && times[i+1]-times[i] > 1 {
+			checkLookup(t, fh, NewQuery(feed, times[i+1]-1, lookup.NoClue), []byte(data))
+		}
+	}
+	if _, err := fh.Lookup(context.Background(), NewQuery(feed, startTime-1, lookup.NoClue)); err == nil {
+		t.Fatal("expected lookup before the first update to fail")
+	}
+
+	// historical lookups don't replace the latest update in the cache
+	if _, data, _ := fh.GetContent(feed); string(data) != "sue" {
+		t.Fatalf("cached content mismatch: have %q, want %q", data, "sue")
+	}
+
+	// updates of another user are not mixed up with ours
+	bob := newBobSigner()
+	update(t, fh, bob, []byte("bob"), false)
+	checkLookup(t, fh, NewQueryLatest(feed, lookup.NoClue), []byte("sue"))
+	checkLookup(t, fh, NewQueryLatest(&Feed{Topic: topic, User: bob.Address()}, lookup.NoClue), []byte("bob"))
+}
+
+func TestFeedsMultihash(t *testing.T) {
+	timeProvider := &fakeTimeProvider{currentTime: startTime}
+	fh, teardown, err := setupTest(timeProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	signer := newAliceSigner()
+	ref := make([]byte, fh.HashSize)
+	copy(ref, "some swarm content address")
+	mh := multihash.ToMultihash(ref)
+
+	update(t, fh, signer, mh, true)
+	entry, err := fh.Lookup(context.Background(), NewQueryLatest(&Feed{Topic: topic, User: signer.Address()}, lookup.NoClue))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !entry.Multihash() || !bytes.Equal(entry.data, mh) {
+		t.Fatalf("multihash mismatch: have %x (multihash %v), want %x", entry.data, entry.Multihash(), mh)
+	}
+
+	// a corrupt multihash is refused
+	request, err := fh.NewRequest(context.Background(), &Feed{Topic: topic, User: signer.Address()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mh[1] = 79
+	request.SetData(mh, true)
+	if err := request.Sign(signer); err == nil {
+		t.Fatal("expected signing an invalid multihash to fail")
+	}
+}
+
+// TestValidator checks that only updates signed by the user of the feed are
+// accepted by the chunk validator
+func TestValidator(t *testing.T) {
+	timeProvider := &fakeTimeProvider{currentTime: startTime}
+	fh, teardown, err := setupTest(timeProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardown()
+
+	alice := newAliceSigner()
+	request := NewFirstRequest(topic)
+	request.SetData([]byte("foo"), false)
+	if err := request.Sign(alice); err != nil {
+		t.Fatal(err)
+	}
+	chunk, err := request.toChunk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fh.Validate(chunk.Addr, chunk.SData) {
+		t.Fatal("chunk signed by the feed user is not valid")
+	}
+
+	// the chunk can't be stored at another address
+	otherAddr := make(storage.Address, len(chunk.Addr))
+	copy(otherAddr, chunk.Addr)
+	otherAddr[0]++
+	if fh.Validate(otherAddr, chunk.SData) {
+		t.Fatal("chunk stored at the wrong address is valid")
+	}
+
+	// the update can't be signed by somebody else
+	bob := newBobSigner()
+	if err := request.Sign(bob); err == nil {
+		t.Fatal("expected signing the update of another user to fail")
+	}
+	signature, err := bob.Sign(common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, len(chunk.SData))
+	copy(data, chunk.SData)
+	copy(data[len(data)-signatureLength:], signature[:])
+	if fh.Validate(chunk.Addr, data) {
+		t.Fatal("chunk signed by another user is valid")
+	}
+
+	// tampered data is refused
+	copy(data, chunk.SData)
+	data[len(data)-signatureLength-1]++
+	if fh.Validate(chunk.Addr, data) {
+		t.Fatal("tampered chunk is valid")
+	}
+}
+
+// check that the signer of an update can be recovered from its chunk
+func TestReverse(t *testing.T) {
+	epoch := lookup.Epoch{
+		Time:  startTime,
+		Level: 2,
+	}
+	data := []byte("note the update epoch is not the first one")
+
+	signer := newAliceSigner()
+	request := new(Request)
+	request.Epoch = epoch
+	request.Feed = Feed{Topic: topic}
+	request.Header.Version = ProtocolVersion
+	request.SetData(data, false)
+	if err := request.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	chunk, err := request.toChunk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(chunk.Addr, request.Addr()) {
+		t.Fatalf("expected chunk address %x, got %x", request.Addr(), chunk.Addr)
+	}
+
+	// check that we can recover the user from the chunk signature
+	var checkUpdate Request
+	if err := checkUpdate.fromChunk(chunk.Addr, chunk.SData); err != nil {
+		t.Fatal(err)
+	}
+	checkDigest, err := checkUpdate.GetDigest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveredAddr, err := getUserAddr(checkDigest, *checkUpdate.Signature)
+	if err != nil {
+		t.Fatalf("Retrieve address from signature fail: %v", err)
+	}
+	if recoveredAddr != signer.Address() {
+		t.Fatalf("Expected recovered user %x, got %x", signer.Address(), recoveredAddr)
+	}
+	if err := checkUpdate.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if checkUpdate.Epoch != epoch {
+		t.Fatalf("Expected epoch %v, got %v", epoch, checkUpdate.Epoch)
+	}
+	if !bytes.Equal(checkUpdate.data, data) {
+		t.Fatalf("Expected data %x, got %x", data, checkUpdate.data)
+	}
+}
+
+// tests that the feed validator works inside a LocalStore
+// and that content addressed chunks are refused by it
+func TestValidatorInStore(t *testing.T) {
+	// set up localstore
+	datadir, err := ioutil.TempDir("", "storage-testfeedsvalidator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(datadir)
+
+	params := storage.NewDefaultLocalStoreParams()
+	params.Init(datadir)
+	store, err := storage.NewLocalStore(params, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// set up the feed handler as the only validator of the store
+	fh, err := NewHandler(&HandlerParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Validators = append(store.Validators, fh)
+
+	// create content addressed chunks, one good, one faulty
+	chunks := storage.GenerateRandomChunks(storage.DefaultChunkSize, 2)
+	goodChunk := chunks[0]
+	badChunk := chunks[1]
+	badChunk.SData = goodChunk.SData
+
+	// create a feed update chunk with correct publickey
+	request := NewFirstRequest(topic)
+	request.SetData([]byte("foo"), false)
+	if err := request.Sign(newAliceSigner()); err != nil {
+		t.Fatal(err)
+	}
+	updateChunk, err := request.toChunk()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// put the chunks in the store and check their error status
+	storage.PutChunks(store, goodChunk)
+	if goodChunk.GetErrored() == nil {
+		t.Fatal("expected error on good content address chunk with feed validator only, but got nil")
+	}
+	storage.PutChunks(store, badChunk)
+	if badChunk.GetErrored() == nil {
+		t.Fatal("expected error on bad content address chunk with feed validator only, but got nil")
+	}
+	storage.PutChunks(store, updateChunk)
+	if err := updateChunk.GetErrored(); err != nil {
+		t.Fatalf("expected no error on feed update chunk with feed validator only, but got: %s", err)
+	}
+}
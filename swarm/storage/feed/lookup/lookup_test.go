@@ -0,0 +1,164 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package lookup
+
+import (
+	"math/rand"
+	"testing"
+)
+
+type testUpdate struct {
+	time  uint64
+	value int
+}
+
+// testStore places updates at the epochs given by GetNextEpoch and reads them back
+type testStore struct {
+	updates map[EpochID]*testUpdate
+	last    Epoch
+	reads   int
+}
+
+func newTestStore() *testStore {
+	return &testStore{updates: make(map[EpochID]*testUpdate)}
+}
+
+func (s *testStore) put(now uint64, value int) Epoch {
+	epoch := GetNextEpoch(s.last, now)
+	s.updates[epoch.ID()] = &testUpdate{time: now, value: value}
+	s.last = epoch
+	return epoch
+}
+
+func (s *testStore) read(epoch Epoch, now uint64) (interface{}, error) {
+	s.reads++
+	u := s.updates[epoch.ID()]
+	if u == nil || u.time > now {
+		return nil, nil
+	}
+	return u, nil
+}
+
+func TestEpochSerialization(t *testing.T) {
+	epoch := Epoch{Time: 1534092715, Level: 17}
+	data, err := epoch.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Epoch
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != epoch {
+		t.Fatalf("decoded epoch mismatch: have %v, want %v", decoded, epoch)
+	}
+	if err := decoded.UnmarshalBinary(data[1:]); err == nil {
+		t.Fatal("expected unmarshalling short data to fail")
+	}
+}
+
+func TestGetNextLevel(t *testing.T) {
+	// the first update is at the highest level
+	if epoch := GetNextEpoch(NoClue, 1534092715); epoch.Level != HighestLevel {
+		t.Fatalf("first epoch level mismatch: have %d, want %d", epoch.Level, HighestLevel)
+	}
+	// an update in the same epoch goes one level below
+	last := Epoch{Time: 1534092715, Level: HighestLevel}
+	if level := GetNextLevel(last, last.Time+1); level != HighestLevel-1 {
+		t.Fatalf("next level mismatch: have %d, want %d", level, HighestLevel-1)
+	}
+	// an update far in the future goes back to the highest level
+	if level := GetNextLevel(last, last.Time+1<<(HighestLevel+1)); level != HighestLevel {
+		t.Fatalf("next level mismatch: have %d, want %d", level, HighestLevel)
+	}
+	// an update in a sibling epoch goes to the level of the sibling
+	last = Epoch{Time: 0x1000, Level: 4}
+	if level := GetNextLevel(last, 0x1020); level != 5 {
+		t.Fatalf("next level mismatch: have %d, want %d", level, 5)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	for run := 0; run < 20; run++ {
+		store := newTestStore()
+		var times []uint64
+		var epochs []Epoch
+		now := uint64(1534092715) + uint64(rnd.Intn(1<<20))
+		for i := 0; i < 100; i++ {
+			// mix bursts of updates with long silences
+			switch rnd.Intn(3) {
+			case 0:
+				now += uint64(rnd.Intn(10)) + 1
+			case 1:
+				now += uint64(rnd.Intn(1 << 12))
+			default:
+				now += uint64(rnd.Intn(1 << 27))
+			}
+			times = append(times, now)
+			epochs = append(epochs, store.put(now, i))
+		}
+
+		// find the latest update before random points in time
+		for i := 0; i < 200; i++ {
+			at := times[0] - 10 + uint64(rnd.Int63n(int64(now-times[0]+100)))
+			want := -1
+			for j, tm := range times {
+				if tm <= at {
+					want = j
+				}
+			}
+			hints := []Epoch{NoClue}
+			if want >= 0 {
+				// a good hint, and an outdated one
+				hints = append(hints, epochs[want], epochs[rnd.Intn(want+1)])
+			}
+			for _, hint := range hints {
+				value, err := Lookup(at, hint, store.read)
+				if err != nil {
+					t.Fatal(err)
+				}
+				have := -1
+				if value != nil {
+					have = value.(*testUpdate).value
+				}
+				if have != want {
+					t.Fatalf("run %d: lookup at %d with hint %v: have update %d, want %d", run, at, hint, have, want)
+				}
+			}
+		}
+	}
+}
+
+// TestLookupBadHint checks that a hint pointing to an epoch without updates
+// doesn't prevent the lookup from finding the latest update
+func TestLookupBadHint(t *testing.T) {
+	store := newTestStore()
+	now := uint64(1534092715)
+	for i := 0; i < 10; i++ {
+		now += 1000
+		store.put(now, i)
+	}
+	hint := Epoch{Time: now + 1000000, Level: 3}
+	value, err := Lookup(now, hint, store.read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value == nil || value.(*testUpdate).value != 9 {
+		t.Fatalf("expected to find the latest update with a bad hint, got %v", value)
+	}
+}
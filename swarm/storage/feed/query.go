@@ -0,0 +1,85 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"strconv"
+
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed/lookup"
+)
+
+// Values interface represents a string key-value store
+// useful for building query strings
+type Values interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// Query is used to specify constraints when performing an update lookup
+// TimeLimit indicates an upper bound for the search. Set to 0 for "now"
+type Query struct {
+	Feed
+	Hint      lookup.Epoch
+	TimeLimit uint64
+}
+
+// NewQuery constructs a Query structure to find updates on or before `time`
+// if time == 0, the latest update will be looked up
+func NewQuery(feed *Feed, time uint64, hint lookup.Epoch) *Query {
+	return &Query{
+		TimeLimit: time,
+		Feed:      *feed,
+		Hint:      hint,
+	}
+}
+
+// NewQueryLatest generates lookup parameters that look for the latest update to a feed
+func NewQueryLatest(feed *Feed, hint lookup.Epoch) *Query {
+	return NewQuery(feed, 0, hint)
+}
+
+// FromValues deserializes this instance from a string key-value store
+// useful to parse query strings
+func (q *Query) FromValues(values Values) error {
+	time, _ := strconv.ParseUint(values.Get("time"), 10, 64)
+	q.TimeLimit = time
+
+	level, _ := strconv.ParseUint(values.Get("hint.level"), 10, 32)
+	q.Hint.Level = uint8(level)
+	q.Hint.Time, _ = strconv.ParseUint(values.Get("hint.time"), 10, 64)
+	if q.Feed.User == zeroAddr {
+		return q.Feed.FromValues(values)
+	}
+	return nil
+}
+
+// AppendValues serializes this structure into the provided string key-value store
+// useful to build query strings
+func (q *Query) AppendValues(values Values) {
+	if q.TimeLimit != 0 {
+		values.Set("time", strconv.FormatUint(q.TimeLimit, 10))
+	}
+	if q.Hint.Level != 0 {
+		values.Set("hint.level", strconv.FormatUint(uint64(q.Hint.Level), 10))
+	}
+	if q.Hint.Time != 0 {
+		values.Set("hint.time", strconv.FormatUint(q.Hint.Time, 10))
+	}
+	if q.Feed != (Feed{}) {
+		q.Feed.AppendValues(values)
+	}
+}
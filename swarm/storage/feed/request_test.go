@@ -0,0 +1,132 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed/lookup"
+)
+
+func getTestRequest() *Request {
+	request := new(Request)
+	request.Feed = Feed{Topic: topic, User: newAliceSigner().Address()}
+	request.Epoch = lookup.Epoch{Time: 1000, Level: 1}
+	request.SetData([]byte("This hour's update: Swarm 99.0 has been released!"), false)
+	return request
+}
+
+func TestTopic(t *testing.T) {
+	related := make([]byte, TopicLength)
+	copy(related, "some related content")
+	topic, err := NewTopic("world news report, every hour", related)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := topic.Name(related); name != "world news report, every hour" {
+		t.Fatalf("topic name mismatch: have %q", name)
+	}
+	var decoded Topic
+	if err := decoded.FromHex(topic.Hex()); err != nil || decoded != topic {
+		t.Fatalf("topic hex mismatch: have %v (%v), want %v", decoded, err, topic)
+	}
+	if _, err := NewTopic(string(make([]byte, TopicLength+1)), nil); err != ErrTopicTooLong {
+		t.Fatalf("expected ErrTopicTooLong, got %v", err)
+	}
+}
+
+func TestEncodingDecodingUpdateRequests(t *testing.T) {
+	signer := newAliceSigner()
+	request := getTestRequest()
+
+	// an unsigned request is not an update and doesn't verify
+	if request.IsUpdate() {
+		t.Fatal("unsigned request should not be an update")
+	}
+	if err := request.Verify(); err == nil {
+		t.Fatal("expected verifying an unsigned request to fail")
+	}
+
+	if err := request.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Request
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.IsUpdate() {
+		t.Fatal("decoded signed request should be an update")
+	}
+	if err := decoded.Verify(); err != nil {
+		t.Fatalf("decoded request doesn't verify: %v", err)
+	}
+	if decoded.ID != request.ID || !bytes.Equal(decoded.data, request.data) {
+		t.Fatalf("decoded request mismatch: have %v %q, want %v %q", decoded.ID, decoded.data, request.ID, request.data)
+	}
+
+	// tampering with the request invalidates the signature
+	decoded.Epoch.Time++
+	decoded.binaryData = nil
+	decoded.idAddr = decoded.Addr()
+	if err := decoded.Verify(); err == nil {
+		t.Fatal("expected tampered request to fail verification")
+	}
+}
+
+func TestUpdateChunkSerialization(t *testing.T) {
+	signer := newAliceSigner()
+	request := getTestRequest()
+	if _, err := request.toChunk(); err == nil {
+		t.Fatal("expected toChunk to fail on an unsigned request")
+	}
+	if err := request.Sign(signer); err != nil {
+		t.Fatal(err)
+	}
+	chunk, err := request.toChunk()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Request
+	if err := decoded.fromChunk(chunk.Addr, chunk.SData); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID != request.ID || !bytes.Equal(decoded.data, request.data) || *decoded.Signature != *request.Signature {
+		t.Fatal("decoded update chunk does not match the request")
+	}
+
+	// chunks too short to hold an update are refused
+	if err := decoded.fromChunk(chunk.Addr, chunk.SData[:minimumUpdateDataLength+signatureLength-1]); err == nil {
+		t.Fatal("expected fromChunk to fail on short chunk data")
+	}
+
+	// data exceeding the chunk size is refused
+	request.SetData(make([]byte, MaxUpdateDataLength+1), false)
+	if err := request.Sign(signer); err == nil {
+		t.Fatal("expected signing oversized data to fail")
+	}
+}
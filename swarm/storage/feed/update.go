@@ -0,0 +1,158 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package feed
+
+import (
+	"github.com/orangeAndSuns/essentia/swarm/log"
+	"github.com/orangeAndSuns/essentia/swarm/multihash"
+)
+
+// ProtocolVersion defines the current version of the feed update layout
+const ProtocolVersion uint8 = 0
+
+// Header models the non-payload components of a feed update
+type Header struct {
+	Version   uint8 // Protocol version of the update layout
+	multihash bool  // Whether the data of this update should be interpreted as multihash
+}
+
+// Header layout:
+// 1 byte version
+// 1 byte flags (multihash bool for now)
+const headerLength = 2
+
+// Update encapsulates the information sent as part of a feed update
+type Update struct {
+	Header Header
+	ID            // Feed and epoch this update is placed at
+	data   []byte // actual data payload
+}
+
+// Update chunk layout:
+// Header: headerLength bytes
+// ID: idLength bytes
+// Data: data (variable length bytes)
+//
+// Minimum size is Header + ID + 1 (minimum data length, enforced)
+const minimumUpdateDataLength = headerLength + idLength + 1
+
+// MaxUpdateDataLength indicates the maximum payload size for a feed update
+const MaxUpdateDataLength = chunkSize - signatureLength - headerLength - idLength
+
+// binaryPut serializes the feed update information into the given slice
+func (r *Update) binaryPut(serializedData []byte) error {
+	datalength := len(r.data)
+	if datalength == 0 {
+		return NewError(ErrInvalidValue, "a feed update must contain data")
+	}
+
+	if datalength > MaxUpdateDataLength {
+		return NewErrorf(ErrInvalidValue, "feed update data is too big (length=%d). Max length=%d", datalength, MaxUpdateDataLength)
+	}
+
+	if len(serializedData) != r.binaryLength() {
+		return NewErrorf(ErrInvalidValue, "slice passed to putBinary must be of exact size. Expected %d bytes", r.binaryLength())
+	}
+
+	if r.Header.multihash {
+		if _, _, err := multihash.GetMultihashLength(r.data); err != nil {
+			return NewError(ErrInvalidValue, "Invalid multihash")
+		}
+	}
+
+	var cursor int
+	serializedData[cursor] = r.Header.Version
+	cursor++
+
+	var flags byte
+	if r.Header.multihash {
+		flags |= 0x01
+	}
+	serializedData[cursor] = flags
+	cursor++
+
+	if err := r.ID.binaryPut(serializedData[cursor : cursor+idLength]); err != nil {
+		return err
+	}
+	cursor += idLength
+
+	// add the data
+	copy(serializedData[cursor:], r.data)
+	cursor += datalength
+
+	return nil
+}
+
+// binaryLength returns the expected number of bytes this structure will take to encode
+func (r *Update) binaryLength() int {
+	return headerLength + idLength + len(r.data)
+}
+
+// binaryGet populates this instance from the information contained in the passed byte slice
+func (r *Update) binaryGet(serializedData []byte) error {
+	if len(serializedData) < minimumUpdateDataLength {
+		return NewErrorf(ErrNothingToReturn, "chunk less than %d bytes cannot be a feed update chunk", minimumUpdateDataLength)
+	}
+	dataLength := len(serializedData) - headerLength - idLength
+
+	var cursor int
+	r.Header.Version = serializedData[cursor]
+	if r.Header.Version != ProtocolVersion {
+		return NewErrorf(ErrCorruptData, "Unknown feed update protocol version %d", r.Header.Version)
+	}
+	cursor++
+
+	r.Header.multihash = serializedData[cursor]&0x01 != 0
+	cursor++
+
+	if err := r.ID.binaryGet(serializedData[cursor : cursor+idLength]); err != nil {
+		return err
+	}
+	cursor += idLength
+
+	data := serializedData[cursor : cursor+dataLength]
+	cursor += dataLength
+
+	// if multihash content is indicated we check the validity of the multihash
+	if r.Header.multihash {
+		mhLength, mhHeaderLength, err := multihash.GetMultihashLength(data)
+		if err != nil {
+			log.Error("multihash parse error", "err", err)
+			return err
+		}
+		if dataLength != mhLength+mhHeaderLength {
+			log.Debug("multihash error", "datalength", dataLength, "mhLength", mhLength, "mhHeaderLength", mhHeaderLength)
+			return NewError(ErrCorruptData, "Corrupt multihash data")
+		}
+	}
+
+	// now that all checks have passed, copy data into structure
+	r.data = make([]byte, dataLength)
+	copy(r.data, data)
+
+	return nil
+}
+
+// Multihash specifies whether the feed update data should be interpreted as multihash
+func (r *Update) Multihash() bool {
+	return r.Header.multihash
+}
+
+// Data returns the payload carried by the feed update
+func (r *Update) Data() []byte {
+	return r.data
+}
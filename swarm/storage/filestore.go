@@ -42,6 +42,7 @@ const (
 type FileStore struct {
 	ChunkStore
 	hashFunc SwarmHasher
+	Tags     *Tags // tags of the uploads, counting their chunks until they are synced
 }
 
 type FileStoreParams struct {
@@ -71,6 +72,7 @@ func NewFileStore(store ChunkStore, params *FileStoreParams) *FileStore {
 	return &FileStore{
 		ChunkStore: store,
 		hashFunc:   hashFunc,
+		Tags:       NewTags(),
 	}
 }
 
@@ -88,8 +90,11 @@ func (f *FileStore) Retrieve(ctx context.Context, addr Address) (reader *LazyChu
 
 // Public API. Main entry point for document storage directly. Used by the
 // FS-aware API and httpaccess
+// If the context carries a tag (see WithTag), the chunks stored are counted
+// by the tag.
 func (f *FileStore) Store(ctx context.Context, data io.Reader, size int64, toEncrypt bool) (addr Address, wait func(context.Context) error, err error) {
 	putter := NewHasherStore(f.ChunkStore, f.hashFunc, toEncrypt)
+	putter.tag = TagFromContext(ctx)
 	return PyramidSplit(ctx, data, putter, putter)
 }
 
@@ -38,6 +38,7 @@ type hasherStore struct {
 	refSize         int64 // reference size (content hash + possibly encryption key)
 	wg              *sync.WaitGroup
 	closed          chan struct{}
+	tag             *Tag // counts the chunks put, if set
 }
 
 func newChunkEncryption(chunkSize, refSize int64) *chunkEncryption {
@@ -86,6 +87,9 @@ func (h *hasherStore) Put(ctx context.Context, chunkData ChunkData) (Reference,
 		}
 	}
 	chunk := h.createChunk(c, size)
+	if h.tag != nil {
+		h.tag.Inc(StateSplit)
+	}
 
 	h.storeChunk(ctx, chunk)
 
@@ -211,6 +215,9 @@ func (h *hasherStore) storeChunk(ctx context.Context, chunk *Chunk) {
 	h.wg.Add(1)
 	go func() {
 		<-chunk.dbStoredC
+		if h.tag != nil {
+			h.tag.chunkStored(chunk)
+		}
 		h.wg.Done()
 	}()
 	h.store.Put(ctx, chunk)
@@ -55,6 +55,8 @@ var (
 	keyDataIdx     = []byte{4}
 	keyData        = byte(6)
 	keyDistanceCnt = byte(7)
+	keyPin         = byte(8)
+	keyPinnedRoot  = byte(9)
 )
 
 type gcItem struct {
@@ -270,6 +272,10 @@ func (s *LDBStore) collectGarbage(ratio float32) {
 		var index dpaDBIndex
 
 		hash := key[1:]
+		// chunks of pinned roots are never collected
+		if s.isPinned(hash) {
+			continue
+		}
 		decodeIndex(val, &index)
 		po := s.po(hash)
 
@@ -523,7 +529,7 @@ func (s *LDBStore) Put(ctx context.Context, chunk *Chunk) {
 	} else {
 		log.Trace("ldbstore.put: chunk already exists, only update access", "key", chunk.Addr)
 		decodeIndex(idata, &index)
-		chunk.markAsStored()
+		chunk.markAsSeen()
 	}
 	index.Access = s.accessCnt
 	s.accessCnt++
@@ -581,13 +587,17 @@ mainLoop:
 					close(done)
 				}()
 
-				e = s.entryCnt
 				select {
 				case <-s.quit:
 					s.lock.Unlock()
 					break mainLoop
 				case <-done:
 				}
+				// stop if only pinned chunks are left
+				if s.entryCnt == e {
+					break
+				}
+				e = s.entryCnt
 			}
 			s.lock.Unlock()
 		}
@@ -723,7 +733,12 @@ func (s *LDBStore) setCapacity(c uint64) {
 			ratio = 1
 		}
 		for s.entryCnt > c {
+			e := s.entryCnt
 			s.collectGarbage(ratio)
+			// stop if only pinned chunks are left
+			if s.entryCnt == e {
+				break
+			}
 		}
 	}
 }
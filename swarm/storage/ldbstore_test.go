@@ -388,6 +388,67 @@ func TestLDBStoreCollectGarbage(t *testing.T) {
 	log.Info("ldbstore", "total", n, "missing", missing, "entrycnt", ldb.entryCnt, "accesscnt", ldb.accessCnt)
 }
 
+// TestLDBStoreCollectGarbagePinned tests that the chunks of pinned roots are
+// not garbage collected until they are unpinned
+func TestLDBStoreCollectGarbagePinned(t *testing.T) {
+	capacity := 10
+	pinned := 5
+	n := 40
+
+	ldb, cleanup := newLDBStore(t)
+	ldb.setCapacity(uint64(capacity))
+	defer cleanup()
+
+	chunks := []*Chunk{}
+	addrs := []Address{}
+	for i := 0; i < n; i++ {
+		c := GenerateRandomChunk(DefaultChunkSize)
+		chunks = append(chunks, c)
+		addrs = append(addrs, c.Addr)
+	}
+	root := &PinnedRoot{Addr: addrs[0], Raw: true}
+	if err := ldb.Pin(root, addrs[:pinned]); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		ldb.Put(context.TODO(), chunks[i])
+		<-chunks[i].dbStoredC
+	}
+	// wait for garbage collection to kick in on the responsible actor
+	time.Sleep(time.Second)
+
+	for i := 0; i < pinned; i++ {
+		if _, err := ldb.Get(context.TODO(), chunks[i].Addr); err != nil {
+			t.Fatalf("pinned chunk %d collected: %v", i, err)
+		}
+	}
+	roots, err := ldb.PinnedRoots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 1 || !bytes.Equal(roots[0].Addr, root.Addr) || !roots[0].Raw || roots[0].Chunks != uint64(pinned) {
+		t.Fatalf("pinned roots mismatch: have %v, want %v", roots, root)
+	}
+
+	if err := ldb.Unpin(root.Addr, addrs[:pinned]); err != nil {
+		t.Fatal(err)
+	}
+	if err := ldb.Unpin(root.Addr, addrs[:pinned]); err != ErrNotPinned {
+		t.Fatalf("expected ErrNotPinned, got %v", err)
+	}
+	for i := 0; i < pinned; i++ {
+		if ldb.isPinned(addrs[i]) {
+			t.Fatalf("chunk %d still pinned", i)
+		}
+	}
+	ldb.lock.Lock()
+	ldb.collectGarbage(1)
+	ldb.lock.Unlock()
+	if _, err := ldb.Get(context.TODO(), chunks[0].Addr); err == nil {
+		t.Fatalf("unpinned chunk not collected")
+	}
+}
+
 // TestLDBStoreAddRemove tests that we can put and then delete a given chunk
 func TestLDBStoreAddRemove(t *testing.T) {
 	ldb, cleanup := newLDBStore(t)
@@ -128,7 +128,7 @@ func (ls *LocalStore) Put(ctx context.Context, chunk *Chunk) {
 	switch err {
 	case nil:
 		if memChunk.ReqC == nil {
-			chunk.markAsStored()
+			chunk.markAsSeen()
 			return
 		}
 	case ErrChunkNotFound:
@@ -0,0 +1,244 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/orangeAndSuns/essentia/metrics"
+	"github.com/orangeAndSuns/essentia/rlp"
+	"github.com/orangeAndSuns/essentia/swarm/log"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+var (
+	// ErrNotPinned is returned when unpinning a root which is not pinned
+	ErrNotPinned = errors.New("root not pinned")
+
+	// ErrPinningNotSupported is returned by chunk stores without a pinning index
+	ErrPinningNotSupported = errors.New("chunk store does not support pinning")
+)
+
+// PinStore is a chunk store keeping the chunks of pinned roots out of garbage
+// collection.
+type PinStore interface {
+	Pin(root *PinnedRoot, addrs []Address) error
+	Unpin(root Address, addrs []Address) error
+	PinnedRoot(root Address) (*PinnedRoot, error)
+	PinnedRoots() ([]*PinnedRoot, error)
+}
+
+// PinnedRoot is a root hash whose chunks are exempt from garbage collection.
+type PinnedRoot struct {
+	Addr   Address `json:"address" rlp:"-"`
+	Raw    bool    `json:"raw"`    // the root was pinned as a raw file rather than a manifest
+	Chunks uint64  `json:"chunks"` // number of distinct chunks pinned for the root
+}
+
+func getPinKey(addr Address) []byte {
+	key := make([]byte, len(addr)+1)
+	key[0] = keyPin
+	copy(key[1:], addr)
+	return key
+}
+
+func getPinnedRootKey(root Address) []byte {
+	key := make([]byte, len(root)+1)
+	key[0] = keyPinnedRoot
+	copy(key[1:], root)
+	return key
+}
+
+// Pin records the root and increments the pin counters of its chunks. Pinning
+// a root which is already pinned has no effect.
+func (s *LDBStore) Pin(root *PinnedRoot, addrs []Address) error {
+	metrics.GetOrRegisterCounter("ldbstore.pin", nil).Inc(1)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rkey := getPinnedRootKey(root.Addr)
+	if _, err := s.db.Get(rkey); err == nil {
+		return nil
+	}
+	batch := new(leveldb.Batch)
+	counts := make(map[string]uint64)
+	for _, addr := range addrs {
+		pkey := string(getPinKey(addr))
+		if _, ok := counts[pkey]; ok {
+			continue
+		}
+		data, _ := s.db.Get([]byte(pkey))
+		counts[pkey] = BytesToU64(data) + 1
+		batch.Put([]byte(pkey), U64ToBytes(counts[pkey]))
+	}
+	root.Chunks = uint64(len(counts))
+	data, err := rlp.EncodeToBytes(root)
+	if err != nil {
+		return err
+	}
+	batch.Put(rkey, data)
+	log.Debug("ldbstore.pin", "root", root.Addr, "chunks", root.Chunks)
+	return s.db.Write(batch)
+}
+
+// Unpin removes the root and decrements the pin counters of its chunks.
+func (s *LDBStore) Unpin(root Address, addrs []Address) error {
+	metrics.GetOrRegisterCounter("ldbstore.unpin", nil).Inc(1)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rkey := getPinnedRootKey(root)
+	if _, err := s.db.Get(rkey); err != nil {
+		return ErrNotPinned
+	}
+	batch := new(leveldb.Batch)
+	seen := make(map[string]bool)
+	for _, addr := range addrs {
+		pkey := string(getPinKey(addr))
+		if seen[pkey] {
+			continue
+		}
+		seen[pkey] = true
+		data, err := s.db.Get([]byte(pkey))
+		if err != nil {
+			continue
+		}
+		if cnt := BytesToU64(data); cnt > 1 {
+			batch.Put([]byte(pkey), U64ToBytes(cnt-1))
+		} else {
+			batch.Delete([]byte(pkey))
+		}
+	}
+	batch.Delete(rkey)
+	log.Debug("ldbstore.unpin", "root", root, "chunks", len(seen))
+	return s.db.Write(batch)
+}
+
+// PinnedRoot returns the pinned root with the given address.
+func (s *LDBStore) PinnedRoot(root Address) (*PinnedRoot, error) {
+	data, err := s.db.Get(getPinnedRootKey(root))
+	if err != nil {
+		return nil, ErrNotPinned
+	}
+	pinned := &PinnedRoot{Addr: root}
+	if err := rlp.DecodeBytes(data, pinned); err != nil {
+		return nil, err
+	}
+	return pinned, nil
+}
+
+// PinnedRoots returns all the pinned roots.
+func (s *LDBStore) PinnedRoots() ([]*PinnedRoot, error) {
+	it := s.db.NewIterator()
+	defer it.Release()
+
+	var roots []*PinnedRoot
+	for ok := it.Seek([]byte{keyPinnedRoot}); ok; ok = it.Next() {
+		key := it.Key()
+		if len(key) == 0 || key[0] != keyPinnedRoot {
+			break
+		}
+		pinned := &PinnedRoot{Addr: Address(append([]byte{}, key[1:]...))}
+		if err := rlp.DecodeBytes(it.Value(), pinned); err != nil {
+			return nil, err
+		}
+		roots = append(roots, pinned)
+	}
+	return roots, it.Error()
+}
+
+// isPinned reports whether the chunk is pinned by any root.
+func (s *LDBStore) isPinned(addr Address) bool {
+	_, err := s.db.Get(getPinKey(addr))
+	return err == nil
+}
+
+func (ls *LocalStore) Pin(root *PinnedRoot, addrs []Address) error {
+	return ls.DbStore.Pin(root, addrs)
+}
+
+func (ls *LocalStore) Unpin(root Address, addrs []Address) error {
+	return ls.DbStore.Unpin(root, addrs)
+}
+
+func (ls *LocalStore) PinnedRoot(root Address) (*PinnedRoot, error) {
+	return ls.DbStore.PinnedRoot(root)
+}
+
+func (ls *LocalStore) PinnedRoots() ([]*PinnedRoot, error) {
+	return ls.DbStore.PinnedRoots()
+}
+
+func (n *NetStore) Pin(root *PinnedRoot, addrs []Address) error {
+	return n.localStore.Pin(root, addrs)
+}
+
+func (n *NetStore) Unpin(root Address, addrs []Address) error {
+	return n.localStore.Unpin(root, addrs)
+}
+
+func (n *NetStore) PinnedRoot(root Address) (*PinnedRoot, error) {
+	return n.localStore.PinnedRoot(root)
+}
+
+func (n *NetStore) PinnedRoots() ([]*PinnedRoot, error) {
+	return n.localStore.PinnedRoots()
+}
+
+// PinStore returns the pinning index of the file store's chunk store.
+func (f *FileStore) PinStore() (PinStore, error) {
+	ps, ok := f.ChunkStore.(PinStore)
+	if !ok {
+		return nil, ErrPinningNotSupported
+	}
+	return ps, nil
+}
+
+// ChunkAddresses returns the addresses of all the chunks of the chunker tree
+// under the given reference, retrieving the missing chunks on the way.
+func (f *FileStore) ChunkAddresses(ctx context.Context, ref Reference) ([]Address, error) {
+	getter := NewHasherStore(f.ChunkStore, f.hashFunc, len(ref) > f.hashFunc().Size())
+	var addrs []Address
+	if err := f.walkChunks(ctx, getter, ref, &addrs); err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+func (f *FileStore) walkChunks(ctx context.Context, getter *hasherStore, ref Reference, addrs *[]Address) error {
+	chunkData, err := getter.Get(ctx, ref)
+	if err != nil {
+		return err
+	}
+	*addrs = append(*addrs, Address(ref[:getter.hashSize]))
+
+	// chunks spanning more than a chunk of data are intermediate nodes
+	// holding the references of their children
+	if chunkData.Size() <= DefaultChunkSize {
+		return nil
+	}
+	refs := chunkData[8:]
+	for i := int64(0); i+getter.refSize <= int64(len(refs)); i += getter.refSize {
+		if err := f.walkChunks(ctx, getter, Reference(refs[i:i+getter.refSize]), addrs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
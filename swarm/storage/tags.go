@@ -0,0 +1,324 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTagNotFound is returned when looking up a tag which does not exist
+var ErrTagNotFound = errors.New("tag not found")
+
+// TagTTL is the time after which a tag is forgotten along with the chunks of
+// its upload which are yet to be synced
+const TagTTL = 24 * time.Hour
+
+// State is a state in the lifecycle of the chunks of an upload
+type State int
+
+const (
+	StateSplit  State = iota // chunk has been processed by the chunker
+	StateStored              // chunk has been stored in the local store
+	StateSeen                // chunk was already in the local store, so it needs no syncing
+	StateSent                // chunk has been sent to a peer by syncing
+	StateSynced              // chunk has been stored by a peer it was synced to
+)
+
+// Tag counts the chunks of an upload as they are split, stored locally and
+// synced to the network. The counters of the sent and synced states include
+// the chunks which were already seen, so an upload is fully synced when the
+// number of synced chunks reaches the total.
+type Tag struct {
+	Uid       uint32
+	Name      string
+	StartedAt time.Time
+
+	split  int64
+	stored int64
+	seen   int64
+	sent   int64
+	synced int64
+
+	mu      sync.RWMutex
+	address Address // root address of the upload, set when splitting is done
+	total   int64   // total number of chunks, set when splitting is done
+
+	tags *Tags
+}
+
+// TagStatus is a snapshot of the counters of a tag
+type TagStatus struct {
+	Uid       uint32    `json:"uid"`
+	Name      string    `json:"name"`
+	Address   Address   `json:"address,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	Total     int64     `json:"total"`
+	Split     int64     `json:"split"`
+	Stored    int64     `json:"stored"`
+	Seen      int64     `json:"seen"`
+	Sent      int64     `json:"sent"`
+	Synced    int64     `json:"synced"`
+}
+
+// Done returns whether all the chunks of the upload have reached the given state
+func (s *TagStatus) Done(state State) bool {
+	if s.Total == 0 {
+		return false
+	}
+	switch state {
+	case StateSplit:
+		return true
+	case StateStored:
+		return s.Stored >= s.Total
+	case StateSeen:
+		return s.Seen >= s.Total
+	case StateSent:
+		return s.Sent >= s.Total
+	case StateSynced:
+		return s.Synced >= s.Total
+	}
+	return false
+}
+
+func (t *Tag) counter(state State) *int64 {
+	switch state {
+	case StateSplit:
+		return &t.split
+	case StateStored:
+		return &t.stored
+	case StateSeen:
+		return &t.seen
+	case StateSent:
+		return &t.sent
+	case StateSynced:
+		return &t.synced
+	}
+	return nil
+}
+
+// Inc increments the counter of the given state
+func (t *Tag) Inc(state State) {
+	if c := t.counter(state); c != nil {
+		atomic.AddInt64(c, 1)
+	}
+}
+
+// Get returns the counter of the given state
+func (t *Tag) Get(state State) int64 {
+	if c := t.counter(state); c != nil {
+		return atomic.LoadInt64(c)
+	}
+	return 0
+}
+
+// DoneSplit records the root address of the upload once the chunker is done,
+// fixing the total number of chunks to the number of chunks split so far
+func (t *Tag) DoneSplit(address Address) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.address = address
+	t.total = t.Get(StateSplit)
+}
+
+// chunkStored counts a chunk of the upload stored in the local store. Chunks
+// which were already in the store are counted as synced, the others are
+// tracked until they are synced.
+func (t *Tag) chunkStored(chunk *Chunk) {
+	t.Inc(StateStored)
+	if chunk.seen() {
+		t.Inc(StateSeen)
+		t.Inc(StateSent)
+		t.Inc(StateSynced)
+		return
+	}
+	t.tags.track(chunk.Addr, t)
+}
+
+// Status returns a snapshot of the counters of the tag
+func (t *Tag) Status() *TagStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return &TagStatus{
+		Uid:       t.Uid,
+		Name:      t.Name,
+		Address:   t.address,
+		StartedAt: t.StartedAt,
+		Total:     t.total,
+		Split:     t.Get(StateSplit),
+		Stored:    t.Get(StateStored),
+		Seen:      t.Get(StateSeen),
+		Sent:      t.Get(StateSent),
+		Synced:    t.Get(StateSynced),
+	}
+}
+
+// tagged is a chunk which is yet to be synced
+type tagged struct {
+	tag  *Tag
+	sent bool
+}
+
+// Tags holds the tags of the uploads of a node and keeps track of the chunks
+// which are yet to be synced, so that syncing can be accounted to the upload
+// they belong to. Tags expire after a TTL, so that neither the tags nor the
+// chunks of uploads which are never synced pile up.
+type Tags struct {
+	mu     sync.RWMutex
+	tags   map[uint32]*Tag
+	chunks map[string]*tagged
+	ttl    time.Duration
+}
+
+// NewTags creates an empty set of tags
+func NewTags() *Tags {
+	return &Tags{
+		tags:   make(map[uint32]*Tag),
+		chunks: make(map[string]*tagged),
+		ttl:    TagTTL,
+	}
+}
+
+// New creates a new tag with the given name. Tags get a random uid, so that
+// the uploads of others can't be looked up by guessing it.
+func (ts *Tags) New(name string) *Tag {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.expire()
+	t := &Tag{
+		Uid:       ts.newUid(),
+		Name:      name,
+		StartedAt: time.Now(),
+		tags:      ts,
+	}
+	ts.tags[t.Uid] = t
+	return t
+}
+
+// newUid returns an unused non-zero uid. The lock must be held.
+func (ts *Tags) newUid() uint32 {
+	var b [4]byte
+	for {
+		if _, err := rand.Read(b[:]); err != nil {
+			panic(err)
+		}
+		uid := binary.BigEndian.Uint32(b[:])
+		if _, ok := ts.tags[uid]; uid != 0 && !ok {
+			return uid
+		}
+	}
+}
+
+// Get returns the tag with the given uid
+func (ts *Tags) Get(uid uint32) (*Tag, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.expire()
+	t, ok := ts.tags[uid]
+	if !ok {
+		return nil, ErrTagNotFound
+	}
+	return t, nil
+}
+
+// All returns all the tags ordered by the time they were started
+func (ts *Tags) All() []*Tag {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.expire()
+	tags := make([]*Tag, 0, len(ts.tags))
+	for _, t := range ts.tags {
+		tags = append(tags, t)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].StartedAt.Before(tags[j].StartedAt) })
+	return tags
+}
+
+// expire forgets the tags older than the TTL and the chunks of their uploads
+// which are yet to be synced. The lock must be held.
+func (ts *Tags) expire() {
+	expired := make(map[*Tag]bool)
+	for uid, t := range ts.tags {
+		if time.Since(t.StartedAt) > ts.ttl {
+			expired[t] = true
+			delete(ts.tags, uid)
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+	for addr, c := range ts.chunks {
+		if expired[c.tag] {
+			delete(ts.chunks, addr)
+		}
+	}
+}
+
+// track records that the chunk at addr belongs to the upload of the tag,
+// unless the tag has expired
+func (ts *Tags) track(addr Address, t *Tag) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.tags[t.Uid] == t {
+		ts.chunks[string(addr)] = &tagged{tag: t}
+	}
+}
+
+// Sent records that the chunk at addr has been sent to a peer by syncing.
+// Only the first time a chunk of an upload is sent is counted.
+func (ts *Tags) Sent(addr Address) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if c, ok := ts.chunks[string(addr)]; ok && !c.sent {
+		c.sent = true
+		c.tag.Inc(StateSent)
+	}
+}
+
+// Synced records that the chunk at addr has been stored by a peer it was
+// synced to. The chunk is not tracked any longer.
+func (ts *Tags) Synced(addr Address) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if c, ok := ts.chunks[string(addr)]; ok {
+		if !c.sent {
+			c.tag.Inc(StateSent)
+		}
+		c.tag.Inc(StateSynced)
+		delete(ts.chunks, string(addr))
+	}
+}
+
+type tagKey struct{}
+
+// WithTag returns a context carrying the tag, which counts the chunks of the
+// content stored with the context by a FileStore
+func WithTag(ctx context.Context, t *Tag) context.Context {
+	return context.WithValue(ctx, tagKey{}, t)
+}
+
+// TagFromContext returns the tag carried by the context, or nil
+func TagFromContext(ctx context.Context) *Tag {
+	t, _ := ctx.Value(tagKey{}).(*Tag)
+	return t
+}
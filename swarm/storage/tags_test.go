@@ -0,0 +1,143 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// TestTagStore checks that the chunks stored with a tagged context are
+// counted by the tag, and that chunks already in the store are counted as
+// synced.
+func TestTagStore(t *testing.T) {
+	tdb, cleanup, err := newTestDbStore(false, false)
+	defer cleanup()
+	if err != nil {
+		t.Fatalf("init dbStore failed: %v", err)
+	}
+	db := tdb.LDBStore
+	memStore := NewMemStore(NewDefaultStoreParams(), db)
+	localStore := &LocalStore{
+		memStore: memStore,
+		DbStore:  db,
+	}
+	fileStore := NewFileStore(localStore, NewFileStoreParams())
+
+	const size = 10 * DefaultChunkSize
+	_, data := generateRandomData(int(size))
+
+	store := func(tag *Tag) {
+		ctx := WithTag(context.Background(), tag)
+		addr, wait, err := fileStore.Store(ctx, bytes.NewReader(data), size, false)
+		if err != nil {
+			t.Fatalf("Store error: %v", err)
+		}
+		if err := wait(ctx); err != nil {
+			t.Fatalf("Store wait error: %v", err)
+		}
+		tag.DoneSplit(addr)
+	}
+
+	tag := fileStore.Tags.New("first")
+	store(tag)
+
+	status := tag.Status()
+	// 10 data chunks and their root
+	if status.Total != 11 {
+		t.Fatalf("expected 11 chunks, got %d", status.Total)
+	}
+	if status.Split != status.Total || status.Stored != status.Total {
+		t.Fatalf("expected all chunks split and stored, got %+v", status)
+	}
+	if status.Seen != 0 || status.Sent != 0 || status.Synced != 0 {
+		t.Fatalf("expected no chunks seen, sent or synced, got %+v", status)
+	}
+	if !status.Done(StateStored) || status.Done(StateSynced) {
+		t.Fatalf("expected upload stored but not synced, got %+v", status)
+	}
+
+	// syncing is counted once per chunk
+	addrs, err := fileStore.ChunkAddresses(context.Background(), Reference(status.Address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range addrs {
+		fileStore.Tags.Sent(addr)
+		fileStore.Tags.Sent(addr)
+	}
+	if sent := tag.Get(StateSent); sent != status.Total {
+		t.Fatalf("expected %d chunks sent, got %d", status.Total, sent)
+	}
+	for _, addr := range addrs {
+		fileStore.Tags.Synced(addr)
+		fileStore.Tags.Synced(addr)
+	}
+	if status = tag.Status(); status.Synced != status.Total || !status.Done(StateSynced) {
+		t.Fatalf("expected all chunks synced, got %+v", status)
+	}
+
+	// storing the same content again needs no syncing
+	again := fileStore.Tags.New("again")
+	store(again)
+	status = again.Status()
+	if status.Seen != status.Total || !status.Done(StateSynced) {
+		t.Fatalf("expected all chunks seen and synced, got %+v", status)
+	}
+
+	// an untagged store is not counted
+	if _, _, err := fileStore.Store(context.Background(), bytes.NewReader(data), size, false); err != nil {
+		t.Fatal(err)
+	}
+	if tags := fileStore.Tags.All(); len(tags) != 2 || tags[0] != tag || tags[1] != again {
+		t.Fatalf("unexpected tags %v", tags)
+	}
+	if _, err := fileStore.Tags.Get(tag.Uid + again.Uid); err != ErrTagNotFound {
+		t.Fatalf("expected ErrTagNotFound, got %v", err)
+	}
+}
+
+// TestTagExpiry checks that tags are forgotten after the TTL along with the
+// chunks of their uploads which are yet to be synced.
+func TestTagExpiry(t *testing.T) {
+	tags := NewTags()
+	tags.ttl = time.Hour
+
+	expired := tags.New("expired")
+	expired.StartedAt = expired.StartedAt.Add(-2 * time.Hour)
+	tags.track(Address{1}, expired)
+	live := tags.New("live")
+	tags.track(Address{2}, live)
+
+	if _, err := tags.Get(expired.Uid); err != ErrTagNotFound {
+		t.Fatalf("expected expired tag not to be found, got %v", err)
+	}
+	if all := tags.All(); len(all) != 1 || all[0] != live {
+		t.Fatalf("expected only the live tag, got %v", all)
+	}
+	if len(tags.chunks) != 1 || tags.chunks[string(Address{2})] == nil {
+		t.Fatalf("expected only the chunk of the live tag to be tracked, got %v", tags.chunks)
+	}
+
+	// chunks stored for an expired tag are not tracked
+	tags.track(Address{3}, expired)
+	if len(tags.chunks) != 1 {
+		t.Fatalf("expected chunk of expired tag not to be tracked, got %v", tags.chunks)
+	}
+}
@@ -142,8 +142,13 @@ func (a Address) MarshalJSON() (out []byte, err error) {
 
 func (a *Address) UnmarshalJSON(value []byte) error {
 	s := string(value)
-	*a = make([]byte, 32)
 	h := common.Hex2Bytes(s[1 : len(s)-1])
+	// encrypted references are longer than plain content hashes
+	if len(h) > 32 {
+		*a = make([]byte, len(h))
+	} else {
+		*a = make([]byte, 32)
+	}
 	copy(*a, h)
 	return nil
 }
@@ -180,6 +185,7 @@ type Chunk struct {
 	ReqC       chan bool // to signal the request done
 	dbStoredC  chan bool // never remove a chunk from memStore before it is written to dbStore
 	dbStored   bool
+	dbSeen     bool // the chunk was already in the store when put
 	dbStoredMu *sync.Mutex
 	errored    error // flag which is set when the chunk request has errored or timeouted
 	erroredMu  sync.Mutex
@@ -218,6 +224,21 @@ func (c *Chunk) markAsStored() {
 	}
 }
 
+// markAsSeen marks a chunk which was already in the store as stored
+func (c *Chunk) markAsSeen() {
+	c.dbStoredMu.Lock()
+	c.dbSeen = true
+	c.dbStoredMu.Unlock()
+	c.markAsStored()
+}
+
+func (c *Chunk) seen() bool {
+	c.dbStoredMu.Lock()
+	defer c.dbStoredMu.Unlock()
+
+	return c.dbSeen
+}
+
 func (c *Chunk) WaitToStore() error {
 	<-c.dbStoredC
 	return c.GetErrored()
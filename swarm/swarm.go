@@ -49,8 +49,8 @@ import (
 	"github.com/orangeAndSuns/essentia/swarm/pss"
 	"github.com/orangeAndSuns/essentia/swarm/state"
 	"github.com/orangeAndSuns/essentia/swarm/storage"
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed"
 	"github.com/orangeAndSuns/essentia/swarm/storage/mock"
-	"github.com/orangeAndSuns/essentia/swarm/storage/mru"
 	"github.com/orangeAndSuns/essentia/swarm/tracing"
 )
 
@@ -179,31 +179,42 @@ func NewSwarm(config *api.Config, mockStore *mock.NodeStore) (self *Swarm, err e
 	)
 	delivery := stream.NewDelivery(to, db)
 
-	self.streamer = stream.NewRegistry(addr, delivery, db, stateStore, &stream.RegistryOptions{
+	// tags count the chunks of uploads until they are synced
+	tags := storage.NewTags()
+
+	registryOptions := &stream.RegistryOptions{
 		SkipCheck:       config.DeliverySkipCheck,
 		DoSync:          config.SyncEnabled,
 		DoRetrieve:      true,
 		SyncUpdateDelay: config.SyncUpdateDelay,
-	})
+		Tags:            tags,
+	}
+	if backend != nil {
+		// account chunk retrievals with peers and pay with the chequebook
+		registryOptions.Swap = config.Swap
+		registryOptions.SwapBackend = backend
+	}
+	self.streamer = stream.NewRegistry(addr, delivery, db, stateStore, registryOptions)
 
 	// set up NetStore, the cloud storage local access layer
 	netStore := storage.NewNetStore(self.lstore, self.streamer.Retrieve)
 	// Swarm Hash Merklised Chunking for Arbitrary-length Document/File storage
 	self.fileStore = storage.NewFileStore(netStore, self.config.FileStoreParams)
+	self.fileStore.Tags = tags
 
-	var resourceHandler *mru.Handler
-	rhparams := &mru.HandlerParams{}
+	var feedsHandler *feed.Handler
+	fhParams := &feed.HandlerParams{}
 
-	resourceHandler, err = mru.NewHandler(rhparams)
+	feedsHandler, err = feed.NewHandler(fhParams)
 	if err != nil {
 		return nil, err
 	}
-	resourceHandler.SetStore(netStore)
+	feedsHandler.SetStore(netStore)
 
 	var validators []storage.ChunkValidator
 	validators = append(validators, storage.NewContentAddressValidator(storage.MakeHashFunc(storage.DefaultHash)))
-	if resourceHandler != nil {
-		validators = append(validators, resourceHandler)
+	if feedsHandler != nil {
+		validators = append(validators, feedsHandler)
 	}
 	self.lstore.Validators = validators
 
@@ -221,7 +232,13 @@ func NewSwarm(config *api.Config, mockStore *mock.NodeStore) (self *Swarm, err e
 		pss.SetHandshakeController(self.ps, pss.NewHandshakeParams())
 	}
 
-	self.api = api.NewAPI(self.fileStore, self.dns, resourceHandler)
+	// the node key only decrypts access controlled content if explicitly enabled,
+	// since any client of the HTTP API could read it otherwise
+	var accessKey *ecdsa.PrivateKey
+	if config.AccessNodeKey {
+		accessKey = self.privateKey
+	}
+	self.api = api.NewAPI(self.fileStore, self.dns, feedsHandler, accessKey)
 	// Manifests for Smart Hosting
 	log.Debug(fmt.Sprintf("-> Web3 virtual server API"))
 
@@ -474,6 +491,18 @@ func (self *Swarm) APIs() []rpc.API {
 			Service:   api.NewControl(self.api, self.bzz.Hive),
 			Public:    false,
 		},
+		{
+			Namespace: "bzz",
+			Version:   "3.0",
+			Service:   api.NewPinAPI(self.api),
+			Public:    false,
+		},
+		{
+			Namespace: "bzz",
+			Version:   "3.0",
+			Service:   api.NewTagAPI(self.api),
+			Public:    false,
+		},
 		{
 			Namespace: "chequebook",
 			Version:   chequebook.Version,
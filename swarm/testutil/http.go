@@ -25,7 +25,7 @@ import (
 
 	"github.com/orangeAndSuns/essentia/swarm/api"
 	"github.com/orangeAndSuns/essentia/swarm/storage"
-	"github.com/orangeAndSuns/essentia/swarm/storage/mru"
+	"github.com/orangeAndSuns/essentia/swarm/storage/feed"
 )
 
 type TestServer interface {
@@ -41,8 +41,8 @@ func (f *fakeTimeProvider) Tick() {
 	f.currentTime++
 }
 
-func (f *fakeTimeProvider) Now() mru.Timestamp {
-	return mru.Timestamp{Time: f.currentTime}
+func (f *fakeTimeProvider) Now() feed.Timestamp {
+	return feed.Timestamp{Time: f.currentTime}
 }
 
 func NewTestSwarmServer(t *testing.T, serverFunc func(*api.API) TestServer) *TestSwarmServer {
@@ -61,8 +61,8 @@ func NewTestSwarmServer(t *testing.T, serverFunc func(*api.API) TestServer) *Tes
 	}
 	fileStore := storage.NewFileStore(localStore, storage.NewFileStoreParams())
 
-	// mutable resources test setup
-	resourceDir, err := ioutil.TempDir("", "swarm-resource-test")
+	// Swarm feeds test setup
+	feedsDir, err := ioutil.TempDir("", "swarm-feeds-test")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -70,14 +70,14 @@ func NewTestSwarmServer(t *testing.T, serverFunc func(*api.API) TestServer) *Tes
 	fakeTimeProvider := &fakeTimeProvider{
 		currentTime: 42,
 	}
-	mru.TimestampProvider = fakeTimeProvider
-	rhparams := &mru.HandlerParams{}
-	rh, err := mru.NewTestHandler(resourceDir, rhparams)
+	feed.TimestampProvider = fakeTimeProvider
+	fhParams := &feed.HandlerParams{}
+	fh, err := feed.NewTestHandler(feedsDir, fhParams)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	a := api.NewAPI(fileStore, nil, rh.Handler)
+	a := api.NewAPI(fileStore, nil, fh.Handler, nil)
 	srv := httptest.NewServer(serverFunc(a))
 	return &TestSwarmServer{
 		Server:            srv,
@@ -87,9 +87,9 @@ func NewTestSwarmServer(t *testing.T, serverFunc func(*api.API) TestServer) *Tes
 		timestampProvider: fakeTimeProvider,
 		cleanup: func() {
 			srv.Close()
-			rh.Close()
+			fh.Close()
 			os.RemoveAll(dir)
-			os.RemoveAll(resourceDir)
+			os.RemoveAll(feedsDir)
 		},
 	}
 }
@@ -107,6 +107,6 @@ func (t *TestSwarmServer) Close() {
 	t.cleanup()
 }
 
-func (t *TestSwarmServer) GetCurrentTime() mru.Timestamp {
+func (t *TestSwarmServer) GetCurrentTime() feed.Timestamp {
 	return t.timestampProvider.Now()
 }
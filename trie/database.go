@@ -19,9 +19,11 @@ package trie
 import (
 	"fmt"
 	"io"
+	"math"
 	"sync"
 	"time"
 
+	"github.com/hashicorp/golang-lru/simplelru"
 	"github.com/orangeAndSuns/essentia/common"
 	"github.com/orangeAndSuns/essentia/essdb"
 	"github.com/orangeAndSuns/essentia/log"
@@ -41,6 +43,11 @@ var (
 	memcacheCommitTimeTimer  = metrics.NewRegisteredResettingTimer("trie/memcache/commit/time", nil)
 	memcacheCommitNodesMeter = metrics.NewRegisteredMeter("trie/memcache/commit/nodes", nil)
 	memcacheCommitSizeMeter  = metrics.NewRegisteredMeter("trie/memcache/commit/size", nil)
+
+	memcacheCleanHitMeter   = metrics.NewRegisteredMeter("trie/memcache/clean/hit", nil)
+	memcacheCleanMissMeter  = metrics.NewRegisteredMeter("trie/memcache/clean/miss", nil)
+	memcacheCleanReadMeter  = metrics.NewRegisteredMeter("trie/memcache/clean/read", nil)
+	memcacheCleanWriteMeter = metrics.NewRegisteredMeter("trie/memcache/clean/write", nil)
 )
 
 // secureKeyPrefix is the database key prefix used to store trie node preimages.
@@ -64,6 +71,7 @@ type DatabaseReader interface {
 type Database struct {
 	diskdb essdb.Database // Persistent storage for matured trie nodes
 
+	cleans *cleanCache                 // Size limited cache of clean (persisted) nodes, nil if disabled
 	nodes  map[common.Hash]*cachedNode // Data and references relationships of a node
 	oldest common.Hash                 // Oldest tracked node, flush-list head
 	newest common.Hash                 // Newest tracked node, flush-list tail
@@ -261,14 +269,76 @@ func expandNode(hash hashNode, n node, cachegen uint16) node {
 	}
 }
 
+// cleanCache is a size limited LRU cache of clean trie nodes, i.e. nodes that
+// are already persisted to disk and thus can be evicted at any time.
+type cleanCache struct {
+	lru   *simplelru.LRU
+	size  common.StorageSize // Total size of the cached blobs
+	limit common.StorageSize // Maximum size of the cached blobs
+	lock  sync.Mutex
+}
+
+// newCleanCache creates a clean node cache with the given size allowance.
+func newCleanCache(limit common.StorageSize) *cleanCache {
+	cache := &cleanCache{limit: limit}
+	cache.lru, _ = simplelru.NewLRU(math.MaxInt32, func(key, value interface{}) {
+		cache.size -= common.StorageSize(common.HashLength + len(value.([]byte)))
+	})
+	return cache
+}
+
+// get retrieves a clean node blob from the cache, or nil if it's not present.
+func (c *cleanCache) get(hash common.Hash) []byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if blob, ok := c.lru.Get(hash); ok {
+		memcacheCleanHitMeter.Mark(1)
+		memcacheCleanReadMeter.Mark(int64(len(blob.([]byte))))
+		return blob.([]byte)
+	}
+	memcacheCleanMissMeter.Mark(1)
+	return nil
+}
+
+// set inserts a clean node blob into the cache, evicting the least recently
+// used entries until the cache fits into its allowance.
+func (c *cleanCache) set(hash common.Hash, blob []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.lru.Contains(hash) {
+		return
+	}
+	c.lru.Add(hash, blob)
+	c.size += common.StorageSize(common.HashLength + len(blob))
+	memcacheCleanWriteMeter.Mark(int64(len(blob)))
+
+	for c.size > c.limit && c.lru.Len() > 0 {
+		c.lru.RemoveOldest()
+	}
+}
+
 // NewDatabase creates a new trie database to store ephemeral trie content before
-// its written out to disk or garbage collected.
+// its written out to disk or garbage collected. No read cache is created, so all
+// data retrievals will hit the underlying disk database.
 func NewDatabase(diskdb essdb.Database) *Database {
-	return &Database{
+	return NewDatabaseWithCache(diskdb, 0)
+}
+
+// NewDatabaseWithCache creates a new trie database to store ephemeral trie content
+// before its written out to disk or garbage collected. It also acts as a read cache
+// for nodes loaded from disk, holding up to cache megabytes of them.
+func NewDatabaseWithCache(diskdb essdb.Database, cache int) *Database {
+	db := &Database{
 		diskdb:    diskdb,
 		nodes:     map[common.Hash]*cachedNode{{}: {}},
 		preimages: make(map[common.Hash][]byte),
 	}
+	if cache > 0 {
+		db.cleans = newCleanCache(common.StorageSize(cache * 1024 * 1024))
+	}
+	return db
 }
 
 // DiskDB retrieves the persistent storage backing the trie database.
@@ -341,11 +411,19 @@ func (db *Database) node(hash common.Hash, cachegen uint16) node {
 	if node != nil {
 		return node.obj(hash, cachegen)
 	}
-	// Content unavailable in memory, attempt to retrieve from disk
+	// Content unavailable in the dirty cache, try the clean one before disk
+	if db.cleans != nil {
+		if enc := db.cleans.get(hash); enc != nil {
+			return mustDecodeNode(hash[:], enc, cachegen)
+		}
+	}
 	enc, err := db.diskdb.Get(hash[:])
 	if err != nil || enc == nil {
 		return nil
 	}
+	if db.cleans != nil {
+		db.cleans.set(hash, enc)
+	}
 	return mustDecodeNode(hash[:], enc, cachegen)
 }
 
@@ -360,8 +438,17 @@ func (db *Database) Node(hash common.Hash) ([]byte, error) {
 	if node != nil {
 		return node.rlp(), nil
 	}
-	// Content unavailable in memory, attempt to retrieve from disk
-	return db.diskdb.Get(hash[:])
+	// Content unavailable in the dirty cache, try the clean one before disk
+	if db.cleans != nil {
+		if enc := db.cleans.get(hash); enc != nil {
+			return enc, nil
+		}
+	}
+	enc, err := db.diskdb.Get(hash[:])
+	if err == nil && enc != nil && db.cleans != nil {
+		db.cleans.set(hash, enc)
+	}
+	return enc, err
 }
 
 // preimage retrieves a cached trie node pre-image from memory. If it cannot be
@@ -570,6 +657,9 @@ func (db *Database) Cap(limit common.StorageSize) error {
 	for db.oldest != oldest {
 		node := db.nodes[db.oldest]
 		delete(db.nodes, db.oldest)
+		if db.cleans != nil {
+			db.cleans.set(db.oldest, node.rlp())
+		}
 		db.oldest = node.flushNext
 
 		db.nodesSize -= common.StorageSize(common.HashLength + int(node.size))
@@ -709,6 +799,11 @@ func (db *Database) uncache(hash common.Hash) {
 	}
 	delete(db.nodes, hash)
 	db.nodesSize -= common.StorageSize(common.HashLength + int(node.size))
+
+	// Keep the freshly persisted node around as a clean one
+	if db.cleans != nil {
+		db.cleans.set(hash, node.rlp())
+	}
 }
 
 // Size returns the current storage size of the memory cache in front of the
@@ -0,0 +1,74 @@
+// Copyright 2018 The qwerty123 Authors
+// This file is part of the qwerty123 library.
+//
+// The qwerty123 library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The qwerty123 library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the qwerty123 library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/orangeAndSuns/essentia/common"
+	"github.com/orangeAndSuns/essentia/essdb"
+)
+
+// Tests that committed trie nodes are retained in the clean cache and served
+// from there even after they were deleted from the underlying disk database.
+func TestDatabaseCleanCache(t *testing.T) {
+	diskdb := essdb.NewMemDatabase()
+	triedb := NewDatabaseWithCache(diskdb, 1)
+
+	trie, _ := New(common.Hash{}, triedb)
+	for i := byte(0); i < 16; i++ {
+		trie.Update([]byte{i}, bytes.Repeat([]byte{i}, 32))
+	}
+	root, _ := trie.Commit(nil)
+	if err := triedb.Commit(root, false); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	// Wipe the disk database and make sure the trie is still accessible
+	for _, key := range diskdb.Keys() {
+		diskdb.Delete(key)
+	}
+	if _, err := triedb.Node(root); err != nil {
+		t.Fatalf("root node not cached: %v", err)
+	}
+	trie, err := New(root, triedb)
+	if err != nil {
+		t.Fatalf("failed to open cached trie: %v", err)
+	}
+	for i := byte(0); i < 16; i++ {
+		if val := trie.Get([]byte{i}); !bytes.Equal(val, bytes.Repeat([]byte{i}, 32)) {
+			t.Errorf("value %d mismatch: have %x", i, val)
+		}
+	}
+}
+
+// Tests that the clean cache respects its size allowance.
+func TestCleanCacheLimit(t *testing.T) {
+	cache := newCleanCache(1024)
+	for i := 0; i < 64; i++ {
+		cache.set(common.Hash{byte(i)}, make([]byte, 96))
+	}
+	if cache.size > cache.limit {
+		t.Fatalf("cache size exceeded: have %v, limit %v", cache.size, cache.limit)
+	}
+	if blob := cache.get(common.Hash{63}); blob == nil {
+		t.Fatalf("most recent entry evicted")
+	}
+	if blob := cache.get(common.Hash{0}); blob != nil {
+		t.Fatalf("oldest entry retained")
+	}
+}